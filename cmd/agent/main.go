@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/agent"
 	"github.com/belo4ya/edu-final-calculate-api/internal/agent/client"
 	"github.com/belo4ya/edu-final-calculate-api/internal/agent/config"
 	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
 	"github.com/belo4ya/edu-final-calculate-api/internal/mgmtserver"
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
 
 	"github.com/belo4ya/runy"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
@@ -39,6 +43,24 @@ func run() error {
 	log.InfoContext(ctx, "logger is configured")
 	log.InfoContext(ctx, "config initialized", "config", conf)
 
+	shutdownTracing, err := tracing.Configure(ctx, &tracing.Config{
+		ServiceName:  "agent",
+		Exporter:     conf.TracingExporter,
+		FilePath:     conf.TracingFilePath,
+		OTLPEndpoint: conf.TracingOTLPEndpoint,
+		SampleRatio:  conf.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("configure tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.ErrorContext(ctx, "failed to shutdown tracing", "error", err)
+		}
+	}()
+
 	calculatorClient, cleanup, err := client.NewAgentAPI(ctx, conf)
 	if err != nil {
 		return fmt.Errorf("create calculator client: %w", err)
@@ -47,7 +69,10 @@ func run() error {
 
 	mgmtSrv := mgmtserver.New(&mgmtserver.Config{Addr: conf.MgmtAddr})
 
-	agent_ := agent.New(conf, log, calculatorClient)
+	agentMetrics := agent.NewMetrics()
+	prometheus.MustRegister(agentMetrics)
+
+	agent_ := agent.New(conf, log, calculatorClient, agentMetrics)
 
 	runy.Add(mgmtSrv, agent_)
 	if err := runy.Start(ctx); err != nil {
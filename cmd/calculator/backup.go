@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+)
+
+// backupDB implements the backup command, which copies the live SQLite database into a file.
+func backupDB(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: calculator backup <file>")
+	}
+	path := args[0]
+
+	ctx := context.Background()
+
+	conf, err := loadSQLiteConfig("backup")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(ctx, conf.DBDriver, conf.DBDSN())
+	if err != nil {
+		return fmt.Errorf("db open: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := database.Backup(ctx, db.Read, path); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	fmt.Printf("Database backed up to %s\n", path)
+	return nil
+}
+
+// restoreDB implements the restore command, which replaces the SQLite database with a backup.
+// The service must be stopped while the database is restored.
+func restoreDB(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: calculator restore <file>")
+	}
+	path := args[0]
+
+	ctx := context.Background()
+
+	conf, err := loadSQLiteConfig("restore")
+	if err != nil {
+		return err
+	}
+
+	if err := database.Restore(ctx, path, conf.DBSQLitePath); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	fmt.Printf("Database restored from %s\n", path)
+
+	m, err := database.NewMigrator(ctx, conf.DBDriver, conf.DBDSN())
+	if err != nil {
+		return fmt.Errorf("init migrator: %w", err)
+	}
+	defer func() {
+		_ = m.Close()
+	}()
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database schema version: %d (latest: %d)\n", version, m.Latest())
+	return nil
+}
+
+func loadSQLiteConfig(cmd string) (*config.Config, error) {
+	conf, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	switch conf.DBDriver {
+	case database.DriverSQLite:
+	case database.DriverPostgres:
+		return nil, fmt.Errorf("%s supports the sqlite driver only, use pg_dump and pg_restore for PostgreSQL", cmd)
+	default:
+		return nil, fmt.Errorf("%s supports the sqlite driver only", cmd)
+	}
+	return conf, nil
+}
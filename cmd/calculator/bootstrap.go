@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/service"
+)
+
+// bootstrapAdmin implements the bootstrap-admin command, which creates an admin with a one-time password.
+func bootstrapAdmin(args []string) error {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ContinueOnError)
+	login := fs.String("login", "admin", "login of the admin")
+	password := fs.String("password", "", "one-time password of the admin, generated if empty")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	ctx := context.Background()
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if conf.DBDriver == database.DriverMemory {
+		return errors.New("bootstrap-admin requires a persistent database, the memory driver keeps no data between runs")
+	}
+
+	if err := checkSchema(ctx, conf); err != nil {
+		return err
+	}
+
+	repo, closeRepo, err := openRepository(ctx, conf)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = closeRepo()
+	}()
+
+	pwd, err := service.BootstrapAdmin(ctx, conf, repo, *login, *password)
+	if err != nil {
+		return fmt.Errorf("bootstrap admin: %w", err)
+	}
+
+	fmt.Printf("Admin %q created. The password must be changed on first login.\n", *login)
+	if *password == "" {
+		fmt.Printf("One-time password: %s\n", pwd)
+	}
+	return nil
+}
@@ -5,28 +5,45 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/calc"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
-	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/server"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/service"
 	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
 	"github.com/belo4ya/edu-final-calculate-api/internal/mgmtserver"
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
 
 	"github.com/belo4ya/runy"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
 	_ = godotenv.Load(".env.calculator")
-	if err := run(); err != nil {
+
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "bootstrap-admin":
+		err = bootstrapAdmin(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "migrate":
+		err = migrateDB(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "backup":
+		err = backupDB(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "restore":
+		err = restoreDB(os.Args[2:])
+	default:
+		err = run()
+	}
+	if err != nil {
 		slog.Error(err.Error())
 		os.Exit(1)
 	}
@@ -48,36 +65,114 @@ func run() error {
 	log.InfoContext(ctx, "logger is configured")
 	log.InfoContext(ctx, "config initialized", "config", conf)
 
-	auth_ := auth.New(conf)
+	shutdownTracing, err := tracing.Configure(ctx, &tracing.Config{
+		ServiceName:  "calculator",
+		Exporter:     conf.TracingExporter,
+		FilePath:     conf.TracingFilePath,
+		OTLPEndpoint: conf.TracingOTLPEndpoint,
+		SampleRatio:  conf.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("configure tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.ErrorContext(ctx, "failed to shutdown tracing", "error", err)
+		}
+	}()
 
-	mgmtSrv := mgmtserver.New(&mgmtserver.Config{Addr: conf.MgmtAddr})
-	grpcSrv := server.NewGRPCServer(conf, auth_)
-	httpSrv := server.NewHTTPServer(conf)
+	if err := checkSchema(ctx, conf); err != nil {
+		return err
+	}
 
-	db, err := database.Connect(ctx, conf.DBSQLitePath)
+	repo, closeRepo, err := openRepository(ctx, conf)
 	if err != nil {
-		return fmt.Errorf("db connect: %w", err)
+		return err
+	}
+	defer func() {
+		_ = closeRepo()
+	}()
+	if conf.DBDriver == database.DriverMemory {
+		log.WarnContext(ctx, "data is kept in memory and will be lost on restart")
+	}
+
+	if ok, err := service.HasAdmin(ctx, repo); err != nil {
+		log.WarnContext(ctx, "failed to check for admin users", "error", err)
+	} else if !ok {
+		log.WarnContext(ctx, "no admin users, create one with `calculator bootstrap-admin`")
 	}
 
-	repo := repository.New(db)
+	auth_, err := auth.New(conf, repo)
+	if err != nil {
+		return fmt.Errorf("init auth: %w", err)
+	}
 
-	calcSvc := service.NewCalculatorService(conf, log, calc.NewCalculator(), repo)
+	mgmtSrv := mgmtserver.New(&mgmtserver.Config{Addr: conf.MgmtAddr})
+	grpcSrv := server.NewGRPCServer(conf, auth_)
+	httpSrv := server.NewHTTPServer(conf, auth_)
+
+	metrics := service.NewMetrics(repo)
+	prometheus.MustRegister(metrics)
+
+	var sinks []service.OutboxSink
+	if conf.OutboxWebhookURL != "" {
+		sinks = append(sinks, service.NewWebhookSink(conf.OutboxWebhookURL, conf.OutboxWebhookTimeout))
+	}
+	if conf.OutboxFilePath != "" {
+		sinks = append(sinks, service.NewFileSink(conf.OutboxFilePath))
+	}
+	var eventStream *service.StreamSink
+	if conf.OutboxStream {
+		eventStream = service.NewStreamSink()
+		sinks = append(sinks, eventStream)
+	}
+
+	calcSvc := service.NewCalculatorService(conf, log, calc.NewCalculator(), repo, metrics)
 	userSvc := service.NewUserService(conf, log, auth_, repo)
-	agentSvc := service.NewAgentService(conf, log, repo)
+	agentSvc := service.NewAgentService(conf, log, repo, metrics)
+	adminSvc := service.NewAdminService(conf, log, repo, eventStream)
 
 	for i, svc := range []interface {
 		RegisterWith(*grpc.Server)
 		RegisterGRPCGateway(context.Context, *runtime.ServeMux, []grpc.DialOption) error
-	}{calcSvc, userSvc, agentSvc} {
+	}{calcSvc, userSvc, agentSvc, adminSvc} {
 		svc.RegisterWith(grpcSrv.GRPC)
 		if err := svc.RegisterGRPCGateway(ctx, httpSrv.GWMux, []grpc.DialOption{
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		}); err != nil {
 			return fmt.Errorf("register grpc gateway %d: %w", i, err)
 		}
 	}
 
+	if conf.AuthOIDCIssuerURL != "" {
+		oidcSvc, err := service.NewOIDCService(ctx, conf, log, userSvc, repo)
+		if err != nil {
+			return fmt.Errorf("init oidc: %w", err)
+		}
+		if err := oidcSvc.RegisterHTTPHandlers(httpSrv.GWMux); err != nil {
+			return fmt.Errorf("register oidc handlers: %w", err)
+		}
+	}
+
 	runy.Add(mgmtSrv, grpcSrv, httpSrv)
+	if conf.BackupInterval > 0 {
+		runy.Add(service.NewBackupScheduler(conf, log, repo))
+	}
+	if conf.RetentionInterval > 0 {
+		runy.Add(service.NewJanitor(conf, log, repo, metrics))
+	}
+	if conf.OutboxInterval > 0 {
+		runy.Add(service.NewDispatcher(conf, log, repo, metrics, sinks...))
+	}
+	if conf.CallbackInterval > 0 {
+		runy.Add(service.NewCallbackDispatcher(conf, log, repo, metrics))
+	}
+	if conf.AgentUnroutableInterval > 0 {
+		runy.Add(service.NewUnroutableReporter(conf, log, repo))
+	}
 	if err := runy.Start(ctx); err != nil {
 		return fmt.Errorf("problem with running app: %w", err)
 	}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+)
+
+const migrateUsage = "usage: calculator migrate up | down [N] | version"
+
+// migrateDB implements the migrate command, which applies the embedded migrations:
+// up applies all of them, down rolls back the last N (1 by default), and version prints the current version.
+func migrateDB(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	steps := 1
+	switch {
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid number of migrations %q, %s", args[1], migrateUsage)
+		}
+		steps = n
+	case len(args) != 1:
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	m, err := database.NewMigrator(ctx, conf.DBDriver, conf.DBDSN())
+	if err != nil {
+		return fmt.Errorf("init migrator: %w", err)
+	}
+	defer func() {
+		_ = m.Close()
+	}()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down(steps)
+	case "version":
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database schema version: %d (latest: %d)\n", version, m.Latest())
+	if dirty {
+		fmt.Println("The last migration has failed, fix the database and force the version with the migrate CLI.")
+	}
+	return nil
+}
+
+// checkSchema applies the migrations if DB_AUTO_MIGRATE is set
+// and refuses to start unless the database schema is of the version the binary works with.
+func checkSchema(ctx context.Context, conf *config.Config) error {
+	if conf.DBDriver == database.DriverMemory {
+		return nil
+	}
+
+	m, err := database.NewMigrator(ctx, conf.DBDriver, conf.DBDSN())
+	if err != nil {
+		return fmt.Errorf("init migrator: %w", err)
+	}
+	defer func() {
+		_ = m.Close()
+	}()
+
+	if conf.DBAutoMigrate {
+		if err := m.Up(); err != nil {
+			return err
+		}
+	}
+	if err := m.Check(); err != nil {
+		if errors.Is(err, database.ErrSchemaOutdated) {
+			return fmt.Errorf("%w; apply the migrations with `calculator migrate up` or set DB_AUTO_MIGRATE=true", err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/memory"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/service"
+)
+
+// calculatorRepository is the storage of all services, implemented by both the SQL and the in-memory repositories.
+type calculatorRepository interface {
+	auth.TokenStore
+	service.CalculatorRepository
+	service.UserRepository
+	service.AgentRepository
+	service.AdminRepository
+	service.MetricsRepository
+	service.OIDCRepository
+	service.BootstrapRepository
+	service.JanitorRepository
+	service.OutboxRepository
+	service.CallbackRepository
+	service.UnroutableRepository
+}
+
+// openRepository opens the repository of the configured database driver. The returned function closes it.
+func openRepository(ctx context.Context, conf *config.Config) (calculatorRepository, func() error, error) {
+	if conf.DBDriver == database.DriverMemory {
+		return memory.New(), func() error { return nil }, nil
+	}
+
+	db, err := database.Open(ctx, conf.DBDriver, conf.DBDSN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("db connect: %w", err)
+	}
+	return repository.New(db), db.Close, nil
+}
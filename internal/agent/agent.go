@@ -11,32 +11,67 @@ import (
 	"github.com/belo4ya/edu-final-calculate-api/internal/agent/client"
 	"github.com/belo4ya/edu-final-calculate-api/internal/agent/config"
 	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
 
 	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
 
 	"github.com/avast/retry-go/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+var tracer = otel.Tracer("github.com/belo4ya/edu-final-calculate-api/internal/agent")
+
 type CalculatorAgentAPIClient interface {
-	GetTask(ctx context.Context) (*calculatorv1.Task, error)
+	GetTask(ctx context.Context, req *calculatorv1.GetTaskRequest) (*calculatorv1.Task, error)
 	SubmitTaskResult(ctx context.Context, res *calculatorv1.SubmitTaskResultRequest) error
 }
 
 // Agent is a worker that fetches and processes calculator tasks from a remote API.
 // It implements a worker pool pattern to handle multiple tasks concurrently.
 type Agent struct {
-	conf   *config.Config
-	log    *slog.Logger
-	client CalculatorAgentAPIClient
+	conf    *config.Config
+	log     *slog.Logger
+	client  CalculatorAgentAPIClient
+	metrics *Metrics
+	taskReq *calculatorv1.GetTaskRequest
 }
 
-// New creates a new Agent with the provided configuration, logger, and API client.
-func New(conf *config.Config, log *slog.Logger, c CalculatorAgentAPIClient) *Agent {
+// New creates a new Agent with the provided configuration, logger, API client and metrics.
+func New(conf *config.Config, log *slog.Logger, c CalculatorAgentAPIClient, metrics *Metrics) *Agent {
 	return &Agent{
-		conf:   conf,
-		log:    logging.WithName(log, "agent"),
-		client: c,
+		conf:    conf,
+		log:     logging.WithName(log, "agent"),
+		client:  c,
+		metrics: metrics,
+		taskReq: newTaskRequest(conf),
+	}
+}
+
+// newTaskRequest builds the task request advertising the agent's capabilities,
+// so that the calculator only hands out tasks the agent can execute.
+func newTaskRequest(conf *config.Config) *calculatorv1.GetTaskRequest {
+	req := &calculatorv1.GetTaskRequest{
+		AgentId:    conf.AgentID,
+		Operations: make([]calculatorv1.TaskOperation, 0, len(conf.Operations)),
+		Labels:     conf.Labels,
 	}
+	for _, op := range conf.Operations {
+		switch op {
+		case "+":
+			req.Operations = append(req.Operations, calculatorv1.TaskOperation_TASK_OPERATION_ADDITION)
+		case "-":
+			req.Operations = append(req.Operations, calculatorv1.TaskOperation_TASK_OPERATION_SUBTRACTION)
+		case "*":
+			req.Operations = append(req.Operations, calculatorv1.TaskOperation_TASK_OPERATION_MULTIPLICATION)
+		case "/":
+			req.Operations = append(req.Operations, calculatorv1.TaskOperation_TASK_OPERATION_DIVISION)
+		}
+	}
+	return req
 }
 
 // Start launches the agent's worker pool based on configured computing power.
@@ -63,58 +98,125 @@ func (a *Agent) Start(ctx context.Context) error {
 func (a *Agent) worker(ctx context.Context, workerID int) {
 	log := a.log.With("worker_id", workerID)
 	log.InfoContext(ctx, "worker started")
+	a.metrics.workerStarted()
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.InfoContext(ctx, "worker stopped")
+			a.metrics.workerStopped()
 			return
 		default:
-			task, err := a.fetchTask(ctx, log)
-			if err != nil {
-				continue // context done
-			}
+			a.processTask(ctx, log)
+		}
+	}
+}
 
-			log := log.With("task_id", task.Id)
-			log.DebugContext(ctx, "executing task")
+// processTask fetches a single task, executes it and submits its result.
+func (a *Agent) processTask(ctx context.Context, log *slog.Logger) {
+	fetchStart := time.Now()
+	task, err := a.fetchTask(ctx, log)
+	if err != nil {
+		return // context done
+	}
+	a.metrics.observeFetchWait(time.Since(fetchStart))
 
-			result, err := a.executeTask(ctx, task)
-			if err != nil {
-				continue // context done
-			}
+	a.metrics.workerBusy()
+	defer a.metrics.workerIdle()
 
-			if err := a.submitTaskResult(ctx, log, task.Id, result); err != nil {
-				continue // context done
-			}
+	// The task is executed in its own trace linked to the request that submitted the expression,
+	// since a single expression fans out into many tasks processed by different agents.
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(
+			attribute.String("task.id", task.Id),
+			attribute.String("task.operation", operationLabel(task.Operation)),
+			attribute.String("agent.id", a.conf.AgentID),
+		),
+	}
+	if sc := tracing.SpanContextFromTraceParent(task.TraceParent); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	ctx, span := tracer.Start(ctx, "agent.ExecuteTask", opts...)
+	defer span.End()
 
-			log.InfoContext(ctx, "task completed", "result", result)
-		}
+	log = log.With("task_id", task.Id)
+	log.DebugContext(ctx, "executing task")
+
+	start := time.Now()
+	result, computeTime, err := a.executeTask(ctx, task)
+	executionTime := time.Since(start)
+	if err != nil {
+		a.metrics.observeExecution(task.Operation, taskOutcomeCanceled, executionTime)
+		span.SetStatus(codes.Error, "task execution canceled")
+		return // context done
+	}
+	if math.IsNaN(result) {
+		a.metrics.observeExecution(task.Operation, taskOutcomeFailed, executionTime)
+		span.SetStatus(codes.Error, "task execution failed")
+	} else {
+		a.metrics.observeExecution(task.Operation, taskOutcomeCompleted, executionTime)
+	}
+	// The calculator learns the operation costs from the reported compute time,
+	// so agents simulating the operation time don't report it.
+	if a.conf.ExecutionMode != config.ExecutionModeReal {
+		computeTime = 0
+	}
+
+	if err := a.submitTaskResult(ctx, log, task.Id, result, computeTime); err != nil {
+		return // context done
 	}
+
+	log.InfoContext(ctx, "task completed", "result", result, "compute_time", computeTime)
 }
 
 // executeTask performs the actual mathematical operation specified by the task.
-// It simulates computation time by waiting for the duration specified in the task.
-func (a *Agent) executeTask(ctx context.Context, task *calculatorv1.Task) (float64, error) {
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	case <-time.After(task.OperationTime.AsDuration()):
+// Depending on the execution mode, it simulates computation time by waiting for
+// the (scaled) duration specified in the task. Returns the result and the time
+// spent computing it, which doesn't include the wait.
+func (a *Agent) executeTask(ctx context.Context, task *calculatorv1.Task) (float64, time.Duration, error) {
+	if delay := a.executionDelay(task); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		case <-time.After(delay):
+		}
+	} else if err := ctx.Err(); err != nil {
+		return 0, 0, err
 	}
 
+	start := time.Now()
+	result := compute(task)
+	return result, time.Since(start), nil
+}
+
+// compute returns the result of the task operation, NaN if it can't be computed.
+func compute(task *calculatorv1.Task) float64 {
 	switch task.Operation {
 	case calculatorv1.TaskOperation_TASK_OPERATION_ADDITION:
-		return task.Arg1 + task.Arg2, nil
+		return task.Arg1 + task.Arg2
 	case calculatorv1.TaskOperation_TASK_OPERATION_SUBTRACTION:
-		return task.Arg1 - task.Arg2, nil
+		return task.Arg1 - task.Arg2
 	case calculatorv1.TaskOperation_TASK_OPERATION_MULTIPLICATION:
-		return task.Arg1 * task.Arg2, nil
+		return task.Arg1 * task.Arg2
 	case calculatorv1.TaskOperation_TASK_OPERATION_DIVISION:
 		if task.Arg2 == 0 {
-			return math.NaN(), nil
+			return math.NaN()
 		}
-		return task.Arg1 / task.Arg2, nil
+		return task.Arg1 / task.Arg2
 	default:
-		return math.NaN(), nil
+		return math.NaN()
+	}
+}
+
+// executionDelay returns how long to wait before computing the task result.
+func (a *Agent) executionDelay(task *calculatorv1.Task) time.Duration {
+	switch a.conf.ExecutionMode {
+	case config.ExecutionModeReal:
+		return 0
+	case config.ExecutionModeScaled:
+		return time.Duration(float64(task.OperationTime.AsDuration()) * a.conf.ExecutionTimeScale)
+	default: // config.ExecutionModeSimulate
+		return task.OperationTime.AsDuration()
 	}
 }
 
@@ -123,7 +225,7 @@ func (a *Agent) executeTask(ctx context.Context, task *calculatorv1.Task) (float
 func (a *Agent) fetchTask(ctx context.Context, log *slog.Logger) (*calculatorv1.Task, error) {
 	task, _ := retry.DoWithData(
 		func() (*calculatorv1.Task, error) {
-			return a.client.GetTask(ctx)
+			return a.client.GetTask(ctx, a.taskReq)
 		},
 		retry.OnRetry(func(attempt uint, err error) {
 			if errors.Is(err, client.ErrNoTasks) {
@@ -142,24 +244,40 @@ func (a *Agent) fetchTask(ctx context.Context, log *slog.Logger) (*calculatorv1.
 }
 
 // submitTaskResult sends the computed result back to the API with exponential backoff.
-// It will retry indefinitely until the context is canceled or the submission succeeds.
-func (a *Agent) submitTaskResult(ctx context.Context, log *slog.Logger, taskID string, result float64) error {
+// It will retry indefinitely until the context is canceled or the submission succeeds,
+// unless the task is no longer in progress.
+func (a *Agent) submitTaskResult(
+	ctx context.Context,
+	log *slog.Logger,
+	taskID string,
+	result float64,
+	computeTime time.Duration,
+) error {
 	req := &calculatorv1.SubmitTaskResultRequest{
-		Id:     taskID,
-		Result: result,
+		Id:          taskID,
+		Result:      result,
+		ComputeTime: durationpb.New(computeTime),
+		AgentId:     a.conf.AgentID,
 	}
-	_ = retry.Do(
+	err := retry.Do(
 		func() error {
 			return a.client.SubmitTaskResult(ctx, req)
 		},
 		retry.OnRetry(func(attempt uint, err error) {
+			a.metrics.submitRetried()
 			log.ErrorContext(ctx, "failed to submit task result", "error", err, "attempt", attempt)
 		}),
+		retry.RetryIf(func(err error) bool {
+			return !errors.Is(err, client.ErrTaskNotFound)
+		}),
 		retry.Context(ctx),
 		retry.UntilSucceeded(),
 		retry.Delay(200*time.Millisecond),
 		retry.MaxDelay(10*time.Second),
 		retry.MaxJitter(1*time.Second),
 	)
+	if errors.Is(err, client.ErrTaskNotFound) {
+		log.WarnContext(ctx, "task result discarded, the task is no longer in progress")
+	}
 	return ctx.Err()
 }
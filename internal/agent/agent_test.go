@@ -15,6 +15,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -138,9 +145,9 @@ func TestAgent_executeTask(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mc := mocks.NewMockCalculatorAgentAPIClient(t)
 
-			agent := New(&config.Config{}, testutil.DiscardLogger(), mc)
+			agent := New(&config.Config{}, testutil.DiscardLogger(), mc, NewMetrics())
 
-			got, err := agent.executeTask(tt.args.ctx, tt.args.task)
+			got, computeTime, err := agent.executeTask(tt.args.ctx, tt.args.task)
 			if !tt.wantErr(t, err, fmt.Sprintf("executeTask(%v, %v)", tt.args.ctx, tt.args.task)) {
 				return
 			}
@@ -149,6 +156,147 @@ func TestAgent_executeTask(t *testing.T) {
 			} else {
 				assert.Equalf(t, tt.want, got, "executeTask(%v, %v)", tt.args.ctx, tt.args.task)
 			}
+			assert.Less(t, computeTime, time.Millisecond, "the wait is not computation")
+		})
+	}
+}
+
+func TestAgent_executionDelay(t *testing.T) {
+	task := &calculatorv1.Task{
+		Id:            "task1",
+		Operation:     calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+		OperationTime: durationpb.New(2 * time.Second),
+	}
+
+	tests := []struct {
+		name string
+		conf *config.Config
+		want time.Duration
+	}{
+		{
+			name: "default mode simulates operation time",
+			conf: &config.Config{},
+			want: 2 * time.Second,
+		},
+		{
+			name: "simulate mode",
+			conf: &config.Config{ExecutionMode: config.ExecutionModeSimulate},
+			want: 2 * time.Second,
+		},
+		{
+			name: "real mode",
+			conf: &config.Config{ExecutionMode: config.ExecutionModeReal},
+			want: 0,
+		},
+		{
+			name: "scaled mode",
+			conf: &config.Config{ExecutionMode: config.ExecutionModeScaled, ExecutionTimeScale: 0.25},
+			want: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := New(tt.conf, testutil.DiscardLogger(), mocks.NewMockCalculatorAgentAPIClient(t), NewMetrics())
+			assert.Equal(t, tt.want, agent.executionDelay(task))
+		})
+	}
+}
+
+func TestAgent_newTaskRequest(t *testing.T) {
+	conf := &config.Config{
+		AgentID:    "agent1",
+		Operations: []string{"+", "/"},
+		Labels:     map[string]string{"gpu": "false"},
+	}
+
+	got := newTaskRequest(conf)
+
+	assert.Equal(t, &calculatorv1.GetTaskRequest{
+		AgentId: "agent1",
+		Operations: []calculatorv1.TaskOperation{
+			calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+			calculatorv1.TaskOperation_TASK_OPERATION_DIVISION,
+		},
+		Labels: map[string]string{"gpu": "false"},
+	}, got)
+}
+
+func TestAgent_processTask_tracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	ctx := context.Background()
+	log := testutil.DiscardLogger()
+	mc := mocks.NewMockCalculatorAgentAPIClient(t)
+	mc.EXPECT().GetTask(mock.Anything, mock.Anything).Return(&calculatorv1.Task{
+		Id:          "task1",
+		Operation:   calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+		Arg1:        1,
+		Arg2:        2,
+		TraceParent: traceParent,
+	}, nil).Once()
+	mc.EXPECT().SubmitTaskResult(mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).IsValid()
+	}), mock.Anything).Return(nil).Once()
+
+	agent := New(&config.Config{AgentID: "agent1"}, log, mc, NewMetrics())
+	agent.processTask(ctx, log)
+
+	spans := sr.Ended()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "agent.ExecuteTask", span.Name())
+	assert.Contains(t, span.Attributes(), attribute.String("task.id", "task1"))
+	assert.Contains(t, span.Attributes(), attribute.String("agent.id", "agent1"))
+	if assert.Len(t, span.Links(), 1) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Links()[0].SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Links()[0].SpanContext.SpanID().String())
+	}
+}
+
+func TestAgent_processTask_computeTime(t *testing.T) {
+	tests := []struct {
+		mode            string
+		wantComputeTime bool
+	}{
+		{mode: config.ExecutionModeSimulate, wantComputeTime: false},
+		{mode: config.ExecutionModeScaled, wantComputeTime: false},
+		{mode: config.ExecutionModeReal, wantComputeTime: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			log := testutil.DiscardLogger()
+			mc := mocks.NewMockCalculatorAgentAPIClient(t)
+			mc.EXPECT().GetTask(mock.Anything, mock.Anything).Return(&calculatorv1.Task{
+				Id:            "task1",
+				Operation:     calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+				OperationTime: durationpb.New(20 * time.Millisecond),
+				Arg1:          1,
+				Arg2:          2,
+			}, nil).Once()
+			var req *calculatorv1.SubmitTaskResultRequest
+			mc.EXPECT().SubmitTaskResult(mock.Anything, mock.Anything).RunAndReturn(
+				func(_ context.Context, r *calculatorv1.SubmitTaskResultRequest) error {
+					req = r
+					return nil
+				}).Once()
+
+			conf := &config.Config{ExecutionMode: tt.mode, ExecutionTimeScale: 1}
+			New(conf, log, mc, NewMetrics()).processTask(context.Background(), log)
+
+			require.NotNil(t, req)
+			assert.Equal(t, 3.0, req.Result)
+			computeTime := req.ComputeTime.AsDuration()
+			if tt.wantComputeTime {
+				assert.Less(t, computeTime, time.Millisecond, "only the computation is measured")
+			} else {
+				assert.Zero(t, computeTime, "the simulated operation time is not reported")
+			}
 		})
 	}
 }
@@ -167,7 +315,7 @@ func TestAgent_fetchTask(t *testing.T) {
 		{
 			name: "successful fetch",
 			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
-				c.EXPECT().GetTask(mock.Anything).Return(&calculatorv1.Task{
+				c.EXPECT().GetTask(mock.Anything, mock.Anything).Return(&calculatorv1.Task{
 					Id:        "task1",
 					Arg1:      10,
 					Arg2:      5,
@@ -186,7 +334,7 @@ func TestAgent_fetchTask(t *testing.T) {
 		{
 			name: "context canceled",
 			setupMocks: func(client *mocks.MockCalculatorAgentAPIClient) {
-				client.EXPECT().GetTask(mock.Anything).Return(nil, context.Canceled).Maybe()
+				client.EXPECT().GetTask(mock.Anything, mock.Anything).Return(nil, context.Canceled).Maybe()
 			},
 			args: args{
 				ctx: func() context.Context {
@@ -201,8 +349,8 @@ func TestAgent_fetchTask(t *testing.T) {
 		{
 			name: "retry once then succeed",
 			setupMocks: func(client *mocks.MockCalculatorAgentAPIClient) {
-				client.EXPECT().GetTask(mock.Anything).Return(nil, assert.AnError).Once()
-				client.EXPECT().GetTask(mock.Anything).Return(&calculatorv1.Task{
+				client.EXPECT().GetTask(mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+				client.EXPECT().GetTask(mock.Anything, mock.Anything).Return(&calculatorv1.Task{
 					Id:        "task2",
 					Arg1:      7,
 					Arg2:      8,
@@ -221,8 +369,8 @@ func TestAgent_fetchTask(t *testing.T) {
 		{
 			name: "no tasks available then succeed",
 			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
-				c.EXPECT().GetTask(mock.Anything).Return(nil, client.ErrNoTasks).Once()
-				c.EXPECT().GetTask(mock.Anything).Return(&calculatorv1.Task{
+				c.EXPECT().GetTask(mock.Anything, mock.Anything).Return(nil, client.ErrNoTasks).Once()
+				c.EXPECT().GetTask(mock.Anything, mock.Anything).Return(&calculatorv1.Task{
 					Id:        "task3",
 					Arg1:      20,
 					Arg2:      4,
@@ -245,7 +393,7 @@ func TestAgent_fetchTask(t *testing.T) {
 			mc := mocks.NewMockCalculatorAgentAPIClient(t)
 
 			tt.setupMocks(mc)
-			agent := New(&config.Config{}, log, mc)
+			agent := New(&config.Config{}, log, mc, NewMetrics())
 
 			got, err := agent.fetchTask(tt.args.ctx, log)
 			if !tt.wantErr(t, err, fmt.Sprintf("fetchTask(%v, %v)", tt.args.ctx, log)) {
@@ -258,9 +406,10 @@ func TestAgent_fetchTask(t *testing.T) {
 
 func TestAgent_submitTaskResult(t *testing.T) {
 	type args struct {
-		ctx    context.Context
-		taskID string
-		result float64
+		ctx         context.Context
+		taskID      string
+		result      float64
+		computeTime time.Duration
 	}
 	tests := []struct {
 		name       string
@@ -272,14 +421,16 @@ func TestAgent_submitTaskResult(t *testing.T) {
 			name: "successful submission",
 			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
 				c.EXPECT().SubmitTaskResult(mock.Anything, &calculatorv1.SubmitTaskResultRequest{
-					Id:     "task1",
-					Result: 15,
+					Id:          "task1",
+					Result:      15,
+					ComputeTime: durationpb.New(time.Second),
 				}).Return(nil).Once()
 			},
 			args: args{
-				ctx:    context.Background(),
-				taskID: "task1",
-				result: 15,
+				ctx:         context.Background(),
+				taskID:      "task1",
+				result:      15,
+				computeTime: time.Second,
 			},
 			wantErr: assert.NoError,
 		},
@@ -303,8 +454,9 @@ func TestAgent_submitTaskResult(t *testing.T) {
 			name: "retry once then succeed",
 			setupMocks: func(client *mocks.MockCalculatorAgentAPIClient) {
 				req := &calculatorv1.SubmitTaskResultRequest{
-					Id:     "task3",
-					Result: 42,
+					Id:          "task3",
+					Result:      42,
+					ComputeTime: durationpb.New(0),
 				}
 				client.EXPECT().SubmitTaskResult(mock.Anything, req).Return(assert.AnError).Once()
 				client.EXPECT().SubmitTaskResult(mock.Anything, req).Return(nil).Once()
@@ -316,6 +468,18 @@ func TestAgent_submitTaskResult(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "task no longer in progress",
+			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
+				c.EXPECT().SubmitTaskResult(mock.Anything, mock.Anything).Return(client.ErrTaskNotFound).Once()
+			},
+			args: args{
+				ctx:    context.Background(),
+				taskID: "task5",
+				result: 1,
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "submit result with NaN",
 			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
@@ -337,12 +501,15 @@ func TestAgent_submitTaskResult(t *testing.T) {
 			mc := mocks.NewMockCalculatorAgentAPIClient(t)
 
 			tt.setupMocks(mc)
-			agent := New(&config.Config{}, log, mc)
+			agent := New(&config.Config{}, log, mc, NewMetrics())
 
 			tt.wantErr(
 				t,
-				agent.submitTaskResult(tt.args.ctx, log, tt.args.taskID, tt.args.result),
-				fmt.Sprintf("submitTaskResult(%v, %v, %v, %v)", tt.args.ctx, log, tt.args.taskID, tt.args.result),
+				agent.submitTaskResult(tt.args.ctx, log, tt.args.taskID, tt.args.result, tt.args.computeTime),
+				fmt.Sprintf(
+					"submitTaskResult(%v, %v, %v, %v, %v)",
+					tt.args.ctx, log, tt.args.taskID, tt.args.result, tt.args.computeTime,
+				),
 			)
 		})
 	}
@@ -14,13 +14,20 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/timeout"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
 
-var ErrNoTasks = fmt.Errorf("no tasks")
+var (
+	ErrNoTasks = fmt.Errorf("no tasks")
+	// ErrTaskNotFound means the task is no longer in progress by the agent, e.g. its lease has expired
+	// and it has been handed to another agent or it has been failed along with the expression,
+	// and the result is discarded.
+	ErrTaskNotFound = fmt.Errorf("task not found")
+)
 
 type AgentAPI struct {
 	client calculatorv1.AgentServiceClient
@@ -33,6 +40,7 @@ func NewAgentAPI(ctx context.Context, conf *config.Config) (*AgentAPI, func(), e
 	conn, err := grpc.NewClient(
 		conf.CalculatorAPIAddr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithChainUnaryInterceptor(
 			timeout.UnaryClientInterceptor(10*time.Second),
 			retry.UnaryClientInterceptor(
@@ -55,8 +63,8 @@ func NewAgentAPI(ctx context.Context, conf *config.Config) (*AgentAPI, func(), e
 	return &AgentAPI{client: calculatorv1.NewAgentServiceClient(conn)}, cleanup, nil
 }
 
-func (c *AgentAPI) GetTask(ctx context.Context) (*calculatorv1.Task, error) {
-	resp, err := c.client.GetTask(ctx, nil)
+func (c *AgentAPI) GetTask(ctx context.Context, req *calculatorv1.GetTaskRequest) (*calculatorv1.Task, error) {
+	resp, err := c.client.GetTask(ctx, req)
 	if err != nil {
 		grpcStatus := status.Convert(err)
 		if grpcStatus.Code() == codes.NotFound {
@@ -70,6 +78,9 @@ func (c *AgentAPI) GetTask(ctx context.Context) (*calculatorv1.Task, error) {
 func (c *AgentAPI) SubmitTaskResult(ctx context.Context, res *calculatorv1.SubmitTaskResultRequest) error {
 	_, err := c.client.SubmitTaskResult(ctx, res)
 	if err != nil {
+		if code := status.Code(err); code == codes.NotFound || code == codes.FailedPrecondition {
+			return ErrTaskNotFound
+		}
 		return fmt.Errorf("submit task result: %w", err)
 	}
 	return nil
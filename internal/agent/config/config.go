@@ -4,8 +4,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/rs/xid"
+)
+
+var SupportedOperations = []string{"+", "-", "*", "/"}
+
+const (
+	// ExecutionModeSimulate waits for the task operation time before computing the result.
+	ExecutionModeSimulate = "simulate"
+	// ExecutionModeReal computes the result without waiting.
+	ExecutionModeReal = "real"
+	// ExecutionModeScaled waits for the task operation time multiplied by ExecutionTimeScale.
+	ExecutionModeScaled = "scaled"
 )
 
 type Config struct {
@@ -13,18 +28,54 @@ type Config struct {
 	MgmtAddr          string `env:"MGMT_ADDR"`
 	CalculatorAPIAddr string `env:"CALCULATOR_API_ADDR"`
 	ComputingPower    int    `env:"COMPUTING_POWER"`
+
+	ExecutionMode      string  `env:"EXECUTION_MODE"`
+	ExecutionTimeScale float64 `env:"EXECUTION_TIME_SCALE"`
+
+	AgentID    string            `env:"AGENT_ID"`
+	Operations []string          `env:"OPERATIONS" envSeparator:","`
+	Labels     map[string]string `env:"LABELS" envSeparator:"," envKeyValSeparator:"="`
+
+	TracingExporter     string  `env:"TRACING_EXPORTER"`
+	TracingFilePath     string  `env:"TRACING_FILE_PATH"`
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO"`
 }
 
 func Load() (*Config, error) {
 	conf := &Config{
-		LogLevel:          "info",
-		MgmtAddr:          ":8082",
-		CalculatorAPIAddr: "localhost:50051",
-		ComputingPower:    4,
+		LogLevel:           "info",
+		MgmtAddr:           ":8082",
+		CalculatorAPIAddr:  "localhost:50051",
+		ComputingPower:     4,
+		ExecutionMode:      ExecutionModeSimulate,
+		ExecutionTimeScale: 0.1,
+		AgentID:            xid.New().String(),
+		Operations:         SupportedOperations,
+		Labels:             map[string]string{},
+		TracingExporter:    tracing.ExporterNone,
+		TracingFilePath:    "traces.jsonl",
+		TracingSampleRatio: 1,
 	}
 	if err := env.Parse(conf); err != nil {
 		return nil, fmt.Errorf("env parse: %w", err)
 	}
+	switch conf.ExecutionMode {
+	case ExecutionModeSimulate, ExecutionModeReal, ExecutionModeScaled:
+	default:
+		return nil, fmt.Errorf("unsupported execution mode %q", conf.ExecutionMode)
+	}
+	if conf.ExecutionTimeScale < 0 {
+		return nil, fmt.Errorf("negative execution time scale %v", conf.ExecutionTimeScale)
+	}
+	for _, op := range conf.Operations {
+		if !slices.Contains(SupportedOperations, op) {
+			return nil, fmt.Errorf("unsupported operation %q", op)
+		}
+	}
+	if !slices.Contains(tracing.Exporters, conf.TracingExporter) {
+		return nil, fmt.Errorf("unsupported tracing exporter %q", conf.TracingExporter)
+	}
 	return conf, nil
 }
 
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	taskOutcomeCompleted = "completed"
+	taskOutcomeFailed    = "failed"
+	taskOutcomeCanceled  = "canceled"
+
+	workerStateBusy = "busy"
+	workerStateIdle = "idle"
+)
+
+// Metrics represents a collection of agent-level metrics to be registered on a
+// Prometheus metrics registry.
+type Metrics struct {
+	tasksExecuted     *prometheus.CounterVec
+	executionDuration *prometheus.HistogramVec
+	fetchWaitDuration prometheus.Histogram
+	workers           *prometheus.GaugeVec
+	submitRetries     prometheus.Counter
+}
+
+// NewMetrics returns a new Metrics object.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		tasksExecuted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_tasks_executed_total",
+			Help: "Total number of tasks executed by the agent.",
+		}, []string{"operation", "outcome"}),
+		executionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "agent_task_execution_duration_seconds",
+			Help:    "Duration of task execution by the agent.",
+			Buckets: []float64{0.0001, 0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"operation"}),
+		fetchWaitDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "agent_task_fetch_wait_duration_seconds",
+			Help:    "Time a worker waits until it receives a task.",
+			Buckets: []float64{0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		}),
+		workers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_workers",
+			Help: "Number of agent workers by state.",
+		}, []string{"state"}),
+		submitRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_task_submit_retries_total",
+			Help: "Total number of task result submission retries.",
+		}),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector to the provided channel and returns once
+// the last descriptor has been sent.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.tasksExecuted.Describe(ch)
+	m.executionDuration.Describe(ch)
+	m.fetchWaitDuration.Describe(ch)
+	m.workers.Describe(ch)
+	m.submitRetries.Describe(ch)
+}
+
+// Collect is called by the Prometheus registry when collecting
+// metrics. The implementation sends each collected metric via the
+// provided channel and returns once the last metric has been sent.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.tasksExecuted.Collect(ch)
+	m.executionDuration.Collect(ch)
+	m.fetchWaitDuration.Collect(ch)
+	m.workers.Collect(ch)
+	m.submitRetries.Collect(ch)
+}
+
+func (m *Metrics) observeExecution(op calculatorv1.TaskOperation, outcome string, d time.Duration) {
+	label := operationLabel(op)
+	m.tasksExecuted.WithLabelValues(label, outcome).Inc()
+	if outcome != taskOutcomeCanceled {
+		m.executionDuration.WithLabelValues(label).Observe(d.Seconds())
+	}
+}
+
+func (m *Metrics) observeFetchWait(d time.Duration) {
+	m.fetchWaitDuration.Observe(d.Seconds())
+}
+
+func (m *Metrics) workerStarted() {
+	m.workers.WithLabelValues(workerStateIdle).Inc()
+}
+
+func (m *Metrics) workerStopped() {
+	m.workers.WithLabelValues(workerStateIdle).Dec()
+}
+
+func (m *Metrics) workerBusy() {
+	m.workers.WithLabelValues(workerStateIdle).Dec()
+	m.workers.WithLabelValues(workerStateBusy).Inc()
+}
+
+func (m *Metrics) workerIdle() {
+	m.workers.WithLabelValues(workerStateBusy).Dec()
+	m.workers.WithLabelValues(workerStateIdle).Inc()
+}
+
+func (m *Metrics) submitRetried() {
+	m.submitRetries.Inc()
+}
+
+// operationLabel converts TASK_OPERATION_ADDITION into addition.
+func operationLabel(op calculatorv1.TaskOperation) string {
+	return strings.ToLower(strings.TrimPrefix(op.String(), "TASK_OPERATION_"))
+}
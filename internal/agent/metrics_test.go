@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/agent/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/agent"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAgent_processTask_metrics(t *testing.T) {
+	tests := []struct {
+		name        string
+		task        *calculatorv1.Task
+		setupMocks  func(c *mocks.MockCalculatorAgentAPIClient)
+		wantOutcome string
+		wantRetries float64
+	}{
+		{
+			name: "completed task",
+			task: &calculatorv1.Task{
+				Id:        "task1",
+				Operation: calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+				Arg1:      1,
+				Arg2:      2,
+			},
+			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
+				c.EXPECT().SubmitTaskResult(mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			wantOutcome: taskOutcomeCompleted,
+			wantRetries: 0,
+		},
+		{
+			name: "failed task with submit retry",
+			task: &calculatorv1.Task{
+				Id:        "task2",
+				Operation: calculatorv1.TaskOperation_TASK_OPERATION_DIVISION,
+				Arg1:      1,
+				Arg2:      0,
+			},
+			setupMocks: func(c *mocks.MockCalculatorAgentAPIClient) {
+				c.EXPECT().SubmitTaskResult(mock.Anything, mock.MatchedBy(func(req *calculatorv1.SubmitTaskResultRequest) bool {
+					return math.IsNaN(req.Result)
+				})).Return(assert.AnError).Once()
+				c.EXPECT().SubmitTaskResult(mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			wantOutcome: taskOutcomeFailed,
+			wantRetries: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			log := testutil.DiscardLogger()
+			mc := mocks.NewMockCalculatorAgentAPIClient(t)
+			mc.EXPECT().GetTask(mock.Anything, mock.Anything).Return(tt.task, nil).Once()
+			tt.setupMocks(mc)
+
+			metrics := NewMetrics()
+			agent := New(&config.Config{}, log, mc, metrics)
+
+			metrics.workerStarted()
+			agent.processTask(ctx, log)
+
+			op := operationLabel(tt.task.Operation)
+			assert.InDelta(t, 1, promtestutil.ToFloat64(metrics.tasksExecuted.WithLabelValues(op, tt.wantOutcome)), 0)
+			assert.InDelta(t, tt.wantRetries, promtestutil.ToFloat64(metrics.submitRetries), 0)
+			assert.InDelta(t, 1, promtestutil.ToFloat64(metrics.workers.WithLabelValues(workerStateIdle)), 0)
+			assert.InDelta(t, 0, promtestutil.ToFloat64(metrics.workers.WithLabelValues(workerStateBusy)), 0)
+			assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.fetchWaitDuration))
+			assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.executionDuration))
+		})
+	}
+}
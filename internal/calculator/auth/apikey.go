@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyPrefix distinguishes API keys from JWTs passed as bearer tokens.
+const APIKeyPrefix = "calc_"
+
+// apiKeyScopes maps the methods available to API keys to the scope they require.
+// Other methods, such as managing API keys, can't be called with an API key.
+var apiKeyScopes = map[string]models.APIKeyScope{
+	calculatorv1.CalculatorService_Calculate_FullMethodName:                models.APIKeyScopeSubmit,
+	calculatorv1.CalculatorService_ListExpressions_FullMethodName:          models.APIKeyScopeReadOnly,
+	calculatorv1.CalculatorService_GetExpression_FullMethodName:            models.APIKeyScopeReadOnly,
+	calculatorv1.CalculatorService_ListExpressionTasks_FullMethodName:      models.APIKeyScopeReadOnly,
+	calculatorv1.CalculatorService_ListExpressionTaskEvents_FullMethodName: models.APIKeyScopeReadOnly,
+	calculatorv1.CalculatorService_GetExpressionCallback_FullMethodName:    models.APIKeyScopeReadOnly,
+}
+
+// NewAPIKey generates a personal API key. Only its [HashAPIKey] should be stored.
+func NewAPIKey() (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + token, nil
+}
+
+// HashAPIKey returns the SHA-256 hex digest of an API key.
+func HashAPIKey(key string) string {
+	return HashRefreshToken(key)
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// authenticateAPIKey resolves the user of an API key allowed to call the method.
+func (a *Auth) authenticateAPIKey(ctx context.Context, key, method string) (UserInfo, error) {
+	apiKey, err := a.store.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return UserInfo{}, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		return UserInfo{}, status.Error(codes.Internal, "internal error")
+	}
+	if !apiKey.Active(time.Now()) {
+		return UserInfo{}, status.Error(codes.Unauthenticated, "invalid api key: key revoked or expired")
+	}
+
+	scope, ok := apiKeyScopes[method]
+	if !ok {
+		return UserInfo{}, status.Error(codes.PermissionDenied, "method is not available with an api key")
+	}
+	if !apiKey.Scopes.Has(scope) {
+		return UserInfo{}, status.Errorf(codes.PermissionDenied, "api key has no %q scope", scope)
+	}
+	return UserInfo{ID: apiKey.UserID, Login: apiKey.UserLogin, Role: apiKey.UserRole}, nil
+}
@@ -4,36 +4,71 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
+	"github.com/rs/xid"
 	"github.com/samber/lo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+func init() {
+	// Access tokens issued before a password change are revoked by their issue time,
+	// so it is kept with the precision of the database timestamps rather than in whole seconds.
+	jwt.TimePrecision = time.Microsecond
+}
+
 type UserInfo struct {
-	ID    string `json:"id"`
-	Login string `json:"login"`
+	ID    string          `json:"id"`
+	Login string          `json:"login"`
+	Role  models.UserRole `json:"role,omitempty"`
+	// PasswordChangeRequired restricts the user to changing the password, see [Auth.UnaryServerAuthorizationInterceptor].
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+	// AuthTime is the time of the login the access token has been issued on,
+	// zero for refreshed access tokens and API keys.
+	AuthTime time.Time `json:"auth_time,omitzero"`
+}
+
+// TokenInfo identifies the access token a request has been authenticated with.
+type TokenInfo struct {
+	ID        string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// TokenStore is consulted on every authenticated request to reject revoked access tokens
+// and to resolve API keys.
+type TokenStore interface {
+	IsTokenRevoked(ctx context.Context, cmd models.IsTokenRevokedCmd) (bool, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
 }
 
 type Auth struct {
-	jwtSecret         string
+	keys              *keySet
 	jwtExpirationTime time.Duration
+	store             TokenStore
 }
 
-func New(conf *config.Config) *Auth {
+func New(conf *config.Config, store TokenStore) (*Auth, error) {
+	keys, err := loadKeySet(conf)
+	if err != nil {
+		return nil, fmt.Errorf("load jwt keys: %w", err)
+	}
 	return &Auth{
-		jwtSecret:         conf.AuthJWTSecret,
+		keys:              keys,
 		jwtExpirationTime: conf.AuthJWTExpirationTime,
-	}
+		store:             store,
+	}, nil
 }
 
 type Claims struct {
@@ -49,11 +84,15 @@ func (a *Auth) GenerateJWT(user UserInfo) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(now.Add(a.jwtExpirationTime)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   user.ID,
+			ID:        xid.New().String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(a.jwtSecret))
+	token := jwt.NewWithClaims(a.keys.current.method, claims)
+	if a.keys.current.kid != "" {
+		token.Header["kid"] = a.keys.current.kid
+	}
+	tokenString, err := token.SignedString(a.keys.privateKey)
 	if err != nil {
 		return "", fmt.Errorf("sign jwt: %w", err)
 	}
@@ -61,33 +100,84 @@ func (a *Auth) GenerateJWT(user UserInfo) (string, error) {
 	return tokenString, nil
 }
 
+// JWKS returns the JSON encoded [JWKS] of the public keys access tokens can be verified with.
+// It is empty if tokens are signed with the HMAC secret.
+func (a *Auth) JWKS() []byte {
+	return a.keys.jwks
+}
+
+// authenticatedUserMethods are the [calculatorv1.UserService] methods requiring authentication.
+var authenticatedUserMethods = []string{
+	calculatorv1.UserService_Logout_FullMethodName,
+	calculatorv1.UserService_GetMe_FullMethodName,
+	calculatorv1.UserService_ChangePassword_FullMethodName,
+	calculatorv1.UserService_DeleteAccount_FullMethodName,
+	calculatorv1.UserService_CreateAPIKey_FullMethodName,
+	calculatorv1.UserService_ListAPIKeys_FullMethodName,
+	calculatorv1.UserService_RevokeAPIKey_FullMethodName,
+}
+
 func (a *Auth) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	authFn := func(ctx context.Context) (context.Context, error) {
-		token, err := auth.AuthFromMD(ctx, "bearer")
+	return selector.UnaryServerInterceptor(auth.UnaryServerInterceptor(a.authenticate), selector.MatchFunc(requiresAuth))
+}
+
+// StreamServerInterceptor is the streaming counterpart of [Auth.UnaryServerInterceptor].
+func (a *Auth) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return selector.StreamServerInterceptor(auth.StreamServerInterceptor(a.authenticate), selector.MatchFunc(requiresAuth))
+}
+
+// authenticate puts the user of the JWT or API key of the request into the context.
+func (a *Auth) authenticate(ctx context.Context) (context.Context, error) {
+	token, err := auth.AuthFromMD(ctx, "bearer")
+	if err != nil {
+		return nil, err
+	}
+	if isAPIKey(token) {
+		method, _ := grpc.Method(ctx)
+		user, err := a.authenticateAPIKey(ctx, token, method)
 		if err != nil {
 			return nil, err
 		}
-		claims, err := a.validateJWT(token)
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid auth token: %v", err)
-		}
-		return WithContext(ctx, claims.UserInfo), nil
+		return WithContext(ctx, user), nil
 	}
-
-	matchFn := func(ctx context.Context, callMeta interceptors.CallMeta) bool {
-		return calculatorv1.CalculatorService_ServiceDesc.ServiceName == callMeta.Service
+	claims, err := a.validateJWT(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid auth token: %v", err)
+	}
+	revoked, err := a.store.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{
+		JTI:      claims.ID,
+		UserID:   claims.UserInfo.ID,
+		IssuedAt: claims.IssuedAt.Time,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
 	}
+	if revoked {
+		return nil, status.Error(codes.Unauthenticated, "invalid auth token: token revoked")
+	}
+	ctx = WithToken(ctx, TokenInfo{ID: claims.ID, IssuedAt: claims.IssuedAt.Time, ExpiresAt: claims.ExpiresAt.Time})
+	return WithContext(ctx, claims.UserInfo), nil
+}
 
-	return selector.UnaryServerInterceptor(auth.UnaryServerInterceptor(authFn), selector.MatchFunc(matchFn))
+// requiresAuth reports whether the method can be called by authenticated users only.
+func requiresAuth(_ context.Context, callMeta interceptors.CallMeta) bool {
+	return callMeta.Service == calculatorv1.CalculatorService_ServiceDesc.ServiceName ||
+		callMeta.Service == calculatorv1.AdminService_ServiceDesc.ServiceName ||
+		slices.Contains(authenticatedUserMethods, callMeta.FullMethod())
 }
 
 func (a *Auth) validateJWT(s string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(s, claims, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.keys.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(a.jwtSecret), nil
+		return key.key, nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("parse jwt: %w", err)
@@ -95,6 +185,9 @@ func (a *Auth) validateJWT(s string) (*Claims, error) {
 	if !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	if claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return nil, errors.New("token has no id, issue or expiration time")
+	}
 	return claims, nil
 }
 
@@ -115,3 +208,14 @@ func UserFromContext(ctx context.Context) (UserInfo, bool) {
 func MustUserIDFromContext(ctx context.Context) string {
 	return lo.Must(UserFromContext(ctx)).ID
 }
+
+type tokenCtxKey struct{}
+
+func WithToken(ctx context.Context, token TokenInfo) context.Context {
+	return context.WithValue(ctx, tokenCtxKey{}, token)
+}
+
+func TokenFromContext(ctx context.Context) (TokenInfo, bool) {
+	token, ok := ctx.Value(tokenCtxKey{}).(TokenInfo)
+	return token, ok
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/memory"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/auth"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuth_UnaryServerInterceptor(t *testing.T) {
+	conf := testConfig()
+	user := UserInfo{ID: "user-id", Login: "testuser", Role: models.UserRoleReadOnly, AuthTime: time.Now().UTC().Round(0)}
+
+	token, err := newTestAuth(t, conf, nil).GenerateJWT(user)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		setupMocks func(store *mocks.MockTokenStore)
+		wantCode   codes.Code
+	}{
+		{
+			name:   "valid token",
+			method: calculatorv1.CalculatorService_Calculate_FullMethodName,
+			token:  token,
+			setupMocks: func(store *mocks.MockTokenStore) {
+				store.EXPECT().IsTokenRevoked(mock.Anything, mock.MatchedBy(func(cmd models.IsTokenRevokedCmd) bool {
+					return cmd.JTI != "" && cmd.UserID == "user-id" && !cmd.IssuedAt.IsZero()
+				})).Return(false, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:   "revoked token",
+			method: calculatorv1.CalculatorService_Calculate_FullMethodName,
+			token:  token,
+			setupMocks: func(store *mocks.MockTokenStore) {
+				store.EXPECT().IsTokenRevoked(mock.Anything, mock.Anything).Return(true, nil)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:   "store error",
+			method: calculatorv1.CalculatorService_Calculate_FullMethodName,
+			token:  token,
+			setupMocks: func(store *mocks.MockTokenStore) {
+				store.EXPECT().IsTokenRevoked(mock.Anything, mock.Anything).Return(false, assert.AnError)
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name:       "invalid token",
+			method:     calculatorv1.CalculatorService_Calculate_FullMethodName,
+			token:      "invalid",
+			setupMocks: func(*mocks.MockTokenStore) {},
+			wantCode:   codes.Unauthenticated,
+		},
+		{
+			name:   "logout requires auth",
+			method: calculatorv1.UserService_Logout_FullMethodName,
+			token:  token,
+			setupMocks: func(store *mocks.MockTokenStore) {
+				store.EXPECT().IsTokenRevoked(mock.Anything, mock.Anything).Return(false, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "login doesn't require auth",
+			method:     calculatorv1.UserService_Login_FullMethodName,
+			setupMocks: func(*mocks.MockTokenStore) {},
+			wantCode:   codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := mocks.NewMockTokenStore(t)
+			tt.setupMocks(store)
+			interceptor := newTestAuth(t, conf, store).UnaryServerInterceptor()
+
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), &serverTransportStream{method: tt.method})
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.token))
+			}
+
+			var handlerCtx context.Context
+			handler := func(ctx context.Context, _ any) (any, error) {
+				handlerCtx = ctx
+				return nil, nil
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK || tt.token == "" {
+				return
+			}
+
+			got, ok := UserFromContext(handlerCtx)
+			require.True(t, ok)
+			assert.Equal(t, user, got)
+
+			tokenInfo, ok := TokenFromContext(handlerCtx)
+			require.True(t, ok)
+			assert.NotEmpty(t, tokenInfo.ID)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), tokenInfo.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestAuth_UnaryServerInterceptor_apiKey(t *testing.T) {
+	conf := testConfig()
+
+	key, err := NewAPIKey()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, APIKeyPrefix))
+
+	apiKey := func(scopes ...models.APIKeyScope) *models.APIKey {
+		return &models.APIKey{ID: "key-id", UserID: "user-id", UserLogin: "testuser", UserRole: models.UserRoleUser, Scopes: scopes}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		apiKey   *models.APIKey
+		storeErr error
+		wantCode codes.Code
+	}{
+		{
+			name:     "submit scope allows calculate",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeSubmit),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "read-only scope denies calculate",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeReadOnly),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "read-only scope allows get expression",
+			method:   calculatorv1.CalculatorService_GetExpression_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeReadOnly),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "read-only scope allows list expression task events",
+			method:   calculatorv1.CalculatorService_ListExpressionTaskEvents_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeReadOnly),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "submit scope denies list expression task events",
+			method:   calculatorv1.CalculatorService_ListExpressionTaskEvents_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeSubmit),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "read-only scope allows get expression callback",
+			method:   calculatorv1.CalculatorService_GetExpressionCallback_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeReadOnly),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "submit scope denies get expression callback",
+			method:   calculatorv1.CalculatorService_GetExpressionCallback_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeSubmit),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "submit scope denies list expressions",
+			method:   calculatorv1.CalculatorService_ListExpressions_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeSubmit),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "api keys can't create api keys",
+			method:   calculatorv1.UserService_CreateAPIKey_FullMethodName,
+			apiKey:   apiKey(models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:   "revoked key",
+			method: calculatorv1.CalculatorService_Calculate_FullMethodName,
+			apiKey: &models.APIKey{
+				UserID:    "user-id",
+				Scopes:    models.APIKeyScopes{models.APIKeyScopeSubmit},
+				RevokedAt: sql.Null[time.Time]{V: time.Now(), Valid: true},
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:   "expired key",
+			method: calculatorv1.CalculatorService_Calculate_FullMethodName,
+			apiKey: &models.APIKey{
+				UserID:    "user-id",
+				Scopes:    models.APIKeyScopes{models.APIKeyScopeSubmit},
+				ExpiresAt: sql.Null[time.Time]{V: time.Now().Add(-time.Minute), Valid: true},
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "unknown key",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			storeErr: models.ErrAPIKeyNotFound,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "store error",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			storeErr: assert.AnError,
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := mocks.NewMockTokenStore(t)
+			store.EXPECT().GetAPIKeyByHash(mock.Anything, HashAPIKey(key)).Return(tt.apiKey, tt.storeErr)
+			interceptor := newTestAuth(t, conf, store).UnaryServerInterceptor()
+
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), &serverTransportStream{method: tt.method})
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+key))
+
+			var handlerCtx context.Context
+			handler := func(ctx context.Context, _ any) (any, error) {
+				handlerCtx = ctx
+				return nil, nil
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK {
+				return
+			}
+
+			got, ok := UserFromContext(handlerCtx)
+			require.True(t, ok)
+			assert.Equal(t, UserInfo{ID: "user-id", Login: "testuser", Role: models.UserRoleUser}, got)
+		})
+	}
+}
+
+func TestAuth_UnaryServerInterceptor_roleChange(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	require.NoError(t, repo.Register(ctx, models.RegisterUserCmd{Login: "admin", PasswordHash: "hash", Role: models.UserRoleAdmin}))
+	user, err := repo.GetUser(ctx, models.GetUserCmd{Login: "admin"})
+	require.NoError(t, err)
+
+	a := newTestAuth(t, testConfig(), repo)
+	interceptor := a.UnaryServerInterceptor()
+	call := func(token string) error {
+		method := calculatorv1.AdminService_ListUsers_FullMethodName
+		ctx := grpc.NewContextWithServerTransportStream(ctx, &serverTransportStream{method: method})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+			return nil, nil
+		})
+		return err
+	}
+
+	adminToken, err := a.GenerateJWT(UserInfo{ID: user.ID, Login: user.Login, Role: user.Role})
+	require.NoError(t, err)
+	require.NoError(t, call(adminToken))
+
+	// Likely within the same second as the token was issued
+	user, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: user.ID, Role: models.UserRoleUser})
+	require.NoError(t, err)
+
+	err = call(adminToken)
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "the token of the demoted admin is revoked: %v", err)
+
+	userToken, err := a.GenerateJWT(UserInfo{ID: user.ID, Login: user.Login, Role: user.Role})
+	require.NoError(t, err)
+	require.NoError(t, call(userToken), "tokens issued after the change are valid")
+}
+
+// serverTransportStream provides the method name to [grpc.Method] outside a real server.
+type serverTransportStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s *serverTransportStream) Method() string {
+	return s.method
+}
+
+func testConfig() *config.Config {
+	return &config.Config{AuthJWTSecret: "secret", AuthJWTExpirationTime: time.Hour}
+}
+
+func newTestAuth(t *testing.T, conf *config.Config, store TokenStore) *Auth {
+	t.Helper()
+	a, err := New(conf, store)
+	require.NoError(t, err)
+	return a
+}
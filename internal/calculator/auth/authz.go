@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Permission string
+
+const (
+	PermissionExpressionsRead    Permission = "expressions:read"
+	PermissionExpressionsSubmit  Permission = "expressions:submit"
+	PermissionExpressionsReadAll Permission = "expressions:read-all" // expressions of other users
+	PermissionAccountManage      Permission = "account:manage"       // own tokens and API keys
+	PermissionUsersManage        Permission = "users:manage"
+	PermissionAgentsManage       Permission = "agents:manage"
+	PermissionDatabaseBackup     Permission = "database:backup"
+)
+
+var rolePermissions = map[models.UserRole][]Permission{
+	models.UserRoleAdmin: {
+		PermissionExpressionsRead,
+		PermissionExpressionsSubmit,
+		PermissionExpressionsReadAll,
+		PermissionAccountManage,
+		PermissionUsersManage,
+		PermissionAgentsManage,
+		PermissionDatabaseBackup,
+	},
+	models.UserRoleUser: {
+		PermissionExpressionsRead,
+		PermissionExpressionsSubmit,
+		PermissionAccountManage,
+	},
+	models.UserRoleReadOnly: {
+		PermissionExpressionsRead,
+		PermissionAccountManage,
+	},
+}
+
+// methodPermissions maps the methods requiring authentication to the permission they require.
+// Methods missing from the map are denied to everyone.
+var methodPermissions = map[string]Permission{
+	calculatorv1.CalculatorService_Calculate_FullMethodName:                PermissionExpressionsSubmit,
+	calculatorv1.CalculatorService_ListExpressions_FullMethodName:          PermissionExpressionsRead,
+	calculatorv1.CalculatorService_GetExpression_FullMethodName:            PermissionExpressionsRead,
+	calculatorv1.CalculatorService_ListExpressionTasks_FullMethodName:      PermissionExpressionsRead,
+	calculatorv1.CalculatorService_ListExpressionTaskEvents_FullMethodName: PermissionExpressionsRead,
+	calculatorv1.CalculatorService_GetExpressionCallback_FullMethodName:    PermissionExpressionsRead,
+
+	calculatorv1.UserService_Logout_FullMethodName:         PermissionAccountManage,
+	calculatorv1.UserService_GetMe_FullMethodName:          PermissionAccountManage,
+	calculatorv1.UserService_ChangePassword_FullMethodName: PermissionAccountManage,
+	calculatorv1.UserService_DeleteAccount_FullMethodName:  PermissionAccountManage,
+	calculatorv1.UserService_CreateAPIKey_FullMethodName:   PermissionAccountManage,
+	calculatorv1.UserService_ListAPIKeys_FullMethodName:    PermissionAccountManage,
+	calculatorv1.UserService_RevokeAPIKey_FullMethodName:   PermissionAccountManage,
+
+	calculatorv1.AdminService_ListUsers_FullMethodName:             PermissionUsersManage,
+	calculatorv1.AdminService_SetUserRole_FullMethodName:           PermissionUsersManage,
+	calculatorv1.AdminService_ListUserExpressions_FullMethodName:   PermissionExpressionsReadAll,
+	calculatorv1.AdminService_ListAgents_FullMethodName:            PermissionAgentsManage,
+	calculatorv1.AdminService_GetRetentionPolicy_FullMethodName:    PermissionUsersManage,
+	calculatorv1.AdminService_SetRetentionPolicy_FullMethodName:    PermissionUsersManage,
+	calculatorv1.AdminService_DeleteRetentionPolicy_FullMethodName: PermissionUsersManage,
+	calculatorv1.AdminService_CreateBackup_FullMethodName:          PermissionDatabaseBackup,
+	calculatorv1.AdminService_WatchExpressionEvents_FullMethodName: PermissionExpressionsReadAll,
+}
+
+// passwordChangeMethods are the only methods allowed until a user with [UserInfo.PasswordChangeRequired] changes the password.
+var passwordChangeMethods = []string{
+	calculatorv1.UserService_GetMe_FullMethodName,
+	calculatorv1.UserService_ChangePassword_FullMethodName,
+	calculatorv1.UserService_Logout_FullMethodName,
+}
+
+// HasPermission reports whether the role grants the permission.
+// Users authenticated with tokens issued before roles were introduced have no role and are treated as [models.UserRoleUser].
+func HasPermission(role models.UserRole, perm Permission) bool {
+	if role == "" {
+		role = models.UserRoleUser
+	}
+	return slices.Contains(rolePermissions[role], perm)
+}
+
+// UnaryServerAuthorizationInterceptor checks that the role of the authenticated user
+// grants the permission required by the method. It must be chained after [Auth.UnaryServerInterceptor].
+// Users required to change the password are only allowed [passwordChangeMethods].
+func (a *Auth) UnaryServerAuthorizationInterceptor() grpc.UnaryServerInterceptor {
+	authzFn := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	return selector.UnaryServerInterceptor(authzFn, selector.MatchFunc(requiresAuth))
+}
+
+// StreamServerAuthorizationInterceptor is the streaming counterpart of [Auth.UnaryServerAuthorizationInterceptor].
+// It must be chained after [Auth.StreamServerInterceptor].
+func (a *Auth) StreamServerAuthorizationInterceptor() grpc.StreamServerInterceptor {
+	authzFn := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+
+	return selector.StreamServerInterceptor(authzFn, selector.MatchFunc(requiresAuth))
+}
+
+func authorize(ctx context.Context, method string) error {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	perm, ok := methodPermissions[method]
+	if !ok || !HasPermission(user.Role, perm) {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	if user.PasswordChangeRequired && !slices.Contains(passwordChangeMethods, method) {
+		return status.Error(codes.PermissionDenied, "password change required")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuth_UnaryServerAuthorizationInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		user     *UserInfo
+		wantCode codes.Code
+	}{
+		{
+			name:     "user submits expressions",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleUser},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "read-only user can't submit expressions",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleReadOnly},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "read-only user reads expressions",
+			method:   calculatorv1.CalculatorService_GetExpression_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleReadOnly},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "read-only user manages own api keys",
+			method:   calculatorv1.UserService_CreateAPIKey_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleReadOnly},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "user can't list users",
+			method:   calculatorv1.AdminService_ListUsers_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleUser},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "user can't read expressions of other users",
+			method:   calculatorv1.AdminService_ListUserExpressions_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: models.UserRoleUser},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "admin reads expressions of other users",
+			method:   calculatorv1.AdminService_ListUserExpressions_FullMethodName,
+			user:     &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "admin manages agents",
+			method:   calculatorv1.AdminService_ListAgents_FullMethodName,
+			user:     &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "token without role is treated as user",
+			method:   calculatorv1.CalculatorService_Calculate_FullMethodName,
+			user:     &UserInfo{ID: "user-id"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "unknown role",
+			method:   calculatorv1.CalculatorService_ListExpressions_FullMethodName,
+			user:     &UserInfo{ID: "user-id", Role: "superuser"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "admin required to change password can't list users",
+			method:   calculatorv1.AdminService_ListUsers_FullMethodName,
+			user:     &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin, PasswordChangeRequired: true},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "admin required to change password changes it",
+			method:   calculatorv1.UserService_ChangePassword_FullMethodName,
+			user:     &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin, PasswordChangeRequired: true},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "unauthenticated",
+			method:   calculatorv1.CalculatorService_ListExpressions_FullMethodName,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "public method",
+			method:   calculatorv1.UserService_Login_FullMethodName,
+			wantCode: codes.OK,
+		},
+	}
+
+	interceptor := newTestAuth(t, testConfig(), nil).UnaryServerAuthorizationInterceptor()
+	handler := func(context.Context, any) (any, error) {
+		return nil, nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = WithContext(ctx, *tt.user)
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+	}
+}
+
+func TestAuth_StreamServerAuthorizationInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *UserInfo
+		wantCode codes.Code
+	}{
+		{name: "admin backs up the database", user: &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin}, wantCode: codes.OK},
+		{name: "user can't back up the database", user: &UserInfo{ID: "user-id", Role: models.UserRoleUser}, wantCode: codes.PermissionDenied},
+		{
+			name:     "admin required to change the password",
+			user:     &UserInfo{ID: "admin-id", Role: models.UserRoleAdmin, PasswordChangeRequired: true},
+			wantCode: codes.PermissionDenied,
+		},
+		{name: "unauthenticated", wantCode: codes.Unauthenticated},
+	}
+
+	interceptor := newTestAuth(t, testConfig(), nil).StreamServerAuthorizationInterceptor()
+	handler := func(any, grpc.ServerStream) error {
+		return nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = WithContext(ctx, *tt.user)
+			}
+
+			info := &grpc.StreamServerInfo{FullMethod: calculatorv1.AdminService_CreateBackup_FullMethodName, IsServerStream: true}
+			err := interceptor(nil, &testServerStream{ctx: ctx}, info, handler)
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestMethodPermissions(t *testing.T) {
+	// Every method requiring authentication must be covered, otherwise it is denied to everyone
+	for _, desc := range []grpc.ServiceDesc{
+		calculatorv1.CalculatorService_ServiceDesc,
+		calculatorv1.AdminService_ServiceDesc,
+	} {
+		for _, m := range desc.Methods {
+			method := "/" + desc.ServiceName + "/" + m.MethodName
+			assert.Contains(t, methodPermissions, method)
+		}
+		for _, s := range desc.Streams {
+			method := "/" + desc.ServiceName + "/" + s.StreamName
+			assert.Contains(t, methodPermissions, method)
+		}
+	}
+	for _, method := range authenticatedUserMethods {
+		assert.Contains(t, methodPermissions, method)
+	}
+}
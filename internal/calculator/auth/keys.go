@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// keySet holds the key access tokens are signed with and the keys they are verified with.
+// Asymmetric keys are identified by the kid header, which is the RFC 7638 thumbprint of the public key.
+// The HMAC secret is used only if no private key is configured, and its tokens have no kid.
+type keySet struct {
+	current    jwtKey // the key new tokens are signed with
+	privateKey any
+	keys       map[string]jwtKey // by kid
+	jwks       []byte
+}
+
+type jwtKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    any
+	jwk    JWK
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517) of the public keys access tokens can be verified with.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func loadKeySet(conf *config.Config) (*keySet, error) {
+	ks := &keySet{keys: make(map[string]jwtKey)}
+
+	if conf.AuthJWTPrivateKeyFile == "" {
+		ks.current = jwtKey{method: jwt.SigningMethodHS256, key: []byte(conf.AuthJWTSecret)}
+		ks.privateKey = []byte(conf.AuthJWTSecret)
+		ks.keys[""] = ks.current
+	} else {
+		priv, err := readPrivateKey(conf.AuthJWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read private key %s: %w", conf.AuthJWTPrivateKeyFile, err)
+		}
+		key, err := newJWTKey(priv.Public())
+		if err != nil {
+			return nil, fmt.Errorf("private key %s: %w", conf.AuthJWTPrivateKeyFile, err)
+		}
+		ks.current, ks.privateKey = key, priv
+		ks.keys[key.kid] = key
+	}
+
+	for _, path := range conf.AuthJWTPublicKeyFiles {
+		pub, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("read public key %s: %w", path, err)
+		}
+		key, err := newJWTKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("public key %s: %w", path, err)
+		}
+		ks.keys[key.kid] = key
+	}
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, kid := range slices.Sorted(maps.Keys(ks.keys)) {
+		if kid != "" {
+			jwks.Keys = append(jwks.Keys, ks.keys[kid].jwk)
+		}
+	}
+	var err error
+	if ks.jwks, err = json.Marshal(jwks); err != nil {
+		return nil, fmt.Errorf("marshal jwks: %w", err)
+	}
+
+	return ks, nil
+}
+
+func newJWTKey(pub crypto.PublicKey) (jwtKey, error) {
+	var (
+		key    = jwtKey{key: pub}
+		fields map[string]string // required members of the thumbprint
+	)
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return jwtKey{}, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.jwk = JWK{Kty: "RSA", N: b64(pub.N.Bytes()), E: b64(big.NewInt(int64(pub.E)).Bytes())}
+		fields = map[string]string{"e": key.jwk.E, "kty": key.jwk.Kty, "n": key.jwk.N}
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.jwk = JWK{Kty: "OKP", Crv: "Ed25519", X: b64(pub)}
+		fields = map[string]string{"crv": key.jwk.Crv, "kty": key.jwk.Kty, "x": key.jwk.X}
+	default:
+		return jwtKey{}, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys are supported", pub)
+	}
+
+	// json.Marshal sorts map keys, which gives the canonical form required by RFC 7638.
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return jwtKey{}, fmt.Errorf("marshal thumbprint: %w", err)
+	}
+	thumbprint := sha256.Sum256(canonical)
+
+	key.kid = b64(thumbprint[:])
+	key.jwk.Kid = key.kid
+	key.jwk.Use = "sig"
+	key.jwk.Alg = key.method.Alg()
+	return key, nil
+}
+
+// readPrivateKey reads a PEM encoded PKCS #8 or PKCS #1 private key.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse pkcs8 private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+// readPublicKey reads a PEM encoded PKIX public key. The public part of a private key is accepted as well.
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		priv, err := readPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return priv.Public(), nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem data found")
+	}
+	return block, nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_asymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		wantAlg string
+	}{
+		{name: "RS256", key: rsaKey, wantAlg: "RS256"},
+		{name: "EdDSA", key: edKey, wantAlg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testConfig()
+			conf.AuthJWTPrivateKeyFile = writePrivateKey(t, tt.key)
+			a := newTestAuth(t, conf, nil)
+
+			token, err := a.GenerateJWT(UserInfo{ID: "user-id", Login: "testuser"})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, parsed.Method.Alg())
+
+			claims, err := a.validateJWT(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-id", claims.UserInfo.ID)
+
+			var jwks JWKS
+			require.NoError(t, json.Unmarshal(a.JWKS(), &jwks))
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, parsed.Header["kid"], jwks.Keys[0].Kid)
+			assert.Equal(t, tt.wantAlg, jwks.Keys[0].Alg)
+
+			// The token can be verified with the published key alone
+			_, err = jwt.Parse(token, func(*jwt.Token) (any, error) { return publicKeyFromJWK(t, jwks.Keys[0]), nil })
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAuth_keyRotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldKeyFile, newKeyFile := writePrivateKey(t, oldKey), writePrivateKey(t, newKey)
+
+	conf := testConfig()
+	conf.AuthJWTPrivateKeyFile = oldKeyFile
+	oldToken, err := newTestAuth(t, conf, nil).GenerateJWT(UserInfo{ID: "user-id"})
+	require.NoError(t, err)
+	hmacToken, err := newTestAuth(t, testConfig(), nil).GenerateJWT(UserInfo{ID: "user-id"})
+	require.NoError(t, err)
+
+	// The new key signs tokens, the old one is kept for verification until its tokens expire
+	conf.AuthJWTPrivateKeyFile = newKeyFile
+	conf.AuthJWTPublicKeyFiles = []string{writePublicKey(t, oldKey.Public())}
+	rotated := newTestAuth(t, conf, nil)
+
+	_, err = rotated.validateJWT(oldToken)
+	require.NoError(t, err)
+	newToken, err := rotated.GenerateJWT(UserInfo{ID: "user-id"})
+	require.NoError(t, err)
+	_, err = rotated.validateJWT(newToken)
+	require.NoError(t, err)
+
+	var jwks JWKS
+	require.NoError(t, json.Unmarshal(rotated.JWKS(), &jwks))
+	assert.Len(t, jwks.Keys, 2)
+
+	_, err = rotated.validateJWT(hmacToken)
+	require.Error(t, err, "hmac tokens should not be accepted once a private key is configured")
+
+	// The old key is removed
+	conf.AuthJWTPublicKeyFiles = nil
+	_, err = newTestAuth(t, conf, nil).validateJWT(oldToken)
+	require.Error(t, err)
+}
+
+func TestNew_invalidKeys(t *testing.T) {
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	for name, path := range map[string]string{
+		"missing file":  filepath.Join(t.TempDir(), "missing.pem"),
+		"weak rsa key":  writePrivateKey(t, weakKey),
+		"not a pem key": writeFile(t, []byte("secret")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf := testConfig()
+			conf.AuthJWTPrivateKeyFile = path
+			_, err := New(conf, nil)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestAuth_JWKS_hmac(t *testing.T) {
+	assert.JSONEq(t, `{"keys": []}`, string(newTestAuth(t, testConfig(), nil).JWKS()))
+}
+
+func writePrivateKey(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writeFile(t, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return writeFile(t, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func writeFile(t *testing.T, data []byte) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "key-*.pem")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func publicKeyFromJWK(t *testing.T, jwk JWK) crypto.PublicKey {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected key type %q", jwk.Kty)
+	return nil
+}
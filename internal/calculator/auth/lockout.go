@@ -0,0 +1,26 @@
+package auth
+
+import "time"
+
+// LockoutPolicy defines how repeated failed login attempts are locked out.
+type LockoutPolicy struct {
+	// MaxAttempts is the number of consecutive failures that triggers a lockout, zero disables lockouts.
+	MaxAttempts int
+	// Duration is the lockout duration after MaxAttempts failures. It doubles with every next failure.
+	Duration time.Duration
+	// MaxDuration caps the lockout duration.
+	MaxDuration time.Duration
+}
+
+// Lockout returns the lockout duration after the given number of consecutive failures,
+// or zero if attempts shouldn't be locked out.
+func (p LockoutPolicy) Lockout(failures int) time.Duration {
+	if p.MaxAttempts <= 0 || failures < p.MaxAttempts {
+		return 0
+	}
+	d := p.Duration
+	for i := p.MaxAttempts; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDuration)
+}
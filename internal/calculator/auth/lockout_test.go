@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicy_Lockout(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 3, Duration: time.Minute, MaxDuration: 10 * time.Minute}
+
+	assert.Zero(t, policy.Lockout(2))
+	assert.Equal(t, time.Minute, policy.Lockout(3))
+	assert.Equal(t, 2*time.Minute, policy.Lockout(4))
+	assert.Equal(t, 8*time.Minute, policy.Lockout(6))
+	assert.Equal(t, 10*time.Minute, policy.Lockout(7))
+	assert.Equal(t, 10*time.Minute, policy.Lockout(100))
+
+	assert.Zero(t, LockoutPolicy{}.Lockout(100), "lockouts are disabled")
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters of newly created hashes, as recommended by RFC 9106.
+// Hashes created with other parameters are reported as needing a rehash.
+const (
+	argon2Time    uint32 = 3
+	argon2Memory  uint32 = 64 * 1024 // KiB
+	argon2Threads uint8  = 4
+	argon2SaltLen        = 16
+	argon2KeyLen  uint32 = 32
+)
+
+const argon2idPrefix = "$argon2id$"
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// HashPassword derives an argon2id hash of the password with a random salt.
+// The hash is encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword reports whether the password matches the hash, and whether the hash
+// should be replaced with [HashPassword] since it uses a legacy format or outdated parameters.
+// Besides argon2id, it accepts the legacy unsalted MD5 hex digests.
+// An empty hash belongs to a user without a password, e.g. signed up with an external identity provider,
+// and matches no password.
+// Returns [ErrUnknownPasswordHash] if the hash format is not recognized.
+func VerifyPassword(hash, password string) (ok bool, rehash bool, err error) {
+	switch {
+	case hash == "":
+		return false, false, nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	case isLegacyMD5(hash):
+		sum := md5.Sum([]byte(password))
+		return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(hash))) == 1, true, nil
+	default:
+		return false, false, ErrUnknownPasswordHash
+	}
+}
+
+func verifyArgon2id(hash, password string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, fmt.Errorf("%w: malformed argon2id hash", ErrUnknownPasswordHash)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, fmt.Errorf("%w: unsupported argon2id version %q", ErrUnknownPasswordHash, parts[2])
+	}
+
+	var (
+		memory, time uint32
+		threads      uint8
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false, fmt.Errorf("%w: malformed argon2id parameters: %v", ErrUnknownPasswordHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, fmt.Errorf("%w: decode salt: %v", ErrUnknownPasswordHash, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, fmt.Errorf("%w: decode key: %v", ErrUnknownPasswordHash, err)
+	}
+
+	other := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	ok := subtle.ConstantTimeCompare(key, other) == 1
+	rehash := memory != argon2Memory || time != argon2Time || threads != argon2Threads ||
+		len(salt) != argon2SaltLen || uint32(len(key)) != argon2KeyLen
+	return ok, rehash, nil
+}
+
+// isLegacyMD5 reports whether the hash is an MD5 hex digest created before the switch to argon2id.
+func isLegacyMD5(hash string) bool {
+	if len(hash) != hex.EncodedLen(md5.Size) {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$"), hash)
+
+	other, err := HashPassword("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "hashes of the same password should be salted")
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("password123")
+	require.NoError(t, err)
+
+	salt := []byte("somesaltsomesalt")
+	outdated := fmt.Sprintf("$argon2id$v=19$m=4096,t=1,p=1$%s$%s",
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("password123"), salt, 1, 4096, 1, 32)),
+	)
+
+	tests := []struct {
+		name       string
+		hash       string
+		password   string
+		wantOK     bool
+		wantRehash bool
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "argon2id match",
+			hash:     hash,
+			password: "password123",
+			wantOK:   true,
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "argon2id mismatch",
+			hash:     hash,
+			password: "wrong",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:       "argon2id with outdated parameters",
+			hash:       outdated,
+			password:   "password123",
+			wantOK:     true,
+			wantRehash: true,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:       "legacy md5 match",
+			hash:       "21232f297a57a5a743894a0e4a801fc3", // MD5 hash of "admin"
+			password:   "admin",
+			wantOK:     true,
+			wantRehash: true,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:       "legacy md5 mismatch",
+			hash:       "21232f297a57a5a743894a0e4a801fc3",
+			password:   "wrong",
+			wantRehash: true,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:     "no password",
+			hash:     "",
+			password: "",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "unknown format",
+			hash:     "plaintext",
+			password: "plaintext",
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrUnknownPasswordHash)
+			},
+		},
+		{
+			name:     "malformed argon2id",
+			hash:     "$argon2id$v=19$m=65536",
+			password: "password123",
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrUnknownPasswordHash)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := VerifyPassword(tt.hash, tt.password)
+			if !tt.wantErr(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantRehash, rehash)
+		})
+	}
+}
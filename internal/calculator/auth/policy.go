@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	LoginMinLength = 3
+	LoginMaxLength = 32
+)
+
+var loginRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateLogin returns the requirements the login doesn't meet, if any.
+// Logins are compared case-insensitively, so only ASCII characters are allowed.
+func ValidateLogin(login string) []string {
+	var problems []string
+	if n := len(login); n < LoginMinLength || n > LoginMaxLength {
+		problems = append(problems, fmt.Sprintf("must be from %d to %d characters long", LoginMinLength, LoginMaxLength))
+	}
+	if login != "" && !loginRe.MatchString(login) {
+		problems = append(problems, "must start with a letter or digit and contain only letters, digits, '.', '_' and '-'")
+	}
+	return problems
+}
+
+// PasswordPolicy defines the requirements for new passwords.
+// Character classes are lowercase letters, uppercase letters, digits and other characters.
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int
+	MinCharClasses int
+}
+
+// Validate returns the requirements the password of the user with the given login doesn't meet, if any.
+func (p PasswordPolicy) Validate(password, login string) []string {
+	var problems []string
+	if n := utf8.RuneCountInString(password); n < p.MinLength || (p.MaxLength > 0 && n > p.MaxLength) {
+		if p.MaxLength > 0 {
+			problems = append(problems, fmt.Sprintf("must be from %d to %d characters long", p.MinLength, p.MaxLength))
+		} else {
+			problems = append(problems, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+		}
+	}
+	if classes := charClasses(password); classes < p.MinCharClasses {
+		problems = append(problems, fmt.Sprintf(
+			"must contain characters of at least %d classes: lowercase letters, uppercase letters, digits, other characters",
+			p.MinCharClasses,
+		))
+	}
+	if login != "" && strings.EqualFold(password, login) {
+		problems = append(problems, "must not match the login")
+	}
+	return problems
+}
+
+const (
+	generatedPasswordLength  = 20
+	generatedPasswordSymbols = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.!@#%"
+)
+
+// Generate returns a random password that meets the policy, such as a one-time password of a bootstrapped user.
+func (p PasswordPolicy) Generate() (string, error) {
+	n := max(generatedPasswordLength, p.MinLength)
+	if p.MaxLength > 0 {
+		n = min(n, p.MaxLength)
+	}
+
+	symbols := big.NewInt(int64(len(generatedPasswordSymbols)))
+	for range 100 {
+		b := make([]byte, n)
+		for i := range b {
+			idx, err := rand.Int(rand.Reader, symbols)
+			if err != nil {
+				return "", fmt.Errorf("generate password: %w", err)
+			}
+			b[i] = generatedPasswordSymbols[idx.Int64()]
+		}
+		if password := string(b); len(p.Validate(password, "")) == 0 {
+			return password, nil
+		}
+	}
+	return "", errors.New("no password meeting the policy was generated")
+}
+
+func charClasses(s string) int {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLogin(t *testing.T) {
+	tests := []struct {
+		login     string
+		wantValid bool
+	}{
+		{login: "user", wantValid: true},
+		{login: "John.Doe-2_", wantValid: true},
+		{login: "42", wantValid: false},
+		{login: "", wantValid: false},
+		{login: strings.Repeat("a", LoginMaxLength+1), wantValid: false},
+		{login: ".user", wantValid: false},
+		{login: "user name", wantValid: false},
+		{login: "пользователь", wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			assert.Equal(t, tt.wantValid, len(ValidateLogin(tt.login)) == 0)
+		})
+	}
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 16, MinCharClasses: 3}
+
+	tests := []struct {
+		name         string
+		password     string
+		wantProblems int
+	}{
+		{name: "valid", password: "Passw0rd", wantProblems: 0},
+		{name: "unicode letters and symbols", password: "Пароль-2025", wantProblems: 0},
+		{name: "too short", password: "Pa0", wantProblems: 1},
+		{name: "too long", password: "Passw0rd-Passw0rd", wantProblems: 1},
+		{name: "too few char classes", password: "password1", wantProblems: 1},
+		{name: "matches login", password: "TestUser1", wantProblems: 1},
+		{name: "empty", password: "", wantProblems: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, policy.Validate(tt.password, "testuser1"), tt.wantProblems)
+		})
+	}
+}
+
+func TestPasswordPolicy_Generate(t *testing.T) {
+	for _, policy := range []PasswordPolicy{
+		{MinLength: 8, MaxLength: 128, MinCharClasses: 2},
+		{MinLength: 32, MinCharClasses: 4},
+		{MinLength: 4, MaxLength: 8, MinCharClasses: 4},
+	} {
+		password, err := policy.Generate()
+		require.NoError(t, err)
+		assert.Empty(t, policy.Validate(password, ""), password)
+	}
+
+	_, err := PasswordPolicy{MinLength: 1, MaxLength: 3, MinCharClasses: 4}.Generate()
+	require.Error(t, err)
+}
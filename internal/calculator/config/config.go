@@ -3,45 +3,217 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"reflect"
+	"slices"
 	"time"
 
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
+
 	"github.com/caarlos0/env/v11"
 )
 
+const (
+	// OperationTimeModeFixed uses the configured time of each operation.
+	OperationTimeModeFixed = "fixed"
+	// OperationTimeModeZero sets operation times to zero.
+	OperationTimeModeZero = "zero"
+	// OperationTimeModeCost estimates operation times from the computation time
+	// measured by agents, falling back to the configured time.
+	OperationTimeModeCost = "cost"
+)
+
 type Config struct {
-	LogLevel     string `env:"LOG_LEVEL"`
-	MgmtAddr     string `env:"MGMT_ADDR"`
-	GRPCAddr     string `env:"GRPC_ADDR"`
-	HTTPAddr     string `env:"HTTP_ADDR"`
-	DBSQLitePath string `env:"DB_SQLITE_PATH"`
+	LogLevel      string `env:"LOG_LEVEL"`
+	MgmtAddr      string `env:"MGMT_ADDR"`
+	GRPCAddr      string `env:"GRPC_ADDR"`
+	HTTPAddr      string `env:"HTTP_ADDR"`
+	DBDriver      string `env:"DB_DRIVER"`
+	DBSQLitePath  string `env:"DB_SQLITE_PATH"`
+	DBPostgresDSN string `env:"DB_POSTGRES_DSN" secret:""`
+	DBAutoMigrate bool   `env:"DB_AUTO_MIGRATE"`
+
+	BackupInterval  time.Duration `env:"BACKUP_INTERVAL"` // 0 disables scheduled backups
+	BackupDir       string        `env:"BACKUP_DIR"`
+	BackupRetention int           `env:"BACKUP_RETENTION"` // number of the latest scheduled backups kept
+
+	RetentionInterval  time.Duration `env:"RETENTION_INTERVAL"`  // 0 disables purging
+	RetentionMaxAge    time.Duration `env:"RETENTION_MAX_AGE"`   // 0 keeps finished expressions regardless of age
+	RetentionMaxCount  int           `env:"RETENTION_MAX_COUNT"` // number of the latest finished expressions kept per user, 0 keeps all
+	RetentionBatchSize int           `env:"RETENTION_BATCH_SIZE"`
+
+	OutboxInterval       time.Duration `env:"OUTBOX_INTERVAL"` // 0 disables the dispatcher, the events are kept in the outbox
+	OutboxBatchSize      int           `env:"OUTBOX_BATCH_SIZE"`
+	OutboxMaxBackoff     time.Duration `env:"OUTBOX_MAX_BACKOFF"`           // of the retries of a failing sink
+	OutboxWebhookURL     string        `env:"OUTBOX_WEBHOOK_URL" secret:""` // enables the webhook sink
+	OutboxWebhookTimeout time.Duration `env:"OUTBOX_WEBHOOK_TIMEOUT"`
+	OutboxFilePath       string        `env:"OUTBOX_FILE_PATH"` // enables the NDJSON file sink
+	OutboxStream         bool          `env:"OUTBOX_STREAM"`    // enables the gRPC stream sink
+
+	CallbackInterval     time.Duration  `env:"CALLBACK_INTERVAL"` // 0 disables the delivery, the callbacks are kept pending
+	CallbackBatchSize    int            `env:"CALLBACK_BATCH_SIZE"`
+	CallbackTimeout      time.Duration  `env:"CALLBACK_TIMEOUT"`
+	CallbackMaxAttempts  int            `env:"CALLBACK_MAX_ATTEMPTS"`
+	CallbackBackoff      time.Duration  `env:"CALLBACK_BACKOFF"` // before the first retry, doubled on each one
+	CallbackMaxBackoff   time.Duration  `env:"CALLBACK_MAX_BACKOFF"`
+	CallbackAllowedCIDRs []netip.Prefix `env:"CALLBACK_ALLOWED_CIDRS"` // exempt from the denied addresses
+	CallbackDeniedCIDRs  []netip.Prefix `env:"CALLBACK_DENIED_CIDRS"`  // besides loopback, private, link-local and unspecified ones
+
+	IdempotencyKeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL"` // for how long a Calculate request can be replayed by its key
 
 	AuthJWTSecret         string        `env:"AUTH_JWT_SECRET" secret:""`
 	AuthJWTExpirationTime time.Duration `env:"AUTH_JWT_EXPIRATION_TIME"`
+	AuthJWTPrivateKeyFile string        `env:"AUTH_JWT_PRIVATE_KEY_FILE"`
+	AuthJWTPublicKeyFiles []string      `env:"AUTH_JWT_PUBLIC_KEY_FILES"`
+
+	AuthRefreshTokenExpirationTime time.Duration `env:"AUTH_REFRESH_TOKEN_EXPIRATION_TIME"`
+	AuthReauthenticationMaxAge     time.Duration `env:"AUTH_REAUTHENTICATION_MAX_AGE"` // of the login confirming the operations of users without a password
+
+	AuthPasswordMinLength      int `env:"AUTH_PASSWORD_MIN_LENGTH"`
+	AuthPasswordMaxLength      int `env:"AUTH_PASSWORD_MAX_LENGTH"`
+	AuthPasswordMinCharClasses int `env:"AUTH_PASSWORD_MIN_CHAR_CLASSES"`
+
+	AuthLoginMaxAttempts        int           `env:"AUTH_LOGIN_MAX_ATTEMPTS"`
+	AuthLoginMaxAttemptsPerIP   int           `env:"AUTH_LOGIN_MAX_ATTEMPTS_PER_IP"`
+	AuthLoginLockoutDuration    time.Duration `env:"AUTH_LOGIN_LOCKOUT_DURATION"`
+	AuthLoginMaxLockoutDuration time.Duration `env:"AUTH_LOGIN_MAX_LOCKOUT_DURATION"`
+	AuthLoginFailureWindow      time.Duration `env:"AUTH_LOGIN_FAILURE_WINDOW"`
+
+	AuthOIDCIssuerURL    string   `env:"AUTH_OIDC_ISSUER_URL"`
+	AuthOIDCClientID     string   `env:"AUTH_OIDC_CLIENT_ID"`
+	AuthOIDCClientSecret string   `env:"AUTH_OIDC_CLIENT_SECRET" secret:""`
+	AuthOIDCRedirectURL  string   `env:"AUTH_OIDC_REDIRECT_URL"`
+	AuthOIDCScopes       []string `env:"AUTH_OIDC_SCOPES"`
+
+	OperationTimeMode     string        `env:"OPERATION_TIME_MODE"`
+	OperationCostWindow   time.Duration `env:"OPERATION_COST_WINDOW"`
+	OperationCostCacheTTL time.Duration `env:"OPERATION_COST_CACHE_TTL"`
 
 	TimeAdditionMs       int `env:"TIME_ADDITION_MS"`
 	TimeSubtractionMs    int `env:"TIME_SUBTRACTION_MS"`
 	TimeMultiplicationMs int `env:"TIME_MULTIPLICATIONS_MS"`
 	TimeDivisionMs       int `env:"TIME_DIVISIONS_MS"`
+
+	AgentTTL                time.Duration `env:"AGENT_TTL"`
+	AgentHeartbeatInterval  time.Duration `env:"AGENT_HEARTBEAT_INTERVAL"`
+	AgentUnroutableInterval time.Duration `env:"AGENT_UNROUTABLE_INTERVAL"`
+	TaskLeaseTimeout        time.Duration `env:"TASK_LEASE_TIMEOUT"`
+
+	TracingExporter     string  `env:"TRACING_EXPORTER"`
+	TracingFilePath     string  `env:"TRACING_FILE_PATH"`
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO"`
 }
 
 func Load() (*Config, error) {
 	conf := &Config{
-		LogLevel:              "info",
-		MgmtAddr:              ":8081",
-		GRPCAddr:              ":50051",
-		HTTPAddr:              ":8080",
-		DBSQLitePath:          ".data/db.sqlite",
-		AuthJWTSecret:         "jwt-secret",
-		AuthJWTExpirationTime: time.Hour,
-		TimeAdditionMs:        1000,
-		TimeSubtractionMs:     1000,
-		TimeMultiplicationMs:  1000,
-		TimeDivisionMs:        1000,
+		LogLevel:                       "info",
+		MgmtAddr:                       ":8081",
+		GRPCAddr:                       ":50051",
+		HTTPAddr:                       ":8080",
+		DBDriver:                       database.DriverSQLite,
+		DBSQLitePath:                   ".data/db.sqlite",
+		BackupDir:                      ".data/backups",
+		BackupRetention:                7,
+		RetentionInterval:              time.Hour,
+		RetentionBatchSize:             500,
+		OutboxInterval:                 time.Second,
+		OutboxBatchSize:                100,
+		OutboxMaxBackoff:               time.Minute,
+		OutboxWebhookTimeout:           10 * time.Second,
+		CallbackInterval:               time.Second,
+		CallbackBatchSize:              20,
+		CallbackTimeout:                10 * time.Second,
+		CallbackMaxAttempts:            8,
+		CallbackBackoff:                time.Second,
+		CallbackMaxBackoff:             time.Hour,
+		IdempotencyKeyTTL:              24 * time.Hour,
+		AuthJWTSecret:                  "jwt-secret",
+		AuthJWTExpirationTime:          time.Hour,
+		AuthRefreshTokenExpirationTime: 30 * 24 * time.Hour,
+		AuthReauthenticationMaxAge:     5 * time.Minute,
+		AuthPasswordMinLength:          8,
+		AuthPasswordMaxLength:          128,
+		AuthPasswordMinCharClasses:     2,
+		AuthLoginMaxAttempts:           5,
+		AuthLoginMaxAttemptsPerIP:      20,
+		AuthLoginLockoutDuration:       time.Minute,
+		AuthLoginMaxLockoutDuration:    time.Hour,
+		AuthLoginFailureWindow:         15 * time.Minute,
+		AuthOIDCRedirectURL:            "http://localhost:8080/api/v1/oidc/callback",
+		AuthOIDCScopes:                 []string{"openid", "profile", "email"},
+		OperationTimeMode:              OperationTimeModeFixed,
+		OperationCostWindow:            time.Hour,
+		OperationCostCacheTTL:          30 * time.Second,
+		TimeAdditionMs:                 1000,
+		TimeSubtractionMs:              1000,
+		TimeMultiplicationMs:           1000,
+		TimeDivisionMs:                 1000,
+		AgentTTL:                       time.Minute,
+		AgentHeartbeatInterval:         5 * time.Second,
+		AgentUnroutableInterval:        10 * time.Second,
+		TaskLeaseTimeout:               5 * time.Minute,
+		TracingExporter:                tracing.ExporterNone,
+		TracingFilePath:                ".data/traces.jsonl",
+		TracingSampleRatio:             1,
 	}
 	if err := env.Parse(conf); err != nil {
 		return nil, fmt.Errorf("env parse: %w", err)
 	}
+	switch conf.OperationTimeMode {
+	case OperationTimeModeFixed, OperationTimeModeZero, OperationTimeModeCost:
+	default:
+		return nil, fmt.Errorf("unsupported operation time mode %q", conf.OperationTimeMode)
+	}
+	if !slices.Contains(database.Drivers, conf.DBDriver) {
+		return nil, fmt.Errorf("unsupported database driver %q", conf.DBDriver)
+	}
+	if conf.DBDriver == database.DriverPostgres && conf.DBPostgresDSN == "" {
+		return nil, fmt.Errorf("postgres dsn is required")
+	}
+	if conf.BackupInterval < 0 || (conf.BackupInterval > 0 && conf.BackupRetention < 1) {
+		return nil, fmt.Errorf("invalid scheduled backups: interval %s, retention %d", conf.BackupInterval, conf.BackupRetention)
+	}
+	if conf.BackupInterval > 0 && conf.DBDriver != database.DriverSQLite {
+		return nil, fmt.Errorf("scheduled backups are supported for sqlite only")
+	}
+	if conf.RetentionInterval < 0 || conf.RetentionMaxAge < 0 || conf.RetentionMaxCount < 0 || conf.RetentionBatchSize < 1 {
+		return nil, fmt.Errorf("invalid retention: interval %s, max age %s, max count %d, batch size %d",
+			conf.RetentionInterval, conf.RetentionMaxAge, conf.RetentionMaxCount, conf.RetentionBatchSize)
+	}
+	if conf.OutboxInterval < 0 || conf.OutboxBatchSize < 1 || conf.OutboxMaxBackoff < conf.OutboxInterval || conf.OutboxWebhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid outbox: interval %s, batch size %d, max backoff %s, webhook timeout %s",
+			conf.OutboxInterval, conf.OutboxBatchSize, conf.OutboxMaxBackoff, conf.OutboxWebhookTimeout)
+	}
+	if u, err := url.Parse(conf.OutboxWebhookURL); conf.OutboxWebhookURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https")) {
+		return nil, fmt.Errorf("outbox webhook url must be an http(s) url")
+	}
+	if conf.CallbackInterval < 0 || conf.CallbackBatchSize < 1 || conf.CallbackTimeout <= 0 || conf.CallbackMaxAttempts < 1 ||
+		conf.CallbackBackoff <= 0 || conf.CallbackMaxBackoff < conf.CallbackBackoff {
+		return nil, fmt.Errorf("invalid callbacks: interval %s, batch size %d, timeout %s, max attempts %d, backoff [%s, %s]",
+			conf.CallbackInterval, conf.CallbackBatchSize, conf.CallbackTimeout, conf.CallbackMaxAttempts,
+			conf.CallbackBackoff, conf.CallbackMaxBackoff)
+	}
+	if conf.IdempotencyKeyTTL <= 0 {
+		return nil, fmt.Errorf("idempotency key ttl must be positive, got %s", conf.IdempotencyKeyTTL)
+	}
+	if conf.AuthPasswordMinLength < 1 || (conf.AuthPasswordMaxLength > 0 && conf.AuthPasswordMaxLength < conf.AuthPasswordMinLength) {
+		return nil, fmt.Errorf("invalid password length limits [%d, %d]", conf.AuthPasswordMinLength, conf.AuthPasswordMaxLength)
+	}
+	if conf.AuthPasswordMinCharClasses < 0 || conf.AuthPasswordMinCharClasses > 4 {
+		return nil, fmt.Errorf("password min char classes must be from 0 to 4, got %d", conf.AuthPasswordMinCharClasses)
+	}
+	if conf.AuthLoginLockoutDuration <= 0 || conf.AuthLoginMaxLockoutDuration < conf.AuthLoginLockoutDuration {
+		return nil, fmt.Errorf("invalid login lockout durations [%s, %s]", conf.AuthLoginLockoutDuration, conf.AuthLoginMaxLockoutDuration)
+	}
+	if conf.AuthOIDCIssuerURL != "" && conf.AuthOIDCClientID == "" {
+		return nil, fmt.Errorf("oidc client id is required")
+	}
+	if !slices.Contains(tracing.Exporters, conf.TracingExporter) {
+		return nil, fmt.Errorf("unsupported tracing exporter %q", conf.TracingExporter)
+	}
 	return conf, nil
 }
 
@@ -65,3 +237,11 @@ func (c *Config) String() string {
 	}
 	return string(bytes)
 }
+
+// DBDSN returns the data source name of the configured database driver.
+func (c *Config) DBDSN() string {
+	if c.DBDriver == database.DriverPostgres {
+		return c.DBPostgresDSN
+	}
+	return c.DBSQLitePath
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrBackupUnsupported is returned by [Backup] for databases other than SQLite, which have backup tools of their own.
+var ErrBackupUnsupported = errors.New("backups are supported for SQLite only")
+
+// Backup copies the SQLite database into the file at path with the online backup API,
+// so that the database stays available for reads and writes while it is being copied.
+// The file is written next to path and renamed once complete, so path never holds a partial backup.
+// Returns [ErrBackupUnsupported] if db is not a SQLite database.
+func Backup(ctx context.Context, db *sqlx.DB, path string) error {
+	if db.DriverName() != sqlDrivers[DriverSQLite] {
+		return ErrBackupUnsupported
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create backup file: %w", err)
+	}
+	_ = tmp.Close()
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("db conn: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := conn.Raw(func(driverConn any) error {
+		return copyDB(tmp.Name(), driverConn.(*sqlite3.SQLiteConn))
+	}); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename backup file: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the SQLite database at dbPath with the backup at path, see [Backup].
+// The backup is checked for integrity first. The service must be stopped while the database is restored,
+// and the schema of the restored database is checked on the next start.
+func Restore(ctx context.Context, path, dbPath string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+
+	src, err := sqlx.ConnectContext(ctx, sqlDrivers[DriverSQLite], sqliteDSN(path, "_query_only=on"))
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	var integrity string
+	if err := src.GetContext(ctx, &integrity, `PRAGMA integrity_check`); err != nil {
+		return fmt.Errorf("check backup integrity: %w", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("backup is corrupted: %s", integrity)
+	}
+
+	conn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("db conn: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return conn.Raw(func(driverConn any) error {
+		return copyDB(dbPath, driverConn.(*sqlite3.SQLiteConn))
+	})
+}
+
+// copyDB copies the main database of src into the SQLite file at path.
+func copyDB(path string, src *sqlite3.SQLiteConn) error {
+	destDriverConn, err := (&sqlite3.SQLiteDriver{}).Open(sqliteDSN(path))
+	if err != nil {
+		return fmt.Errorf("open backup destination: %w", err)
+	}
+	dest := destDriverConn.(*sqlite3.SQLiteConn)
+	defer func() {
+		_ = dest.Close()
+	}()
+
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return fmt.Errorf("init backup: %w", err)
+	}
+	// Copying all pages in a single step reads a consistent snapshot of the source
+	if _, err := backup.Step(-1); err != nil {
+		_ = backup.Finish()
+		return fmt.Errorf("backup step: %w", err)
+	}
+	if err := backup.Finish(); err != nil {
+		return fmt.Errorf("finish backup: %w", err)
+	}
+	return nil
+}
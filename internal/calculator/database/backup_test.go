@@ -0,0 +1,50 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, backupPath := filepath.Join(dir, "db.sqlite"), filepath.Join(dir, "backup.sqlite")
+
+	db, err := Open(t.Context(), DriverSQLite, dbPath)
+	require.NoError(t, err)
+	_, err = db.Write.Exec(`CREATE TABLE t (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	_, err = db.Write.Exec(`INSERT INTO t (id) VALUES (1), (2)`)
+	require.NoError(t, err)
+
+	require.NoError(t, Backup(t.Context(), db.Read, backupPath))
+	_, err = db.Write.Exec(`INSERT INTO t (id) VALUES (3)`)
+	require.NoError(t, err, "the database is writable after the backup")
+	require.NoError(t, db.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.NotContains(t, e.Name(), ".tmp", "no temporary files are left")
+	}
+
+	require.NoError(t, Restore(t.Context(), backupPath, dbPath))
+
+	db, err = Open(t.Context(), DriverSQLite, dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var ids []int
+	require.NoError(t, db.Read.Select(&ids, `SELECT id FROM t ORDER BY id`))
+	assert.Equal(t, []int{1, 2}, ids, "the database is restored to the backup")
+
+	corrupted := filepath.Join(dir, "corrupted.sqlite")
+	require.NoError(t, os.WriteFile(corrupted, []byte("not a database"), 0o600))
+	require.Error(t, Restore(t.Context(), corrupted, dbPath))
+	require.Error(t, Restore(t.Context(), filepath.Join(dir, "missing.sqlite"), dbPath))
+
+	require.NoError(t, db.Read.Select(&ids, `SELECT id FROM t ORDER BY id`))
+	assert.Equal(t, []int{1, 2}, ids, "failed restores leave the database intact")
+}
@@ -2,16 +2,156 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/avast/retry-go/v4"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-func Connect(ctx context.Context, path string) (*sqlx.DB, error) {
-	db, err := sqlx.ConnectContext(ctx, "sqlite3", path)
+const (
+	// DriverSQLite stores data in a SQLite file.
+	DriverSQLite = "sqlite"
+	// DriverPostgres stores data in a PostgreSQL database.
+	DriverPostgres = "postgres"
+	// DriverMemory keeps data in memory of the process, which is lost on restart.
+	// It has no SQL database to connect to.
+	DriverMemory = "memory"
+)
+
+// Drivers lists the supported storage backends.
+var Drivers = []string{DriverSQLite, DriverPostgres, DriverMemory}
+
+// sqlDrivers maps the storage backends to the names of the registered database/sql drivers.
+var sqlDrivers = map[string]string{
+	DriverSQLite:   "sqlite3",
+	DriverPostgres: "pgx",
+}
+
+// sqliteBusyTimeout is how long a SQLite connection waits for the lock held by another connection.
+const sqliteBusyTimeout = 5 * time.Second
+
+const (
+	busyRetryAttempts = 3
+	busyRetryDelay    = 50 * time.Millisecond
+)
+
+// ErrBusy is returned by [RetryBusy] if the database stays locked by other connections.
+var ErrBusy = errors.New("database is busy")
+
+// DB is a database opened with separate connection pools for writes and reads.
+type DB struct {
+	// Write runs transactions and statements modifying data.
+	Write *sqlx.DB
+	// Read runs queries outside of transactions.
+	Read *sqlx.DB
+}
+
+// Open opens a database of the driver for the repository, see [Connect].
+//
+// SQLite is opened in WAL mode with foreign keys enforced. Writes are serialized through a pool
+// of a single connection, which begins transactions with BEGIN IMMEDIATE, so that concurrent
+// read-modify-write transactions queue for the connection instead of failing to upgrade their locks.
+// Reads use a read-only pool of their own, which WAL lets run alongside the writer.
+// PostgreSQL uses the same pool for both.
+func Open(ctx context.Context, driver, dsn string) (*DB, error) {
+	if driver != DriverSQLite {
+		db, err := Connect(ctx, driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{Write: db, Read: db}, nil
+	}
+
+	write, err := connect(ctx, driver, sqliteDSN(dsn, "_journal_mode=WAL", "_foreign_keys=on", "_txlock=immediate"))
+	if err != nil {
+		return nil, err
+	}
+	write.SetMaxOpenConns(1)
+
+	read, err := connect(ctx, driver, sqliteDSN(dsn, "_foreign_keys=on", "_query_only=on"))
+	if err != nil {
+		_ = write.Close()
+		return nil, err
+	}
+	read.SetMaxOpenConns(max(4, runtime.NumCPU()))
+
+	return &DB{Write: write, Read: read}, nil
+}
+
+// Close closes the connection pools.
+func (db *DB) Close() error {
+	if db.Read == db.Write {
+		return db.Write.Close()
+	}
+	return errors.Join(db.Write.Close(), db.Read.Close())
+}
+
+// Connect opens a database of the driver, where dsn is the file path for SQLite
+// and the connection string for PostgreSQL.
+func Connect(ctx context.Context, driver, dsn string) (*sqlx.DB, error) {
+	if driver == DriverSQLite {
+		dsn = sqliteDSN(dsn)
+	}
+	return connect(ctx, driver, dsn)
+}
+
+func connect(ctx context.Context, driver, dsn string) (*sqlx.DB, error) {
+	sqlDriver, ok := sqlDrivers[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	db, err := sqlx.ConnectContext(ctx, sqlDriver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("sqlx connect: %w", err)
 	}
 	return db, nil
 }
+
+// sqliteDSN appends the busy timeout and the params to the SQLite file path.
+func sqliteDSN(path string, params ...string) string {
+	params = append([]string{fmt.Sprintf("_busy_timeout=%d", sqliteBusyTimeout.Milliseconds())}, params...)
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + strings.Join(params, "&")
+}
+
+// IsPostgres reports whether the database has been opened with [DriverPostgres].
+func IsPostgres(db *sqlx.DB) bool {
+	return db.DriverName() == sqlDrivers[DriverPostgres]
+}
+
+// IsBusy reports whether the error is caused by SQLite failing to acquire a lock held by another connection
+// for longer than the busy timeout. Such statements have no effect and can be retried.
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return errors.Is(sqliteErr.Code, sqlite3.ErrBusy) || errors.Is(sqliteErr.Code, sqlite3.ErrLocked)
+	}
+	return false
+}
+
+// RetryBusy runs fn, retrying it a few times while the database is busy, see [IsBusy].
+// Returns [ErrBusy] wrapping the last error if the database stays busy.
+func RetryBusy(ctx context.Context, fn func() error) error {
+	err := retry.Do(
+		fn,
+		retry.Context(ctx),
+		retry.RetryIf(IsBusy),
+		retry.Attempts(busyRetryAttempts),
+		retry.Delay(busyRetryDelay),
+		retry.LastErrorOnly(true),
+	)
+	if IsBusy(err) {
+		return fmt.Errorf("%w: %w", ErrBusy, err)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_sqlite(t *testing.T) {
+	db, err := Open(t.Context(), DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	var journalMode string
+	require.NoError(t, db.Write.Get(&journalMode, `PRAGMA journal_mode`))
+	assert.Equal(t, "wal", journalMode)
+
+	for name, pool := range map[string]*sqlx.DB{"write": db.Write, "read": db.Read} {
+		var busyTimeout, foreignKeys int
+		require.NoError(t, pool.Get(&busyTimeout, `PRAGMA busy_timeout`))
+		assert.Equal(t, int(sqliteBusyTimeout.Milliseconds()), busyTimeout, name)
+		require.NoError(t, pool.Get(&foreignKeys, `PRAGMA foreign_keys`))
+		assert.Equal(t, 1, foreignKeys, name)
+	}
+	assert.Equal(t, 1, db.Write.Stats().MaxOpenConnections, "writes are serialized")
+
+	_, err = db.Write.Exec(`CREATE TABLE t (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	_, err = db.Read.Exec(`INSERT INTO t (id) VALUES (1)`)
+	require.Error(t, err, "the read pool is read-only")
+}
+
+func TestRetryBusy(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", errs: []error{nil}, wantCalls: 1},
+		{name: "busy once", errs: []error{busy, nil}, wantCalls: 2},
+		{name: "busy", errs: []error{busy, busy, busy}, wantCalls: busyRetryAttempts, wantErr: ErrBusy},
+		{name: "other error", errs: []error{assert.AnError}, wantCalls: 1, wantErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := RetryBusy(context.Background(), func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
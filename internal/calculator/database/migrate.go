@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/belo4ya/edu-final-calculate-api/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+var (
+	// ErrSchemaOutdated is returned by [Migrator.Check] if the database lacks migrations of the binary.
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+	// ErrSchemaUnsupported is returned by [Migrator.Check] if the database has been migrated
+	// by a newer binary or a migration has failed.
+	ErrSchemaUnsupported = errors.New("database schema is unsupported")
+)
+
+// migrationDirs maps the drivers to the directories of their migrations in [migrations.FS].
+var migrationDirs = map[string]string{
+	DriverSQLite:   "sqlite",
+	DriverPostgres: "postgres",
+}
+
+// Migrator applies the migrations embedded into the binary.
+// It has a database connection of its own, which is closed along with the migrator.
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+// NewMigrator connects to the database of the driver to migrate it, see [Connect].
+func NewMigrator(ctx context.Context, driver, dsn string) (*Migrator, error) {
+	dir, ok := migrationDirs[driver]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+
+	src, err := iofs.New(migrations.FS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("open migrations: %w", err)
+	}
+	latest, err := latestVersion(src)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	db, err := Connect(ctx, driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	var dbDriver migratedb.Driver
+	if driver == DriverPostgres {
+		dbDriver, err = pgx.WithInstance(db.DB, &pgx.Config{})
+	} else {
+		dbDriver, err = sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	}
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init migrate driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, driver, dbDriver)
+	if err != nil {
+		_ = dbDriver.Close()
+		return nil, fmt.Errorf("init migrate: %w", err)
+	}
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// Up applies all migrations that have not been applied yet.
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the given number of the last applied migrations, or all of them if there are fewer.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("invalid number of steps %d", steps)
+	}
+	err := m.m.Steps(-steps)
+	var short migrate.ErrShortLimit
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) && !errors.As(err, &short) {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+	return nil
+}
+
+// Version returns the version of the last applied migration, which is 0 if none have been applied,
+// and whether that migration has failed.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrate version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Latest returns the version of the last migration embedded into the binary.
+func (m *Migrator) Latest() uint {
+	return m.latest
+}
+
+// Check makes sure that the database schema is of the version the binary works with.
+// Returns [ErrSchemaOutdated] or [ErrSchemaUnsupported] otherwise.
+func (m *Migrator) Check() error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	switch {
+	case dirty:
+		return fmt.Errorf("%w: migration %d has failed, fix the database and force the version", ErrSchemaUnsupported, version)
+	case version < m.latest:
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaOutdated, version, m.latest)
+	case version > m.latest:
+		return fmt.Errorf("%w: version %d is newer than %d, the latest known to the binary", ErrSchemaUnsupported, version, m.latest)
+	}
+	return nil
+}
+
+// Close closes the database connection of the migrator.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	m, err := NewMigrator(t.Context(), DriverSQLite, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = m.Close()
+	})
+	latest := m.Latest()
+	require.Positive(t, latest)
+
+	version, dirty, err := m.Version()
+	require.NoError(t, err)
+	assert.Zero(t, version, "a fresh database has no migrations")
+	assert.False(t, dirty)
+	require.ErrorIs(t, m.Check(), ErrSchemaOutdated)
+
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Up(), "applying no migrations is not an error")
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	require.NoError(t, m.Check())
+
+	db, err := Connect(t.Context(), DriverSQLite, dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	var users int
+	require.NoError(t, db.Get(&users, `SELECT COUNT(*) FROM users`), "the schema is created")
+
+	require.NoError(t, m.Down(2))
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest-2, version)
+	require.ErrorIs(t, m.Check(), ErrSchemaOutdated)
+
+	require.NoError(t, m.Down(int(latest)), "rolls back the remaining migrations")
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	require.Error(t, m.Down(0))
+
+	// A database migrated by a newer binary
+	require.NoError(t, m.Up())
+	_, err = db.Exec(`UPDATE schema_migrations SET version = ?`, latest+1)
+	require.NoError(t, err)
+	require.ErrorIs(t, m.Check(), ErrSchemaUnsupported)
+
+	_, err = db.Exec(`UPDATE schema_migrations SET version = ?, dirty = TRUE`, latest)
+	require.NoError(t, err)
+	require.ErrorIs(t, m.Check(), ErrSchemaUnsupported, "a failed migration")
+}
+
+func TestNewMigrator_memory(t *testing.T) {
+	_, err := NewMigrator(t.Context(), DriverMemory, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// UpsertAgent registers an agent or refreshes its capabilities and last seen time.
+func (r *Repository) UpsertAgent(ctx context.Context, cmd models.UpsertAgentCmd) error {
+	const q = `
+        INSERT INTO agents (id, operations, labels, last_seen_at, created_at, updated_at)
+        VALUES (:id, :operations, :labels, :last_seen_at, :created_at, :updated_at)
+        ON CONFLICT (id) DO UPDATE
+        SET operations   = excluded.operations,
+            labels       = excluded.labels,
+            last_seen_at = excluded.last_seen_at,
+            updated_at   = excluded.updated_at
+    `
+
+	now := time.Now().UTC()
+	agent := models.Agent{
+		ID:         cmd.ID,
+		Operations: cmd.Operations,
+		Labels:     cmd.Labels,
+		LastSeenAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if _, err := r.namedExec(ctx, q, agent); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
+
+// ListAliveAgents retrieves agents seen since the specified time.
+func (r *Repository) ListAliveAgents(ctx context.Context, since time.Time) ([]models.Agent, error) {
+	const q = `
+        SELECT id, operations, labels, last_seen_at, created_at, updated_at
+        FROM agents
+        WHERE last_seen_at >= ?
+        ORDER BY id
+    `
+
+	var agents []models.Agent
+	if err := r.rdb.SelectContext(ctx, &agents, r.db.Rebind(q), since.UTC()); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return agents, nil
+}
+
+// ReportUnroutableTasks sets an error on pending expressions having pending tasks
+// that none of the agents seen since the specified time can execute.
+// Returns the number of reported expressions.
+func (r *Repository) ReportUnroutableTasks(ctx context.Context, since time.Time) (int, error) {
+	agents, err := r.ListAliveAgents(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("list alive agents: %w", err)
+	}
+	if len(agents) == 0 {
+		return 0, nil // nothing is known about agent capabilities
+	}
+
+	q := `SELECT DISTINCT expression_id, operation FROM tasks WHERE status = ?`
+
+	var pending []struct {
+		ExpressionID string               `db:"expression_id"`
+		Operation    models.TaskOperation `db:"operation"`
+	}
+	if err := r.rdb.SelectContext(ctx, &pending, r.db.Rebind(q), models.TaskStatusPending); err != nil {
+		return 0, fmt.Errorf("db select: %w", err)
+	}
+
+	q = `UPDATE expressions SET error = ?, updated_at = ? WHERE id = ? AND error IS NULL`
+
+	reported := 0
+	now := time.Now().UTC()
+	for _, p := range pending {
+		if supportedByAny(agents, p.Operation) {
+			continue
+		}
+		msg := fmt.Sprintf("no available agent supports operation %q", p.Operation)
+		res, err := r.exec(ctx, r.db.Rebind(q), msg, now, p.ExpressionID)
+		if err != nil {
+			return 0, fmt.Errorf("db exec: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			reported++
+		}
+	}
+	return reported, nil
+}
+
+func supportedByAny(agents []models.Agent, op models.TaskOperation) bool {
+	for _, a := range agents {
+		if a.Supports(op) {
+			return true
+		}
+	}
+	return false
+}
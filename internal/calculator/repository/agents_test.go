@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_UpsertAgent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	before := time.Now().Add(-time.Second)
+
+	err := repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent1",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+		Labels:     models.AgentLabels{"gpu": "false"},
+	})
+	require.NoError(t, err)
+
+	// Re-registration updates capabilities
+	err = repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent1",
+		Operations: models.TaskOperations{models.TaskOperationAddition, models.TaskOperationDivision},
+		Labels:     models.AgentLabels{"precision": "decimal"},
+	})
+	require.NoError(t, err)
+
+	err = repo.UpsertAgent(ctx, models.UpsertAgentCmd{ID: "agent2"})
+	require.NoError(t, err)
+
+	agents, err := repo.ListAliveAgents(ctx, before)
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+
+	assert.Equal(t, "agent1", agents[0].ID)
+	assert.Equal(t, models.TaskOperations{models.TaskOperationAddition, models.TaskOperationDivision}, agents[0].Operations)
+	assert.Equal(t, models.AgentLabels{"precision": "decimal"}, agents[0].Labels)
+
+	assert.Equal(t, "agent2", agents[1].ID)
+	assert.Empty(t, agents[1].Operations)
+	assert.True(t, agents[1].Supports(models.TaskOperationMultiplication), "Agent without operations should support all")
+
+	agents, err = repo.ListAliveAgents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, agents)
+}
+
+func TestRepository_GetPendingTask_Operations(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	_, err := repo.CreateExpression(ctx, userID, models.CreateExpressionCmd{
+		Expression: "5/3",
+		Tasks: []models.CreateExpressionCmdTask{
+			{ID: "task1", Arg1: 5, Arg2: 3, Operation: models.TaskOperationDivision},
+		},
+	})
+	require.NoError(t, err)
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{
+		Operations: models.TaskOperations{models.TaskOperationAddition, models.TaskOperationSubtraction},
+	})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks)
+	require.Nil(t, task)
+
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{
+		Operations: models.TaskOperations{models.TaskOperationDivision},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "task1", task.ID)
+}
+
+func TestRepository_ReportUnroutableTasks(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	since := time.Now().Add(-time.Minute)
+
+	userID := createTestUser(t, repo, ctx)
+	exprID, err := repo.CreateExpression(ctx, userID, models.CreateExpressionCmd{
+		Expression: "5/3",
+		Tasks: []models.CreateExpressionCmdTask{
+			{ID: "task1", Arg1: 5, Arg2: 3, Operation: models.TaskOperationDivision},
+		},
+	})
+	require.NoError(t, err)
+
+	// Nothing is known about agents yet
+	n, err := repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	err = repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent1",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+	})
+	require.NoError(t, err)
+
+	n, err = repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	expr, err := repo.GetExpression(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExpressionStatusPending, expr.Status)
+	assert.True(t, expr.Error.Valid)
+	assert.Contains(t, expr.Error.V, `"/"`)
+
+	// Already reported expressions are not reported twice
+	n, err = repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// Claiming the task by a capable agent clears the error
+	err = repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent2",
+		Operations: models.TaskOperations{models.TaskOperationDivision},
+	})
+	require.NoError(t, err)
+
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{Operations: models.TaskOperations{models.TaskOperationDivision}})
+	require.NoError(t, err)
+
+	expr, err = repo.GetExpression(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.False(t, expr.Error.Valid)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+const apiKeyColumns = `
+        k.id, k.user_id, u.login AS user_login, u.role AS user_role, k.name, k.key_hash, k.scopes,
+        k.expires_at, k.revoked_at, k.created_at
+    `
+
+// CreateAPIKey stores a new API key of the user.
+func (r *Repository) CreateAPIKey(ctx context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error) {
+	const q = `
+        INSERT INTO api_keys (id, user_id, name, key_hash, scopes, expires_at, created_at)
+        VALUES (:id, :user_id, :name, :key_hash, :scopes, :expires_at, :created_at)
+    `
+
+	key := models.APIKey{
+		ID:        xid.New().String(),
+		UserID:    cmd.UserID,
+		Name:      cmd.Name,
+		KeyHash:   cmd.KeyHash,
+		Scopes:    cmd.Scopes,
+		ExpiresAt: sql.Null[time.Time]{V: cmd.ExpiresAt.V.UTC(), Valid: cmd.ExpiresAt.Valid},
+		CreatedAt: time.Now().UTC(),
+	}
+	if _, err := r.namedExec(ctx, q, key); err != nil {
+		return nil, fmt.Errorf("db exec: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys retrieves all API keys of the user, newest first.
+func (r *Repository) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	q := `
+        SELECT ` + apiKeyColumns + `
+        FROM api_keys k
+        JOIN users u ON u.id = k.user_id
+        WHERE k.user_id = ?
+        ORDER BY k.created_at DESC, k.id DESC
+    `
+
+	var keys []models.APIKey
+	if err := r.rdb.SelectContext(ctx, &keys, r.db.Rebind(q), userID); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key, regardless of whether it is active.
+// Returns [models.ErrAPIKeyNotFound] if no matching key exists.
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	q := `
+        SELECT ` + apiKeyColumns + `
+        FROM api_keys k
+        JOIN users u ON u.id = k.user_id
+        WHERE k.key_hash = ?
+    `
+
+	var key models.APIKey
+	if err := r.rdb.GetContext(ctx, &key, r.db.Rebind(q), keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revokes an API key of the user. Revoking an already revoked key is a no-op.
+// Returns [models.ErrAPIKeyNotFound] if the user has no such key.
+func (r *Repository) RevokeAPIKey(ctx context.Context, cmd models.RevokeAPIKeyCmd) error {
+	const q = `
+        UPDATE api_keys
+        SET revoked_at = COALESCE(revoked_at, ?)
+        WHERE id = ? AND user_id = ?
+    `
+
+	res, err := r.exec(ctx, r.db.Rebind(q), time.Now().UTC(), cmd.ID, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+	return nil
+}
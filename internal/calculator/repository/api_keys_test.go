@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_APIKeys(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	otherUserID := createTestUser(t, repo, ctx)
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	created, err := repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{
+		UserID:    userID,
+		Name:      "ci",
+		KeyHash:   "hash1",
+		Scopes:    models.APIKeyScopes{models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit},
+		ExpiresAt: sql.Null[time.Time]{V: expiresAt, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	got, err := repo.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Equal(t, userID, got.UserID)
+	assert.NotEmpty(t, got.UserLogin)
+	assert.Equal(t, "ci", got.Name)
+	assert.Equal(t, models.APIKeyScopes{models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit}, got.Scopes)
+	assert.True(t, got.ExpiresAt.Valid)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt.V))
+	assert.True(t, got.Active(time.Now()))
+
+	_, err = repo.GetAPIKeyByHash(ctx, "nonexistent")
+	require.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+
+	_, err = repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{
+		UserID:  userID,
+		Name:    "read-only",
+		KeyHash: "hash2",
+		Scopes:  models.APIKeyScopes{models.APIKeyScopeReadOnly},
+	})
+	require.NoError(t, err)
+
+	keys, err := repo.ListAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "read-only", keys[0].Name)
+	assert.False(t, keys[0].ExpiresAt.Valid)
+	assert.Equal(t, "ci", keys[1].Name)
+
+	keys, err = repo.ListAPIKeys(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	err = repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: otherUserID, ID: created.ID})
+	require.ErrorIs(t, err, models.ErrAPIKeyNotFound, "key of another user should not be revoked")
+
+	err = repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: userID, ID: created.ID})
+	require.NoError(t, err)
+
+	got, err = repo.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.True(t, got.RevokedAt.Valid)
+	assert.False(t, got.Active(time.Now()))
+
+	revokedAt := got.RevokedAt.V
+	err = repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: userID, ID: created.ID})
+	require.NoError(t, err)
+	got, err = repo.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.True(t, revokedAt.Equal(got.RevokedAt.V), "revocation time should not change")
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, (&models.APIKey{}).Active(now))
+	assert.True(t, (&models.APIKey{ExpiresAt: sql.Null[time.Time]{V: now.Add(time.Minute), Valid: true}}).Active(now))
+	assert.False(t, (&models.APIKey{ExpiresAt: sql.Null[time.Time]{V: now, Valid: true}}).Active(now))
+	assert.False(t, (&models.APIKey{RevokedAt: sql.Null[time.Time]{V: now, Valid: true}}).Active(now))
+}
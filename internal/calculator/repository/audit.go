@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// CreateAuditEvent records a security-relevant event.
+func (r *Repository) CreateAuditEvent(ctx context.Context, cmd models.CreateAuditEventCmd) error {
+	const q = `INSERT INTO audit_events (id, event, subject, details, created_at) VALUES (?, ?, ?, ?, ?)`
+
+	if _, err := r.exec(ctx, r.db.Rebind(q), xid.New().String(), cmd.Event, cmd.Subject, cmd.Details, time.Now().UTC()); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
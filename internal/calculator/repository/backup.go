@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+)
+
+// Backup copies the database into the file at path, see [database.Backup].
+// Returns [database.ErrBackupUnsupported] for PostgreSQL.
+func (r *Repository) Backup(ctx context.Context, path string) error {
+	return database.Backup(ctx, r.rdb, path)
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+)
+
+// GetExpressionCallback retrieves the callback of a specific expression for a specific user.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist
+// and [models.ErrCallbackNotFound] if it was submitted without a callback.
+func (r *Repository) GetExpressionCallback(ctx context.Context, userID string, exprID string) (*models.Callback, error) {
+	if err := r.checkExpression(ctx, userID, exprID); err != nil {
+		return nil, err
+	}
+
+	const q = `
+        SELECT expression_id, url, secret, status, attempts, next_attempt_at, created_at, updated_at
+        FROM expression_callbacks
+        WHERE expression_id = ?
+    `
+
+	var callback models.Callback
+	if err := r.rdb.GetContext(ctx, &callback, r.db.Rebind(q), exprID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrCallbackNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+	return &callback, nil
+}
+
+// ListExpressionCallbackAttempts retrieves the delivery log of the callback of a specific expression
+// for a specific user, oldest first.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) ListExpressionCallbackAttempts(ctx context.Context, userID string, exprID string) ([]models.CallbackAttempt, error) {
+	if err := r.checkExpression(ctx, userID, exprID); err != nil {
+		return nil, err
+	}
+
+	const q = `
+        SELECT id, expression_id, attempt, status_code, error, duration, created_at
+        FROM callback_attempts
+        WHERE expression_id = ?
+        ORDER BY attempt
+    `
+
+	var attempts []models.CallbackAttempt
+	if err := r.rdb.SelectContext(ctx, &attempts, r.db.Rebind(q), exprID); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return attempts, nil
+}
+
+// ClaimDueCallbacks retrieves and claims a batch of the pending callbacks of the finished expressions
+// whose retry time has come, longest finished first. The claimed callbacks are postponed by the lease,
+// so that concurrent dispatchers don't attempt them too.
+func (r *Repository) ClaimDueCallbacks(ctx context.Context, cmd models.ClaimDueCallbacksCmd) ([]models.DueCallback, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Concurrent dispatchers skip the callbacks being claimed by others instead of waiting for them
+	q := fmt.Sprintf(`
+        UPDATE expression_callbacks
+        SET next_attempt_at = ?
+        WHERE expression_id IN (
+            SELECT c.expression_id
+            FROM expression_callbacks c
+            JOIN expressions e ON e.id = c.expression_id
+            WHERE c.status = ? AND e.status IN (?, ?) AND (c.next_attempt_at IS NULL OR c.next_attempt_at <= ?)
+            ORDER BY e.updated_at, e.id
+            LIMIT ? %s
+        )
+        RETURNING expression_id, url, secret, status, attempts, next_attempt_at, created_at, updated_at
+    `, r.forUpdate(true))
+
+	now := cmd.Now.UTC()
+	var callbacks []models.Callback
+	if err := tx.SelectContext(
+		ctx, &callbacks, tx.Rebind(q),
+		now.Add(cmd.Lease),
+		models.CallbackStatusPending, models.ExpressionStatusCompleted, models.ExpressionStatusFailed, now, cmd.Limit,
+	); err != nil {
+		return nil, fmt.Errorf("claim callbacks: %w", err)
+	}
+	if len(callbacks) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(callbacks))
+	for _, c := range callbacks {
+		ids = append(ids, c.ExpressionID)
+	}
+	query, args, err := sqlx.In(`SELECT id, user_id, expression, status, result, error, created_at, updated_at FROM expressions WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+	var exprs []models.Expression
+	if err := tx.SelectContext(ctx, &exprs, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("select expressions: %w", err)
+	}
+	byID := make(map[string]models.Expression, len(exprs))
+	for _, expr := range exprs {
+		byID[expr.ID] = expr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	due := make([]models.DueCallback, 0, len(callbacks))
+	for _, c := range callbacks {
+		due = append(due, models.DueCallback{Callback: c, Expression: byID[c.ExpressionID]})
+	}
+	// RETURNING doesn't keep the order of the subquery
+	slices.SortFunc(due, func(a, b models.DueCallback) int {
+		return cmp.Or(a.Expression.UpdatedAt.Compare(b.Expression.UpdatedAt), cmp.Compare(a.Expression.ID, b.Expression.ID))
+	})
+	return due, nil
+}
+
+// RecordCallbackAttempt appends the attempt to the delivery log of a pending callback and updates its status.
+// Returns [models.ErrCallbackNotFound] if there is no pending callback of the expression.
+func (r *Repository) RecordCallbackAttempt(ctx context.Context, cmd models.RecordCallbackAttemptCmd) error {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	status, nextAttemptAt := models.CallbackStatusPending, sql.Null[time.Time]{V: cmd.NextAttemptAt.UTC(), Valid: true}
+	switch {
+	case cmd.Delivered:
+		status, nextAttemptAt = models.CallbackStatusDelivered, sql.Null[time.Time]{}
+	case cmd.NextAttemptAt.IsZero():
+		status, nextAttemptAt = models.CallbackStatusFailed, sql.Null[time.Time]{}
+	}
+
+	const q = `
+        UPDATE expression_callbacks
+        SET status          = ?,
+            attempts        = attempts + 1,
+            next_attempt_at = ?,
+            updated_at      = ?
+        WHERE expression_id = ? AND status = ?
+        RETURNING attempts
+    `
+
+	now := time.Now().UTC()
+	var attempts []int
+	if err := tx.SelectContext(
+		ctx, &attempts, tx.Rebind(q),
+		status, nextAttemptAt, now, cmd.ExpressionID, models.CallbackStatusPending,
+	); err != nil {
+		return fmt.Errorf("update callback: %w", err)
+	}
+	if len(attempts) == 0 {
+		return models.ErrCallbackNotFound
+	}
+
+	const attemptQ = `
+        INSERT INTO callback_attempts (id, expression_id, attempt, status_code, error, duration, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+	if _, err := tx.ExecContext(
+		ctx, tx.Rebind(attemptQ),
+		xid.New().String(),
+		cmd.ExpressionID,
+		attempts[0],
+		sql.Null[int]{V: cmd.StatusCode, Valid: cmd.StatusCode != 0},
+		sql.Null[string]{V: cmd.Error, Valid: cmd.Error != ""},
+		cmd.Duration,
+		now,
+	); err != nil {
+		return fmt.Errorf("insert callback attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkExpression returns [models.ErrExpressionNotFound] unless the expression of the user exists.
+func (r *Repository) checkExpression(ctx context.Context, userID string, exprID string) error {
+	const q = `SELECT COUNT(*) FROM expressions WHERE id = ? AND user_id = ?`
+
+	var count int
+	if err := r.rdb.GetContext(ctx, &count, r.db.Rebind(q), exprID, userID); err != nil {
+		return fmt.Errorf("db get: %w", err)
+	}
+	if count == 0 {
+		return models.ErrExpressionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/repotest"
+)
+
+// TestRepository_conformance runs against each SQL database, PostgreSQL ones are skipped if it is not available.
+func TestRepository_conformance(t *testing.T) {
+	for _, driver := range []string{database.DriverSQLite, database.DriverPostgres} {
+		t.Run(driver, func(t *testing.T) {
+			if driver == database.DriverPostgres {
+				postgresTestDSN(t)
+			}
+			repotest.Run(t, func(t *testing.T) repotest.Repository {
+				return New(setupDriverTestDB(t, driver))
+			})
+		})
+	}
+}
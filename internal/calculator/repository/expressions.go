@@ -14,17 +14,16 @@ import (
 	"github.com/rs/xid"
 )
 
-// CreateExpression stores a new expression with its associated tasks
+// CreateExpression stores a new expression with its associated tasks, callback and idempotency key, if any,
 // and returns the ID of the created expression.
+// Returns [models.ErrIdempotencyKeyExists] if the user has an unexpired expression with the same key.
 func (r *Repository) CreateExpression(ctx context.Context, userID string, cmd models.CreateExpressionCmd) (string, error) {
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.beginTx(ctx)
 	if err != nil {
 		return "", fmt.Errorf("begin transaction: %w", err)
 	}
 	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
+		_ = tx.Rollback()
 	}()
 
 	const q = `
@@ -50,9 +49,9 @@ func (r *Repository) CreateExpression(ctx context.Context, userID string, cmd mo
 
 	tasks := make([]models.Task, 0, len(cmd.Tasks))
 	for _, t := range cmd.Tasks {
-		status := models.TaskStatusCreated
+		status, pendingAt := models.TaskStatusCreated, sql.Null[time.Time]{}
 		if t.ParentTask1ID == "" && t.ParentTask2ID == "" {
-			status = models.TaskStatusPending
+			status, pendingAt = models.TaskStatusPending, sql.Null[time.Time]{V: expr.CreatedAt, Valid: true}
 		}
 		tasks = append(tasks, models.Task{
 			ID:            t.ID,
@@ -66,6 +65,8 @@ func (r *Repository) CreateExpression(ctx context.Context, userID string, cmd mo
 			Status:        status,
 			Result:        sql.Null[float64]{},
 			ExpireAt:      sql.Null[time.Time]{},
+			PendingAt:     pendingAt,
+			TraceParent:   sql.Null[string]{V: cmd.TraceParent, Valid: cmd.TraceParent != ""},
 			CreatedAt:     expr.CreatedAt,
 			UpdatedAt:     expr.UpdatedAt,
 		})
@@ -73,20 +74,49 @@ func (r *Repository) CreateExpression(ctx context.Context, userID string, cmd mo
 
 	sb := sqlbuilder.InsertInto("tasks").Cols(
 		"id", "expression_id", "parent_task_1_id", "parent_task_2_id",
-		"arg1", "arg2", "operation", "operation_time", "status", "created_at", "updated_at",
+		"arg1", "arg2", "operation", "operation_time", "status", "pending_at", "trace_parent",
+		"created_at", "updated_at",
 	)
 	for _, t := range tasks {
 		sb.Values(
 			t.ID, t.ExpressionID, t.ParentTask1ID, t.ParentTask2ID,
-			t.Arg1, t.Arg2, t.Operation, t.OperationTime, t.Status, t.CreatedAt, t.UpdatedAt,
+			t.Arg1, t.Arg2, t.Operation, t.OperationTime, t.Status, t.PendingAt, t.TraceParent,
+			t.CreatedAt, t.UpdatedAt,
 		)
 	}
 
 	query, args := sb.Build()
-	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
 		return "", fmt.Errorf("db query: %w", err)
 	}
 
+	events := make([]models.TaskEvent, 0, len(tasks))
+	for _, t := range tasks {
+		events = append(events, models.TaskEvent{TaskID: t.ID, ExpressionID: expr.ID, Type: models.TaskEventCreated, CreatedAt: now})
+	}
+	if err = r.insertTaskEvents(ctx, tx, events...); err != nil {
+		return "", err
+	}
+
+	if cmd.CallbackURL != "" {
+		const callbackQ = `
+            INSERT INTO expression_callbacks (expression_id, url, secret, status, created_at, updated_at)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `
+		if _, err = tx.ExecContext(
+			ctx, tx.Rebind(callbackQ),
+			expr.ID, cmd.CallbackURL, cmd.CallbackSecret, models.CallbackStatusPending, now, now,
+		); err != nil {
+			return "", fmt.Errorf("insert callback: %w", err)
+		}
+	}
+
+	if cmd.IdempotencyKey != nil {
+		if err = r.insertIdempotencyKey(ctx, tx, userID, expr.ID, *cmd.IdempotencyKey, now); err != nil {
+			return "", err
+		}
+	}
+
 	if err = tx.Commit(); err != nil {
 		return "", fmt.Errorf("commit transaction: %w", err)
 	}
@@ -104,7 +134,7 @@ func (r *Repository) ListExpressions(ctx context.Context, userID string) ([]mode
     `
 
 	var exprs []models.Expression
-	if err := r.db.SelectContext(ctx, &exprs, q, userID); err != nil {
+	if err := r.rdb.SelectContext(ctx, &exprs, r.db.Rebind(q), userID); err != nil {
 		return nil, fmt.Errorf("db select: %w", err)
 	}
 
@@ -121,7 +151,7 @@ func (r *Repository) GetExpression(ctx context.Context, userID string, exprID st
     `
 
 	var expr models.Expression
-	if err := r.db.GetContext(ctx, &expr, q, exprID, userID); err != nil {
+	if err := r.rdb.GetContext(ctx, &expr, r.db.Rebind(q), exprID, userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrExpressionNotFound
 		}
@@ -137,7 +167,7 @@ func (r *Repository) ListExpressionTasks(ctx context.Context, userID string, exp
 	q := `SELECT COUNT(*) FROM expressions WHERE id = ? AND user_id = ?`
 
 	var count int
-	if err := r.db.GetContext(ctx, &count, q, exprID, userID); err != nil {
+	if err := r.rdb.GetContext(ctx, &count, r.db.Rebind(q), exprID, userID); err != nil {
 		return nil, fmt.Errorf("db get: %w", err)
 	}
 	if count == 0 {
@@ -146,39 +176,65 @@ func (r *Repository) ListExpressionTasks(ctx context.Context, userID string, exp
 
 	q = `
         SELECT id, expression_id, parent_task_1_id, parent_task_2_id, 
-               arg1, arg2, operation, operation_time, status, result, expire_at,
-               created_at, updated_at
+               arg1, arg2, operation, operation_time, status, result, compute_time, expire_at,
+               pending_at, trace_parent, created_at, updated_at
         FROM tasks 
         WHERE expression_id = ?
         ORDER BY created_at
     `
 
 	var tasks []models.Task
-	if err := r.db.SelectContext(ctx, &tasks, q, exprID); err != nil {
+	if err := r.rdb.SelectContext(ctx, &tasks, r.db.Rebind(q), exprID); err != nil {
 		return nil, fmt.Errorf("db select: %w", err)
 	}
 
 	return tasks, nil
 }
 
-// GetPendingTask retrieves and claims the first available pending task.
-// Returns [models.ErrNoPendingTasks] if there are no pending tasks available.
-func (r *Repository) GetPendingTask(ctx context.Context) (*models.Task, error) {
+// GetPendingTask retrieves and claims the first available pending task
+// with an operation the agent supports, clearing any routing error reported on its expression.
+// The claimed tasks whose lease has expired are handed back to the queue first.
+// Returns [models.ErrNoPendingTasks] if there are no suitable pending tasks available.
+func (r *Repository) GetPendingTask(ctx context.Context, cmd models.GetPendingTaskCmd) (*models.Task, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	if err = r.releaseExpiredTasks(ctx, tx, now); err != nil {
+		return nil, err
+	}
+
+	// Concurrent agents skip the tasks being claimed by others instead of waiting for them
 	const q = `
         UPDATE tasks
-		SET status     = :status_in_progress,
-			updated_at = :updated_at
-		WHERE id = ( SELECT id FROM tasks WHERE status = :status_pending ORDER BY created_at LIMIT 1 )
+		SET status     = ?,
+			expire_at  = ?,
+			agent_id   = ?,
+			updated_at = ?
+		WHERE id = ( SELECT id FROM tasks WHERE status = ? %s ORDER BY created_at LIMIT 1 %s )
 		RETURNING id, expression_id, parent_task_1_id, parent_task_2_id,
-			arg1, arg2, operation, operation_time, status, result,
-			expire_at, created_at, updated_at
+			arg1, arg2, operation, operation_time, status, result, compute_time,
+			expire_at, agent_id, pending_at, trace_parent, created_at, updated_at
     `
 
-	row, err := r.db.NamedQueryContext(ctx, q, map[string]any{
-		"status_in_progress": models.TaskStatusInProgress,
-		"updated_at":         time.Now().UTC(),
-		"status_pending":     models.TaskStatusPending,
-	})
+	expireAt := sql.Null[time.Time]{V: now.Add(cmd.Lease), Valid: cmd.Lease > 0}
+	agentID := sql.Null[string]{V: cmd.AgentID, Valid: cmd.AgentID != ""}
+	filter, args := "", []any{models.TaskStatusInProgress, expireAt, agentID, now, models.TaskStatusPending}
+	if len(cmd.Operations) > 0 {
+		filter = "AND operation IN (?)"
+		args = append(args, []models.TaskOperation(cmd.Operations))
+	}
+	query, args, err := sqlx.In(fmt.Sprintf(q, filter, r.forUpdate(true)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	row, err := tx.QueryxContext(ctx, tx.Rebind(query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("db query: %w", err)
 	}
@@ -187,132 +243,256 @@ func (r *Repository) GetPendingTask(ctx context.Context) (*models.Task, error) {
 	}(row)
 
 	if !row.Next() {
-		return nil, models.ErrNoPendingTasks
+		err = models.ErrNoPendingTasks
+		return nil, err
 	}
 
 	var task models.Task
-	if err := row.StructScan(&task); err != nil {
+	if err = row.StructScan(&task); err != nil {
 		return nil, fmt.Errorf("scan task: %w", err)
 	}
+	_ = row.Close()
+
+	const clearErrQ = `UPDATE expressions SET error = NULL, updated_at = ? WHERE id = ? AND error IS NOT NULL AND status IN (?, ?)`
+	if _, err = tx.ExecContext(
+		ctx, tx.Rebind(clearErrQ),
+		task.UpdatedAt, task.ExpressionID, models.ExpressionStatusPending, models.ExpressionStatusInProgress,
+	); err != nil {
+		return nil, fmt.Errorf("clear expression error: %w", err)
+	}
+
+	// A task released by an agent that ran out of its lease is claimed again
+	const releasedQ = `SELECT COUNT(*) FROM task_events WHERE task_id = ? AND type = ?`
+	var released int
+	if err = tx.GetContext(ctx, &released, tx.Rebind(releasedQ), task.ID, models.TaskEventReleased); err != nil {
+		return nil, fmt.Errorf("count releases: %w", err)
+	}
+	eventType := models.TaskEventClaimed
+	if released > 0 {
+		eventType = models.TaskEventReclaimed
+	}
+
+	if err = r.insertTaskEvents(ctx, tx, models.TaskEvent{
+		TaskID:       task.ID,
+		ExpressionID: task.ExpressionID,
+		Type:         eventType,
+		AgentID:      agentID,
+		CreatedAt:    task.UpdatedAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
 
 	return &task, nil
 }
 
+// releaseExpiredTasks hands the claimed tasks whose lease has expired by now back to the queue.
+func (r *Repository) releaseExpiredTasks(ctx context.Context, tx *sqlx.Tx, now time.Time) error {
+	const q = `
+        UPDATE tasks
+        SET status     = ?,
+            expire_at  = NULL,
+            agent_id   = NULL,
+            pending_at = ?,
+            updated_at = ?
+        WHERE status = ? AND expire_at <= ?
+        RETURNING id, expression_id
+    `
+
+	var tasks []models.Task
+	if err := tx.SelectContext(
+		ctx, &tasks, tx.Rebind(q),
+		models.TaskStatusPending, now, now, models.TaskStatusInProgress, now,
+	); err != nil {
+		return fmt.Errorf("release expired tasks: %w", err)
+	}
+
+	events := make([]models.TaskEvent, 0, len(tasks))
+	for _, task := range tasks {
+		events = append(events, models.TaskEvent{TaskID: task.ID, ExpressionID: task.ExpressionID, Type: models.TaskEventReleased, CreatedAt: now})
+	}
+	return r.insertTaskEvents(ctx, tx, events...)
+}
+
 // FinishTask updates a task's status and result, and handles subsequent operations
 // like updating related tasks, enqueueing child tasks, or completing expressions.
-// Returns [models.ErrTaskNotFound] if the task doesn't exist.
-func (r *Repository) FinishTask(ctx context.Context, cmd models.FinishTaskCmd) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
+// Returns the expression if the task has completed or failed it, nil otherwise,
+// recording the event of the finished expression in the outbox.
+// Returns [models.ErrTaskNotFound] if the task doesn't exist or isn't in progress,
+// such as when its result has already been submitted, and [models.ErrTaskNotClaimed]
+// if it's in progress by another agent than cmd.AgentID, such as after the lease has expired.
+func (r *Repository) FinishTask(ctx context.Context, cmd models.FinishTaskCmd) (*models.Expression, error) {
+	tx, err := r.beginTx(ctx)
 	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
+		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
 	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
+		_ = tx.Rollback()
 	}()
 
 	const q = `
         UPDATE tasks
         SET status = :status,
             result = :result,
+            compute_time = :compute_time,
             updated_at = :updated_at
-        WHERE id = :id
+        WHERE id = :id AND status = :in_progress AND COALESCE(agent_id, '') = :agent_id
         RETURNING id, expression_id, parent_task_1_id, parent_task_2_id,
-			arg1, arg2, operation, operation_time, status, result,
-			expire_at, created_at, updated_at
+			arg1, arg2, operation, operation_time, status, result, compute_time,
+			expire_at, agent_id, pending_at, trace_parent, created_at, updated_at
     `
 
 	row, err := sqlx.NamedQueryContext(ctx, tx, q, map[string]any{
-		"status":     cmd.Status,
-		"result":     sql.Null[float64]{V: cmd.Result, Valid: cmd.Status == models.TaskStatusCompleted},
-		"updated_at": time.Now().UTC(),
-		"id":         cmd.ID,
+		"status":       cmd.Status,
+		"result":       sql.Null[float64]{V: cmd.Result, Valid: cmd.Status == models.TaskStatusCompleted},
+		"compute_time": sql.Null[int64]{V: int64(cmd.ComputeTime), Valid: cmd.ComputeTime > 0},
+		"updated_at":   time.Now().UTC(),
+		"id":           cmd.ID,
+		"in_progress":  models.TaskStatusInProgress,
+		"agent_id":     cmd.AgentID,
 	})
 	if err != nil {
-		return fmt.Errorf("update task: %w", err)
+		return nil, fmt.Errorf("update task: %w", err)
 	}
 	defer func(row *sqlx.Rows) {
 		_ = row.Close()
 	}(row)
 
 	if !row.Next() {
-		return models.ErrTaskNotFound
+		_ = row.Close()
+		// The task is still in progress, but its lease has expired and it has been handed to another agent
+		const claimedQ = `SELECT COUNT(*) FROM tasks WHERE id = ? AND status = ?`
+		var claimed int
+		if err = tx.GetContext(ctx, &claimed, tx.Rebind(claimedQ), cmd.ID, models.TaskStatusInProgress); err != nil {
+			return nil, fmt.Errorf("count claimed tasks: %w", err)
+		}
+		if claimed > 0 {
+			err = models.ErrTaskNotClaimed
+			return nil, err
+		}
+		err = models.ErrTaskNotFound
+		return nil, err
 	}
 
 	var task models.Task
-	if err := row.StructScan(&task); err != nil {
-		return fmt.Errorf("scan task: %w", err)
+	if err = row.StructScan(&task); err != nil {
+		return nil, fmt.Errorf("scan task: %w", err)
 	}
+	_ = row.Close()
 
-	// Handle task failure - propagate failure to entire expression
+	event := models.TaskEvent{
+		TaskID:       task.ID,
+		ExpressionID: task.ExpressionID,
+		Type:         models.TaskEventCompleted,
+		AgentID:      sql.Null[string]{V: cmd.AgentID, Valid: cmd.AgentID != ""},
+		Result:       task.Result,
+		CreatedAt:    task.UpdatedAt,
+	}
+	if task.Status == models.TaskStatusFailed {
+		event.Type = models.TaskEventFailed
+	}
+	if err = r.insertTaskEvents(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	finished := true
 	if cmd.Status == models.TaskStatusFailed {
-		if err := r.failExpression(ctx, tx, &task); err != nil {
-			return fmt.Errorf("fail expr: %w", err)
+		// Handle task failure - propagate failure to entire expression
+		if finished, err = r.failExpression(ctx, tx, &task); err != nil {
+			return nil, fmt.Errorf("fail expr: %w", err)
 		}
-		if err = tx.Commit(); err != nil {
-			return fmt.Errorf("commit transaction: %w", err)
+	} else {
+		// Process successfully completed task - either enqueue child or complete expression
+		isFinal, err := r.isFinalTask(ctx, tx, task.ID)
+		if err != nil {
+			return nil, fmt.Errorf("is final task: %w", err)
 		}
-		return nil
-	}
 
-	// Process successfully completed task - either enqueue child or complete expression
-	isFinal, err := r.isFinalTask(ctx, tx, task.ID)
-	if err != nil {
-		return fmt.Errorf("is final task: %w", err)
+		if !isFinal {
+			finished = false
+			if err := r.enqueueChildTask(ctx, tx, &task); err != nil {
+				return nil, fmt.Errorf("enqueue child task: %w", err)
+			}
+		} else {
+			if err := r.completeExpression(ctx, tx, task.ExpressionID, &task); err != nil {
+				return nil, fmt.Errorf("complete expr: %w", err)
+			}
+		}
 	}
 
-	if !isFinal {
-		if err := r.enqueueChildTask(ctx, tx, &task); err != nil {
-			return fmt.Errorf("enqueue child task: %w", err)
+	var expr *models.Expression
+	if finished {
+		expr = &models.Expression{}
+		const exprQ = `SELECT id, user_id, expression, status, result, error, created_at, updated_at FROM expressions WHERE id = ?`
+		if err = tx.GetContext(ctx, expr, tx.Rebind(exprQ), task.ExpressionID); err != nil {
+			return nil, fmt.Errorf("get expr: %w", err)
 		}
-	} else {
-		if err := r.completeExpression(ctx, tx, task.ExpressionID, &task); err != nil {
-			return fmt.Errorf("complete expr: %w", err)
+		if err = r.insertOutboxEvent(ctx, tx, expr); err != nil {
+			return nil, err
 		}
 	}
 
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
+		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
-	return nil
+	return expr, nil
 }
 
-func (r *Repository) failExpression(ctx context.Context, tx *sqlx.Tx, task *models.Task) error {
-	q := `UPDATE expressions SET status = ?, updated_at = ? WHERE id = ?`
-	if _, err := tx.ExecContext(
-		ctx, q,
-		models.ExpressionStatusFailed, task.UpdatedAt, task.ExpressionID,
-	); err != nil {
-		return fmt.Errorf("fail expression: %w", err)
+// failExpression fails the task's expression along with all of its unfinished tasks.
+// Reports whether the expression has been failed by this call.
+func (r *Repository) failExpression(ctx context.Context, tx *sqlx.Tx, task *models.Task) (bool, error) {
+	q := `UPDATE expressions SET status = ?, updated_at = ? WHERE id = ? AND status != ?`
+	res, err := tx.ExecContext(
+		ctx, tx.Rebind(q),
+		models.ExpressionStatusFailed, task.UpdatedAt, task.ExpressionID, models.ExpressionStatusFailed,
+	)
+	if err != nil {
+		return false, fmt.Errorf("fail expression: %w", err)
+	}
+	failed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
 	}
 
-	q = `UPDATE tasks SET status = ?, updated_at = ? WHERE expression_id = ? AND status NOT IN (?, ?)`
-	if _, err := tx.ExecContext(
-		ctx, q,
+	q = `UPDATE tasks SET status = ?, updated_at = ? WHERE expression_id = ? AND status NOT IN (?, ?) RETURNING id`
+	var taskIDs []string
+	if err := tx.SelectContext(
+		ctx, &taskIDs, tx.Rebind(q),
 		models.TaskStatusFailed,
 		task.UpdatedAt,
 		task.ExpressionID,
 		models.TaskStatusCompleted,
 		models.TaskStatusFailed,
 	); err != nil {
-		return fmt.Errorf("tx exec: %w", err)
+		return false, fmt.Errorf("tx exec: %w", err)
 	}
-	return nil
+
+	events := make([]models.TaskEvent, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		events = append(events, models.TaskEvent{TaskID: id, ExpressionID: task.ExpressionID, Type: models.TaskEventFailed, CreatedAt: task.UpdatedAt})
+	}
+	if err := r.insertTaskEvents(ctx, tx, events...); err != nil {
+		return false, err
+	}
+	return failed > 0, nil
 }
 
 func (r *Repository) isFinalTask(ctx context.Context, tx *sqlx.Tx, taskID string) (bool, error) {
 	const q = `SELECT COUNT(*) FROM tasks WHERE parent_task_1_id = ? OR parent_task_2_id = ?`
 
 	var child int
-	if err := tx.GetContext(ctx, &child, q, taskID, taskID); err != nil {
+	if err := tx.GetContext(ctx, &child, tx.Rebind(q), taskID, taskID); err != nil {
 		return false, fmt.Errorf("check child tasks: %w", err)
 	}
 	return child == 0, nil
 }
 
 func (r *Repository) enqueueChildTask(ctx context.Context, tx *sqlx.Tx, completedTask *models.Task) error {
-	// Find child task that depends on the completed task
+	// Find child task that depends on the completed task. It is locked as its sibling
+	// may be finished concurrently and would overwrite the argument otherwise.
 	q := `
 			SELECT id,
 				   expression_id,
@@ -324,13 +504,16 @@ func (r *Repository) enqueueChildTask(ctx context.Context, tx *sqlx.Tx, complete
 				   operation_time,
 				   status,
 				   result,
+				   compute_time,
 				   expire_at,
+				   pending_at,
+				   trace_parent,
 				   created_at,
 				   updated_at
 			FROM tasks
 			WHERE parent_task_1_id = :task_id
 			   OR parent_task_2_id = :task_id
-		`
+		` + r.forUpdate(false)
 
 	row, err := sqlx.NamedQueryContext(ctx, tx, q, map[string]any{"task_id": completedTask.ID})
 	if err != nil {
@@ -357,6 +540,7 @@ func (r *Repository) enqueueChildTask(ctx context.Context, tx *sqlx.Tx, complete
 	}
 	if childTask.Arg1.Valid && childTask.Arg2.Valid {
 		childTask.Status = models.TaskStatusPending
+		childTask.PendingAt = sql.Null[time.Time]{V: completedTask.UpdatedAt, Valid: true}
 	}
 	childTask.UpdatedAt = completedTask.UpdatedAt
 
@@ -365,6 +549,7 @@ func (r *Repository) enqueueChildTask(ctx context.Context, tx *sqlx.Tx, complete
 			SET arg1       = :arg1,
 				arg2       = :arg2,
 				status     = :status,
+				pending_at = :pending_at,
 				updated_at = :updated_at
 			WHERE id = :id
 		`
@@ -379,10 +564,73 @@ func (r *Repository) completeExpression(ctx context.Context, tx *sqlx.Tx, exprID
 	const q = `UPDATE expressions SET status = ?, result = ?, updated_at = ? WHERE id = ?`
 
 	if _, err := tx.ExecContext(
-		ctx, q,
+		ctx, tx.Rebind(q),
 		models.ExpressionStatusCompleted, finalTask.Result, finalTask.UpdatedAt, exprID,
 	); err != nil {
 		return fmt.Errorf("update expr: %w", err)
 	}
 	return nil
 }
+
+// GetOperationCosts calculates the average computation time per operation
+// over tasks completed since the specified time.
+func (r *Repository) GetOperationCosts(ctx context.Context, since time.Time) (map[models.TaskOperation]time.Duration, error) {
+	const q = `
+        SELECT operation, AVG(compute_time) AS compute_time
+        FROM tasks
+        WHERE status = ? AND compute_time IS NOT NULL AND updated_at >= ?
+        GROUP BY operation
+    `
+
+	var rows []struct {
+		Operation   models.TaskOperation `db:"operation"`
+		ComputeTime float64              `db:"compute_time"`
+	}
+	if err := r.rdb.SelectContext(ctx, &rows, r.db.Rebind(q), models.TaskStatusCompleted, since.UTC()); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+
+	costs := make(map[models.TaskOperation]time.Duration, len(rows))
+	for _, row := range rows {
+		costs[row.Operation] = time.Duration(row.ComputeTime)
+	}
+	return costs, nil
+}
+
+// CountExpressionsByStatus counts the stored expressions of all users grouped by status.
+func (r *Repository) CountExpressionsByStatus(ctx context.Context) (map[models.ExpressionStatus]int, error) {
+	const q = `SELECT status, COUNT(*) AS count FROM expressions GROUP BY status`
+
+	var rows []struct {
+		Status models.ExpressionStatus `db:"status"`
+		Count  int                     `db:"count"`
+	}
+	if err := r.rdb.SelectContext(ctx, &rows, q); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+
+	counts := make(map[models.ExpressionStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountTasksByStatus counts the stored tasks of all expressions grouped by status.
+func (r *Repository) CountTasksByStatus(ctx context.Context) (map[models.TaskStatus]int, error) {
+	const q = `SELECT status, COUNT(*) AS count FROM tasks GROUP BY status`
+
+	var rows []struct {
+		Status models.TaskStatus `db:"status"`
+		Count  int               `db:"count"`
+	}
+	if err := r.rdb.SelectContext(ctx, &rows, q); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+
+	counts := make(map[models.TaskStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -268,14 +268,15 @@ func TestRepository_GetPendingTask(t *testing.T) {
 	repo := New(db)
 	ctx := context.Background()
 
-	task, err := repo.GetPendingTask(ctx)
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
 	require.Error(t, err)
 	require.ErrorIs(t, err, models.ErrNoPendingTasks)
 	require.Nil(t, task)
 
 	userID := createTestUser(t, repo, ctx)
 	cmd := models.CreateExpressionCmd{
-		Expression: "(5+3)*2",
+		Expression:  "(5+3)*2",
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
 		Tasks: []models.CreateExpressionCmdTask{
 			{
 				ID:            "task1",
@@ -302,7 +303,7 @@ func TestRepository_GetPendingTask(t *testing.T) {
 	require.NoError(t, err, "Failed to create test expression")
 
 	// Test getting the pending task
-	task, err = repo.GetPendingTask(ctx)
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
 	require.NoError(t, err)
 	require.NotNil(t, task)
 
@@ -313,9 +314,12 @@ func TestRepository_GetPendingTask(t *testing.T) {
 	assert.Equal(t, models.TaskOperationAddition, task.Operation)
 	assert.Equal(t, float64(5), task.Arg1.V)
 	assert.Equal(t, float64(3), task.Arg2.V)
+	assert.True(t, task.PendingAt.Valid)
+	assert.False(t, task.UpdatedAt.Before(task.PendingAt.V))
+	assert.Equal(t, cmd.TraceParent, task.TraceParent.V)
 
 	// Try getting another pending task - should return error as there are no more pending tasks
-	task, err = repo.GetPendingTask(ctx)
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
 	require.Error(t, err)
 	require.ErrorIs(t, err, models.ErrNoPendingTasks)
 	require.Nil(t, task)
@@ -354,7 +358,7 @@ func TestRepository_FinishTask(t *testing.T) {
 	_, err := repo.CreateExpression(ctx, userID, cmd)
 	require.NoError(t, err, "Failed to create test expression")
 
-	task, err := repo.GetPendingTask(ctx)
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
 	require.NoError(t, err)
 	require.NotNil(t, task)
 
@@ -379,7 +383,7 @@ func TestRepository_FinishTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.FinishTask(ctx, tt.cmd)
+			_, err := repo.FinishTask(ctx, tt.cmd)
 			tt.wantErr(t, err)
 		})
 	}
@@ -418,7 +422,7 @@ func TestRepository_FinishTask_Failed(t *testing.T) {
 	exprID, err := repo.CreateExpression(ctx, userID, cmd)
 	require.NoError(t, err, "Failed to create test expression")
 
-	task, err := repo.GetPendingTask(ctx)
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
 	require.NoError(t, err)
 
 	failCmd := models.FinishTaskCmd{
@@ -426,8 +430,11 @@ func TestRepository_FinishTask_Failed(t *testing.T) {
 		Status: models.TaskStatusFailed,
 		Result: 0,
 	}
-	err = repo.FinishTask(ctx, failCmd)
+	finished, err := repo.FinishTask(ctx, failCmd)
 	require.NoError(t, err)
+	require.NotNil(t, finished)
+	assert.Equal(t, exprID, finished.ID)
+	assert.Equal(t, models.ExpressionStatusFailed, finished.Status)
 
 	// Check that the expression is marked as failed
 	expr, err := repo.GetExpression(ctx, userID, exprID)
@@ -442,6 +449,155 @@ func TestRepository_FinishTask_Failed(t *testing.T) {
 	}
 }
 
+func TestRepository_FinishTask_Completed(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	cmd := models.CreateExpressionCmd{
+		Expression: "(5+3)*2",
+		Tasks: []models.CreateExpressionCmdTask{
+			{ID: "task1", Arg1: 5, Arg2: 3, Operation: models.TaskOperationAddition},
+			{ID: "task2", ParentTask1ID: "task1", Arg2: 2, Operation: models.TaskOperationMultiplication},
+		},
+	}
+
+	exprID, err := repo.CreateExpression(ctx, userID, cmd)
+	require.NoError(t, err, "Failed to create test expression")
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+
+	finished, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: 8})
+	require.NoError(t, err)
+	assert.Nil(t, finished, "Expression should not be finished by a non-final task")
+
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	assert.Equal(t, "task2", task.ID)
+	assert.True(t, task.PendingAt.Valid, "Child task should become pending")
+
+	finished, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: 16})
+	require.NoError(t, err)
+	require.NotNil(t, finished)
+	assert.Equal(t, exprID, finished.ID)
+	assert.Equal(t, models.ExpressionStatusCompleted, finished.Status)
+	assert.Equal(t, float64(16), finished.Result.V)
+}
+
+// TestRepository_FinishTask_rollback checks that a transaction failed midway doesn't hold
+// the single write connection of SQLite, so that the following writes don't wait for it.
+func TestRepository_FinishTask_rollback(t *testing.T) {
+	db := setupSQLiteTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	cmd := models.CreateExpressionCmd{
+		Expression: "(5+3)*2",
+		Tasks: []models.CreateExpressionCmdTask{
+			{ID: "task1", Arg1: 5, Arg2: 3, Operation: models.TaskOperationAddition},
+			{ID: "task2", ParentTask1ID: "task1", Arg2: 2, Operation: models.TaskOperationMultiplication},
+		},
+	}
+	_, err := repo.CreateExpression(ctx, userID, cmd)
+	require.NoError(t, err)
+
+	// Enqueueing the child task fails after the finished task has been updated
+	_, err = db.Write.ExecContext(ctx, `
+		CREATE TRIGGER fail_enqueue BEFORE UPDATE OF arg1 ON tasks WHEN NEW.id = 'task2'
+		BEGIN SELECT RAISE(ABORT, 'enqueue failed'); END
+	`)
+	require.NoError(t, err)
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: 8})
+	require.ErrorContains(t, err, "enqueue failed")
+
+	wctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err = repo.CreateExpression(wctx, userID, models.CreateExpressionCmd{
+		Expression: "1+1",
+		Tasks:      []models.CreateExpressionCmdTask{{ID: "task3", Arg1: 1, Arg2: 1, Operation: models.TaskOperationAddition}},
+	})
+	require.NoError(t, err, "the failed transaction should have been rolled back")
+
+	tasks, err := repo.ListExpressionTasks(ctx, userID, task.ExpressionID)
+	require.NoError(t, err)
+	for _, task := range tasks {
+		if task.ID == "task1" {
+			assert.Equal(t, models.TaskStatusInProgress, task.Status, "the update of the finished task should have been rolled back")
+		}
+	}
+}
+
+func TestRepository_CountByStatus(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	exprCounts, err := repo.CountExpressionsByStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, exprCounts)
+
+	userID := createTestUser(t, repo, ctx)
+	createTestExpressions(t, repo, ctx, userID, 2)
+
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+
+	exprCounts, err = repo.CountExpressionsByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.ExpressionStatus]int{models.ExpressionStatusPending: 2}, exprCounts)
+
+	taskCounts, err := repo.CountTasksByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskStatus]int{
+		models.TaskStatusPending:    1,
+		models.TaskStatusInProgress: 1,
+	}, taskCounts)
+}
+
+func TestRepository_GetOperationCosts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	since := time.Now().Add(-time.Minute)
+
+	userID := createTestUser(t, repo, ctx)
+	createTestExpressions(t, repo, ctx, userID, 2)
+
+	costs, err := repo.GetOperationCosts(ctx, since)
+	require.NoError(t, err)
+	assert.Empty(t, costs)
+
+	for _, computeTime := range []time.Duration{10 * time.Millisecond, 30 * time.Millisecond} {
+		task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+
+		_, err = repo.FinishTask(ctx, models.FinishTaskCmd{
+			ID:          task.ID,
+			Status:      models.TaskStatusCompleted,
+			Result:      8,
+			ComputeTime: computeTime,
+		})
+		require.NoError(t, err)
+	}
+
+	costs, err = repo.GetOperationCosts(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskOperation]time.Duration{
+		models.TaskOperationAddition: 20 * time.Millisecond,
+	}, costs)
+
+	costs, err = repo.GetOperationCosts(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, costs)
+}
+
 // Helper functions
 
 func createTestUser(t *testing.T, repo *Repository, ctx context.Context) string {
@@ -455,7 +611,7 @@ func createTestUser(t *testing.T, repo *Repository, ctx context.Context) string
 	err := repo.Register(ctx, cmd)
 	require.NoError(t, err, "Failed to create test user")
 
-	user, err := repo.GetUser(ctx, models.GetUserCmd(cmd))
+	user, err := repo.GetUser(ctx, models.GetUserCmd{Login: cmd.Login})
 	require.NoError(t, err, "Failed to get created test user")
 
 	return user.ID
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetIdempotencyKey retrieves an unexpired idempotency key of a user.
+// Returns [models.ErrIdempotencyKeyNotFound] if the key doesn't exist or has expired.
+func (r *Repository) GetIdempotencyKey(ctx context.Context, cmd models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error) {
+	const q = `
+        SELECT user_id, idempotency_key, expression_id, request_hash, created_at, expires_at
+        FROM idempotency_keys
+        WHERE user_id = ? AND idempotency_key = ? AND expires_at > ?
+    `
+
+	var key models.IdempotencyKey
+	if err := r.rdb.GetContext(ctx, &key, r.db.Rebind(q), cmd.UserID, cmd.Key, cmd.Now.UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrIdempotencyKeyNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+	return &key, nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the idempotency keys expired by now. Returns the number of deleted keys.
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.exec(ctx, r.db.Rebind(`DELETE FROM idempotency_keys WHERE expires_at <= ?`), now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// insertIdempotencyKey stores the key of the expression within the transaction, replacing an expired one.
+// Returns [models.ErrIdempotencyKeyExists] if the user has an unexpired key with the same value.
+func (r *Repository) insertIdempotencyKey(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	userID string,
+	exprID string,
+	key models.CreateExpressionCmdIdempotencyKey,
+	now time.Time,
+) error {
+	const q = `
+        INSERT INTO idempotency_keys (user_id, idempotency_key, expression_id, request_hash, created_at, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id, idempotency_key) DO UPDATE
+        SET expression_id = excluded.expression_id,
+            request_hash  = excluded.request_hash,
+            created_at    = excluded.created_at,
+            expires_at    = excluded.expires_at
+        WHERE idempotency_keys.expires_at <= excluded.created_at
+    `
+
+	res, err := tx.ExecContext(ctx, tx.Rebind(q), userID, key.Key, exprID, key.RequestHash, now, key.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("insert idempotency key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return models.ErrIdempotencyKeyExists
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// GetUserByIdentity retrieves the user linked to an external identity.
+// Returns [models.ErrUserNotFound] if the identity isn't linked to any user.
+func (r *Repository) GetUserByIdentity(ctx context.Context, cmd models.GetUserByIdentityCmd) (*models.User, error) {
+	const q = `
+		SELECT u.id, u.login, u.password_hash, u.role, u.password_change_required, u.created_at, u.updated_at
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = ? AND i.subject = ?
+		`
+
+	var user models.User
+	if err := r.rdb.GetContext(ctx, &user, r.db.Rebind(q), cmd.Issuer, cmd.Subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+
+	return &user, nil
+}
+
+// CreateUserWithIdentity creates a user without a password linked to an external identity.
+// Returns [models.ErrUserExists] if the login is taken
+// and [models.ErrIdentityExists] if the identity is already linked to a user.
+func (r *Repository) CreateUserWithIdentity(ctx context.Context, cmd models.CreateUserWithIdentityCmd) (*models.User, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	user := models.User{
+		ID:        xid.New().String(),
+		Login:     cmd.Login,
+		Role:      models.UserRoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	const insertUserQ = `
+		INSERT INTO users (id, login, password_hash, role, created_at, updated_at)
+		VALUES (:id, :login, :password_hash, :role, :created_at, :updated_at)
+		`
+
+	if _, err := tx.NamedExecContext(ctx, insertUserQ, user); err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrUserExists
+		}
+		return nil, fmt.Errorf("insert user: %w", err)
+	}
+
+	const insertIdentityQ = `INSERT INTO user_identities (issuer, subject, user_id, created_at) VALUES (?, ?, ?, ?)`
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(insertIdentityQ), cmd.Issuer, cmd.Subject, user.ID, now); err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrIdentityExists
+		}
+		return nil, fmt.Errorf("insert identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_UserIdentities(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	identity := models.GetUserByIdentityCmd{Issuer: "https://idp.example.com", Subject: "ext-1"}
+	_, err := repo.GetUserByIdentity(ctx, identity)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	created, err := repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{
+		Login:   "jdoe",
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleUser, created.Role)
+
+	got, err := repo.GetUserByIdentity(ctx, identity)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Equal(t, "jdoe", got.Login)
+	assert.Empty(t, got.PasswordHash)
+
+	_, err = repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{Login: "JDoe", Issuer: "other", Subject: "ext-2"})
+	require.ErrorIs(t, err, models.ErrUserExists)
+	_, err = repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{Login: "jdoe2", Issuer: identity.Issuer, Subject: identity.Subject})
+	require.ErrorIs(t, err, models.ErrIdentityExists)
+	_, err = repo.GetUser(ctx, models.GetUserCmd{Login: "jdoe2"})
+	require.ErrorIs(t, err, models.ErrUserNotFound, "user should not be created without the identity")
+
+	require.NoError(t, repo.DeleteUser(ctx, created.ID))
+	_, err = repo.GetUserByIdentity(ctx, identity)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+// TestRepository_concurrentAgents runs agents claiming and finishing tasks concurrently
+// with users submitting and listing expressions and failing to log in. It checks that every task
+// is computed exactly once and no login failure is lost to a concurrent read-modify-write transaction.
+func TestRepository_concurrentAgents(t *testing.T) {
+	const (
+		agents            = 8
+		submitters        = 4
+		exprsPerSubmitter = 25
+		total             = submitters * exprsPerSubmitter
+		loginFailures     = 50
+	)
+
+	repo := New(setupTestDB(t))
+	ctx := context.Background()
+	userID := createTestUser(t, repo, ctx)
+
+	var (
+		mu        sync.Mutex
+		claims    = make(map[string]int)
+		completed atomic.Int64
+	)
+	g, gctx := errgroup.WithContext(ctx)
+
+	// Each expression is (i+1)*2, computed by an addition and a multiplication waiting for it
+	for s := range submitters {
+		g.Go(func() error {
+			for i := range exprsPerSubmitter {
+				n := float64(s*exprsPerSubmitter + i)
+				addID, mulID := xid.New().String(), xid.New().String()
+				_, err := repo.CreateExpression(gctx, userID, models.CreateExpressionCmd{
+					Expression: fmt.Sprintf("(%v+1)*2", n),
+					Tasks: []models.CreateExpressionCmdTask{
+						{ID: addID, Arg1: n, Arg2: 1, Operation: models.TaskOperationAddition},
+						{ID: mulID, ParentTask1ID: addID, Arg2: 2, Operation: models.TaskOperationMultiplication},
+					},
+				})
+				if err != nil {
+					return fmt.Errorf("create expression: %w", err)
+				}
+				if _, err := repo.ListExpressions(gctx, userID); err != nil {
+					return fmt.Errorf("list expressions: %w", err)
+				}
+			}
+			return nil
+		})
+	}
+
+	for range submitters {
+		g.Go(func() error {
+			for range loginFailures {
+				if _, err := repo.RecordLoginFailure(gctx, models.RecordLoginFailureCmd{Key: "login", Window: time.Hour}); err != nil {
+					return fmt.Errorf("record login failure: %w", err)
+				}
+			}
+			return nil
+		})
+	}
+
+	for range agents {
+		g.Go(func() error {
+			for completed.Load() < total {
+				if err := gctx.Err(); err != nil {
+					return err
+				}
+				task, err := repo.GetPendingTask(gctx, models.GetPendingTaskCmd{})
+				if errors.Is(err, models.ErrNoPendingTasks) {
+					runtime.Gosched()
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("get pending task: %w", err)
+				}
+
+				mu.Lock()
+				claims[task.ID]++
+				mu.Unlock()
+
+				result := task.Arg1.V + task.Arg2.V
+				if task.Operation == models.TaskOperationMultiplication {
+					result = task.Arg1.V * task.Arg2.V
+				}
+				expr, err := repo.FinishTask(gctx, models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: result})
+				if err != nil {
+					return fmt.Errorf("finish task: %w", err)
+				}
+				if expr != nil {
+					completed.Add(1)
+				}
+			}
+			return nil
+		})
+	}
+
+	require.NoError(t, g.Wait())
+
+	assert.Len(t, claims, 2*total)
+	for id, n := range claims {
+		assert.Equal(t, 1, n, "task %s is claimed once", id)
+	}
+
+	throttles, err := repo.GetLoginThrottles(ctx, []string{"login"})
+	require.NoError(t, err)
+	require.Len(t, throttles, 1)
+	assert.Equal(t, submitters*loginFailures, throttles[0].Failures)
+
+	exprs, err := repo.ListExpressions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, exprs, total)
+	for _, expr := range exprs {
+		var n float64
+		_, err := fmt.Sscanf(expr.Expression, "(%v+1)*2", &n)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExpressionStatusCompleted, expr.Status, expr.Expression)
+		assert.Equal(t, (n+1)*2, expr.Result.V, expr.Expression)
+	}
+}
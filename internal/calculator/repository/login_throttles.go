@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetLoginThrottles retrieves the login throttles with the given keys. Keys without failures are omitted.
+func (r *Repository) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	q, args, err := sqlx.In(`
+		SELECT key, failures, locked_until, last_failure_at
+		FROM login_throttles
+		WHERE key IN (?)
+		`, keys)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx in: %w", err)
+	}
+
+	var throttles []models.LoginThrottle
+	if err := r.rdb.SelectContext(ctx, &throttles, r.db.Rebind(q), args...); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return throttles, nil
+}
+
+// RecordLoginFailure increments the number of consecutive failures of the key and returns the updated throttle.
+// Failures are counted from scratch once the window has passed since the last failure and the end of the lockout.
+func (r *Repository) RecordLoginFailure(ctx context.Context, cmd models.RecordLoginFailureCmd) (*models.LoginThrottle, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	selectQ := `
+		SELECT key, failures, locked_until, last_failure_at
+		FROM login_throttles
+		WHERE key = ?
+		` + r.forUpdate(false)
+
+	now := time.Now().UTC()
+	throttle := models.LoginThrottle{Key: cmd.Key}
+	if err := tx.GetContext(ctx, &throttle, tx.Rebind(selectQ), cmd.Key); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+
+	lastActivity := throttle.LastFailureAt
+	if throttle.LockedUntil.Valid && throttle.LockedUntil.V.After(lastActivity) {
+		lastActivity = throttle.LockedUntil.V
+	}
+	if now.Sub(lastActivity) > cmd.Window {
+		throttle.Failures = 0
+		throttle.LockedUntil = sql.Null[time.Time]{}
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+
+	const upsertQ = `
+		INSERT INTO login_throttles (key, failures, locked_until, last_failure_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = excluded.failures,
+			locked_until = excluded.locked_until,
+			last_failure_at = excluded.last_failure_at
+		`
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(upsertQ),
+		throttle.Key, throttle.Failures, throttle.LockedUntil, throttle.LastFailureAt,
+	); err != nil {
+		return nil, fmt.Errorf("db exec: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &throttle, nil
+}
+
+// LockLogin locks out login attempts with the key until the given time.
+func (r *Repository) LockLogin(ctx context.Context, cmd models.LockLoginCmd) error {
+	const q = `UPDATE login_throttles SET locked_until = ? WHERE key = ?`
+
+	if _, err := r.exec(ctx, r.db.Rebind(q), cmd.LockedUntil.UTC(), cmd.Key); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginFailures forgets the failures of the key, e.g. after a successful login.
+func (r *Repository) ResetLoginFailures(ctx context.Context, key string) error {
+	const q = `DELETE FROM login_throttles WHERE key = ?`
+
+	if _, err := r.exec(ctx, r.db.Rebind(q), key); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
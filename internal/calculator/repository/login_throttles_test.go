@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_LoginThrottles(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	recordFailure := func(key string, window time.Duration) *models.LoginThrottle {
+		t.Helper()
+		throttle, err := repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: key, Window: window})
+		require.NoError(t, err)
+		return throttle
+	}
+
+	assert.Equal(t, 1, recordFailure("login:user", time.Hour).Failures)
+	assert.Equal(t, 2, recordFailure("login:user", time.Hour).Failures)
+	assert.Equal(t, 1, recordFailure("ip:192.0.2.1", time.Hour).Failures)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	err := repo.LockLogin(ctx, models.LockLoginCmd{Key: "login:user", LockedUntil: lockedUntil})
+	require.NoError(t, err)
+
+	throttles, err := repo.GetLoginThrottles(ctx, []string{"login:user", "ip:192.0.2.1", "ip:192.0.2.2"})
+	require.NoError(t, err)
+	require.Len(t, throttles, 2)
+	for _, throttle := range throttles {
+		assert.Equal(t, throttle.Key == "login:user", throttle.Locked(time.Now()), throttle.Key)
+	}
+
+	// Failures are still counted until the lockout ends
+	assert.Equal(t, 3, recordFailure("login:user", time.Nanosecond).Failures)
+
+	// and forgotten once the window has passed since its end
+	err = repo.LockLogin(ctx, models.LockLoginCmd{Key: "login:user", LockedUntil: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, recordFailure("login:user", time.Nanosecond).Failures)
+
+	err = repo.ResetLoginFailures(ctx, "login:user")
+	require.NoError(t, err)
+	throttles, err = repo.GetLoginThrottles(ctx, []string{"login:user"})
+	require.NoError(t, err)
+	assert.Empty(t, throttles)
+}
+
+func TestRepository_CreateAuditEvent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	err := repo.CreateAuditEvent(ctx, models.CreateAuditEventCmd{
+		Event:   models.AuditEventLoginLockout,
+		Subject: "login:user",
+		Details: "5 consecutive failed attempts, locked out for 1m0s",
+	})
+	require.NoError(t, err)
+
+	var events []models.AuditEvent
+	err = db.Read.SelectContext(ctx, &events, `SELECT id, event, subject, details, created_at FROM audit_events`)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditEventLoginLockout, events[0].Event)
+	assert.Equal(t, "login:user", events[0].Subject)
+}
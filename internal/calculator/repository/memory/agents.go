@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// UpsertAgent registers an agent or refreshes its capabilities and last seen time.
+func (r *Repository) UpsertAgent(_ context.Context, cmd models.UpsertAgentCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	agent, ok := r.agents[cmd.ID]
+	if !ok {
+		agent = &models.Agent{ID: cmd.ID, CreatedAt: now}
+		r.agents[cmd.ID] = agent
+	}
+	// Stored the same way as the SQL columns read back: no operations as nil, no labels as an empty map
+	agent.Operations = nil
+	if len(cmd.Operations) > 0 {
+		agent.Operations = slices.Clone(cmd.Operations)
+	}
+	agent.Labels = models.AgentLabels{}
+	maps.Copy(agent.Labels, cmd.Labels)
+	agent.LastSeenAt = now
+	agent.UpdatedAt = now
+	return nil
+}
+
+// ListAliveAgents retrieves agents seen since the specified time.
+func (r *Repository) ListAliveAgents(_ context.Context, since time.Time) ([]models.Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.aliveAgents(since), nil
+}
+
+func (r *Repository) aliveAgents(since time.Time) []models.Agent {
+	var agents []models.Agent
+	for _, a := range r.agents {
+		if !a.LastSeenAt.Before(since) {
+			agents = append(agents, cloneAgent(a))
+		}
+	}
+	slices.SortFunc(agents, func(a, b models.Agent) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return agents
+}
+
+// ReportUnroutableTasks sets an error on pending expressions having pending tasks
+// that none of the agents seen since the specified time can execute.
+// Returns the number of reported expressions.
+func (r *Repository) ReportUnroutableTasks(_ context.Context, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agents := r.aliveAgents(since)
+	if len(agents) == 0 {
+		return 0, nil // nothing is known about agent capabilities
+	}
+
+	reported := 0
+	now := time.Now().UTC()
+	for _, t := range r.tasks {
+		if t.Status != models.TaskStatusPending || supportedByAny(agents, t.Operation) {
+			continue
+		}
+		expr, ok := r.expressions[t.ExpressionID]
+		if !ok || expr.Error.Valid {
+			continue
+		}
+		expr.Error = sql.Null[string]{V: fmt.Sprintf("no available agent supports operation %q", t.Operation), Valid: true}
+		expr.UpdatedAt = now
+		reported++
+	}
+	return reported, nil
+}
+
+func supportedByAny(agents []models.Agent, op models.TaskOperation) bool {
+	for _, a := range agents {
+		if a.Supports(op) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// CreateAPIKey stores a new API key of the user.
+func (r *Repository) CreateAPIKey(_ context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash == cmd.KeyHash {
+			return nil, errors.New("api key hash already exists")
+		}
+	}
+
+	key := &models.APIKey{
+		ID:        xid.New().String(),
+		UserID:    cmd.UserID,
+		Name:      cmd.Name,
+		KeyHash:   cmd.KeyHash,
+		Scopes:    slices.Clone(cmd.Scopes),
+		ExpiresAt: sql.Null[time.Time]{V: cmd.ExpiresAt.V.UTC(), Valid: cmd.ExpiresAt.Valid},
+		CreatedAt: time.Now().UTC(),
+	}
+	r.apiKeys[key.ID] = key
+
+	clone := cloneAPIKey(key)
+	return &clone, nil
+}
+
+// ListAPIKeys retrieves all API keys of the user, newest first.
+func (r *Repository) ListAPIKeys(_ context.Context, userID string) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []models.APIKey
+	for _, k := range r.apiKeys {
+		if k.UserID != userID {
+			continue
+		}
+		if key, ok := r.withUser(k); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b models.APIKey) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key, regardless of whether it is active.
+// Returns [models.ErrAPIKeyNotFound] if no matching key exists.
+func (r *Repository) GetAPIKeyByHash(_ context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash != keyHash {
+			continue
+		}
+		if key, ok := r.withUser(k); ok {
+			return &key, nil
+		}
+	}
+	return nil, models.ErrAPIKeyNotFound
+}
+
+// RevokeAPIKey revokes an API key of the user. Revoking an already revoked key is a no-op.
+// Returns [models.ErrAPIKeyNotFound] if the user has no such key.
+func (r *Repository) RevokeAPIKey(_ context.Context, cmd models.RevokeAPIKeyCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.apiKeys[cmd.ID]
+	if !ok || key.UserID != cmd.UserID {
+		return models.ErrAPIKeyNotFound
+	}
+	if !key.RevokedAt.Valid {
+		key.RevokedAt = sql.Null[time.Time]{V: time.Now().UTC(), Valid: true}
+	}
+	return nil
+}
+
+// withUser returns a copy of the key with the login and role of its user, as joined by the SQL repository.
+// Reports false if the user doesn't exist.
+func (r *Repository) withUser(k *models.APIKey) (models.APIKey, bool) {
+	user, ok := r.users[k.UserID]
+	if !ok {
+		return models.APIKey{}, false
+	}
+	key := cloneAPIKey(k)
+	key.UserLogin, key.UserRole = user.Login, user.Role
+	return key, true
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// CreateAuditEvent records a security-relevant event.
+func (r *Repository) CreateAuditEvent(_ context.Context, cmd models.CreateAuditEventCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.auditEvents = append(r.auditEvents, models.AuditEvent{
+		ID:        xid.New().String(),
+		Event:     cmd.Event,
+		Subject:   cmd.Subject,
+		Details:   cmd.Details,
+		CreatedAt: time.Now().UTC(),
+	})
+	return nil
+}
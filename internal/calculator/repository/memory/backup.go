@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+)
+
+// Backup always returns [database.ErrBackupUnsupported], there is no file to back up.
+func (r *Repository) Backup(context.Context, string) error {
+	return database.ErrBackupUnsupported
+}
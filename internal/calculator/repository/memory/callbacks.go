@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// GetExpressionCallback retrieves the callback of a specific expression for a specific user.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist
+// and [models.ErrCallbackNotFound] if it was submitted without a callback.
+func (r *Repository) GetExpressionCallback(_ context.Context, userID string, exprID string) (*models.Callback, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expr, ok := r.expressions[exprID]
+	if !ok || expr.UserID != userID {
+		return nil, models.ErrExpressionNotFound
+	}
+	callback, ok := r.callbacks[exprID]
+	if !ok {
+		return nil, models.ErrCallbackNotFound
+	}
+	clone := *callback
+	return &clone, nil
+}
+
+// ListExpressionCallbackAttempts retrieves the delivery log of the callback of a specific expression
+// for a specific user, oldest first.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) ListExpressionCallbackAttempts(_ context.Context, userID string, exprID string) ([]models.CallbackAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expr, ok := r.expressions[exprID]
+	if !ok || expr.UserID != userID {
+		return nil, models.ErrExpressionNotFound
+	}
+
+	var attempts []models.CallbackAttempt
+	for _, a := range r.callbackLog {
+		if a.ExpressionID == exprID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+// ClaimDueCallbacks retrieves and claims a batch of the pending callbacks of the finished expressions
+// whose retry time has come, longest finished first. The claimed callbacks are postponed by the lease,
+// so that concurrent dispatchers don't attempt them too.
+func (r *Repository) ClaimDueCallbacks(_ context.Context, cmd models.ClaimDueCallbacksCmd) ([]models.DueCallback, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*models.Callback
+	for _, c := range r.callbacks {
+		expr := r.expressions[c.ExpressionID]
+		if c.Status != models.CallbackStatusPending ||
+			(expr.Status != models.ExpressionStatusCompleted && expr.Status != models.ExpressionStatusFailed) ||
+			(c.NextAttemptAt.Valid && c.NextAttemptAt.V.After(cmd.Now)) {
+			continue
+		}
+		due = append(due, c)
+	}
+	slices.SortFunc(due, func(a, b *models.Callback) int {
+		ea, eb := r.expressions[a.ExpressionID], r.expressions[b.ExpressionID]
+		return cmp.Or(ea.UpdatedAt.Compare(eb.UpdatedAt), cmp.Compare(ea.ID, eb.ID))
+	})
+
+	claimed := make([]models.DueCallback, 0, min(len(due), cmd.Limit))
+	for _, c := range due[:min(len(due), cmd.Limit)] {
+		c.NextAttemptAt = sql.Null[time.Time]{V: cmd.Now.UTC().Add(cmd.Lease), Valid: true}
+		claimed = append(claimed, models.DueCallback{Callback: *c, Expression: *r.expressions[c.ExpressionID]})
+	}
+	return claimed, nil
+}
+
+// RecordCallbackAttempt appends the attempt to the delivery log of a pending callback and updates its status.
+// Returns [models.ErrCallbackNotFound] if there is no pending callback of the expression.
+func (r *Repository) RecordCallbackAttempt(_ context.Context, cmd models.RecordCallbackAttemptCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	callback, ok := r.callbacks[cmd.ExpressionID]
+	if !ok || callback.Status != models.CallbackStatusPending {
+		return models.ErrCallbackNotFound
+	}
+
+	now := time.Now().UTC()
+	callback.Attempts++
+	callback.UpdatedAt = now
+	switch {
+	case cmd.Delivered:
+		callback.Status, callback.NextAttemptAt = models.CallbackStatusDelivered, sql.Null[time.Time]{}
+	case cmd.NextAttemptAt.IsZero():
+		callback.Status, callback.NextAttemptAt = models.CallbackStatusFailed, sql.Null[time.Time]{}
+	default:
+		callback.NextAttemptAt = sql.Null[time.Time]{V: cmd.NextAttemptAt.UTC(), Valid: true}
+	}
+
+	r.callbackLog = append(r.callbackLog, models.CallbackAttempt{
+		ID:           xid.New().String(),
+		ExpressionID: cmd.ExpressionID,
+		Attempt:      callback.Attempts,
+		StatusCode:   sql.Null[int]{V: cmd.StatusCode, Valid: cmd.StatusCode != 0},
+		Error:        sql.Null[string]{V: cmd.Error, Valid: cmd.Error != ""},
+		Duration:     cmd.Duration,
+		CreatedAt:    now,
+	})
+	return nil
+}
+
+// deleteCallbacks deletes the callbacks of the expressions along with their delivery log.
+func (r *Repository) deleteCallbacks(exprIDs map[string]bool) {
+	for id := range exprIDs {
+		delete(r.callbacks, id)
+	}
+	r.callbackLog = slices.DeleteFunc(r.callbackLog, func(a models.CallbackAttempt) bool {
+		return exprIDs[a.ExpressionID]
+	})
+}
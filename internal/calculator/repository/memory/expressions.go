@@ -0,0 +1,360 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// CreateExpression stores a new expression with its associated tasks, callback and idempotency key, if any,
+// and returns the ID of the created expression.
+// Returns [models.ErrIdempotencyKeyExists] if the user has an unexpired expression with the same key.
+func (r *Repository) CreateExpression(_ context.Context, userID string, cmd models.CreateExpressionCmd) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range cmd.Tasks {
+		if _, ok := r.tasksByID[t.ID]; ok {
+			return "", fmt.Errorf("task %q already exists", t.ID)
+		}
+	}
+
+	now := time.Now().UTC()
+	if cmd.IdempotencyKey != nil {
+		key, ok := r.idempotencyKeys[idempotencyKeyID{userID: userID, key: cmd.IdempotencyKey.Key}]
+		if ok && key.ExpiresAt.After(now) {
+			return "", models.ErrIdempotencyKeyExists
+		}
+	}
+
+	expr := &models.Expression{
+		ID:         xid.New().String(),
+		UserID:     userID,
+		Expression: cmd.Expression,
+		Status:     models.ExpressionStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	r.expressions[expr.ID] = expr
+
+	for _, t := range cmd.Tasks {
+		status, pendingAt := models.TaskStatusCreated, sql.Null[time.Time]{}
+		if t.ParentTask1ID == "" && t.ParentTask2ID == "" {
+			status, pendingAt = models.TaskStatusPending, sql.Null[time.Time]{V: now, Valid: true}
+		}
+		task := &models.Task{
+			ID:            t.ID,
+			ExpressionID:  expr.ID,
+			ParentTask1ID: sql.Null[string]{V: t.ParentTask1ID, Valid: t.ParentTask1ID != ""},
+			ParentTask2ID: sql.Null[string]{V: t.ParentTask2ID, Valid: t.ParentTask2ID != ""},
+			Arg1:          sql.Null[float64]{V: t.Arg1, Valid: t.ParentTask1ID == ""},
+			Arg2:          sql.Null[float64]{V: t.Arg2, Valid: t.ParentTask2ID == ""},
+			Operation:     t.Operation,
+			OperationTime: t.OperationTime,
+			Status:        status,
+			PendingAt:     pendingAt,
+			TraceParent:   sql.Null[string]{V: cmd.TraceParent, Valid: cmd.TraceParent != ""},
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		r.tasks = append(r.tasks, task)
+		r.tasksByID[task.ID] = task
+		r.appendTaskEvent(task, models.TaskEventCreated, "")
+	}
+
+	if cmd.CallbackURL != "" {
+		r.callbacks[expr.ID] = &models.Callback{
+			ExpressionID: expr.ID,
+			URL:          cmd.CallbackURL,
+			Secret:       cmd.CallbackSecret,
+			Status:       models.CallbackStatusPending,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+	}
+
+	if cmd.IdempotencyKey != nil {
+		r.idempotencyKeys[idempotencyKeyID{userID: userID, key: cmd.IdempotencyKey.Key}] = models.IdempotencyKey{
+			UserID:       userID,
+			Key:          cmd.IdempotencyKey.Key,
+			ExpressionID: expr.ID,
+			RequestHash:  cmd.IdempotencyKey.RequestHash,
+			CreatedAt:    now,
+			ExpiresAt:    cmd.IdempotencyKey.ExpiresAt.UTC(),
+		}
+	}
+
+	return expr.ID, nil
+}
+
+// ListExpressions retrieves all stored expressions for a specific user.
+func (r *Repository) ListExpressions(_ context.Context, userID string) ([]models.Expression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var exprs []models.Expression
+	for _, expr := range r.expressions {
+		if expr.UserID == userID {
+			exprs = append(exprs, *expr)
+		}
+	}
+	slices.SortFunc(exprs, func(a, b models.Expression) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return exprs, nil
+}
+
+// GetExpression retrieves a specific expression by its ID for a specific user.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) GetExpression(_ context.Context, userID string, exprID string) (*models.Expression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expr, ok := r.expressions[exprID]
+	if !ok || expr.UserID != userID {
+		return nil, models.ErrExpressionNotFound
+	}
+	clone := *expr
+	return &clone, nil
+}
+
+// ListExpressionTasks retrieves all tasks associated with a specific expression for a specific user.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) ListExpressionTasks(_ context.Context, userID string, exprID string) ([]models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expr, ok := r.expressions[exprID]
+	if !ok || expr.UserID != userID {
+		return nil, models.ErrExpressionNotFound
+	}
+
+	var tasks []models.Task
+	for _, t := range r.tasks {
+		if t.ExpressionID == exprID {
+			tasks = append(tasks, *t)
+		}
+	}
+	slices.SortStableFunc(tasks, func(a, b models.Task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return tasks, nil
+}
+
+// GetPendingTask retrieves and claims the first available pending task
+// with an operation the agent supports, clearing any routing error reported on its expression.
+// The claimed tasks whose lease has expired are handed back to the queue first.
+// Returns [models.ErrNoPendingTasks] if there are no suitable pending tasks available.
+func (r *Repository) GetPendingTask(_ context.Context, cmd models.GetPendingTaskCmd) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	r.releaseExpiredTasks(now)
+
+	var task *models.Task
+	for _, t := range r.tasks {
+		if t.Status != models.TaskStatusPending {
+			continue
+		}
+		if len(cmd.Operations) > 0 && !slices.Contains(cmd.Operations, t.Operation) {
+			continue
+		}
+		if task == nil || t.CreatedAt.Before(task.CreatedAt) {
+			task = t
+		}
+	}
+	if task == nil {
+		return nil, models.ErrNoPendingTasks
+	}
+
+	task.Status = models.TaskStatusInProgress
+	task.ExpireAt = sql.Null[time.Time]{V: now.Add(cmd.Lease), Valid: cmd.Lease > 0}
+	task.AgentID = sql.Null[string]{V: cmd.AgentID, Valid: cmd.AgentID != ""}
+	task.UpdatedAt = now
+
+	if expr := r.expressions[task.ExpressionID]; expr != nil && expr.Error.Valid &&
+		(expr.Status == models.ExpressionStatusPending || expr.Status == models.ExpressionStatusInProgress) {
+		expr.Error = sql.Null[string]{}
+		expr.UpdatedAt = task.UpdatedAt
+	}
+	eventType := models.TaskEventClaimed
+	if slices.ContainsFunc(r.taskEvents, func(e models.TaskEvent) bool {
+		return e.TaskID == task.ID && e.Type == models.TaskEventReleased
+	}) {
+		eventType = models.TaskEventReclaimed
+	}
+	r.appendTaskEvent(task, eventType, cmd.AgentID)
+
+	clone := *task
+	return &clone, nil
+}
+
+// releaseExpiredTasks hands the claimed tasks whose lease has expired by now back to the queue.
+func (r *Repository) releaseExpiredTasks(now time.Time) {
+	for _, t := range r.tasks {
+		if t.Status != models.TaskStatusInProgress || !t.ExpireAt.Valid || t.ExpireAt.V.After(now) {
+			continue
+		}
+		t.Status = models.TaskStatusPending
+		t.ExpireAt = sql.Null[time.Time]{}
+		t.AgentID = sql.Null[string]{}
+		t.PendingAt = sql.Null[time.Time]{V: now, Valid: true}
+		t.UpdatedAt = now
+		r.appendTaskEvent(t, models.TaskEventReleased, "")
+	}
+}
+
+// FinishTask updates a task's status and result, and handles subsequent operations
+// like updating related tasks, enqueueing child tasks, or completing expressions.
+// Returns the expression if the task has completed or failed it, nil otherwise,
+// recording the event of the finished expression in the outbox.
+// Returns [models.ErrTaskNotFound] if the task doesn't exist or isn't in progress,
+// such as when its result has already been submitted, and [models.ErrTaskNotClaimed]
+// if it's in progress by another agent than cmd.AgentID, such as after the lease has expired.
+func (r *Repository) FinishTask(_ context.Context, cmd models.FinishTaskCmd) (*models.Expression, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasksByID[cmd.ID]
+	if !ok || task.Status != models.TaskStatusInProgress {
+		return nil, models.ErrTaskNotFound
+	}
+	if task.AgentID.V != cmd.AgentID {
+		return nil, models.ErrTaskNotClaimed
+	}
+	expr, ok := r.expressions[task.ExpressionID]
+	if !ok {
+		return nil, fmt.Errorf("expression %q of task not found", task.ExpressionID)
+	}
+
+	task.Status = cmd.Status
+	task.Result = sql.Null[float64]{V: cmd.Result, Valid: cmd.Status == models.TaskStatusCompleted}
+	task.ComputeTime = sql.Null[time.Duration]{V: cmd.ComputeTime, Valid: cmd.ComputeTime > 0}
+	task.UpdatedAt = time.Now().UTC()
+	eventType := models.TaskEventCompleted
+	if cmd.Status == models.TaskStatusFailed {
+		eventType = models.TaskEventFailed
+	}
+	r.appendTaskEvent(task, eventType, cmd.AgentID)
+
+	if cmd.Status == models.TaskStatusFailed {
+		// Handle task failure - propagate failure to entire expression
+		if !r.failExpression(expr, task) {
+			return nil, nil
+		}
+	} else if child := r.childTask(task.ID); child != nil {
+		r.enqueueChildTask(child, task)
+		return nil, nil
+	} else {
+		expr.Status = models.ExpressionStatusCompleted
+		expr.Result = task.Result
+		expr.UpdatedAt = task.UpdatedAt
+	}
+	r.appendOutboxEvent(expr)
+
+	clone := *expr
+	return &clone, nil
+}
+
+// failExpression fails the expression along with all of its unfinished tasks.
+// Reports whether the expression has been failed by this call.
+func (r *Repository) failExpression(expr *models.Expression, task *models.Task) bool {
+	failed := expr.Status != models.ExpressionStatusFailed
+	if failed {
+		expr.Status = models.ExpressionStatusFailed
+		expr.UpdatedAt = task.UpdatedAt
+	}
+
+	for _, t := range r.tasks {
+		if t.ExpressionID == expr.ID && t.Status != models.TaskStatusCompleted && t.Status != models.TaskStatusFailed {
+			t.Status = models.TaskStatusFailed
+			t.UpdatedAt = task.UpdatedAt
+			r.appendTaskEvent(t, models.TaskEventFailed, "")
+		}
+	}
+	return failed
+}
+
+// childTask returns the task depending on the result of the task, or nil if the task is final.
+func (r *Repository) childTask(taskID string) *models.Task {
+	for _, t := range r.tasks {
+		if (t.ParentTask1ID.Valid && t.ParentTask1ID.V == taskID) || (t.ParentTask2ID.Valid && t.ParentTask2ID.V == taskID) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *Repository) enqueueChildTask(child *models.Task, completedTask *models.Task) {
+	// Update child task with parent's result value
+	if child.ParentTask1ID.Valid && child.ParentTask1ID.V == completedTask.ID {
+		child.Arg1 = completedTask.Result
+	} else { // child.ParentTask2ID == completedTask.ID
+		child.Arg2 = completedTask.Result
+	}
+	if child.Arg1.Valid && child.Arg2.Valid {
+		child.Status = models.TaskStatusPending
+		child.PendingAt = sql.Null[time.Time]{V: completedTask.UpdatedAt, Valid: true}
+	}
+	child.UpdatedAt = completedTask.UpdatedAt
+}
+
+// GetOperationCosts calculates the average computation time per operation
+// over tasks completed since the specified time.
+func (r *Repository) GetOperationCosts(_ context.Context, since time.Time) (map[models.TaskOperation]time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type total struct {
+		sum   float64
+		count int
+	}
+	totals := make(map[models.TaskOperation]total)
+	for _, t := range r.tasks {
+		if t.Status != models.TaskStatusCompleted || !t.ComputeTime.Valid || t.UpdatedAt.Before(since) {
+			continue
+		}
+		tt := totals[t.Operation]
+		tt.sum += float64(t.ComputeTime.V)
+		tt.count++
+		totals[t.Operation] = tt
+	}
+
+	costs := make(map[models.TaskOperation]time.Duration, len(totals))
+	for op, tt := range totals {
+		costs[op] = time.Duration(tt.sum / float64(tt.count))
+	}
+	return costs, nil
+}
+
+// CountExpressionsByStatus counts the stored expressions of all users grouped by status.
+func (r *Repository) CountExpressionsByStatus(_ context.Context) (map[models.ExpressionStatus]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[models.ExpressionStatus]int)
+	for _, expr := range r.expressions {
+		counts[expr.Status]++
+	}
+	return counts, nil
+}
+
+// CountTasksByStatus counts the stored tasks of all expressions grouped by status.
+func (r *Repository) CountTasksByStatus(_ context.Context) (map[models.TaskStatus]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[models.TaskStatus]int)
+	for _, t := range r.tasks {
+		counts[t.Status]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// GetIdempotencyKey retrieves an unexpired idempotency key of a user.
+// Returns [models.ErrIdempotencyKeyNotFound] if the key doesn't exist or has expired.
+func (r *Repository) GetIdempotencyKey(_ context.Context, cmd models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.idempotencyKeys[idempotencyKeyID{userID: cmd.UserID, key: cmd.Key}]
+	if !ok || !key.ExpiresAt.After(cmd.Now) {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+	return &key, nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the idempotency keys expired by now. Returns the number of deleted keys.
+func (r *Repository) DeleteExpiredIdempotencyKeys(_ context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for id, key := range r.idempotencyKeys {
+		if !key.ExpiresAt.After(now) {
+			delete(r.idempotencyKeys, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// deleteIdempotencyKeys deletes the idempotency keys of the expressions.
+func (r *Repository) deleteIdempotencyKeys(exprIDs map[string]bool) {
+	for id, key := range r.idempotencyKeys {
+		if exprIDs[key.ExpressionID] {
+			delete(r.idempotencyKeys, id)
+		}
+	}
+}
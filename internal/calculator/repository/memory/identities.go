@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// GetUserByIdentity retrieves the user linked to an external identity.
+// Returns [models.ErrUserNotFound] if the identity isn't linked to any user.
+func (r *Repository) GetUserByIdentity(_ context.Context, cmd models.GetUserByIdentityCmd) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[r.identities[identityKey{issuer: cmd.Issuer, subject: cmd.Subject}]]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// CreateUserWithIdentity creates a user without a password linked to an external identity.
+// Returns [models.ErrUserExists] if the login is taken
+// and [models.ErrIdentityExists] if the identity is already linked to a user.
+func (r *Repository) CreateUserWithIdentity(_ context.Context, cmd models.CreateUserWithIdentityCmd) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userByLogin(cmd.Login) != nil {
+		return nil, models.ErrUserExists
+	}
+	identity := identityKey{issuer: cmd.Issuer, subject: cmd.Subject}
+	if _, ok := r.identities[identity]; ok {
+		return nil, models.ErrIdentityExists
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:        xid.New().String(),
+		Login:     cmd.Login,
+		Role:      models.UserRoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.users[user.ID] = user
+	r.identities[identity] = user.ID
+
+	clone := *user
+	return &clone, nil
+}
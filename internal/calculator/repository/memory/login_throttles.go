@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// GetLoginThrottles retrieves the login throttles with the given keys. Keys without failures are omitted.
+func (r *Repository) GetLoginThrottles(_ context.Context, keys []string) ([]models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var throttles []models.LoginThrottle
+	for _, key := range keys {
+		if throttle, ok := r.loginThrottles[key]; ok {
+			throttles = append(throttles, throttle)
+		}
+	}
+	return throttles, nil
+}
+
+// RecordLoginFailure increments the number of consecutive failures of the key and returns the updated throttle.
+// Failures are counted from scratch once the window has passed since the last failure and the end of the lockout.
+func (r *Repository) RecordLoginFailure(_ context.Context, cmd models.RecordLoginFailureCmd) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	throttle, ok := r.loginThrottles[cmd.Key]
+	if !ok {
+		throttle = models.LoginThrottle{Key: cmd.Key}
+	}
+
+	lastActivity := throttle.LastFailureAt
+	if throttle.LockedUntil.Valid && throttle.LockedUntil.V.After(lastActivity) {
+		lastActivity = throttle.LockedUntil.V
+	}
+	if now.Sub(lastActivity) > cmd.Window {
+		throttle.Failures = 0
+		throttle.LockedUntil = sql.Null[time.Time]{}
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+
+	r.loginThrottles[cmd.Key] = throttle
+	return &throttle, nil
+}
+
+// LockLogin locks out login attempts with the key until the given time.
+func (r *Repository) LockLogin(_ context.Context, cmd models.LockLoginCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if throttle, ok := r.loginThrottles[cmd.Key]; ok {
+		throttle.LockedUntil = sql.Null[time.Time]{V: cmd.LockedUntil.UTC(), Valid: true}
+		r.loginThrottles[cmd.Key] = throttle
+	}
+	return nil
+}
+
+// ResetLoginFailures forgets the failures of the key, e.g. after a successful login.
+func (r *Repository) ResetLoginFailures(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.loginThrottles, key)
+	return nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// ListOutboxEvents retrieves a batch of the events not delivered to the sink yet, oldest first.
+func (r *Repository) ListOutboxEvents(_ context.Context, cmd models.ListOutboxEventsCmd) ([]models.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []models.OutboxEvent
+	for _, e := range r.outboxEvents {
+		if len(events) == cmd.Limit {
+			break
+		}
+		if !r.outboxDelivered[e.ID][cmd.Sink] {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// MarkOutboxEventsDelivered records that the events have been delivered to the sink.
+// Events already marked or deleted are skipped.
+func (r *Repository) MarkOutboxEventsDelivered(_ context.Context, cmd models.MarkOutboxEventsDeliveredCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range cmd.EventIDs {
+		if sinks, ok := r.outboxDelivered[id]; ok {
+			sinks[cmd.Sink] = true
+		}
+	}
+	return nil
+}
+
+// DeleteDeliveredOutboxEvents deletes the events delivered to all the sinks, i.e. every event without sinks,
+// and returns the number of deleted events.
+func (r *Repository) DeleteDeliveredOutboxEvents(_ context.Context, sinks []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.outboxEvents)
+	r.outboxEvents = slices.DeleteFunc(r.outboxEvents, func(e models.OutboxEvent) bool {
+		for _, sink := range sinks {
+			if !r.outboxDelivered[e.ID][sink] {
+				return false
+			}
+		}
+		delete(r.outboxDelivered, e.ID)
+		return true
+	})
+	return n - len(r.outboxEvents), nil
+}
+
+// appendOutboxEvent records the event of the expression having finished.
+func (r *Repository) appendOutboxEvent(expr *models.Expression) {
+	event := models.OutboxEvent{
+		ID:           xid.New().String(),
+		Type:         models.OutboxEventExpressionCompleted,
+		ExpressionID: expr.ID,
+		UserID:       expr.UserID,
+		Expression:   expr.Expression,
+		Status:       expr.Status,
+		Result:       expr.Result,
+		Error:        expr.Error,
+		CreatedAt:    expr.UpdatedAt,
+	}
+	if expr.Status == models.ExpressionStatusFailed {
+		event.Type = models.OutboxEventExpressionFailed
+	}
+	r.outboxEvents = append(r.outboxEvents, event)
+	r.outboxDelivered[event.ID] = make(map[string]bool)
+}
@@ -0,0 +1,101 @@
+// Package memory implements the storage in memory of the process with the same semantics as the SQL repository.
+// It is meant for tests and ephemeral runs: the data is lost on restart.
+package memory
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// Repository keeps the data in maps guarded by a single mutex, so every call is atomic
+// just like a transaction of the SQL repository. Returned models are copies of the stored ones.
+type Repository struct {
+	mu sync.Mutex
+
+	users           map[string]*models.User // by ID
+	tokensRevokedAt map[string]time.Time    // by user ID
+	identities      map[identityKey]string  // user IDs
+
+	expressions map[string]*models.Expression // by ID
+	tasks       []*models.Task                // in insertion order
+	tasksByID   map[string]*models.Task       // the same tasks by ID
+	taskEvents  []models.TaskEvent            // in insertion order
+	callbacks   map[string]*models.Callback   // by expression ID
+	callbackLog []models.CallbackAttempt      // in insertion order
+	agents      map[string]*models.Agent      // by ID
+
+	idempotencyKeys map[idempotencyKeyID]models.IdempotencyKey
+
+	retentionPolicies map[string]models.RetentionPolicy // by user ID
+
+	outboxEvents    []models.OutboxEvent       // in insertion order
+	outboxDelivered map[string]map[string]bool // sinks by event ID
+
+	refreshTokens  map[string]*models.RefreshToken // by ID
+	revokedTokens  map[string]revokedToken         // by JTI
+	apiKeys        map[string]*models.APIKey       // by ID
+	loginThrottles map[string]models.LoginThrottle // by key
+	auditEvents    []models.AuditEvent
+}
+
+type identityKey struct {
+	issuer  string
+	subject string
+}
+
+type idempotencyKeyID struct {
+	userID string
+	key    string
+}
+
+type revokedToken struct {
+	userID    string
+	expiresAt time.Time
+}
+
+func New() *Repository {
+	return &Repository{
+		users:             make(map[string]*models.User),
+		tokensRevokedAt:   make(map[string]time.Time),
+		identities:        make(map[identityKey]string),
+		expressions:       make(map[string]*models.Expression),
+		tasksByID:         make(map[string]*models.Task),
+		callbacks:         make(map[string]*models.Callback),
+		idempotencyKeys:   make(map[idempotencyKeyID]models.IdempotencyKey),
+		agents:            make(map[string]*models.Agent),
+		retentionPolicies: make(map[string]models.RetentionPolicy),
+		outboxDelivered:   make(map[string]map[string]bool),
+		refreshTokens:     make(map[string]*models.RefreshToken),
+		revokedTokens:     make(map[string]revokedToken),
+		apiKeys:           make(map[string]*models.APIKey),
+		loginThrottles:    make(map[string]models.LoginThrottle),
+	}
+}
+
+// userByLogin returns the user with the login compared case-insensitively, or nil.
+func (r *Repository) userByLogin(login string) *models.User {
+	for _, u := range r.users {
+		if strings.EqualFold(u.Login, login) {
+			return u
+		}
+	}
+	return nil
+}
+
+func cloneAgent(a *models.Agent) models.Agent {
+	clone := *a
+	clone.Operations = slices.Clone(a.Operations)
+	clone.Labels = maps.Clone(a.Labels)
+	return clone
+}
+
+func cloneAPIKey(k *models.APIKey) models.APIKey {
+	clone := *k
+	clone.Scopes = slices.Clone(k.Scopes)
+	return clone
+}
@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/repotest"
+)
+
+func TestRepository_conformance(t *testing.T) {
+	repotest.Run(t, func(*testing.T) repotest.Repository {
+		return New()
+	})
+}
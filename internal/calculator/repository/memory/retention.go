@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+// SetRetentionPolicy creates or replaces the retention policy of a user.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) SetRetentionPolicy(_ context.Context, cmd models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[cmd.UserID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+	policy := models.RetentionPolicy{
+		UserID:    cmd.UserID,
+		MaxAge:    cmd.MaxAge,
+		MaxCount:  cmd.MaxCount,
+		UpdatedAt: time.Now().UTC(),
+	}
+	r.retentionPolicies[cmd.UserID] = policy
+	return &policy, nil
+}
+
+// GetRetentionPolicy retrieves the retention policy of a user.
+// Returns [models.ErrRetentionPolicyNotFound] if the user has no policy of their own.
+func (r *Repository) GetRetentionPolicy(_ context.Context, userID string) (*models.RetentionPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.retentionPolicies[userID]
+	if !ok {
+		return nil, models.ErrRetentionPolicyNotFound
+	}
+	return &policy, nil
+}
+
+// ListRetentionPolicies retrieves the retention policies of all users ordered by user ID.
+func (r *Repository) ListRetentionPolicies(_ context.Context) ([]models.RetentionPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var policies []models.RetentionPolicy
+	for _, policy := range r.retentionPolicies {
+		policies = append(policies, policy)
+	}
+	slices.SortFunc(policies, func(a, b models.RetentionPolicy) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	return policies, nil
+}
+
+// DeleteRetentionPolicy deletes the retention policy of a user, so that the global policy applies to them.
+// Returns [models.ErrRetentionPolicyNotFound] if the user has no policy of their own.
+func (r *Repository) DeleteRetentionPolicy(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.retentionPolicies[userID]; !ok {
+		return models.ErrRetentionPolicyNotFound
+	}
+	delete(r.retentionPolicies, userID)
+	return nil
+}
+
+// PurgeExpressions deletes a batch of finished expressions breaking the retention limits, oldest first,
+// along with their tasks, task events, callbacks and idempotency keys.
+func (r *Repository) PurgeExpressions(_ context.Context, cmd models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cmd.UpdatedBefore.IsZero() && cmd.KeepLatest <= 0 {
+		return models.PurgeExpressionsResult{}, nil
+	}
+
+	// Finished expressions of the matching users, newest first
+	var finished []*models.Expression
+	for _, expr := range r.expressions {
+		if expr.Status != models.ExpressionStatusCompleted && expr.Status != models.ExpressionStatusFailed {
+			continue
+		}
+		if cmd.UserID != "" {
+			if expr.UserID != cmd.UserID {
+				continue
+			}
+		} else if _, ok := r.retentionPolicies[expr.UserID]; ok {
+			continue
+		}
+		finished = append(finished, expr)
+	}
+	slices.SortFunc(finished, func(a, b *models.Expression) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	var purged []*models.Expression
+	latest := make(map[string]int) // by user ID
+	for _, expr := range finished {
+		latest[expr.UserID]++
+		if (!cmd.UpdatedBefore.IsZero() && expr.UpdatedAt.Before(cmd.UpdatedBefore)) ||
+			(cmd.KeepLatest > 0 && latest[expr.UserID] > cmd.KeepLatest) {
+			purged = append(purged, expr)
+		}
+	}
+	// Oldest first
+	slices.Reverse(purged)
+	purged = purged[:min(len(purged), cmd.Limit)]
+
+	ids := make(map[string]bool, len(purged))
+	for _, expr := range purged {
+		ids[expr.ID] = true
+		delete(r.expressions, expr.ID)
+	}
+	res := models.PurgeExpressionsResult{Expressions: len(purged)}
+	r.tasks = slices.DeleteFunc(r.tasks, func(t *models.Task) bool {
+		if ids[t.ExpressionID] {
+			delete(r.tasksByID, t.ID)
+			res.Tasks++
+			return true
+		}
+		return false
+	})
+	r.deleteTaskEvents(ids)
+	r.deleteCallbacks(ids)
+	r.deleteIdempotencyKeys(ids)
+	return res, nil
+}
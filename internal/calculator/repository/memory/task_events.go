@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// ListExpressionTaskEvents retrieves the history of the tasks of a specific expression for a specific user, oldest first.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) ListExpressionTaskEvents(_ context.Context, userID string, exprID string) ([]models.TaskEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expr, ok := r.expressions[exprID]
+	if !ok || expr.UserID != userID {
+		return nil, models.ErrExpressionNotFound
+	}
+
+	var events []models.TaskEvent
+	for _, e := range r.taskEvents {
+		if e.ExpressionID == exprID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// appendTaskEvent appends the event of the task transition to its current status at the time of its last update.
+func (r *Repository) appendTaskEvent(task *models.Task, typ models.TaskEventType, agentID string) {
+	event := models.TaskEvent{
+		ID:           xid.New().String(),
+		TaskID:       task.ID,
+		ExpressionID: task.ExpressionID,
+		Type:         typ,
+		AgentID:      sql.Null[string]{V: agentID, Valid: agentID != ""},
+		CreatedAt:    task.UpdatedAt,
+	}
+	if typ == models.TaskEventCompleted {
+		event.Result = task.Result
+	}
+	r.taskEvents = append(r.taskEvents, event)
+}
+
+// deleteTaskEvents deletes the history of the expressions.
+func (r *Repository) deleteTaskEvents(exprIDs map[string]bool) {
+	r.taskEvents = slices.DeleteFunc(r.taskEvents, func(e models.TaskEvent) bool {
+		return exprIDs[e.ExpressionID]
+	})
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// CreateRefreshToken stores a refresh token starting a new token family.
+func (r *Repository) CreateRefreshToken(_ context.Context, cmd models.CreateRefreshTokenCmd) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := models.RefreshToken{
+		ID:        xid.New().String(),
+		UserID:    cmd.UserID,
+		FamilyID:  xid.New().String(),
+		TokenHash: cmd.TokenHash,
+		ExpiresAt: cmd.ExpiresAt.UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.insertRefreshToken(token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken replaces a refresh token with a new one of the same family.
+// Presenting an already rotated or revoked token revokes the whole family,
+// since it means that the token has leaked.
+// Returns [models.ErrRefreshTokenNotFound] if the token doesn't exist,
+// [models.ErrRefreshTokenReused] if it has already been rotated or revoked,
+// and [models.ErrRefreshTokenExpired] if it has expired.
+func (r *Repository) RotateRefreshToken(_ context.Context, cmd models.RotateRefreshTokenCmd) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.refreshTokenByHash(cmd.TokenHash)
+	if old == nil {
+		return nil, models.ErrRefreshTokenNotFound
+	}
+
+	now := time.Now().UTC()
+	if old.RevokedAt.Valid || old.ReplacedBy.Valid {
+		r.revokeRefreshTokenFamily(old.FamilyID, now)
+		return nil, models.ErrRefreshTokenReused
+	}
+	if !old.ExpiresAt.After(now) {
+		return nil, models.ErrRefreshTokenExpired
+	}
+
+	token := models.RefreshToken{
+		ID:        xid.New().String(),
+		UserID:    old.UserID,
+		FamilyID:  old.FamilyID,
+		TokenHash: cmd.NewTokenHash,
+		ExpiresAt: cmd.ExpiresAt.UTC(),
+		CreatedAt: now,
+	}
+	if err := r.insertRefreshToken(token); err != nil {
+		return nil, err
+	}
+
+	old.RevokedAt.V, old.RevokedAt.Valid = now, true
+	old.ReplacedBy.V, old.ReplacedBy.Valid = token.ID, true
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the user.
+// Returns [models.ErrRefreshTokenNotFound] if the user has no such token.
+func (r *Repository) RevokeRefreshToken(_ context.Context, cmd models.RevokeRefreshTokenCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := r.refreshTokenByHash(cmd.TokenHash)
+	if token == nil || token.UserID != cmd.UserID {
+		return models.ErrRefreshTokenNotFound
+	}
+	r.revokeRefreshTokenFamily(token.FamilyID, time.Now().UTC())
+	return nil
+}
+
+// RevokeToken adds an access token to the revocation list until it expires.
+// Entries of already expired tokens are purged along the way.
+func (r *Repository) RevokeToken(_ context.Context, cmd models.RevokeTokenCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for jti, token := range r.revokedTokens {
+		if token.expiresAt.Before(now) {
+			delete(r.revokedTokens, jti)
+		}
+	}
+
+	if _, ok := r.revokedTokens[cmd.JTI]; !ok {
+		r.revokedTokens[cmd.JTI] = revokedToken{userID: cmd.UserID, expiresAt: cmd.ExpiresAt.UTC()}
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token has been revoked: either by itself,
+// or along with all tokens of the user issued before a password change, or by deletion of the user.
+func (r *Repository) IsTokenRevoked(_ context.Context, cmd models.IsTokenRevokedCmd) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[cmd.UserID]; !ok {
+		return true, nil
+	}
+	if revokedAt, ok := r.tokensRevokedAt[cmd.UserID]; ok && revokedAt.After(cmd.IssuedAt) {
+		return true, nil
+	}
+	_, revoked := r.revokedTokens[cmd.JTI]
+	return revoked, nil
+}
+
+func (r *Repository) refreshTokenByHash(tokenHash string) *models.RefreshToken {
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return token
+		}
+	}
+	return nil
+}
+
+func (r *Repository) insertRefreshToken(token models.RefreshToken) error {
+	if r.refreshTokenByHash(token.TokenHash) != nil {
+		return errors.New("insert refresh token: token hash already exists")
+	}
+	r.refreshTokens[token.ID] = &token
+	return nil
+}
+
+func (r *Repository) revokeRefreshTokenFamily(familyID string, now time.Time) {
+	for _, token := range r.refreshTokens {
+		if token.FamilyID == familyID && !token.RevokedAt.Valid {
+			token.RevokedAt.V, token.RevokedAt.Valid = now, true
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+)
+
+// Register creates a new user account with the provided credentials and the user role unless another role is set.
+// Returns [models.ErrUserExists] if a user with the same login, compared case-insensitively, already exists.
+func (r *Repository) Register(_ context.Context, cmd models.RegisterUserCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userByLogin(cmd.Login) != nil {
+		return models.ErrUserExists
+	}
+
+	role := cmd.Role
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:                     xid.New().String(),
+		Login:                  cmd.Login,
+		PasswordHash:           cmd.PasswordHash,
+		Role:                   role,
+		PasswordChangeRequired: cmd.PasswordChangeRequired,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+// GetUser retrieves a user by case-insensitive login. The password must be verified by the caller.
+// Returns [models.ErrUserNotFound] if no matching user exists.
+func (r *Repository) GetUser(_ context.Context, cmd models.GetUserCmd) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := r.userByLogin(cmd.Login)
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// UpdatePasswordHash replaces the password hash of a user.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) UpdatePasswordHash(_ context.Context, cmd models.UpdatePasswordHashCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[cmd.UserID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+	user.PasswordHash = cmd.PasswordHash
+	user.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// GetUserByID retrieves a user by ID.
+// Returns [models.ErrUserNotFound] if no matching user exists.
+func (r *Repository) GetUserByID(_ context.Context, userID string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// ListUsers retrieves all users ordered by login.
+func (r *Repository) ListUsers(_ context.Context) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []models.User
+	for _, u := range r.users {
+		users = append(users, *u)
+	}
+	slices.SortFunc(users, func(a, b models.User) int {
+		return cmp.Compare(a.Login, b.Login)
+	})
+	return users, nil
+}
+
+// SetUserRole changes the role of a user and revokes all their tokens, since access tokens carry the role:
+// refresh tokens are revoked, and access tokens issued before the change are rejected by [Repository.IsTokenRevoked].
+// Setting the current role is a no-op.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) SetUserRole(_ context.Context, cmd models.SetUserRoleCmd) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[cmd.UserID]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	if user.Role != cmd.Role {
+		now := time.Now().UTC()
+		user.Role = cmd.Role
+		user.UpdatedAt = now
+		r.revokeUserTokens(user.ID, now)
+	}
+
+	clone := *user
+	return &clone, nil
+}
+
+// ChangePassword replaces the password hash of a user, clears [models.User.PasswordChangeRequired] and revokes all their tokens:
+// refresh tokens are revoked, and access tokens issued before the change are rejected by [Repository.IsTokenRevoked].
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) ChangePassword(_ context.Context, cmd models.ChangePasswordCmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[cmd.UserID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	user.PasswordHash = cmd.PasswordHash
+	user.PasswordChangeRequired = false
+	user.UpdatedAt = now
+	r.revokeUserTokens(user.ID, now)
+	return nil
+}
+
+// revokeUserTokens revokes the refresh tokens of a user and the access tokens issued before now.
+func (r *Repository) revokeUserTokens(userID string, now time.Time) {
+	// Access tokens carry the issue time in microseconds, as PostgreSQL stores it
+	r.tokensRevokedAt[userID] = now.Truncate(time.Microsecond)
+
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID && !token.RevokedAt.Valid {
+			token.RevokedAt.V, token.RevokedAt.Valid = now, true
+		}
+	}
+}
+
+// DeleteUser deletes a user along with their expressions, tasks, callbacks, idempotency keys, refresh tokens,
+// API keys, retention policy, revoked access tokens and login throttle.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) DeleteUser(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+
+	r.tasks = slices.DeleteFunc(r.tasks, func(t *models.Task) bool {
+		expr, ok := r.expressions[t.ExpressionID]
+		if ok && expr.UserID == userID {
+			delete(r.tasksByID, t.ID)
+			return true
+		}
+		return false
+	})
+	exprIDs := make(map[string]bool)
+	for id, expr := range r.expressions {
+		if expr.UserID == userID {
+			exprIDs[id] = true
+			delete(r.expressions, id)
+		}
+	}
+	r.deleteTaskEvents(exprIDs)
+	r.deleteCallbacks(exprIDs)
+	r.deleteIdempotencyKeys(exprIDs)
+	for id, token := range r.refreshTokens {
+		if token.UserID == userID {
+			delete(r.refreshTokens, id)
+		}
+	}
+	for id, key := range r.apiKeys {
+		if key.UserID == userID {
+			delete(r.apiKeys, id)
+		}
+	}
+	for identity, id := range r.identities {
+		if id == userID {
+			delete(r.identities, identity)
+		}
+	}
+	for jti, token := range r.revokedTokens {
+		if token.userID == userID {
+			delete(r.revokedTokens, jti)
+		}
+	}
+	r.outboxEvents = slices.DeleteFunc(r.outboxEvents, func(e models.OutboxEvent) bool {
+		if e.UserID == userID {
+			delete(r.outboxDelivered, e.ID)
+			return true
+		}
+		return false
+	})
+	delete(r.loginThrottles, models.LoginThrottleKey(user.Login))
+	delete(r.tokensRevokedAt, userID)
+	delete(r.retentionPolicies, userID)
+	delete(r.users, userID)
+	return nil
+}
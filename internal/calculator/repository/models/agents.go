@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Agent struct {
+	ID         string         `db:"id"`
+	Operations TaskOperations `db:"operations"`
+	Labels     AgentLabels    `db:"labels"`
+	LastSeenAt time.Time      `db:"last_seen_at"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Supports reports whether the agent can execute the operation.
+// An agent without advertised operations supports all of them.
+func (a *Agent) Supports(op TaskOperation) bool {
+	return len(a.Operations) == 0 || slices.Contains(a.Operations, op)
+}
+
+// TaskOperations is stored as a comma-separated list.
+type TaskOperations []TaskOperation
+
+func (ops TaskOperations) Value() (driver.Value, error) {
+	s := make([]string, 0, len(ops))
+	for _, op := range ops {
+		s = append(s, string(op))
+	}
+	return strings.Join(s, ","), nil
+}
+
+func (ops *TaskOperations) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+
+	*ops = nil
+	if s == "" {
+		return nil
+	}
+	for _, op := range strings.Split(s, ",") {
+		*ops = append(*ops, TaskOperation(op))
+	}
+	return nil
+}
+
+// AgentLabels is stored as a JSON object.
+type AgentLabels map[string]string
+
+func (l AgentLabels) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+	return string(b), nil
+}
+
+func (l *AgentLabels) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+	return json.Unmarshal(b, l)
+}
+
+type UpsertAgentCmd struct {
+	ID         string
+	Operations TaskOperations
+	Labels     AgentLabels
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKey struct {
+	ID        string              `db:"id"`
+	UserID    string              `db:"user_id"`
+	UserLogin string              `db:"user_login"` // joined from users
+	UserRole  UserRole            `db:"user_role"`  // joined from users
+	Name      string              `db:"name"`
+	KeyHash   string              `db:"key_hash"`
+	Scopes    APIKeyScopes        `db:"scopes"`
+	ExpiresAt sql.Null[time.Time] `db:"expires_at"`
+	RevokedAt sql.Null[time.Time] `db:"revoked_at"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	return !k.RevokedAt.Valid && (!k.ExpiresAt.Valid || k.ExpiresAt.V.After(now))
+}
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeReadOnly APIKeyScope = "read-only"
+	APIKeyScopeSubmit   APIKeyScope = "submit"
+)
+
+// APIKeyScopes is stored as a comma-separated list.
+type APIKeyScopes []APIKeyScope
+
+// Has reports whether the scope is granted.
+func (s APIKeyScopes) Has(scope APIKeyScope) bool {
+	return slices.Contains(s, scope)
+}
+
+func (s APIKeyScopes) Value() (driver.Value, error) {
+	ss := make([]string, 0, len(s))
+	for _, scope := range s {
+		ss = append(ss, string(scope))
+	}
+	return strings.Join(ss, ","), nil
+}
+
+func (s *APIKeyScopes) Scan(src any) error {
+	var v string
+	switch src := src.(type) {
+	case string:
+		v = src
+	case []byte:
+		v = string(src)
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+
+	*s = nil
+	if v == "" {
+		return nil
+	}
+	for _, scope := range strings.Split(v, ",") {
+		*s = append(*s, APIKeyScope(scope))
+	}
+	return nil
+}
+
+type CreateAPIKeyCmd struct {
+	UserID    string
+	Name      string
+	KeyHash   string
+	Scopes    APIKeyScopes
+	ExpiresAt sql.Null[time.Time]
+}
+
+type RevokeAPIKeyCmd struct {
+	UserID string
+	ID     string
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type AuditEvent struct {
+	ID        string         `db:"id"`
+	Event     AuditEventType `db:"event"`
+	Subject   string         `db:"subject"`
+	Details   string         `db:"details"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+type AuditEventType string
+
+const (
+	AuditEventLoginLockout AuditEventType = "login_lockout"
+)
+
+type CreateAuditEventCmd struct {
+	Event   AuditEventType
+	Subject string
+	Details string
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrCallbackNotFound = errors.New("callback not found")
+
+// Callback is a request to POST an expression to a URL once it is completed or failed.
+type Callback struct {
+	ExpressionID  string              `db:"expression_id"`
+	URL           string              `db:"url"`
+	Secret        string              `db:"secret"` // HMAC key of the signature, empty for unsigned callbacks
+	Status        CallbackStatus      `db:"status"`
+	Attempts      int                 `db:"attempts"`
+	NextAttemptAt sql.Null[time.Time] `db:"next_attempt_at"` // earliest time of the retry after a failed attempt
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type CallbackStatus string
+
+const (
+	CallbackStatusPending   CallbackStatus = "Pending" // awaiting the expression or a retry
+	CallbackStatusDelivered CallbackStatus = "Delivered"
+	CallbackStatusFailed    CallbackStatus = "Failed" // out of attempts
+)
+
+// CallbackAttempt records an attempt to deliver a callback. Attempts are never updated.
+type CallbackAttempt struct {
+	ID           string           `db:"id"`
+	ExpressionID string           `db:"expression_id"`
+	Attempt      int              `db:"attempt"`     // starting from 1
+	StatusCode   sql.Null[int]    `db:"status_code"` // HTTP status of the response, if any
+	Error        sql.Null[string] `db:"error"`
+	Duration     time.Duration    `db:"duration"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// DueCallback is a callback to be attempted along with its finished expression.
+type DueCallback struct {
+	Callback
+	Expression Expression
+}
+
+// ClaimDueCallbacksCmd selects the pending callbacks of finished expressions whose retry time has come.
+type ClaimDueCallbacksCmd struct {
+	Now   time.Time
+	Limit int
+	Lease time.Duration // the claimed callbacks aren't due again for this long, unless an attempt is recorded
+}
+
+type RecordCallbackAttemptCmd struct {
+	ExpressionID  string
+	StatusCode    int // 0 if there was no response
+	Error         string
+	Duration      time.Duration
+	Delivered     bool
+	NextAttemptAt time.Time // of the retry of an undelivered callback, zero gives up on it
+}
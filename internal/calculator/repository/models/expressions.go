@@ -10,6 +10,7 @@ var (
 	ErrExpressionNotFound = errors.New("expression not found")
 	ErrTaskNotFound       = errors.New("task not found")
 	ErrNoPendingTasks     = errors.New("no pending tasks")
+	ErrTaskNotClaimed     = errors.New("task not claimed by the agent")
 )
 
 type Expression struct {
@@ -39,13 +40,17 @@ type Task struct {
 	ParentTask1ID sql.Null[string] `db:"parent_task_1_id"`
 	ParentTask2ID sql.Null[string] `db:"parent_task_2_id"`
 
-	Arg1          sql.Null[float64]   `db:"arg1"`
-	Arg2          sql.Null[float64]   `db:"arg2"`
-	Operation     TaskOperation       `db:"operation"`
-	OperationTime time.Duration       `db:"operation_time"`
-	Status        TaskStatus          `db:"status"`
-	Result        sql.Null[float64]   `db:"result"`
-	ExpireAt      sql.Null[time.Time] `db:"expire_at"` // TODO: to think
+	Arg1          sql.Null[float64]       `db:"arg1"`
+	Arg2          sql.Null[float64]       `db:"arg2"`
+	Operation     TaskOperation           `db:"operation"`
+	OperationTime time.Duration           `db:"operation_time"`
+	Status        TaskStatus              `db:"status"`
+	Result        sql.Null[float64]       `db:"result"`
+	ComputeTime   sql.Null[time.Duration] `db:"compute_time"`
+	ExpireAt      sql.Null[time.Time]     `db:"expire_at"` // end of the lease of a claimed task
+	AgentID       sql.Null[string]        `db:"agent_id"`  // agent that claimed the task, if known
+	PendingAt     sql.Null[time.Time]     `db:"pending_at"`
+	TraceParent   sql.Null[string]        `db:"trace_parent"`
 
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
@@ -71,8 +76,14 @@ const (
 )
 
 type CreateExpressionCmd struct {
-	Expression string
-	Tasks      []CreateExpressionCmdTask
+	Expression  string
+	Tasks       []CreateExpressionCmdTask
+	TraceParent string // W3C traceparent stored with each task, empty if untraced
+
+	CallbackURL    string // the expression is POSTed to once finished, empty without a callback
+	CallbackSecret string
+
+	IdempotencyKey *CreateExpressionCmdIdempotencyKey // stored with the expression, nil without a key
 }
 
 type CreateExpressionCmdTask struct {
@@ -86,8 +97,22 @@ type CreateExpressionCmdTask struct {
 	OperationTime time.Duration
 }
 
+type CreateExpressionCmdIdempotencyKey struct {
+	Key         string
+	RequestHash string
+	ExpiresAt   time.Time
+}
+
 type FinishTaskCmd struct {
-	ID     string
-	Status TaskStatus
-	Result float64
+	ID          string
+	Status      TaskStatus
+	Result      float64
+	ComputeTime time.Duration
+	AgentID     string // empty if unknown
+}
+
+type GetPendingTaskCmd struct {
+	Operations TaskOperations // empty means all operations
+	AgentID    string         // empty if unknown
+	Lease      time.Duration  // time to finish the task before it's handed back to the queue, unlimited if zero
 }
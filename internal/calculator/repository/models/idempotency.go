@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	ErrIdempotencyKeyExists   = errors.New("idempotency key already exists")
+)
+
+// IdempotencyKey maps a key of a Calculate request of a user to the expression created by the request.
+type IdempotencyKey struct {
+	UserID       string `db:"user_id"`
+	Key          string `db:"idempotency_key"`
+	ExpressionID string `db:"expression_id"`
+	RequestHash  string `db:"request_hash"` // replays with the key must be the same request
+
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type GetIdempotencyKeyCmd struct {
+	UserID string
+	Key    string
+	Now    time.Time // keys expired by then are not found
+}
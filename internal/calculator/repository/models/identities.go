@@ -0,0 +1,17 @@
+package models
+
+import "errors"
+
+var ErrIdentityExists = errors.New("identity exists")
+
+type GetUserByIdentityCmd struct {
+	Issuer  string
+	Subject string
+}
+
+// CreateUserWithIdentityCmd creates a user without a password, who logs in with an external identity provider.
+type CreateUserWithIdentityCmd struct {
+	Login   string
+	Issuer  string
+	Subject string
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type LoginThrottle struct {
+	Key           string              `db:"key"`
+	Failures      int                 `db:"failures"`
+	LockedUntil   sql.Null[time.Time] `db:"locked_until"`
+	LastFailureAt time.Time           `db:"last_failure_at"`
+}
+
+// LoginThrottleKey returns the key the login attempts of a user are throttled by.
+func LoginThrottleKey(login string) string {
+	return "login:" + strings.ToLower(login)
+}
+
+// Locked reports whether login attempts are locked out at the given time.
+func (t LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil.Valid && t.LockedUntil.V.After(now)
+}
+
+type RecordLoginFailureCmd struct {
+	Key string
+	// Window is the period after the last failure or lockout, after which failures are forgotten.
+	Window time.Duration
+}
+
+type LockLoginCmd struct {
+	Key         string
+	LockedUntil time.Time
+}
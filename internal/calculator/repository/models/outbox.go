@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OutboxEvent is a lifecycle event of an expression awaiting delivery to the sinks.
+// It carries a snapshot of the expression at the time of the event.
+type OutboxEvent struct {
+	ID           string            `db:"id"`
+	Type         OutboxEventType   `db:"type"`
+	ExpressionID string            `db:"expression_id"`
+	UserID       string            `db:"user_id"`
+	Expression   string            `db:"expression"`
+	Status       ExpressionStatus  `db:"status"`
+	Result       sql.Null[float64] `db:"result"`
+	Error        sql.Null[string]  `db:"error"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OutboxEventType string
+
+const (
+	OutboxEventExpressionCompleted OutboxEventType = "expression.completed"
+	OutboxEventExpressionFailed    OutboxEventType = "expression.failed"
+)
+
+// ListOutboxEventsCmd selects the events not delivered to the sink yet.
+type ListOutboxEventsCmd struct {
+	Sink  string
+	Limit int
+}
+
+type MarkOutboxEventsDeliveredCmd struct {
+	Sink     string
+	EventIDs []string
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+
+// RetentionPolicy limits the finished expressions kept for a user, overriding the global policy.
+// Zero limits keep the expressions.
+type RetentionPolicy struct {
+	UserID   string        `db:"user_id"`
+	MaxAge   time.Duration `db:"max_age"`   // finished expressions last updated earlier are purged
+	MaxCount int           `db:"max_count"` // number of the latest finished expressions kept
+
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Enabled reports whether the policy purges any expressions.
+func (p *RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0
+}
+
+type SetRetentionPolicyCmd struct {
+	UserID   string
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+// PurgeExpressionsCmd selects the finished, i.e. completed or failed, expressions to be purged.
+// An expression is purged if it breaks either limit, zero limits are ignored, so nothing is purged without limits.
+type PurgeExpressionsCmd struct {
+	UserID        string    // empty means all users without a retention policy of their own
+	UpdatedBefore time.Time // finished expressions last updated earlier are purged
+	KeepLatest    int       // number of the latest finished expressions kept per user
+	Limit         int       // maximum number of expressions purged
+}
+
+type PurgeExpressionsResult struct {
+	Expressions int
+	Tasks       int
+}
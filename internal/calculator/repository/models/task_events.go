@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TaskEvent records a state transition of a task. Events are never updated.
+type TaskEvent struct {
+	ID           string            `db:"id"`
+	TaskID       string            `db:"task_id"`
+	ExpressionID string            `db:"expression_id"`
+	Type         TaskEventType     `db:"type"`
+	AgentID      sql.Null[string]  `db:"agent_id"` // agent claiming or finishing the task, if known
+	Result       sql.Null[float64] `db:"result"`   // result of a completed task
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type TaskEventType string
+
+const (
+	TaskEventCreated   TaskEventType = "created"
+	TaskEventClaimed   TaskEventType = "claimed"
+	TaskEventReleased  TaskEventType = "released"  // handed back to the queue after the lease has expired
+	TaskEventReclaimed TaskEventType = "reclaimed" // claimed again after being released
+	TaskEventCompleted TaskEventType = "completed"
+	TaskEventFailed    TaskEventType = "failed" // by the agent or along with the expression
+)
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+)
+
+type RefreshToken struct {
+	ID         string              `db:"id"`
+	UserID     string              `db:"user_id"`
+	FamilyID   string              `db:"family_id"`
+	TokenHash  string              `db:"token_hash"`
+	ExpiresAt  time.Time           `db:"expires_at"`
+	RevokedAt  sql.Null[time.Time] `db:"revoked_at"`
+	ReplacedBy sql.Null[string]    `db:"replaced_by"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type CreateRefreshTokenCmd struct {
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type RotateRefreshTokenCmd struct {
+	TokenHash    string
+	NewTokenHash string
+	ExpiresAt    time.Time
+}
+
+type RevokeRefreshTokenCmd struct {
+	UserID    string
+	TokenHash string
+}
+
+type RevokeTokenCmd struct {
+	JTI       string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+type IsTokenRevokedCmd struct {
+	JTI      string
+	UserID   string
+	IssuedAt time.Time
+}
@@ -11,20 +11,47 @@ var (
 )
 
 type User struct {
-	ID           string `db:"id"`
-	Login        string `db:"login"`
-	PasswordHash string `db:"password_hash"`
+	ID           string   `db:"id"`
+	Login        string   `db:"login"`
+	PasswordHash string   `db:"password_hash"`
+	Role         UserRole `db:"role"`
+	// PasswordChangeRequired is set for users created with a one-time password.
+	PasswordChangeRequired bool `db:"password_change_required"`
 
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
 
+type UserRole string
+
+const (
+	UserRoleAdmin    UserRole = "admin"
+	UserRoleUser     UserRole = "user"
+	UserRoleReadOnly UserRole = "read-only"
+)
+
 type RegisterUserCmd struct {
-	Login        string
-	PasswordHash string
+	Login                  string
+	PasswordHash           string
+	Role                   UserRole // UserRoleUser if empty
+	PasswordChangeRequired bool
 }
 
 type GetUserCmd struct {
-	Login        string
+	Login string
+}
+
+type UpdatePasswordHashCmd struct {
+	UserID       string
+	PasswordHash string
+}
+
+type SetUserRoleCmd struct {
+	UserID string
+	Role   UserRole
+}
+
+type ChangePasswordCmd struct {
+	UserID       string
 	PasswordHash string
 }
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+)
+
+// ListOutboxEvents retrieves a batch of the events not delivered to the sink yet, oldest first.
+func (r *Repository) ListOutboxEvents(ctx context.Context, cmd models.ListOutboxEventsCmd) ([]models.OutboxEvent, error) {
+	const q = `
+        SELECT id, type, expression_id, user_id, expression, status, result, error, created_at
+        FROM outbox_events e
+        WHERE NOT EXISTS (SELECT 1 FROM outbox_deliveries d WHERE d.event_id = e.id AND d.sink = ?)
+        ORDER BY created_at, id
+        LIMIT ?
+    `
+
+	var events []models.OutboxEvent
+	if err := r.rdb.SelectContext(ctx, &events, r.db.Rebind(q), cmd.Sink, cmd.Limit); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return events, nil
+}
+
+// MarkOutboxEventsDelivered records that the events have been delivered to the sink.
+// Events already marked or deleted are skipped.
+func (r *Repository) MarkOutboxEventsDelivered(ctx context.Context, cmd models.MarkOutboxEventsDeliveredCmd) error {
+	if len(cmd.EventIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+        INSERT INTO outbox_deliveries (event_id, sink, delivered_at)
+        SELECT id, ?, ? FROM outbox_events WHERE id IN (?)
+        ON CONFLICT (event_id, sink) DO NOTHING
+    `, cmd.Sink, time.Now().UTC(), cmd.EventIDs)
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+	if _, err := r.exec(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeliveredOutboxEvents deletes the events delivered to all the sinks, i.e. every event without sinks,
+// and returns the number of deleted events.
+func (r *Repository) DeleteDeliveredOutboxEvents(ctx context.Context, sinks []string) (int, error) {
+	query, args := `DELETE FROM outbox_events`, []any(nil)
+	if len(sinks) > 0 {
+		var err error
+		query, args, err = sqlx.In(`
+            DELETE FROM outbox_events
+            WHERE id IN (
+                SELECT event_id FROM outbox_deliveries WHERE sink IN (?) GROUP BY event_id HAVING COUNT(*) = ?
+            )
+        `, sinks, len(sinks))
+		if err != nil {
+			return 0, fmt.Errorf("build query: %w", err)
+		}
+	}
+
+	res, err := r.exec(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// insertOutboxEvent records the event of the expression having finished within the transaction of the transition.
+func (r *Repository) insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, expr *models.Expression) error {
+	const q = `
+        INSERT INTO outbox_events (id, type, expression_id, user_id, expression, status, result, error, created_at)
+        VALUES (:id, :type, :expression_id, :user_id, :expression, :status, :result, :error, :created_at)
+    `
+
+	event := models.OutboxEvent{
+		ID:           xid.New().String(),
+		Type:         models.OutboxEventExpressionCompleted,
+		ExpressionID: expr.ID,
+		UserID:       expr.UserID,
+		Expression:   expr.Expression,
+		Status:       expr.Status,
+		Result:       expr.Result,
+		Error:        expr.Error,
+		CreatedAt:    expr.UpdatedAt,
+	}
+	if expr.Status == models.ExpressionStatusFailed {
+		event.Type = models.OutboxEventExpressionFailed
+	}
+	if _, err := tx.NamedExecContext(ctx, q, event); err != nil {
+		return fmt.Errorf("insert outbox event: %w", err)
+	}
+	return nil
+}
@@ -1,13 +1,86 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
+// pgUniqueViolation is the PostgreSQL error code of unique and primary key constraint violations.
+const pgUniqueViolation = "23505"
+
+// Repository implements the storage on top of SQLite or PostgreSQL.
+// Queries are written with ? placeholders and rebound to the dialect of the database.
+// Transactions and writes run on the write pool and retry while SQLite is busy, other reads run on the read pool.
 type Repository struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	rdb      *sqlx.DB
+	postgres bool
+}
+
+func New(db *database.DB) *Repository {
+	return &Repository{db: db.Write, rdb: db.Read, postgres: database.IsPostgres(db.Write)}
+}
+
+// beginTx begins a transaction on the write pool.
+func (r *Repository) beginTx(ctx context.Context) (*sqlx.Tx, error) {
+	var tx *sqlx.Tx
+	err := database.RetryBusy(ctx, func() (err error) {
+		tx, err = r.db.BeginTxx(ctx, nil)
+		return err
+	})
+	return tx, err
+}
+
+// exec executes a statement outside of transactions on the write pool.
+func (r *Repository) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := database.RetryBusy(ctx, func() (err error) {
+		res, err = r.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+// namedExec executes a statement with named parameters outside of transactions on the write pool.
+func (r *Repository) namedExec(ctx context.Context, query string, arg any) (sql.Result, error) {
+	var res sql.Result
+	err := database.RetryBusy(ctx, func() (err error) {
+		res, err = r.db.NamedExecContext(ctx, query, arg)
+		return err
+	})
+	return res, err
+}
+
+// forUpdate returns the clause locking the rows selected by a read-modify-write transaction,
+// optionally skipping the rows locked by others. It is empty for SQLite, which serializes write transactions.
+func (r *Repository) forUpdate(skipLocked bool) string {
+	switch {
+	case !r.postgres:
+		return ""
+	case skipLocked:
+		return "FOR UPDATE SKIP LOCKED"
+	default:
+		return "FOR UPDATE"
+	}
 }
 
-func New(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+// isUniqueViolation reports whether the error is caused by a unique or primary key constraint violation.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return errors.Is(sqliteErr.Code, sqlite3.ErrConstraint) &&
+			(errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) || errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey))
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	return false
 }
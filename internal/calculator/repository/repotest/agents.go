@@ -0,0 +1,95 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAgents(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	since := time.Now().Add(-time.Minute)
+
+	agents, err := repo.ListAliveAgents(ctx, since)
+	require.NoError(t, err)
+	assert.Empty(t, agents)
+
+	require.NoError(t, repo.UpsertAgent(ctx, models.UpsertAgentCmd{ID: "agent-b"}))
+	require.NoError(t, repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent-a",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+		Labels:     models.AgentLabels{"zone": "a"},
+	}))
+
+	agents, err = repo.ListAliveAgents(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.Equal(t, "agent-a", agents[0].ID, "ordered by ID")
+	assert.Equal(t, models.TaskOperations{models.TaskOperationAddition}, agents[0].Operations)
+	assert.Equal(t, models.AgentLabels{"zone": "a"}, agents[0].Labels)
+	assert.Equal(t, "agent-b", agents[1].ID)
+	assert.Empty(t, agents[1].Operations)
+	assert.Empty(t, agents[1].Labels)
+	createdAt := agents[0].CreatedAt
+
+	require.NoError(t, repo.UpsertAgent(ctx, models.UpsertAgentCmd{
+		ID:         "agent-a",
+		Operations: models.TaskOperations{models.TaskOperationAddition, models.TaskOperationDivision},
+	}))
+
+	agents, err = repo.ListAliveAgents(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.Equal(t, models.TaskOperations{models.TaskOperationAddition, models.TaskOperationDivision}, agents[0].Operations)
+	assert.Empty(t, agents[0].Labels, "the labels are replaced")
+	assert.WithinDuration(t, createdAt, agents[0].CreatedAt, time.Millisecond, "the creation time is kept")
+	assert.False(t, agents[0].LastSeenAt.Before(agents[0].CreatedAt))
+
+	agents, err = repo.ListAliveAgents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, agents, "agents not seen since the time are not alive")
+}
+
+func testUnroutableTasks(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	since := time.Now().Add(-time.Minute)
+
+	userID := registerUser(t, repo, "alice")
+	addExprID, _ := createExpression(t, repo, userID, task("add", models.TaskOperationAddition, 1, 1))
+	mulExprID, _ := createExpression(t, repo, userID,
+		task("mul1", models.TaskOperationMultiplication, 2, 2),
+		task("mul2", models.TaskOperationMultiplication, 3, 3),
+	)
+
+	reported, err := repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Zero(t, reported, "nothing is reported without alive agents")
+
+	require.NoError(t, repo.UpsertAgent(ctx, models.UpsertAgentCmd{ID: "agent", Operations: models.TaskOperations{models.TaskOperationAddition}}))
+
+	reported, err = repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reported)
+
+	expr, err := repo.GetExpression(ctx, userID, mulExprID)
+	require.NoError(t, err)
+	assert.Equal(t, `no available agent supports operation "*"`, expr.Error.V)
+	expr, err = repo.GetExpression(ctx, userID, addExprID)
+	require.NoError(t, err)
+	assert.False(t, expr.Error.Valid)
+
+	reported, err = repo.ReportUnroutableTasks(ctx, since)
+	require.NoError(t, err)
+	assert.Zero(t, reported, "expressions are reported once")
+
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{Operations: models.TaskOperations{models.TaskOperationMultiplication}})
+	require.NoError(t, err)
+
+	expr, err = repo.GetExpression(ctx, userID, mulExprID)
+	require.NoError(t, err)
+	assert.False(t, expr.Error.Valid, "the error is cleared once a task is claimed")
+}
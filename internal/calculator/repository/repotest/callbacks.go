@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCallbacks(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	plainID, _ := createExpression(t, repo, userID, task("add", models.TaskOperationAddition, 2, 3))
+	_, err := repo.GetExpressionCallback(ctx, userID, plainID)
+	require.ErrorIs(t, err, models.ErrCallbackNotFound)
+	_, err = repo.GetExpressionCallback(ctx, userID, "missing")
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+
+	exprID, err := repo.CreateExpression(ctx, userID, models.CreateExpressionCmd{
+		Expression:     "2*2",
+		Tasks:          []models.CreateExpressionCmdTask{task(xid.New().String(), models.TaskOperationMultiplication, 2, 2)},
+		CallbackURL:    "https://example.com/hook",
+		CallbackSecret: "s3cret",
+	})
+	require.NoError(t, err)
+
+	callback, err := repo.GetExpressionCallback(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.Equal(t, exprID, callback.ExpressionID)
+	assert.Equal(t, "https://example.com/hook", callback.URL)
+	assert.Equal(t, "s3cret", callback.Secret)
+	assert.Equal(t, models.CallbackStatusPending, callback.Status)
+	assert.Zero(t, callback.Attempts)
+	assert.False(t, callback.NextAttemptAt.Valid)
+	_, err = repo.GetExpressionCallback(ctx, registerUser(t, repo, "bob"), exprID)
+	require.ErrorIs(t, err, models.ErrExpressionNotFound, "callbacks of other users are not visible")
+
+	due, err := repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: time.Now(), Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	assert.Empty(t, due, "callbacks wait for the expression to finish")
+
+	// Finish both expressions, the one without a callback first
+	for range 2 {
+		pending, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+		_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusCompleted, Result: 4})
+		require.NoError(t, err)
+	}
+
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: time.Now(), Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, exprID, due[0].ExpressionID)
+	assert.Equal(t, "s3cret", due[0].Secret)
+	assert.Equal(t, exprID, due[0].Expression.ID)
+	assert.Equal(t, models.ExpressionStatusCompleted, due[0].Expression.Status)
+	assert.Equal(t, 4.0, due[0].Expression.Result.V)
+
+	// A failed attempt postpones the callback
+	retryAt := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	require.NoError(t, repo.RecordCallbackAttempt(ctx, models.RecordCallbackAttemptCmd{
+		ExpressionID: exprID, StatusCode: 503, Error: "unexpected status 503", Duration: 5 * time.Millisecond, NextAttemptAt: retryAt,
+	}))
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: time.Now(), Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	assert.Empty(t, due, "retried not earlier than the next attempt time")
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: retryAt, Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.WithinDuration(t, retryAt.Add(time.Minute), due[0].NextAttemptAt.V, 0, "postponed by the lease")
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: retryAt, Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	assert.Empty(t, due, "claimed callbacks aren't attempted concurrently")
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: retryAt.Add(time.Minute), Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, due, 1, "claimed again once the lease expires without a recorded attempt")
+
+	require.NoError(t, repo.RecordCallbackAttempt(ctx, models.RecordCallbackAttemptCmd{
+		ExpressionID: exprID, StatusCode: 200, Duration: time.Millisecond, Delivered: true,
+	}))
+	callback, err = repo.GetExpressionCallback(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CallbackStatusDelivered, callback.Status)
+	assert.Equal(t, 2, callback.Attempts)
+	assert.False(t, callback.NextAttemptAt.Valid)
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: retryAt, Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	err = repo.RecordCallbackAttempt(ctx, models.RecordCallbackAttemptCmd{ExpressionID: exprID, Delivered: true})
+	require.ErrorIs(t, err, models.ErrCallbackNotFound, "delivered callbacks are final")
+
+	attempts, err := repo.ListExpressionCallbackAttempts(ctx, userID, exprID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, 503, attempts[0].StatusCode.V)
+	assert.Equal(t, "unexpected status 503", attempts[0].Error.V)
+	assert.Equal(t, 5*time.Millisecond, attempts[0].Duration)
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.False(t, attempts[1].Error.Valid)
+
+	// Giving up fails the callback
+	failedID, err := repo.CreateExpression(ctx, userID, models.CreateExpressionCmd{
+		Expression:  "1/0",
+		Tasks:       []models.CreateExpressionCmdTask{task(xid.New().String(), models.TaskOperationDivision, 1, 0)},
+		CallbackURL: "http://localhost:1/hook",
+	})
+	require.NoError(t, err)
+	pending, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusFailed})
+	require.NoError(t, err)
+	due, err = repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{Now: time.Now(), Limit: 10, Lease: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, due, 1, "failed expressions are called back too")
+	assert.Empty(t, due[0].Secret)
+	require.NoError(t, repo.RecordCallbackAttempt(ctx, models.RecordCallbackAttemptCmd{
+		ExpressionID: failedID, Error: "connection refused",
+	}))
+	callback, err = repo.GetExpressionCallback(ctx, userID, failedID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CallbackStatusFailed, callback.Status)
+	attempts, err = repo.ListExpressionCallbackAttempts(ctx, userID, failedID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.False(t, attempts[0].StatusCode.Valid, "no response")
+
+	// Callbacks are deleted along with their user
+	require.NoError(t, repo.DeleteUser(ctx, userID))
+	_, err = repo.GetExpressionCallback(ctx, userID, exprID)
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+}
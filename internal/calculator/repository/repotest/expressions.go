@@ -0,0 +1,293 @@
+package repotest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExpressions(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+
+	var exprIDs []string
+	for range 3 {
+		exprID, _ := createExpression(t, repo, userID, task("t1", models.TaskOperationAddition, 1, 2))
+		exprIDs = append(exprIDs, exprID)
+	}
+	createExpression(t, repo, otherID, task("t1", models.TaskOperationAddition, 1, 2))
+
+	exprs, err := repo.ListExpressions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, exprs, 3)
+	for i, expr := range exprs {
+		assert.Equal(t, exprIDs[len(exprIDs)-1-i], expr.ID, "newest first")
+		assert.Equal(t, userID, expr.UserID)
+		assert.Equal(t, models.ExpressionStatusPending, expr.Status)
+		assert.False(t, expr.Result.Valid)
+		assert.False(t, expr.Error.Valid)
+	}
+
+	exprs, err = repo.ListExpressions(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, exprs)
+
+	expr, err := repo.GetExpression(ctx, userID, exprIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "expr", expr.Expression)
+
+	_, err = repo.GetExpression(ctx, otherID, exprIDs[0])
+	require.ErrorIs(t, err, models.ErrExpressionNotFound, "expressions of other users are not found")
+	_, err = repo.GetExpression(ctx, userID, "unknown")
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+	_, err = repo.ListExpressionTasks(ctx, otherID, exprIDs[0])
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+	_, err = repo.ListExpressionTasks(ctx, userID, "unknown")
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+
+	exprCounts, err := repo.CountExpressionsByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.ExpressionStatus]int{models.ExpressionStatusPending: 4}, exprCounts)
+}
+
+// testEvaluateExpression evaluates (2+3)*(4-1) task by task.
+func testEvaluateExpression(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	exprID, taskIDs := createExpression(t, repo, userID,
+		task("add", models.TaskOperationAddition, 2, 3),
+		task("sub", models.TaskOperationSubtraction, 4, 1),
+		childTask("mul", models.TaskOperationMultiplication, "add", "sub", 0, 0),
+	)
+	add, sub, mul := taskIDs[0], taskIDs[1], taskIDs[2]
+
+	tasks, err := repo.ListExpressionTasks(ctx, userID, exprID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	byID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+		assert.Equal(t, exprID, task.ExpressionID)
+		assert.Equal(t, time.Second, task.OperationTime)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", task.TraceParent.V)
+	}
+	assert.Equal(t, models.TaskStatusPending, byID[add].Status)
+	assert.True(t, byID[add].PendingAt.Valid)
+	assert.Equal(t, 2.0, byID[add].Arg1.V)
+	assert.Equal(t, 3.0, byID[add].Arg2.V)
+	assert.Equal(t, models.TaskStatusCreated, byID[mul].Status)
+	assert.False(t, byID[mul].PendingAt.Valid)
+	assert.False(t, byID[mul].Arg1.Valid)
+	assert.False(t, byID[mul].Arg2.Valid)
+	assert.Equal(t, add, byID[mul].ParentTask1ID.V)
+	assert.Equal(t, sub, byID[mul].ParentTask2ID.V)
+
+	// Both leaves are claimed, the child is not pending until both are done
+	claimed := map[string]float64{}
+	for range 2 {
+		task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskStatusInProgress, task.Status)
+		claimed[task.ID] = task.Arg1.V
+	}
+	assert.Len(t, claimed, 2)
+	assert.NotContains(t, claimed, mul)
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks)
+
+	expr, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 5, ComputeTime: time.Millisecond})
+	require.NoError(t, err)
+	assert.Nil(t, expr, "the expression is not finished yet")
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks, "the child waits for the other parent")
+
+	expr, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: sub, Status: models.TaskStatusCompleted, Result: 3})
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	assert.Equal(t, mul, task.ID)
+	assert.Equal(t, 5.0, task.Arg1.V)
+	assert.Equal(t, 3.0, task.Arg2.V)
+	assert.True(t, task.PendingAt.Valid)
+
+	expr, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: mul, Status: models.TaskStatusCompleted, Result: 15})
+	require.NoError(t, err)
+	require.NotNil(t, expr, "the final task completes the expression")
+	assert.Equal(t, exprID, expr.ID)
+	assert.Equal(t, models.ExpressionStatusCompleted, expr.Status)
+	assert.Equal(t, 15.0, expr.Result.V)
+
+	expr, err = repo.GetExpression(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExpressionStatusCompleted, expr.Status)
+	assert.True(t, expr.Result.Valid)
+	assert.Equal(t, 15.0, expr.Result.V)
+
+	tasks, err = repo.ListExpressionTasks(ctx, userID, exprID)
+	require.NoError(t, err)
+	for _, task := range tasks {
+		assert.Equal(t, models.TaskStatusCompleted, task.Status)
+		assert.True(t, task.Result.Valid)
+		assert.Equal(t, task.ID == add, task.ComputeTime.Valid, "compute time is stored only if reported")
+	}
+
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: "unknown", Status: models.TaskStatusCompleted})
+	require.ErrorIs(t, err, models.ErrTaskNotFound)
+
+	taskCounts, err := repo.CountTasksByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskStatus]int{models.TaskStatusCompleted: 3}, taskCounts)
+}
+
+// testFinishSiblingTasks finishes both parents of a child at the same time,
+// the child must get both of the arguments and become pending.
+func testFinishSiblingTasks(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	const n = 10
+	children := make(map[string]bool, n)
+	var parents [][2]string
+	for range n {
+		_, taskIDs := createExpression(t, repo, userID,
+			task("add", models.TaskOperationAddition, 2, 3),
+			task("sub", models.TaskOperationSubtraction, 4, 1),
+			childTask("mul", models.TaskOperationMultiplication, "add", "sub", 0, 0),
+		)
+		parents = append(parents, [2]string{taskIDs[0], taskIDs[1]})
+		children[taskIDs[2]] = true
+	}
+	for range 2 * n {
+		_, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, ids := range parents {
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: id, Status: models.TaskStatusCompleted, Result: float64(5 - 2*i)})
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+
+	for range n {
+		task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err, "the child is pending once both parents are finished")
+		assert.True(t, children[task.ID])
+		assert.Equal(t, 5.0, task.Arg1.V)
+		assert.Equal(t, 3.0, task.Arg2.V)
+	}
+	_, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks)
+}
+
+func testFailExpression(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	exprID, taskIDs := createExpression(t, repo, userID,
+		task("div", models.TaskOperationDivision, 1, 0),
+		task("add", models.TaskOperationAddition, 1, 1),
+		task("sub", models.TaskOperationSubtraction, 2, 1),
+		childTask("mul", models.TaskOperationMultiplication, "div", "add", 0, 0),
+	)
+	div, add, sub := taskIDs[0], taskIDs[1], taskIDs[2]
+	for range 3 {
+		_, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+	}
+
+	_, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 2})
+	require.NoError(t, err)
+
+	expr, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: div, Status: models.TaskStatusFailed})
+	require.NoError(t, err)
+	require.NotNil(t, expr, "a failed task fails the expression")
+	assert.Equal(t, models.ExpressionStatusFailed, expr.Status)
+	assert.False(t, expr.Result.Valid)
+
+	tasks, err := repo.ListExpressionTasks(ctx, userID, exprID)
+	require.NoError(t, err)
+	for _, task := range tasks {
+		want := models.TaskStatusFailed
+		if task.ID == add {
+			want = models.TaskStatusCompleted
+		}
+		assert.Equal(t, want, task.Status, "unfinished tasks are failed")
+		assert.Equal(t, task.ID == add, task.Result.Valid)
+	}
+
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: sub, Status: models.TaskStatusCompleted, Result: 1})
+	require.ErrorIs(t, err, models.ErrTaskNotFound, "the task has already been failed along with the expression")
+
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks)
+}
+
+func testPendingTaskFilter(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	_, addIDs := createExpression(t, repo, userID, task("add", models.TaskOperationAddition, 1, 1))
+	_, mulIDs := createExpression(t, repo, userID, task("mul", models.TaskOperationMultiplication, 2, 2))
+
+	_, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{Operations: models.TaskOperations{models.TaskOperationDivision}})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks)
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{
+		Operations: models.TaskOperations{models.TaskOperationMultiplication, models.TaskOperationDivision},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mulIDs[0], task.ID)
+
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	assert.Equal(t, addIDs[0], task.ID, "all operations if none are set")
+}
+
+func testOperationCosts(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	since := time.Now().Add(-time.Minute)
+
+	costs, err := repo.GetOperationCosts(ctx, since)
+	require.NoError(t, err)
+	assert.Empty(t, costs)
+
+	userID := registerUser(t, repo, "alice")
+	for _, computeTime := range []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 0} {
+		_, taskIDs := createExpression(t, repo, userID, task("add", models.TaskOperationAddition, 1, 1))
+		_, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+		_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: taskIDs[0], Status: models.TaskStatusCompleted, Result: 2, ComputeTime: computeTime})
+		require.NoError(t, err)
+	}
+	_, taskIDs := createExpression(t, repo, userID, task("div", models.TaskOperationDivision, 1, 0))
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: taskIDs[0], Status: models.TaskStatusFailed, ComputeTime: time.Second})
+	require.NoError(t, err)
+
+	costs, err = repo.GetOperationCosts(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskOperation]time.Duration{models.TaskOperationAddition: 20 * time.Millisecond}, costs,
+		"averaged over completed tasks with the reported compute time")
+
+	costs, err = repo.GetOperationCosts(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, costs)
+}
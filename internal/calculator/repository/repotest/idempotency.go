@@ -0,0 +1,76 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIdempotencyKeys(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	create := func(userID, key, hash string, expiresAt time.Time) (string, error) {
+		return repo.CreateExpression(ctx, userID, models.CreateExpressionCmd{
+			Expression:     "2+2",
+			Tasks:          []models.CreateExpressionCmdTask{task(xid.New().String(), models.TaskOperationAddition, 2, 2)},
+			IdempotencyKey: &models.CreateExpressionCmdIdempotencyKey{Key: key, RequestHash: hash, ExpiresAt: expiresAt},
+		})
+	}
+
+	alice, bob := registerUser(t, repo, "alice"), registerUser(t, repo, "bob")
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	exprID, err := create(alice, "key1", "hash1", expiresAt)
+	require.NoError(t, err)
+
+	key, err := repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: alice, Key: "key1", Now: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, alice, key.UserID)
+	assert.Equal(t, "key1", key.Key)
+	assert.Equal(t, exprID, key.ExpressionID)
+	assert.Equal(t, "hash1", key.RequestHash)
+	assert.WithinDuration(t, expiresAt, key.ExpiresAt, 0)
+	_, err = repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: alice, Key: "key1", Now: expiresAt})
+	require.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound, "expired keys are not found")
+	_, err = repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: bob, Key: "key1", Now: time.Now()})
+	require.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound, "keys are per user")
+
+	_, err = create(alice, "key1", "hash2", expiresAt)
+	require.ErrorIs(t, err, models.ErrIdempotencyKeyExists)
+	exprs, err := repo.ListExpressions(ctx, alice)
+	require.NoError(t, err)
+	assert.Len(t, exprs, 1, "no expression is created with a taken key")
+	_, err = create(bob, "key1", "hash1", expiresAt)
+	require.NoError(t, err, "other users may use the same key")
+
+	// An expired key is replaced
+	expiredID, err := create(alice, "key2", "hash1", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	replacedID, err := create(alice, "key2", "hash2", expiresAt)
+	require.NoError(t, err)
+	assert.NotEqual(t, expiredID, replacedID)
+	key, err = repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: alice, Key: "key2", Now: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, replacedID, key.ExpressionID)
+	assert.Equal(t, "hash2", key.RequestHash)
+
+	_, err = create(alice, "key3", "hash1", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	n, err := repo.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = repo.DeleteExpiredIdempotencyKeys(ctx, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// Keys are deleted along with their user
+	_, err = create(alice, "key4", "hash1", expiresAt)
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteUser(ctx, alice))
+	_, err = repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: alice, Key: "key4", Now: time.Now()})
+	require.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound)
+}
@@ -0,0 +1,122 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutbox(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	exprID, _ := createExpression(t, repo, userID,
+		task("add", models.TaskOperationAddition, 2, 3),
+		childTask("mul", models.TaskOperationMultiplication, "add", "", 0, 4),
+	)
+
+	events, err := repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, events, "no events until the expression finishes")
+
+	pending, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusCompleted, Result: 5})
+	require.NoError(t, err)
+	events, err = repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, events, "no events for intermediate tasks")
+
+	pending, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	expr, err := repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusCompleted, Result: 20})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusCompleted, Result: 20})
+	require.ErrorIs(t, err, models.ErrTaskNotFound, "a result submitted twice doesn't finish the expression again")
+
+	failedID, _ := createExpression(t, repo, userID, task("div", models.TaskOperationDivision, 1, 0))
+	pending, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusFailed})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusFailed})
+	require.ErrorIs(t, err, models.ErrTaskNotFound)
+
+	events, err = repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 2, "one event per finished expression")
+	completed, failed := events[0], events[1]
+	assert.NotEmpty(t, completed.ID)
+	assert.Equal(t, models.OutboxEventExpressionCompleted, completed.Type)
+	assert.Equal(t, exprID, completed.ExpressionID)
+	assert.Equal(t, userID, completed.UserID)
+	assert.Equal(t, "expr", completed.Expression)
+	assert.Equal(t, models.ExpressionStatusCompleted, completed.Status)
+	assert.Equal(t, 20.0, completed.Result.V)
+	assert.True(t, completed.Result.Valid)
+	assert.WithinDuration(t, expr.UpdatedAt, completed.CreatedAt, 0, "the event occurs when the expression finishes")
+	assert.Equal(t, models.OutboxEventExpressionFailed, failed.Type)
+	assert.Equal(t, failedID, failed.ExpressionID)
+	assert.Equal(t, models.ExpressionStatusFailed, failed.Status)
+	assert.False(t, failed.Result.Valid)
+
+	events, err = repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1, "limited")
+	assert.Equal(t, completed.ID, events[0].ID, "oldest first")
+
+	require.NoError(t, repo.MarkOutboxEventsDelivered(ctx, models.MarkOutboxEventsDeliveredCmd{
+		Sink: "webhook", EventIDs: []string{completed.ID, "unknown"},
+	}))
+	require.NoError(t, repo.MarkOutboxEventsDelivered(ctx, models.MarkOutboxEventsDeliveredCmd{
+		Sink: "webhook", EventIDs: []string{completed.ID},
+	}), "marking twice is a no-op")
+	assertOutboxEvents(t, repo, "webhook", failed.ID)
+	assertOutboxEvents(t, repo, "file", completed.ID, failed.ID)
+
+	n, err := repo.DeleteDeliveredOutboxEvents(ctx, []string{"webhook", "file"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "events are kept until delivered to all sinks")
+
+	require.NoError(t, repo.MarkOutboxEventsDelivered(ctx, models.MarkOutboxEventsDeliveredCmd{
+		Sink: "file", EventIDs: []string{completed.ID},
+	}))
+	n, err = repo.DeleteDeliveredOutboxEvents(ctx, []string{"webhook", "file"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assertOutboxEvents(t, repo, "webhook", failed.ID)
+	assertOutboxEvents(t, repo, "file", failed.ID)
+
+	require.NoError(t, repo.DeleteUser(ctx, userID))
+	assertOutboxEvents(t, repo, "webhook") // the events are deleted along with the user
+
+	otherID := registerUser(t, repo, "bob")
+	createExpression(t, repo, otherID, task("div", models.TaskOperationDivision, 1, 0))
+	pending, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: pending.ID, Status: models.TaskStatusFailed})
+	require.NoError(t, err)
+
+	n, err = repo.DeleteDeliveredOutboxEvents(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "events without sinks are delivered")
+	assertOutboxEvents(t, repo, "webhook")
+}
+
+// assertOutboxEvents asserts the IDs of the events not delivered to the sink, oldest first.
+func assertOutboxEvents(t *testing.T, repo Repository, sink string, want ...string) {
+	t.Helper()
+	events, err := repo.ListOutboxEvents(t.Context(), models.ListOutboxEventsCmd{Sink: sink, Limit: 10})
+	require.NoError(t, err)
+	got := make([]string, 0, len(events))
+	for _, e := range events {
+		got = append(got, e.ID)
+	}
+	if len(want) == 0 {
+		want = []string{}
+	}
+	assert.Equal(t, want, got)
+}
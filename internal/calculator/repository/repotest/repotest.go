@@ -0,0 +1,153 @@
+// Package repotest provides the conformance test suite of the storage implementations,
+// which makes sure that they are interchangeable.
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+)
+
+// Repository is the storage used by the calculator services.
+type Repository interface {
+	Register(ctx context.Context, cmd models.RegisterUserCmd) error
+	GetUser(ctx context.Context, cmd models.GetUserCmd) (*models.User, error)
+	UpdatePasswordHash(ctx context.Context, cmd models.UpdatePasswordHashCmd) error
+	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	SetUserRole(ctx context.Context, cmd models.SetUserRoleCmd) (*models.User, error)
+	ChangePassword(ctx context.Context, cmd models.ChangePasswordCmd) error
+	DeleteUser(ctx context.Context, userID string) error
+	GetUserByIdentity(ctx context.Context, cmd models.GetUserByIdentityCmd) (*models.User, error)
+	CreateUserWithIdentity(ctx context.Context, cmd models.CreateUserWithIdentityCmd) (*models.User, error)
+
+	CreateExpression(ctx context.Context, userID string, cmd models.CreateExpressionCmd) (string, error)
+	ListExpressions(ctx context.Context, userID string) ([]models.Expression, error)
+	GetExpression(ctx context.Context, userID string, exprID string) (*models.Expression, error)
+	ListExpressionTasks(ctx context.Context, userID string, exprID string) ([]models.Task, error)
+	ListExpressionTaskEvents(ctx context.Context, userID string, exprID string) ([]models.TaskEvent, error)
+	GetPendingTask(ctx context.Context, cmd models.GetPendingTaskCmd) (*models.Task, error)
+	FinishTask(ctx context.Context, cmd models.FinishTaskCmd) (*models.Expression, error)
+	GetOperationCosts(ctx context.Context, since time.Time) (map[models.TaskOperation]time.Duration, error)
+	CountExpressionsByStatus(ctx context.Context) (map[models.ExpressionStatus]int, error)
+	CountTasksByStatus(ctx context.Context) (map[models.TaskStatus]int, error)
+
+	SetRetentionPolicy(ctx context.Context, cmd models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error)
+	GetRetentionPolicy(ctx context.Context, userID string) (*models.RetentionPolicy, error)
+	ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error)
+	DeleteRetentionPolicy(ctx context.Context, userID string) error
+	PurgeExpressions(ctx context.Context, cmd models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error)
+
+	ListOutboxEvents(ctx context.Context, cmd models.ListOutboxEventsCmd) ([]models.OutboxEvent, error)
+	MarkOutboxEventsDelivered(ctx context.Context, cmd models.MarkOutboxEventsDeliveredCmd) error
+	DeleteDeliveredOutboxEvents(ctx context.Context, sinks []string) (int, error)
+
+	GetExpressionCallback(ctx context.Context, userID string, exprID string) (*models.Callback, error)
+	ListExpressionCallbackAttempts(ctx context.Context, userID string, exprID string) ([]models.CallbackAttempt, error)
+	ClaimDueCallbacks(ctx context.Context, cmd models.ClaimDueCallbacksCmd) ([]models.DueCallback, error)
+	RecordCallbackAttempt(ctx context.Context, cmd models.RecordCallbackAttemptCmd) error
+
+	GetIdempotencyKey(ctx context.Context, cmd models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+
+	UpsertAgent(ctx context.Context, cmd models.UpsertAgentCmd) error
+	ListAliveAgents(ctx context.Context, since time.Time) ([]models.Agent, error)
+	ReportUnroutableTasks(ctx context.Context, since time.Time) (int, error)
+
+	CreateRefreshToken(ctx context.Context, cmd models.CreateRefreshTokenCmd) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, cmd models.RotateRefreshTokenCmd) (*models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, cmd models.RevokeRefreshTokenCmd) error
+	RevokeToken(ctx context.Context, cmd models.RevokeTokenCmd) error
+	IsTokenRevoked(ctx context.Context, cmd models.IsTokenRevokedCmd) (bool, error)
+
+	CreateAPIKey(ctx context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, cmd models.RevokeAPIKeyCmd) error
+
+	GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, cmd models.RecordLoginFailureCmd) (*models.LoginThrottle, error)
+	LockLogin(ctx context.Context, cmd models.LockLoginCmd) error
+	ResetLoginFailures(ctx context.Context, key string) error
+	CreateAuditEvent(ctx context.Context, cmd models.CreateAuditEventCmd) error
+}
+
+// Run runs the conformance tests. newRepo must return an empty repository on each call.
+func Run(t *testing.T, newRepo func(t *testing.T) Repository) {
+	for name, test := range map[string]func(*testing.T, Repository){
+		"Users":              testUsers,
+		"ChangePassword":     testChangePassword,
+		"SetUserRole":        testSetUserRole,
+		"DeleteUser":         testDeleteUser,
+		"UserIdentities":     testUserIdentities,
+		"Expressions":        testExpressions,
+		"EvaluateExpression": testEvaluateExpression,
+		"FinishSiblingTasks": testFinishSiblingTasks,
+		"FailExpression":     testFailExpression,
+		"TaskEvents":         testTaskEvents,
+		"TaskLeases":         testTaskLeases,
+		"Outbox":             testOutbox,
+		"Callbacks":          testCallbacks,
+		"IdempotencyKeys":    testIdempotencyKeys,
+		"PendingTaskFilter":  testPendingTaskFilter,
+		"OperationCosts":     testOperationCosts,
+		"RetentionPolicies":  testRetentionPolicies,
+		"PurgeExpressions":   testPurgeExpressions,
+		"Agents":             testAgents,
+		"UnroutableTasks":    testUnroutableTasks,
+		"RefreshTokens":      testRefreshTokens,
+		"RevokedTokens":      testRevokedTokens,
+		"APIKeys":            testAPIKeys,
+		"LoginThrottles":     testLoginThrottles,
+	} {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// registerUser registers a user with the login and returns its ID.
+func registerUser(t *testing.T, repo Repository, login string) string {
+	t.Helper()
+	require.NoError(t, repo.Register(t.Context(), models.RegisterUserCmd{Login: login, PasswordHash: "hash-" + login}))
+	user, err := repo.GetUser(t.Context(), models.GetUserCmd{Login: login})
+	require.NoError(t, err)
+	return user.ID
+}
+
+// createExpression creates an expression of the tasks, whose IDs are replaced with unique ones.
+// Returns the ID of the expression and the unique IDs of the tasks in the same order.
+func createExpression(t *testing.T, repo Repository, userID string, tasks ...models.CreateExpressionCmdTask) (string, []string) {
+	t.Helper()
+
+	ids := make(map[string]string, len(tasks))
+	taskIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids[task.ID] = xid.New().String()
+		taskIDs = append(taskIDs, ids[task.ID])
+	}
+	cmd := models.CreateExpressionCmd{Expression: "expr", TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	for _, task := range tasks {
+		task.ID, task.ParentTask1ID, task.ParentTask2ID = ids[task.ID], ids[task.ParentTask1ID], ids[task.ParentTask2ID]
+		cmd.Tasks = append(cmd.Tasks, task)
+	}
+
+	exprID, err := repo.CreateExpression(t.Context(), userID, cmd)
+	require.NoError(t, err)
+	return exprID, taskIDs
+}
+
+func task(id string, op models.TaskOperation, arg1, arg2 float64) models.CreateExpressionCmdTask {
+	return models.CreateExpressionCmdTask{ID: id, Arg1: arg1, Arg2: arg2, Operation: op, OperationTime: time.Second}
+}
+
+func childTask(id string, op models.TaskOperation, parent1, parent2 string, arg1, arg2 float64) models.CreateExpressionCmdTask {
+	t := task(id, op, arg1, arg2)
+	t.ParentTask1ID, t.ParentTask2ID = parent1, parent2
+	return t
+}
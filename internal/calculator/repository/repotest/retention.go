@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRetentionPolicies(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+
+	_, err := repo.GetRetentionPolicy(ctx, userID)
+	require.ErrorIs(t, err, models.ErrRetentionPolicyNotFound)
+	_, err = repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{UserID: "unknown", MaxCount: 1})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	policy, err := repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{UserID: userID, MaxCount: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10, policy.MaxCount)
+	policy, err = repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{UserID: userID, MaxAge: time.Hour, MaxCount: 5})
+	require.NoError(t, err)
+
+	got, err := repo.GetRetentionPolicy(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, got.UserID)
+	assert.Equal(t, time.Hour, got.MaxAge)
+	assert.Equal(t, 5, got.MaxCount, "the policy is replaced")
+	assert.WithinDuration(t, policy.UpdatedAt, got.UpdatedAt, time.Second)
+
+	_, err = repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{UserID: otherID, MaxCount: 1})
+	require.NoError(t, err)
+	policies, err := repo.ListRetentionPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+
+	require.NoError(t, repo.DeleteRetentionPolicy(ctx, userID))
+	require.ErrorIs(t, repo.DeleteRetentionPolicy(ctx, userID), models.ErrRetentionPolicyNotFound)
+	require.NoError(t, repo.DeleteUser(ctx, otherID))
+	policies, err = repo.ListRetentionPolicies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, policies, "policies of deleted users are deleted")
+}
+
+func testPurgeExpressions(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+	customID := registerUser(t, repo, "carol")
+	_, err := repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{UserID: customID, MaxCount: 1})
+	require.NoError(t, err)
+
+	userExprs := []string{
+		finishedExpression(t, repo, userID),
+		finishedExpression(t, repo, userID),
+		finishedExpression(t, repo, userID),
+	}
+	otherExprs := []string{
+		finishedExpression(t, repo, otherID),
+		finishedExpression(t, repo, otherID),
+	}
+	customExprs := []string{
+		finishedExpression(t, repo, customID),
+		finishedExpression(t, repo, customID),
+	}
+	pendingExprID, _ := createExpression(t, repo, userID, task("t1", models.TaskOperationAddition, 1, 2))
+
+	res, err := repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, res, "nothing is purged without limits")
+	res, err = repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{UpdatedBefore: time.Now().Add(-time.Hour), Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, res, "recent expressions are kept")
+
+	res, err = repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{KeepLatest: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeExpressionsResult{Expressions: 1, Tasks: 1}, res)
+	assertExpressions(t, repo, userID, pendingExprID, userExprs[2], userExprs[1])
+
+	res, err = repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{KeepLatest: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeExpressionsResult{Expressions: 2, Tasks: 2}, res)
+	assertExpressions(t, repo, userID, pendingExprID, userExprs[2])
+	assertExpressions(t, repo, otherID, otherExprs[1])
+	assertExpressions(t, repo, customID, customExprs[1], customExprs[0])
+
+	res, err = repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{UpdatedBefore: time.Now().Add(time.Hour), Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Expressions, "pending expressions and users with their own policy are skipped")
+	assertExpressions(t, repo, userID, pendingExprID)
+	assertExpressions(t, repo, otherID)
+
+	res, err = repo.PurgeExpressions(ctx, models.PurgeExpressionsCmd{UserID: customID, KeepLatest: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeExpressionsResult{Expressions: 1, Tasks: 1}, res)
+	assertExpressions(t, repo, customID, customExprs[1])
+
+	tasks, err := repo.CountTasksByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskStatus]int{
+		models.TaskStatusPending:   1,
+		models.TaskStatusCompleted: 1,
+	}, tasks, "the tasks are purged with their expressions")
+}
+
+// finishedExpression creates an expression of a single task and completes it. Returns the ID of the expression.
+func finishedExpression(t *testing.T, repo Repository, userID string) string {
+	t.Helper()
+	exprID, _ := createExpression(t, repo, userID, task("t1", models.TaskOperationAddition, 1, 2))
+	task, err := repo.GetPendingTask(t.Context(), models.GetPendingTaskCmd{})
+	require.NoError(t, err)
+	expr, err := repo.FinishTask(t.Context(), models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: 3})
+	require.NoError(t, err)
+	require.Equal(t, exprID, expr.ID)
+	return exprID
+}
+
+// assertExpressions asserts the IDs of the expressions of the user, newest first.
+func assertExpressions(t *testing.T, repo Repository, userID string, want ...string) {
+	t.Helper()
+	exprs, err := repo.ListExpressions(t.Context(), userID)
+	require.NoError(t, err)
+	got := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		got = append(got, expr.ID)
+	}
+	if len(want) == 0 {
+		want = []string{}
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,133 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTaskEvents(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+	exprID, taskIDs := createExpression(t, repo, userID,
+		task("add", models.TaskOperationAddition, 2, 3),
+		task("div", models.TaskOperationDivision, 1, 0),
+		childTask("mul", models.TaskOperationMultiplication, "add", "div", 0, 0),
+	)
+	add, div, mul := taskIDs[0], taskIDs[1], taskIDs[2]
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{Operations: models.TaskOperations{models.TaskOperationAddition}, AgentID: "agent-1"})
+	require.NoError(t, err)
+	require.Equal(t, add, task.ID)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 5, AgentID: "agent-1"})
+	require.NoError(t, err)
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{AgentID: "agent-2"})
+	require.NoError(t, err)
+	require.Equal(t, div, task.ID)
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: div, Status: models.TaskStatusFailed, AgentID: "agent-2"})
+	require.NoError(t, err)
+
+	events, err := repo.ListExpressionTaskEvents(ctx, userID, exprID)
+	require.NoError(t, err)
+	type event struct {
+		taskID  string
+		typ     models.TaskEventType
+		agentID string
+	}
+	var got []event
+	for i, e := range events {
+		got = append(got, event{taskID: e.TaskID, typ: e.Type, agentID: e.AgentID.V})
+		assert.Equal(t, exprID, e.ExpressionID)
+		assert.NotEmpty(t, e.ID)
+		assert.False(t, e.CreatedAt.IsZero())
+		if i > 0 {
+			assert.False(t, e.CreatedAt.Before(events[i-1].CreatedAt), "oldest first")
+		}
+		assert.Equal(t, e.Type == models.TaskEventCompleted, e.Result.Valid, "only completed events have results")
+		if e.Type == models.TaskEventCompleted {
+			assert.Equal(t, 5.0, e.Result.V)
+		}
+	}
+	assert.ElementsMatch(t, []event{
+		{taskID: add, typ: models.TaskEventCreated},
+		{taskID: div, typ: models.TaskEventCreated},
+		{taskID: mul, typ: models.TaskEventCreated},
+	}, got[:3], "the tasks are created together")
+	assert.Equal(t, []event{
+		{taskID: add, typ: models.TaskEventClaimed, agentID: "agent-1"},
+		{taskID: add, typ: models.TaskEventCompleted, agentID: "agent-1"},
+		{taskID: div, typ: models.TaskEventClaimed, agentID: "agent-2"},
+		{taskID: div, typ: models.TaskEventFailed, agentID: "agent-2"},
+		{taskID: mul, typ: models.TaskEventFailed}, // along with the expression
+	}, got[3:])
+
+	_, err = repo.ListExpressionTaskEvents(ctx, otherID, exprID)
+	require.ErrorIs(t, err, models.ErrExpressionNotFound, "events of expressions of other users are not found")
+	_, err = repo.ListExpressionTaskEvents(ctx, userID, "unknown")
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+
+	require.NoError(t, repo.DeleteUser(ctx, userID))
+	_, err = repo.ListExpressionTaskEvents(ctx, userID, exprID)
+	require.ErrorIs(t, err, models.ErrExpressionNotFound)
+}
+
+func testTaskLeases(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	exprID, taskIDs := createExpression(t, repo, userID, task("add", models.TaskOperationAddition, 2, 3))
+	add := taskIDs[0]
+
+	task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{AgentID: "agent-1", Lease: time.Millisecond})
+	require.NoError(t, err)
+	require.Equal(t, add, task.ID)
+	require.True(t, task.ExpireAt.Valid, "the lease ends at the expiry time")
+	assert.WithinDuration(t, task.UpdatedAt.Add(time.Millisecond), task.ExpireAt.V, time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	task, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{AgentID: "agent-2", Lease: time.Hour})
+	require.NoError(t, err, "the expired task is handed to another agent")
+	require.Equal(t, add, task.ID)
+	assert.Equal(t, models.TaskStatusInProgress, task.Status)
+	assert.Equal(t, "agent-2", task.AgentID.V)
+
+	_, err = repo.GetPendingTask(ctx, models.GetPendingTaskCmd{AgentID: "agent-3"})
+	require.ErrorIs(t, err, models.ErrNoPendingTasks, "the lease of the second agent hasn't expired")
+
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 4, AgentID: "agent-1"})
+	require.ErrorIs(t, err, models.ErrTaskNotClaimed, "the result of the agent that ran out of its lease is rejected")
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 4})
+	require.ErrorIs(t, err, models.ErrTaskNotClaimed, "the result of an unknown agent is rejected")
+
+	_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: add, Status: models.TaskStatusCompleted, Result: 5, AgentID: "agent-2"})
+	require.NoError(t, err)
+
+	events, err := repo.ListExpressionTaskEvents(ctx, userID, exprID)
+	require.NoError(t, err)
+	type event struct {
+		typ     models.TaskEventType
+		agentID string
+	}
+	var got []event
+	for _, e := range events {
+		assert.Equal(t, add, e.TaskID)
+		got = append(got, event{typ: e.Type, agentID: e.AgentID.V})
+	}
+	assert.Equal(t, []event{
+		{typ: models.TaskEventCreated},
+		{typ: models.TaskEventClaimed, agentID: "agent-1"},
+		{typ: models.TaskEventReleased},
+		{typ: models.TaskEventReclaimed, agentID: "agent-2"},
+		{typ: models.TaskEventCompleted, agentID: "agent-2"},
+	}, got)
+
+	expr, err := repo.GetExpression(ctx, userID, exprID)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, expr.Result.V, "the result of the agent holding the lease is accepted")
+}
@@ -0,0 +1,146 @@
+package repotest
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRefreshTokens(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	expiresAt := time.Now().Add(time.Hour)
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+
+	first, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "first", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	assert.Equal(t, userID, first.UserID)
+	assert.NotEmpty(t, first.FamilyID)
+
+	second, err := repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "first", NewTokenHash: "second", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	assert.Equal(t, first.FamilyID, second.FamilyID, "rotated tokens are of the same family")
+	assert.Equal(t, userID, second.UserID)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "unknown", NewTokenHash: "third", ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, models.ErrRefreshTokenNotFound)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "first", NewTokenHash: "third", ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused)
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "second", NewTokenHash: "third", ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused, "reuse revokes the whole family")
+
+	_, err = repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "expired", NewTokenHash: "third", ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, models.ErrRefreshTokenExpired)
+
+	_, err = repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "revoked", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	require.ErrorIs(t, repo.RevokeRefreshToken(ctx, models.RevokeRefreshTokenCmd{UserID: otherID, TokenHash: "revoked"}), models.ErrRefreshTokenNotFound,
+		"tokens of other users are not found")
+	require.NoError(t, repo.RevokeRefreshToken(ctx, models.RevokeRefreshTokenCmd{UserID: userID, TokenHash: "revoked"}))
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "revoked", NewTokenHash: "third", ExpiresAt: expiresAt})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused)
+}
+
+func testRevokedTokens(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	now := time.Now()
+
+	userID := registerUser(t, repo, "alice")
+
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "jti", UserID: userID, IssuedAt: now})
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	for range 2 {
+		require.NoError(t, repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti", UserID: userID, ExpiresAt: now.Add(time.Hour)}))
+	}
+
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "jti", UserID: userID, IssuedAt: now})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "other", UserID: userID, IssuedAt: now})
+	require.NoError(t, err)
+	assert.False(t, revoked, "other tokens are not revoked")
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "other", UserID: "unknown", IssuedAt: now})
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens of unknown users are revoked")
+}
+
+func testAPIKeys(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	expiresAt := time.Now().Add(time.Hour)
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+
+	first, err := repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{
+		UserID:  userID,
+		Name:    "first",
+		KeyHash: "first-hash",
+		Scopes:  models.APIKeyScopes{models.APIKeyScopeReadOnly},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.False(t, first.ExpiresAt.Valid)
+
+	second, err := repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{
+		UserID:    userID,
+		Name:      "second",
+		KeyHash:   "second-hash",
+		Scopes:    models.APIKeyScopes{models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit},
+		ExpiresAt: sql.Null[time.Time]{V: expiresAt, Valid: true},
+	})
+	require.NoError(t, err)
+
+	keys, err := repo.ListAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, second.ID, keys[0].ID, "newest first")
+	assert.Equal(t, "alice", keys[0].UserLogin)
+	assert.Equal(t, models.UserRoleUser, keys[0].UserRole)
+	assert.Equal(t, models.APIKeyScopes{models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit}, keys[0].Scopes)
+	assert.WithinDuration(t, expiresAt, keys[0].ExpiresAt.V, time.Millisecond)
+	assert.Equal(t, first.ID, keys[1].ID)
+
+	keys, err = repo.ListAPIKeys(ctx, otherID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	key, err := repo.GetAPIKeyByHash(ctx, "first-hash")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, key.ID)
+	assert.Equal(t, "first", key.Name)
+	assert.Equal(t, "alice", key.UserLogin)
+	assert.True(t, key.Active(time.Now()))
+	_, err = repo.GetAPIKeyByHash(ctx, "unknown")
+	require.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+
+	require.ErrorIs(t, repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: otherID, ID: first.ID}), models.ErrAPIKeyNotFound,
+		"keys of other users are not found")
+	require.NoError(t, repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: userID, ID: first.ID}))
+	key, err = repo.GetAPIKeyByHash(ctx, "first-hash")
+	require.NoError(t, err, "revoked keys are still found")
+	assert.False(t, key.Active(time.Now()))
+	revokedAt := key.RevokedAt.V
+
+	require.NoError(t, repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{UserID: userID, ID: first.ID}))
+	key, err = repo.GetAPIKeyByHash(ctx, "first-hash")
+	require.NoError(t, err)
+	assert.True(t, revokedAt.Equal(key.RevokedAt.V), "revoking again is a no-op")
+
+	_, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: userID, Role: models.UserRoleReadOnly})
+	require.NoError(t, err)
+	key, err = repo.GetAPIKeyByHash(ctx, "second-hash")
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleReadOnly, key.UserRole, "the current role of the user")
+}
@@ -0,0 +1,249 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUsers(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	users, err := repo.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users, "no users in an empty repository")
+
+	userID := registerUser(t, repo, "bob")
+	require.NoError(t, repo.Register(ctx, models.RegisterUserCmd{
+		Login:                  "alice",
+		PasswordHash:           "hash-alice",
+		Role:                   models.UserRoleAdmin,
+		PasswordChangeRequired: true,
+	}))
+
+	require.ErrorIs(t, repo.Register(ctx, models.RegisterUserCmd{Login: "BOB", PasswordHash: "hash"}), models.ErrUserExists)
+
+	user, err := repo.GetUser(ctx, models.GetUserCmd{Login: "Bob"})
+	require.NoError(t, err, "logins are case-insensitive")
+	assert.Equal(t, userID, user.ID)
+	assert.Equal(t, "bob", user.Login)
+	assert.Equal(t, "hash-bob", user.PasswordHash)
+	assert.Equal(t, models.UserRoleUser, user.Role, "user role by default")
+	assert.False(t, user.PasswordChangeRequired)
+
+	_, err = repo.GetUser(ctx, models.GetUserCmd{Login: "carol"})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	users, err = repo.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Login, "ordered by login")
+	assert.Equal(t, models.UserRoleAdmin, users[0].Role)
+	assert.True(t, users[0].PasswordChangeRequired)
+	assert.Equal(t, "bob", users[1].Login)
+
+	require.NoError(t, repo.UpdatePasswordHash(ctx, models.UpdatePasswordHashCmd{UserID: userID, PasswordHash: "new-hash"}))
+	require.ErrorIs(t, repo.UpdatePasswordHash(ctx, models.UpdatePasswordHashCmd{UserID: "unknown", PasswordHash: "hash"}), models.ErrUserNotFound)
+
+	user, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: userID, Role: models.UserRoleReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleReadOnly, user.Role)
+	assert.Equal(t, "new-hash", user.PasswordHash)
+	_, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: "unknown", Role: models.UserRoleAdmin})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	user, err = repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleReadOnly, user.Role)
+	_, err = repo.GetUserByID(ctx, "unknown")
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func testChangePassword(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	require.NoError(t, repo.Register(ctx, models.RegisterUserCmd{Login: "admin", PasswordHash: "hash", PasswordChangeRequired: true}))
+	user, err := repo.GetUser(ctx, models.GetUserCmd{Login: "admin"})
+	require.NoError(t, err)
+
+	_, err = repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: user.ID, TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: user.ID, PasswordHash: "new-hash"}))
+	require.ErrorIs(t, repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: "unknown", PasswordHash: "hash"}), models.ErrUserNotFound)
+
+	user, err = repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", user.PasswordHash)
+	assert.False(t, user.PasswordChangeRequired, "the change is no longer required")
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "refresh", NewTokenHash: "next", ExpiresAt: time.Now().Add(time.Hour)})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused, "refresh tokens are revoked")
+
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "old", UserID: user.ID, IssuedAt: time.Now().Add(-2 * time.Second)})
+	require.NoError(t, err)
+	assert.True(t, revoked, "access tokens issued before the change are revoked")
+
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "new", UserID: user.ID, IssuedAt: time.Now().Add(time.Second)})
+	require.NoError(t, err)
+	assert.False(t, revoked, "access tokens issued after the change are valid")
+}
+
+func testSetUserRole(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: userID, Role: models.UserRoleUser})
+	require.NoError(t, err)
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "refresh", NewTokenHash: "next", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err, "setting the current role revokes nothing")
+
+	issuedBefore := time.Now().Add(-time.Millisecond)
+	user, err := repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: userID, Role: models.UserRoleReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleReadOnly, user.Role)
+	issuedAfter := time.Now().Add(time.Millisecond)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "next", NewTokenHash: "last", ExpiresAt: time.Now().Add(time.Hour)})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused, "refresh tokens are revoked")
+
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "old", UserID: userID, IssuedAt: issuedBefore})
+	require.NoError(t, err)
+	assert.True(t, revoked, "access tokens carrying the old role are revoked")
+
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "new", UserID: userID, IssuedAt: issuedAfter})
+	require.NoError(t, err)
+	assert.False(t, revoked, "access tokens issued after the change are valid")
+}
+
+func testDeleteUser(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	userID := registerUser(t, repo, "alice")
+	otherID := registerUser(t, repo, "bob")
+
+	createExpression(t, repo, userID, task("t1", models.TaskOperationAddition, 1, 2))
+	otherExprID, _ := createExpression(t, repo, otherID, task("t1", models.TaskOperationAddition, 1, 2))
+	for range 2 { // both expressions are finished, recording their events in the outbox
+		task, err := repo.GetPendingTask(ctx, models.GetPendingTaskCmd{})
+		require.NoError(t, err)
+		_, err = repo.FinishTask(ctx, models.FinishTaskCmd{ID: task.ID, Status: models.TaskStatusCompleted, Result: 3})
+		require.NoError(t, err)
+	}
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{UserID: userID, Name: "ci", KeyHash: "key"})
+	require.NoError(t, err)
+	for _, key := range []string{models.LoginThrottleKey("Alice"), models.LoginThrottleKey("bob")} {
+		_, err = repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: key, Window: time.Hour})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.DeleteUser(ctx, userID))
+	require.ErrorIs(t, repo.DeleteUser(ctx, userID), models.ErrUserNotFound)
+
+	_, err = repo.GetUserByID(ctx, userID)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+	exprs, err := repo.ListExpressions(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, exprs)
+	_, err = repo.GetAPIKeyByHash(ctx, "key")
+	require.ErrorIs(t, err, models.ErrAPIKeyNotFound)
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{TokenHash: "refresh", NewTokenHash: "next", ExpiresAt: time.Now().Add(time.Hour)})
+	require.ErrorIs(t, err, models.ErrRefreshTokenNotFound)
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "jti", UserID: userID, IssuedAt: time.Now()})
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens of deleted users are revoked")
+	throttles, err := repo.GetLoginThrottles(ctx, []string{models.LoginThrottleKey("alice"), models.LoginThrottleKey("bob")})
+	require.NoError(t, err)
+	require.Len(t, throttles, 1, "the login of a deleted user can be registered again without a lockout")
+	assert.Equal(t, models.LoginThrottleKey("bob"), throttles[0].Key)
+
+	tasks, err := repo.CountTasksByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.TaskStatus]int{models.TaskStatusCompleted: 1}, tasks, "only the tasks of the other user are left")
+	_, err = repo.GetExpression(ctx, otherID, otherExprID)
+	require.NoError(t, err)
+	events, err := repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1, "the undelivered events of the user are deleted")
+	assert.Equal(t, otherExprID, events[0].ExpressionID)
+}
+
+func testUserIdentities(t *testing.T, repo Repository) {
+	ctx := t.Context()
+	identity := models.GetUserByIdentityCmd{Issuer: "https://idp.example.com", Subject: "sub"}
+
+	_, err := repo.GetUserByIdentity(ctx, identity)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	user, err := repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{Login: "alice", Issuer: identity.Issuer, Subject: identity.Subject})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Login)
+	assert.Equal(t, models.UserRoleUser, user.Role)
+	assert.Empty(t, user.PasswordHash)
+
+	got, err := repo.GetUserByIdentity(ctx, identity)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	_, err = repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{Login: "ALICE", Issuer: identity.Issuer, Subject: "other"})
+	require.ErrorIs(t, err, models.ErrUserExists)
+	_, err = repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{Login: "alice-2", Issuer: identity.Issuer, Subject: identity.Subject})
+	require.ErrorIs(t, err, models.ErrIdentityExists)
+	_, err = repo.GetUser(ctx, models.GetUserCmd{Login: "alice-2"})
+	require.ErrorIs(t, err, models.ErrUserNotFound, "the user is not created without the identity")
+}
+
+func testLoginThrottles(t *testing.T, repo Repository) {
+	ctx := t.Context()
+
+	throttles, err := repo.GetLoginThrottles(ctx, []string{"login:alice"})
+	require.NoError(t, err)
+	assert.Empty(t, throttles)
+
+	for i := 1; i <= 2; i++ {
+		throttle, err := repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: "login:alice", Window: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, i, throttle.Failures)
+	}
+	_, err = repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: "ip:127.0.0.1", Window: time.Hour})
+	require.NoError(t, err)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	require.NoError(t, repo.LockLogin(ctx, models.LockLoginCmd{Key: "login:alice", LockedUntil: lockedUntil}))
+	require.NoError(t, repo.LockLogin(ctx, models.LockLoginCmd{Key: "login:unknown", LockedUntil: lockedUntil}))
+
+	throttles, err = repo.GetLoginThrottles(ctx, []string{"login:alice", "login:unknown"})
+	require.NoError(t, err)
+	require.Len(t, throttles, 1, "keys without failures are omitted")
+	assert.Equal(t, 2, throttles[0].Failures)
+	assert.True(t, throttles[0].Locked(time.Now()))
+	assert.WithinDuration(t, lockedUntil, throttles[0].LockedUntil.V, time.Millisecond)
+
+	throttle, err := repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: "ip:127.0.0.1", Window: 0})
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Failures, "failures are forgotten after the window")
+	throttle, err = repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{Key: "login:alice", Window: 0})
+	require.NoError(t, err)
+	assert.Equal(t, 3, throttle.Failures, "the window starts at the end of the lockout")
+
+	require.NoError(t, repo.ResetLoginFailures(ctx, "login:alice"))
+	throttles, err = repo.GetLoginThrottles(ctx, []string{"login:alice", "ip:127.0.0.1"})
+	require.NoError(t, err)
+	require.Len(t, throttles, 1)
+	assert.Equal(t, "ip:127.0.0.1", throttles[0].Key)
+
+	require.NoError(t, repo.CreateAuditEvent(ctx, models.CreateAuditEventCmd{
+		Event:   models.AuditEventLoginLockout,
+		Subject: "login:alice",
+		Details: "locked",
+	}))
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SetRetentionPolicy creates or replaces the retention policy of a user.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) SetRetentionPolicy(ctx context.Context, cmd models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error) {
+	const q = `
+        INSERT INTO retention_policies (user_id, max_age, max_count, updated_at)
+        SELECT id, ?, ?, ? FROM users WHERE id = ?
+        ON CONFLICT (user_id) DO UPDATE
+        SET max_age    = excluded.max_age,
+            max_count  = excluded.max_count,
+            updated_at = excluded.updated_at
+    `
+
+	policy := models.RetentionPolicy{
+		UserID:    cmd.UserID,
+		MaxAge:    cmd.MaxAge,
+		MaxCount:  cmd.MaxCount,
+		UpdatedAt: time.Now().UTC(),
+	}
+	res, err := r.exec(ctx, r.db.Rebind(q), policy.MaxAge, policy.MaxCount, policy.UpdatedAt, policy.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return nil, models.ErrUserNotFound
+	}
+	return &policy, nil
+}
+
+// GetRetentionPolicy retrieves the retention policy of a user.
+// Returns [models.ErrRetentionPolicyNotFound] if the user has no policy of their own.
+func (r *Repository) GetRetentionPolicy(ctx context.Context, userID string) (*models.RetentionPolicy, error) {
+	const q = `SELECT user_id, max_age, max_count, updated_at FROM retention_policies WHERE user_id = ?`
+
+	var policy models.RetentionPolicy
+	if err := r.rdb.GetContext(ctx, &policy, r.db.Rebind(q), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrRetentionPolicyNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+	return &policy, nil
+}
+
+// ListRetentionPolicies retrieves the retention policies of all users ordered by user ID.
+func (r *Repository) ListRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	const q = `SELECT user_id, max_age, max_count, updated_at FROM retention_policies ORDER BY user_id`
+
+	var policies []models.RetentionPolicy
+	if err := r.rdb.SelectContext(ctx, &policies, q); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+	return policies, nil
+}
+
+// DeleteRetentionPolicy deletes the retention policy of a user, so that the global policy applies to them.
+// Returns [models.ErrRetentionPolicyNotFound] if the user has no policy of their own.
+func (r *Repository) DeleteRetentionPolicy(ctx context.Context, userID string) error {
+	const q = `DELETE FROM retention_policies WHERE user_id = ?`
+
+	res, err := r.exec(ctx, r.db.Rebind(q), userID)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return models.ErrRetentionPolicyNotFound
+	}
+	return nil
+}
+
+// PurgeExpressions deletes a batch of finished expressions breaking the retention limits, oldest first.
+// Their tasks, task events, callbacks and idempotency keys are deleted by the ON DELETE CASCADE foreign keys.
+func (r *Repository) PurgeExpressions(ctx context.Context, cmd models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error) {
+	var conds []string
+	args := []any{models.ExpressionStatusCompleted, models.ExpressionStatusFailed}
+	userFilter := "user_id NOT IN (SELECT user_id FROM retention_policies)"
+	if cmd.UserID != "" {
+		userFilter = "user_id = ?"
+		args = append(args, cmd.UserID)
+	}
+	if !cmd.UpdatedBefore.IsZero() {
+		conds = append(conds, "updated_at < ?")
+		args = append(args, cmd.UpdatedBefore.UTC())
+	}
+	if cmd.KeepLatest > 0 {
+		conds = append(conds, "row_num > ?")
+		args = append(args, cmd.KeepLatest)
+	}
+	if len(conds) == 0 {
+		return models.PurgeExpressionsResult{}, nil
+	}
+	args = append(args, cmd.Limit)
+
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := fmt.Sprintf(`
+        SELECT id
+        FROM (
+            SELECT id, created_at, updated_at,
+                   ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS row_num
+            FROM expressions
+            WHERE status IN (?, ?) AND %s
+        ) AS finished
+        WHERE %s
+        ORDER BY created_at, id
+        LIMIT ?
+    `, userFilter, strings.Join(conds, " OR "))
+
+	var ids []string
+	if err := tx.SelectContext(ctx, &ids, tx.Rebind(q), args...); err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("select expressions: %w", err)
+	}
+	if len(ids) == 0 {
+		return models.PurgeExpressionsResult{}, nil
+	}
+
+	query, inArgs, err := sqlx.In(`SELECT COUNT(*) FROM tasks WHERE expression_id IN (?)`, ids)
+	if err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("build query: %w", err)
+	}
+	var tasks int
+	if err := tx.GetContext(ctx, &tasks, tx.Rebind(query), inArgs...); err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("count tasks: %w", err)
+	}
+
+	query, inArgs, err = sqlx.In(`DELETE FROM expressions WHERE id IN (?)`, ids)
+	if err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), inArgs...); err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("delete expressions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return models.PurgeExpressionsResult{Expressions: len(ids), Tasks: tasks}, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+)
+
+// ListExpressionTaskEvents retrieves the history of the tasks of a specific expression for a specific user, oldest first.
+// Returns [models.ErrExpressionNotFound] if the expression doesn't exist.
+func (r *Repository) ListExpressionTaskEvents(ctx context.Context, userID string, exprID string) ([]models.TaskEvent, error) {
+	q := `SELECT COUNT(*) FROM expressions WHERE id = ? AND user_id = ?`
+
+	var count int
+	if err := r.rdb.GetContext(ctx, &count, r.db.Rebind(q), exprID, userID); err != nil {
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+	if count == 0 {
+		return nil, models.ErrExpressionNotFound
+	}
+
+	q = `
+        SELECT id, task_id, expression_id, type, agent_id, result, created_at
+        FROM task_events
+        WHERE expression_id = ?
+        ORDER BY created_at, id
+    `
+
+	var events []models.TaskEvent
+	if err := r.rdb.SelectContext(ctx, &events, r.db.Rebind(q), exprID); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+
+	return events, nil
+}
+
+// insertTaskEvents appends the events to the history of the tasks within the transaction of the transitions.
+func (r *Repository) insertTaskEvents(ctx context.Context, tx *sqlx.Tx, events ...models.TaskEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	sb := sqlbuilder.InsertInto("task_events").Cols("id", "task_id", "expression_id", "type", "agent_id", "result", "created_at")
+	for _, e := range events {
+		sb.Values(xid.New().String(), e.TaskID, e.ExpressionID, e.Type, e.AgentID, e.Result, e.CreatedAt)
+	}
+
+	query, args := sb.Build()
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("insert task events: %w", err)
+	}
+	return nil
+}
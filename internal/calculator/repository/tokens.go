@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+)
+
+// CreateRefreshToken stores a refresh token starting a new token family.
+func (r *Repository) CreateRefreshToken(ctx context.Context, cmd models.CreateRefreshTokenCmd) (*models.RefreshToken, error) {
+	token := models.RefreshToken{
+		ID:        xid.New().String(),
+		UserID:    cmd.UserID,
+		FamilyID:  xid.New().String(),
+		TokenHash: cmd.TokenHash,
+		ExpiresAt: cmd.ExpiresAt.UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.insertRefreshToken(ctx, r.db, token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken replaces a refresh token with a new one of the same family.
+// Presenting an already rotated or revoked token revokes the whole family,
+// since it means that the token has leaked.
+// Returns [models.ErrRefreshTokenNotFound] if the token doesn't exist,
+// [models.ErrRefreshTokenReused] if it has already been rotated or revoked,
+// and [models.ErrRefreshTokenExpired] if it has expired.
+func (r *Repository) RotateRefreshToken(ctx context.Context, cmd models.RotateRefreshTokenCmd) (*models.RefreshToken, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := `
+        SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+        FROM refresh_tokens
+        WHERE token_hash = ?
+    ` + r.forUpdate(false)
+
+	var old models.RefreshToken
+	if err := tx.GetContext(ctx, &old, tx.Rebind(q), cmd.TokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if old.RevokedAt.Valid || old.ReplacedBy.Valid {
+		if err := r.revokeRefreshTokenFamily(ctx, tx, old.FamilyID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", err)
+		}
+		return nil, models.ErrRefreshTokenReused
+	}
+	if !old.ExpiresAt.After(now) {
+		return nil, models.ErrRefreshTokenExpired
+	}
+
+	token := models.RefreshToken{
+		ID:        xid.New().String(),
+		UserID:    old.UserID,
+		FamilyID:  old.FamilyID,
+		TokenHash: cmd.NewTokenHash,
+		ExpiresAt: cmd.ExpiresAt.UTC(),
+		CreatedAt: now,
+	}
+	if err := r.insertRefreshToken(ctx, tx, token); err != nil {
+		return nil, err
+	}
+
+	const rotateQ = `UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, tx.Rebind(rotateQ), now, token.ID, old.ID); err != nil {
+		return nil, fmt.Errorf("rotate token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the user.
+// Returns [models.ErrRefreshTokenNotFound] if the user has no such token.
+func (r *Repository) RevokeRefreshToken(ctx context.Context, cmd models.RevokeRefreshTokenCmd) error {
+	const q = `SELECT family_id FROM refresh_tokens WHERE token_hash = ? AND user_id = ?`
+
+	var familyID string
+	if err := r.rdb.GetContext(ctx, &familyID, r.db.Rebind(q), cmd.TokenHash, cmd.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrRefreshTokenNotFound
+		}
+		return fmt.Errorf("db get: %w", err)
+	}
+	return r.revokeRefreshTokenFamily(ctx, r.db, familyID, time.Now().UTC())
+}
+
+// RevokeToken adds an access token to the revocation list until it expires.
+// Entries of already expired tokens are purged along the way.
+func (r *Repository) RevokeToken(ctx context.Context, cmd models.RevokeTokenCmd) error {
+	now := time.Now().UTC()
+
+	q := `DELETE FROM revoked_tokens WHERE expires_at < ?`
+	if _, err := r.exec(ctx, r.db.Rebind(q), now); err != nil {
+		return fmt.Errorf("purge expired: %w", err)
+	}
+
+	q = `INSERT INTO revoked_tokens (jti, user_id, expires_at, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (jti) DO NOTHING`
+	if _, err := r.exec(ctx, r.db.Rebind(q), cmd.JTI, cmd.UserID, cmd.ExpiresAt.UTC(), now); err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token has been revoked: either by itself,
+// or along with all tokens of the user issued before a password change, or by deletion of the user.
+func (r *Repository) IsTokenRevoked(ctx context.Context, cmd models.IsTokenRevokedCmd) (bool, error) {
+	const q = `
+        SELECT NOT EXISTS (
+            SELECT 1 FROM users WHERE id = ? AND (tokens_revoked_at IS NULL OR tokens_revoked_at <= ?)
+        ) OR EXISTS (
+            SELECT 1 FROM revoked_tokens WHERE jti = ?
+        )
+    `
+
+	var revoked bool
+	if err := r.rdb.GetContext(ctx, &revoked, r.db.Rebind(q), cmd.UserID, cmd.IssuedAt.UTC(), cmd.JTI); err != nil {
+		return false, fmt.Errorf("db get: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *Repository) insertRefreshToken(ctx context.Context, db sqlx.ExtContext, token models.RefreshToken) error {
+	const q = `
+        INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+        VALUES (:id, :user_id, :family_id, :token_hash, :expires_at, :created_at)
+    `
+	if _, err := sqlx.NamedExecContext(ctx, db, q, token); err != nil {
+		return fmt.Errorf("insert refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) revokeRefreshTokenFamily(ctx context.Context, db sqlx.ExtContext, familyID string, now time.Time) error {
+	const q = `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`
+	if _, err := db.ExecContext(ctx, db.Rebind(q), now, familyID); err != nil {
+		return fmt.Errorf("revoke token family: %w", err)
+	}
+	return nil
+}
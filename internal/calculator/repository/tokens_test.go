@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_RotateRefreshToken(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	expiresAt := time.Now().Add(time.Hour)
+
+	first, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{
+		UserID:    userID,
+		TokenHash: "hash1",
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.FamilyID)
+
+	second, err := repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash1",
+		NewTokenHash: "hash2",
+		ExpiresAt:    expiresAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, userID, second.UserID)
+	assert.Equal(t, first.FamilyID, second.FamilyID)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	// Reusing the rotated token revokes the whole family
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash1",
+		NewTokenHash: "hash3",
+		ExpiresAt:    expiresAt,
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash2",
+		NewTokenHash: "hash4",
+		ExpiresAt:    expiresAt,
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused, "descendant of a reused token should be revoked")
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "nonexistent",
+		NewTokenHash: "hash5",
+		ExpiresAt:    expiresAt,
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenNotFound)
+}
+
+func TestRepository_RotateRefreshToken_expired(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{
+		UserID:    userID,
+		TokenHash: "hash1",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash1",
+		NewTokenHash: "hash2",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenExpired)
+}
+
+func TestRepository_RevokeRefreshToken(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	otherUserID := createTestUser(t, repo, ctx)
+
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{
+		UserID:    userID,
+		TokenHash: "hash1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = repo.RevokeRefreshToken(ctx, models.RevokeRefreshTokenCmd{UserID: otherUserID, TokenHash: "hash1"})
+	require.ErrorIs(t, err, models.ErrRefreshTokenNotFound, "token of another user should not be revoked")
+
+	err = repo.RevokeRefreshToken(ctx, models.RevokeRefreshTokenCmd{UserID: userID, TokenHash: "hash1"})
+	require.NoError(t, err)
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash1",
+		NewTokenHash: "hash2",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused)
+}
+
+func TestRepository_RevokeToken(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	isRevoked := func(jti string) bool {
+		t.Helper()
+		revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: jti, UserID: userID, IssuedAt: time.Now()})
+		require.NoError(t, err)
+		return revoked
+	}
+
+	assert.False(t, isRevoked("jti1"))
+
+	err := repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti1", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.True(t, isRevoked("jti1"))
+
+	// Revoking twice is not an error
+	err = repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti1", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	// Expired entries are purged on the next revocation
+	err = repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti2", UserID: userID, ExpiresAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	err = repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti3", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.False(t, isRevoked("jti2"))
+
+	// Tokens of nonexistent users are revoked
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "jti4", UserID: "nonexistent", IssuedAt: time.Now()})
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
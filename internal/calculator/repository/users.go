@@ -5,44 +5,46 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 
-	"github.com/mattn/go-sqlite3"
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/xid"
 )
 
-// Register creates a new user account with the provided credentials.
-// Returns [models.ErrUserExists] if a user with the same login already exists.
+// Register creates a new user account with the provided credentials and the user role unless another role is set.
+// Returns [models.ErrUserExists] if a user with the same login, compared case-insensitively, already exists.
 func (r *Repository) Register(ctx context.Context, cmd models.RegisterUserCmd) error {
-	const q = `INSERT INTO users (id, login, password_hash) VALUES (?, ?, ?)`
+	const q = `INSERT INTO users (id, login, password_hash, role, password_change_required) VALUES (?, ?, ?, ?, ?)`
 
-	_, err := r.db.ExecContext(ctx, q, xid.New().String(), cmd.Login, cmd.PasswordHash)
+	role := cmd.Role
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
+	_, err := r.exec(ctx, r.db.Rebind(q), xid.New().String(), cmd.Login, cmd.PasswordHash, role, cmd.PasswordChangeRequired)
 	if err == nil {
 		return nil
 	}
-
-	var sqliteErr sqlite3.Error
-	if errors.As(err, &sqliteErr) {
-		if errors.Is(sqliteErr.Code, sqlite3.ErrConstraint) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
-			return models.ErrUserExists
-		}
+	if isUniqueViolation(err) {
+		return models.ErrUserExists
 	}
 
 	return fmt.Errorf("db exec: %w", err)
 }
 
-// GetUser retrieves a user by login and password hash.
+// GetUser retrieves a user by case-insensitive login. The password must be verified by the caller.
 // Returns [models.ErrUserNotFound] if no matching user exists.
 func (r *Repository) GetUser(ctx context.Context, cmd models.GetUserCmd) (*models.User, error) {
 	const q = `
-		SELECT id, login, password_hash, created_at, updated_at
+		SELECT id, login, password_hash, role, password_change_required, created_at, updated_at
 		FROM users
-		WHERE login = ? AND password_hash = ?
+		WHERE lower(login) = lower(?)
 		`
 
 	var user models.User
-	if err := r.db.GetContext(ctx, &user, q, cmd.Login, cmd.PasswordHash); err != nil {
+	if err := r.rdb.GetContext(ctx, &user, r.db.Rebind(q), cmd.Login); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrUserNotFound
 		}
@@ -51,3 +53,189 @@ func (r *Repository) GetUser(ctx context.Context, cmd models.GetUserCmd) (*model
 
 	return &user, nil
 }
+
+// UpdatePasswordHash replaces the password hash of a user.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) UpdatePasswordHash(ctx context.Context, cmd models.UpdatePasswordHashCmd) error {
+	const q = `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`
+
+	res, err := r.exec(ctx, r.db.Rebind(q), cmd.PasswordHash, time.Now().UTC(), cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+// GetUserByID retrieves a user by ID.
+// Returns [models.ErrUserNotFound] if no matching user exists.
+func (r *Repository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	const q = `
+		SELECT id, login, password_hash, role, password_change_required, created_at, updated_at
+		FROM users
+		WHERE id = ?
+		`
+
+	var user models.User
+	if err := r.rdb.GetContext(ctx, &user, r.db.Rebind(q), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("db get: %w", err)
+	}
+
+	return &user, nil
+}
+
+// ListUsers retrieves all users ordered by login.
+func (r *Repository) ListUsers(ctx context.Context) ([]models.User, error) {
+	const q = `
+		SELECT id, login, password_hash, role, password_change_required, created_at, updated_at
+		FROM users
+		ORDER BY login
+		`
+
+	var users []models.User
+	if err := r.rdb.SelectContext(ctx, &users, q); err != nil {
+		return nil, fmt.Errorf("db select: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole changes the role of a user and revokes all their tokens, since access tokens carry the role:
+// refresh tokens are revoked, and access tokens issued before the change are rejected by [Repository.IsTokenRevoked].
+// Setting the current role is a no-op.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) SetUserRole(ctx context.Context, cmd models.SetUserRoleCmd) (*models.User, error) {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var role models.UserRole
+	if err := tx.GetContext(ctx, &role, tx.Rebind(`SELECT role FROM users WHERE id = ?`), cmd.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get role: %w", err)
+	}
+
+	if role != cmd.Role {
+		now := time.Now().UTC()
+		q := `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, tx.Rebind(q), cmd.Role, now, cmd.UserID); err != nil {
+			return nil, fmt.Errorf("update user: %w", err)
+		}
+		if err := r.revokeUserTokens(ctx, tx, cmd.UserID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return r.GetUserByID(ctx, cmd.UserID)
+}
+
+// ChangePassword replaces the password hash of a user, clears [models.User.PasswordChangeRequired] and revokes all their tokens:
+// refresh tokens are revoked, and access tokens issued before the change are rejected by [Repository.IsTokenRevoked].
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) ChangePassword(ctx context.Context, cmd models.ChangePasswordCmd) error {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	q := `
+		UPDATE users
+		SET password_hash = ?, password_change_required = FALSE, updated_at = ?
+		WHERE id = ?
+		`
+	res, err := tx.ExecContext(ctx, tx.Rebind(q), cmd.PasswordHash, now, cmd.UserID)
+	if err != nil {
+		return fmt.Errorf("update user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return models.ErrUserNotFound
+	}
+
+	if err := r.revokeUserTokens(ctx, tx, cmd.UserID, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// revokeUserTokens revokes the refresh tokens of a user and the access tokens issued before now within the transaction.
+func (r *Repository) revokeUserTokens(ctx context.Context, tx *sqlx.Tx, userID string, now time.Time) error {
+	// Access tokens carry the issue time in microseconds, as PostgreSQL stores it
+	q := `UPDATE users SET tokens_revoked_at = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, tx.Rebind(q), now.Truncate(time.Microsecond), userID); err != nil {
+		return fmt.Errorf("revoke access tokens: %w", err)
+	}
+
+	q = `UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, tx.Rebind(q), now, userID); err != nil {
+		return fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser deletes a user along with their expressions, tasks, callbacks, idempotency keys, refresh tokens,
+// API keys, retention policy, revoked access tokens and login throttle.
+// Returns [models.ErrUserNotFound] if the user doesn't exist.
+func (r *Repository) DeleteUser(ctx context.Context, userID string) error {
+	tx, err := r.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var login string
+	if err := tx.GetContext(ctx, &login, tx.Rebind(`DELETE FROM users WHERE id = ? RETURNING login`), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrUserNotFound
+		}
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	// The rows referencing the user are deleted by the foreign keys, like the expressions purged by the retention policies.
+	// These ones don't reference it.
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM revoked_tokens WHERE user_id = ?`), userID); err != nil {
+		return fmt.Errorf("delete revoked tokens: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM login_throttles WHERE key = ?`), models.LoginThrottleKey(login)); err != nil {
+		return fmt.Errorf("delete login throttle: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM outbox_events WHERE user_id = ?`), userID); err != nil {
+		return fmt.Errorf("delete outbox events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 
@@ -41,6 +42,16 @@ func TestRepository_Register(t *testing.T) {
 				require.ErrorIs(t, err, models.ErrUserExists)
 			},
 		},
+		{
+			name: "duplicate user with different case",
+			cmd: models.RegisterUserCmd{
+				Login:        "TestUser",
+				PasswordHash: "anotherpassword",
+			},
+			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.ErrorIs(t, err, models.ErrUserExists)
+			},
+		},
 		{
 			name: "register another user",
 			cmd: models.RegisterUserCmd{
@@ -89,8 +100,7 @@ func TestRepository_GetUser(t *testing.T) {
 		{
 			name: "get existing user",
 			cmd: models.GetUserCmd{
-				Login:        "user1",
-				PasswordHash: "password1hash",
+				Login: "user1",
 			},
 			want: func(user *models.User) bool {
 				return user != nil && user.Login == "user1" && user.PasswordHash == "password1hash"
@@ -98,24 +108,19 @@ func TestRepository_GetUser(t *testing.T) {
 			wantErr: require.NoError,
 		},
 		{
-			name: "wrong password",
+			name: "get existing user with different case",
 			cmd: models.GetUserCmd{
-				Login:        "user1",
-				PasswordHash: "wrongpassword",
+				Login: "USER2",
 			},
 			want: func(user *models.User) bool {
-				return user == nil
-			},
-			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
-				require.Error(t, err)
-				require.ErrorIs(t, err, models.ErrUserNotFound)
+				return user != nil && user.Login == "user2"
 			},
+			wantErr: require.NoError,
 		},
 		{
 			name: "user not found",
 			cmd: models.GetUserCmd{
-				Login:        "nonexistentuser",
-				PasswordHash: "anypassword",
+				Login: "nonexistentuser",
 			},
 			want: func(user *models.User) bool {
 				return user == nil
@@ -126,17 +131,16 @@ func TestRepository_GetUser(t *testing.T) {
 			},
 		},
 		{
-			name: "admin user from migrations",
+			name: "no admin user from migrations",
 			cmd: models.GetUserCmd{
-				Login:        "admin",
-				PasswordHash: "21232f297a57a5a743894a0e4a801fc3", // MD5 hash of "admin"
+				Login: "admin",
 			},
 			want: func(user *models.User) bool {
-				return user != nil && user.Login == "admin" &&
-					user.PasswordHash == "21232f297a57a5a743894a0e4a801fc3" &&
-					user.ID == "00000000000000000000"
+				return user == nil
+			},
+			wantErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.ErrorIs(t, err, models.ErrUserNotFound)
 			},
-			wantErr: require.NoError,
 		},
 	}
 
@@ -164,7 +168,7 @@ func TestRepository_RegisterAndGetUser(t *testing.T) {
 	require.NoError(t, err, "Failed to register new user")
 
 	// Try to get the user we just registered
-	getUserCmd := models.GetUserCmd(registerCmd)
+	getUserCmd := models.GetUserCmd{Login: registerCmd.Login}
 
 	user, err := repo.GetUser(ctx, getUserCmd)
 	require.NoError(t, err, "Failed to get registered user")
@@ -178,4 +182,177 @@ func TestRepository_RegisterAndGetUser(t *testing.T) {
 	// Verify timestamps were set
 	assert.False(t, user.CreatedAt.IsZero(), "CreatedAt should be set")
 	assert.False(t, user.UpdatedAt.IsZero(), "UpdatedAt should be set")
+
+	assert.Equal(t, models.UserRoleUser, user.Role)
+	assert.False(t, user.PasswordChangeRequired)
+}
+
+func TestRepository_Register_admin(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	err := repo.Register(ctx, models.RegisterUserCmd{
+		Login:                  "admin",
+		PasswordHash:           "$argon2id$hash",
+		Role:                   models.UserRoleAdmin,
+		PasswordChangeRequired: true,
+	})
+	require.NoError(t, err)
+
+	admin, err := repo.GetUser(ctx, models.GetUserCmd{Login: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleAdmin, admin.Role)
+	assert.True(t, admin.PasswordChangeRequired)
+
+	err = repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: admin.ID, PasswordHash: "$argon2id$new"})
+	require.NoError(t, err)
+
+	admin, err = repo.GetUserByID(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.False(t, admin.PasswordChangeRequired, "changing the password should clear the flag")
+}
+
+func TestRepository_UpdatePasswordHash(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+
+	err := repo.UpdatePasswordHash(ctx, models.UpdatePasswordHashCmd{UserID: userID, PasswordHash: "$argon2id$new"})
+	require.NoError(t, err)
+
+	updated, err := repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "$argon2id$new", updated.PasswordHash)
+
+	err = repo.UpdatePasswordHash(ctx, models.UpdatePasswordHashCmd{UserID: "nonexistent", PasswordHash: "$argon2id$new"})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestRepository_GetUserByID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+
+	user, err := repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	user, err = repo.GetUserByID(ctx, "nonexistent")
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.Nil(t, user)
+}
+
+func TestRepository_SetUserRole(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	err := repo.Register(ctx, models.RegisterUserCmd{Login: "admin", PasswordHash: "testhash", Role: models.UserRoleAdmin})
+	require.NoError(t, err)
+
+	userID := createTestUser(t, repo, ctx)
+	user, err := repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleUser, user.Role, "new users should have the user role")
+
+	user, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: userID, Role: models.UserRoleReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRoleReadOnly, user.Role)
+
+	users, err := repo.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "admin", users[0].Login)
+	assert.Equal(t, models.UserRoleReadOnly, users[1].Role)
+
+	_, err = repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: "nonexistent", Role: models.UserRoleAdmin})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestRepository_ChangePassword(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{
+		UserID:    userID,
+		TokenHash: "hash1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Likely within the same second as the change, tokens are told apart by their issue time in microseconds
+	issuedBefore := time.Now().Add(-time.Millisecond)
+	err = repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: userID, PasswordHash: "newhash"})
+	require.NoError(t, err)
+	issuedAfter := time.Now().Add(time.Millisecond)
+
+	user, err := repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "newhash", user.PasswordHash)
+
+	revoked, err := repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "old", UserID: userID, IssuedAt: issuedBefore})
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens issued before the password change should be revoked")
+
+	revoked, err = repo.IsTokenRevoked(ctx, models.IsTokenRevokedCmd{JTI: "new", UserID: userID, IssuedAt: issuedAfter})
+	require.NoError(t, err)
+	assert.False(t, revoked, "tokens issued after the password change should be valid")
+
+	_, err = repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    "hash1",
+		NewTokenHash: "hash2",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, models.ErrRefreshTokenReused, "refresh tokens should be revoked")
+
+	err = repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: "nonexistent", PasswordHash: "newhash"})
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestRepository_DeleteUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, ctx)
+	otherUserID := createTestUser(t, repo, ctx)
+	exprIDs := createTestExpressions(t, repo, ctx, userID, 2)
+	otherExprIDs := createTestExpressions(t, repo, ctx, otherUserID, 1)
+
+	_, err := repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{UserID: userID, TokenHash: "hash1", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{UserID: userID, Name: "ci", KeyHash: "key1", Scopes: models.APIKeyScopes{models.APIKeyScopeSubmit}})
+	require.NoError(t, err)
+	err = repo.RevokeToken(ctx, models.RevokeTokenCmd{JTI: "jti1", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	err = repo.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+
+	_, err = repo.GetUserByID(ctx, userID)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	for _, table := range []string{"expressions", "refresh_tokens", "api_keys", "revoked_tokens", "outbox_events"} {
+		var count int
+		require.NoError(t, db.Read.GetContext(ctx, &count, db.Read.Rebind(`SELECT COUNT(*) FROM `+table+` WHERE user_id = ?`), userID))
+		assert.Zero(t, count, table)
+	}
+	var count int
+	require.NoError(t, db.Read.GetContext(ctx, &count, db.Read.Rebind(`SELECT COUNT(*) FROM tasks WHERE expression_id IN (?, ?)`), exprIDs[0], exprIDs[1]))
+	assert.Zero(t, count, "tasks")
+
+	// Data of other users is kept
+	_, err = repo.GetExpression(ctx, otherUserID, otherExprIDs[0])
+	require.NoError(t, err)
+
+	err = repo.DeleteUser(ctx, userID)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
 }
@@ -11,12 +11,16 @@ import (
 	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 type Auth interface {
 	UnaryServerInterceptor() grpc.UnaryServerInterceptor
+	UnaryServerAuthorizationInterceptor() grpc.UnaryServerInterceptor
+	StreamServerInterceptor() grpc.StreamServerInterceptor
+	StreamServerAuthorizationInterceptor() grpc.StreamServerInterceptor
 }
 
 type GRPCServer struct {
@@ -30,10 +34,18 @@ func NewGRPCServer(conf *config.Config, auth Auth) *GRPCServer {
 	prometheus.MustRegister(srvMetrics)
 
 	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
 			srvMetrics.UnaryServerInterceptor(),
 			grpcLoggingUnaryServerInterceptor(),
 			auth.UnaryServerInterceptor(),
+			auth.UnaryServerAuthorizationInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			srvMetrics.StreamServerInterceptor(),
+			grpcLoggingStreamServerInterceptor(),
+			auth.StreamServerInterceptor(),
+			auth.StreamServerAuthorizationInterceptor(),
 		),
 	)
 	reflection.Register(srv)
@@ -69,22 +81,27 @@ func (s *GRPCServer) Start(ctx context.Context) error {
 }
 
 func grpcLoggingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	interceptorLogger := func() logging.Logger {
-		return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
-			log := slog.With(fields...)
-			switch lvl {
-			case logging.LevelDebug:
-				log.DebugContext(ctx, msg)
-			case logging.LevelInfo:
-				log.InfoContext(ctx, msg)
-			case logging.LevelWarn:
-				log.WarnContext(ctx, msg)
-			case logging.LevelError:
-				log.ErrorContext(ctx, msg)
-			default: // should not happen
-				panic(fmt.Sprintf("unknown level %v", lvl))
-			}
-		})
-	}
-	return logging.UnaryServerInterceptor(interceptorLogger())
+	return logging.UnaryServerInterceptor(grpcInterceptorLogger())
+}
+
+func grpcLoggingStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return logging.StreamServerInterceptor(grpcInterceptorLogger())
+}
+
+func grpcInterceptorLogger() logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		log := slog.With(fields...)
+		switch lvl {
+		case logging.LevelDebug:
+			log.DebugContext(ctx, msg)
+		case logging.LevelInfo:
+			log.InfoContext(ctx, msg)
+		case logging.LevelWarn:
+			log.WarnContext(ctx, msg)
+		case logging.LevelError:
+			log.ErrorContext(ctx, msg)
+		default: // should not happen
+			panic(fmt.Sprintf("unknown level %v", lvl))
+		}
+	})
 }
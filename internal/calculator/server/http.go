@@ -15,24 +15,39 @@ import (
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
+// JWKSProvider provides the public keys access tokens can be verified with.
+type JWKSProvider interface {
+	JWKS() []byte
+}
+
 type HTTPServer struct {
 	HTTP  *http.Server
 	GWMux *runtime.ServeMux
 	conf  *config.Config
 }
 
-func NewHTTPServer(conf *config.Config) *HTTPServer {
+func NewHTTPServer(conf *config.Config, jwks JWKSProvider) *HTTPServer {
 	s := &HTTPServer{conf: conf}
 
 	mux := http.NewServeMux()
 	s.setupDocsRoutes(mux)
+	s.setupJWKSRoute(mux, jwks)
 
 	gwmux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, rawStreamMarshaler{HTTPBodyMarshaler: &runtime.HTTPBodyMarshaler{
+			Marshaler: &runtime.JSONPb{
+				MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+				UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+			},
+		}}),
+		runtime.WithMetadata(idempotencyKeyMetadata),
 		runtime.WithForwardResponseOption(s.grpcGatewayResponseModifier),
 		runtime.WithErrorHandler(s.grpcGatewayErrorHandler),
 	)
@@ -40,8 +55,10 @@ func NewHTTPServer(conf *config.Config) *HTTPServer {
 
 	s.GWMux = gwmux
 	s.HTTP = &http.Server{
-		Addr:    conf.HTTPAddr,
-		Handler: mux,
+		Addr: conf.HTTPAddr,
+		Handler: otelhttp.NewHandler(mux, "http-server", otelhttp.WithSpanNameFormatter(
+			func(_ string, r *http.Request) string { return r.Method + " " + r.URL.Path },
+		)),
 	}
 	return s
 }
@@ -69,6 +86,16 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 	}
 }
 
+// rawStreamMarshaler is the default marshaler of the gateway, except that it doesn't delimit the messages
+// of server streams with newlines, so that files streamed as google.api.HttpBody chunks are written as they are.
+type rawStreamMarshaler struct {
+	*runtime.HTTPBodyMarshaler
+}
+
+func (rawStreamMarshaler) Delimiter() []byte {
+	return nil
+}
+
 const mdHeaderHTTPCode = "x-http-code"
 
 func WithHTTPResponseCode(ctx context.Context, code int) {
@@ -83,12 +110,21 @@ func (s *HTTPServer) setupDocsRoutes(mux *http.ServeMux) {
 	mux.Handle("/docs/", httpSwagger.Handler(httpSwagger.URL("/docs/openapi.json")))
 }
 
+func (s *HTTPServer) setupJWKSRoute(mux *http.ServeMux, jwks JWKSProvider) {
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write(jwks.JWKS())
+	})
+}
+
 func (s *HTTPServer) grpcGatewayResponseModifier(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
 	md, ok := runtime.ServerMetadataFromContext(ctx)
 	if !ok {
 		return nil
 	}
 
+	setIdempotentReplayHeader(md, w)
 	if code, ok := s.getHTTPStatusFromMetadata(md, w); ok {
 		w.WriteHeader(code)
 	}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// mdIdempotencyKey carries the Idempotency-Key header of HTTP requests.
+	mdIdempotencyKey = "idempotency-key"
+	// mdIdempotentReplay marks the responses replayed by idempotency key, sent as the Idempotent-Replayed header.
+	mdIdempotentReplay = "idempotent-replayed"
+)
+
+// IdempotencyKey returns the idempotency key passed in the request metadata, or an empty string.
+func IdempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get(mdIdempotencyKey); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// WithIdempotentReplay marks the response as a replay of the one to an earlier request with the same idempotency key.
+func WithIdempotentReplay(ctx context.Context) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(mdIdempotentReplay, "true"))
+}
+
+// idempotencyKeyMetadata passes the Idempotency-Key header of an HTTP request to the gRPC service.
+func idempotencyKeyMetadata(_ context.Context, r *http.Request) metadata.MD {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return metadata.Pairs(mdIdempotencyKey, key)
+	}
+	return nil
+}
+
+// setIdempotentReplayHeader replaces the gateway header of the replay mark with the Idempotent-Replayed header.
+func setIdempotentReplayHeader(md runtime.ServerMetadata, w http.ResponseWriter) {
+	if vals := md.HeaderMD.Get(mdIdempotentReplay); len(vals) > 0 {
+		delete(md.HeaderMD, mdIdempotentReplay)
+		delete(w.Header(), "Grpc-Metadata-Idempotent-Replayed")
+		w.Header().Set("Idempotent-Replayed", vals[0])
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/calculate", nil)
+	assert.Nil(t, idempotencyKeyMetadata(context.Background(), r))
+
+	r.Header.Set("Idempotency-Key", "key1")
+	ctx := metadata.NewIncomingContext(context.Background(), idempotencyKeyMetadata(context.Background(), r))
+	assert.Equal(t, "key1", IdempotencyKey(ctx))
+	assert.Empty(t, IdempotencyKey(context.Background()))
+}
+
+func TestSetIdempotentReplayHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Grpc-Metadata-Idempotent-Replayed", "true")
+	md := runtime.ServerMetadata{HeaderMD: metadata.Pairs(mdIdempotentReplay, "true")}
+
+	setIdempotentReplayHeader(md, w)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, w.Header().Get("Grpc-Metadata-Idempotent-Replayed"))
+	assert.Empty(t, md.HeaderMD.Get(mdIdempotentReplay))
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIP(t *testing.T) {
+	withPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234}})
+	}
+	withXFF := func(ctx context.Context, xff string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", xff))
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "no peer", ctx: context.Background(), want: ""},
+		{name: "direct grpc client", ctx: withPeer("192.0.2.1"), want: "192.0.2.1"},
+		{name: "x-forwarded-for of a direct client is ignored", ctx: withXFF(withPeer("192.0.2.1"), "198.51.100.1"), want: "192.0.2.1"},
+		{name: "gateway", ctx: withXFF(withPeer("127.0.0.1"), "198.51.100.1"), want: "198.51.100.1"},
+		{name: "gateway behind a proxy", ctx: withXFF(withPeer("::1"), "203.0.113.1, 198.51.100.1"), want: "198.51.100.1"},
+		{name: "loopback without x-forwarded-for", ctx: withPeer("127.0.0.1"), want: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClientIP(tt.ctx))
+		})
+	}
+}
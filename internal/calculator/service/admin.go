@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type AdminRepository interface {
+	ListUsers(context.Context) ([]models.User, error)
+	GetUserByID(context.Context, string) (*models.User, error)
+	SetUserRole(context.Context, models.SetUserRoleCmd) (*models.User, error)
+	ListExpressions(context.Context, string) ([]models.Expression, error)
+	ListAliveAgents(context.Context, time.Time) ([]models.Agent, error)
+	Backup(ctx context.Context, path string) error
+	GetRetentionPolicy(context.Context, string) (*models.RetentionPolicy, error)
+	SetRetentionPolicy(context.Context, models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error)
+	DeleteRetentionPolicy(context.Context, string) error
+}
+
+// backupChunkSize is the size of the chunks the backup file is streamed in.
+const backupChunkSize = 64 << 10
+
+// backupContentType is the media type of SQLite database files.
+const backupContentType = "application/vnd.sqlite3"
+
+type AdminService struct {
+	calculatorv1.UnimplementedAdminServiceServer
+	conf   *config.Config
+	log    *slog.Logger
+	repo   AdminRepository
+	events *StreamSink // nil unless the stream sink is enabled
+}
+
+func NewAdminService(conf *config.Config, log *slog.Logger, repo AdminRepository, events *StreamSink) *AdminService {
+	return &AdminService{
+		conf:   conf,
+		log:    logging.WithName(log, "admin-service"),
+		repo:   repo,
+		events: events,
+	}
+}
+
+func (s *AdminService) RegisterWith(srv *grpc.Server) {
+	calculatorv1.RegisterAdminServiceServer(srv, s)
+}
+
+func (s *AdminService) RegisterGRPCGateway(ctx context.Context, mux *runtime.ServeMux, clientOpts []grpc.DialOption) error {
+	return calculatorv1.RegisterAdminServiceHandlerFromEndpoint(ctx, mux, "localhost"+s.conf.GRPCAddr, clientOpts)
+}
+
+func (s *AdminService) ListUsers(ctx context.Context, _ *emptypb.Empty) (*calculatorv1.ListUsersResponse, error) {
+	users, err := s.repo.ListUsers(ctx)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("list users: %w", err))
+	}
+
+	resp := &calculatorv1.ListUsersResponse{Users: make([]*calculatorv1.User, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, mapUserToResponse(&user))
+	}
+	return resp, nil
+}
+
+func (s *AdminService) SetUserRole(ctx context.Context, req *calculatorv1.SetUserRoleRequest) (*calculatorv1.User, error) {
+	role := mapUserRoleToModel(req.Role)
+	if role == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported role %q", req.Role)
+	}
+	if req.Id == auth.MustUserIDFromContext(ctx) {
+		return nil, status.Error(codes.FailedPrecondition, "can't change own role")
+	}
+
+	user, err := s.repo.SetUserRole(ctx, models.SetUserRoleCmd{UserID: req.Id, Role: role})
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("set user role: %w", err))
+	}
+
+	s.log.InfoContext(ctx, "user role changed, tokens revoked", "user_id", user.ID, "role", user.Role, "by", auth.MustUserIDFromContext(ctx))
+	return mapUserToResponse(user), nil
+}
+
+func (s *AdminService) ListUserExpressions(
+	ctx context.Context,
+	req *calculatorv1.ListUserExpressionsRequest,
+) (*calculatorv1.ListExpressionsResponse, error) {
+	if _, err := s.repo.GetUserByID(ctx, req.UserId); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("get user: %w", err))
+	}
+
+	exprs, err := s.repo.ListExpressions(ctx, req.UserId)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("list expressions: %w", err))
+	}
+
+	resp := &calculatorv1.ListExpressionsResponse{Expressions: make([]*calculatorv1.Expression, 0, len(exprs))}
+	for _, expr := range exprs {
+		resp.Expressions = append(resp.Expressions, mapExpressionToExpressionResponse(&expr))
+	}
+	return resp, nil
+}
+
+func (s *AdminService) ListAgents(ctx context.Context, _ *emptypb.Empty) (*calculatorv1.ListAgentsResponse, error) {
+	agents, err := s.repo.ListAliveAgents(ctx, time.Time{})
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("list agents: %w", err))
+	}
+
+	resp := &calculatorv1.ListAgentsResponse{Agents: make([]*calculatorv1.Agent, 0, len(agents))}
+	for _, agent := range agents {
+		resp.Agents = append(resp.Agents, mapAgentToResponse(&agent))
+	}
+	return resp, nil
+}
+
+// GetRetentionPolicy returns the policy of the user, or the global one if the user has no policy of their own.
+func (s *AdminService) GetRetentionPolicy(
+	ctx context.Context,
+	req *calculatorv1.GetRetentionPolicyRequest,
+) (*calculatorv1.RetentionPolicy, error) {
+	if _, err := s.repo.GetUserByID(ctx, req.UserId); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("get user: %w", err))
+	}
+
+	policy, err := s.repo.GetRetentionPolicy(ctx, req.UserId)
+	if errors.Is(err, models.ErrRetentionPolicyNotFound) {
+		global := &models.RetentionPolicy{UserID: req.UserId, MaxAge: s.conf.RetentionMaxAge, MaxCount: s.conf.RetentionMaxCount}
+		return mapRetentionPolicyToResponse(global, false), nil
+	}
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("get retention policy: %w", err))
+	}
+	return mapRetentionPolicyToResponse(policy, true), nil
+}
+
+func (s *AdminService) SetRetentionPolicy(
+	ctx context.Context,
+	req *calculatorv1.SetRetentionPolicyRequest,
+) (*calculatorv1.RetentionPolicy, error) {
+	if req.MaxAge != nil && (!req.MaxAge.IsValid() || req.MaxAge.AsDuration() < 0) {
+		return nil, status.Error(codes.InvalidArgument, "max age must be a non-negative duration")
+	}
+	if req.MaxCount < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max count must be non-negative")
+	}
+
+	policy, err := s.repo.SetRetentionPolicy(ctx, models.SetRetentionPolicyCmd{
+		UserID:   req.UserId,
+		MaxAge:   req.MaxAge.AsDuration(),
+		MaxCount: int(req.MaxCount),
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("set retention policy: %w", err))
+	}
+
+	s.log.InfoContext(ctx, "retention policy set",
+		"user_id", policy.UserID, "max_age", policy.MaxAge, "max_count", policy.MaxCount, "by", auth.MustUserIDFromContext(ctx))
+	return mapRetentionPolicyToResponse(policy, true), nil
+}
+
+func (s *AdminService) DeleteRetentionPolicy(ctx context.Context, req *calculatorv1.DeleteRetentionPolicyRequest) (*emptypb.Empty, error) {
+	if err := s.repo.DeleteRetentionPolicy(ctx, req.UserId); err != nil {
+		if errors.Is(err, models.ErrRetentionPolicyNotFound) {
+			return nil, status.Error(codes.NotFound, "retention policy not found")
+		}
+		return nil, InternalError(fmt.Errorf("delete retention policy: %w", err))
+	}
+
+	s.log.InfoContext(ctx, "retention policy deleted", "user_id", req.UserId, "by", auth.MustUserIDFromContext(ctx))
+	return &emptypb.Empty{}, nil
+}
+
+// CreateBackup backs up the database into a temporary file and streams it.
+func (s *AdminService) CreateBackup(_ *emptypb.Empty, stream grpc.ServerStreamingServer[httpbody.HttpBody]) error {
+	ctx := stream.Context()
+
+	f, err := os.CreateTemp("", "calculator-backup-*.sqlite")
+	if err != nil {
+		return InternalError(fmt.Errorf("create backup file: %w", err))
+	}
+	_ = f.Close()
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	if err := s.repo.Backup(ctx, f.Name()); err != nil {
+		if errors.Is(err, database.ErrBackupUnsupported) {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return InternalError(fmt.Errorf("backup: %w", err))
+	}
+	s.log.InfoContext(ctx, "database backed up", "by", auth.MustUserIDFromContext(ctx))
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		return InternalError(fmt.Errorf("open backup file: %w", err))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	buf := make([]byte, backupChunkSize)
+	for first := true; ; first = false {
+		n, err := f.Read(buf)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return InternalError(fmt.Errorf("read backup file: %w", err))
+		}
+		chunk := &httpbody.HttpBody{Data: buf[:n]}
+		if first {
+			chunk.ContentType = backupContentType
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+// WatchExpressionEvents streams the expression lifecycle events delivered to the stream sink.
+func (s *AdminService) WatchExpressionEvents(_ *emptypb.Empty, stream grpc.ServerStreamingServer[calculatorv1.ExpressionEvent]) error {
+	if s.events == nil {
+		return status.Error(codes.FailedPrecondition, "expression events stream is disabled")
+	}
+
+	ctx := stream.Context()
+	s.log.InfoContext(ctx, "expression events watcher connected", "by", auth.MustUserIDFromContext(ctx))
+	if err := s.events.Watch(ctx, stream.Send); err != nil {
+		if errors.Is(err, errStreamWatcherTooSlow) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return err
+	}
+	return nil
+}
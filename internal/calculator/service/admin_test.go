@@ -0,0 +1,356 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAdminService_SetUserRole(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "admin-id", Login: "admin", Role: models.UserRoleAdmin})
+	createdAt := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockAdminRepository)
+		req        *calculatorv1.SetUserRoleRequest
+		want       *calculatorv1.User
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful role change",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetUserRole(mock.Anything, models.SetUserRoleCmd{UserID: "user-id", Role: models.UserRoleReadOnly}).
+					Return(&models.User{ID: "user-id", Login: "user", Role: models.UserRoleReadOnly, CreatedAt: createdAt}, nil)
+			},
+			req: &calculatorv1.SetUserRoleRequest{Id: "user-id", Role: calculatorv1.UserRole_USER_ROLE_READ_ONLY},
+			want: &calculatorv1.User{
+				Id:        "user-id",
+				Login:     "user",
+				Role:      calculatorv1.UserRole_USER_ROLE_READ_ONLY,
+				CreatedAt: timestamppb.New(createdAt),
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "unspecified role",
+			setupMocks: func(*mocks.MockAdminRepository) {},
+			req:        &calculatorv1.SetUserRoleRequest{Id: "user-id"},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "own role",
+			setupMocks: func(*mocks.MockAdminRepository) {},
+			req:        &calculatorv1.SetUserRoleRequest{Id: "admin-id", Role: calculatorv1.UserRole_USER_ROLE_USER},
+			wantCode:   codes.FailedPrecondition,
+		},
+		{
+			name: "user not found",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetUserRole(mock.Anything, mock.Anything).Return(nil, models.ErrUserNotFound)
+			},
+			req:      &calculatorv1.SetUserRoleRequest{Id: "nonexistent", Role: calculatorv1.UserRole_USER_ROLE_USER},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetUserRole(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			req:      &calculatorv1.SetUserRoleRequest{Id: "user-id", Role: calculatorv1.UserRole_USER_ROLE_USER},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockAdminRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+			got, err := svc.SetUserRole(ctx, tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdminService_ListUserExpressions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("lists expressions of another user", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{ID: "user-id"}, nil)
+		repo.EXPECT().ListExpressions(mock.Anything, "user-id").Return([]models.Expression{
+			{ID: "expr-1", UserID: "user-id", Expression: "2+2", Status: models.ExpressionStatusPending},
+		}, nil)
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+		got, err := svc.ListUserExpressions(ctx, &calculatorv1.ListUserExpressionsRequest{UserId: "user-id"})
+		require.NoError(t, err)
+		assert.Equal(t, &calculatorv1.ListExpressionsResponse{Expressions: []*calculatorv1.Expression{{
+			Id:         "expr-1",
+			Expression: "2+2",
+			Status:     calculatorv1.ExpressionStatus_EXPRESSION_STATUS_PENDING,
+		}}}, got)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().GetUserByID(mock.Anything, "nonexistent").Return(nil, models.ErrUserNotFound)
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+		_, err := svc.ListUserExpressions(ctx, &calculatorv1.ListUserExpressionsRequest{UserId: "nonexistent"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestAdminService_ListAgents(t *testing.T) {
+	lastSeenAt := time.Now().UTC()
+
+	repo := mocks.NewMockAdminRepository(t)
+	repo.EXPECT().ListAliveAgents(mock.Anything, time.Time{}).Return([]models.Agent{{
+		ID:         "agent-1",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+		Labels:     models.AgentLabels{"zone": "a"},
+		LastSeenAt: lastSeenAt,
+	}}, nil)
+	svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+	got, err := svc.ListAgents(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, &calculatorv1.ListAgentsResponse{Agents: []*calculatorv1.Agent{{
+		Id:         "agent-1",
+		Operations: []calculatorv1.TaskOperation{calculatorv1.TaskOperation_TASK_OPERATION_ADDITION},
+		Labels:     map[string]string{"zone": "a"},
+		LastSeenAt: timestamppb.New(lastSeenAt),
+	}}}, got)
+}
+
+func TestAdminService_GetRetentionPolicy(t *testing.T) {
+	ctx := context.Background()
+	conf := &config.Config{RetentionMaxAge: 30 * 24 * time.Hour}
+
+	t.Run("global policy", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{ID: "user-id"}, nil)
+		repo.EXPECT().GetRetentionPolicy(mock.Anything, "user-id").Return(nil, models.ErrRetentionPolicyNotFound)
+		svc := NewAdminService(conf, testutil.DiscardLogger(), repo, nil)
+
+		got, err := svc.GetRetentionPolicy(ctx, &calculatorv1.GetRetentionPolicyRequest{UserId: "user-id"})
+		require.NoError(t, err)
+		assert.Equal(t, &calculatorv1.RetentionPolicy{UserId: "user-id", MaxAge: durationpb.New(30 * 24 * time.Hour)}, got)
+	})
+
+	t.Run("user policy", func(t *testing.T) {
+		updatedAt := time.Now().UTC()
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{ID: "user-id"}, nil)
+		repo.EXPECT().GetRetentionPolicy(mock.Anything, "user-id").
+			Return(&models.RetentionPolicy{UserID: "user-id", MaxCount: 100, UpdatedAt: updatedAt}, nil)
+		svc := NewAdminService(conf, testutil.DiscardLogger(), repo, nil)
+
+		got, err := svc.GetRetentionPolicy(ctx, &calculatorv1.GetRetentionPolicyRequest{UserId: "user-id"})
+		require.NoError(t, err)
+		assert.Equal(t, &calculatorv1.RetentionPolicy{
+			UserId:    "user-id",
+			MaxCount:  100,
+			Custom:    true,
+			UpdatedAt: timestamppb.New(updatedAt),
+		}, got, "the user policy overrides the global one")
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().GetUserByID(mock.Anything, "nonexistent").Return(nil, models.ErrUserNotFound)
+		svc := NewAdminService(conf, testutil.DiscardLogger(), repo, nil)
+
+		_, err := svc.GetRetentionPolicy(ctx, &calculatorv1.GetRetentionPolicyRequest{UserId: "nonexistent"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestAdminService_SetRetentionPolicy(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "admin-id", Login: "admin", Role: models.UserRoleAdmin})
+	updatedAt := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockAdminRepository)
+		req        *calculatorv1.SetRetentionPolicyRequest
+		want       *calculatorv1.RetentionPolicy
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful policy change",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetRetentionPolicy(mock.Anything, models.SetRetentionPolicyCmd{UserID: "user-id", MaxAge: time.Hour, MaxCount: 10}).
+					Return(&models.RetentionPolicy{UserID: "user-id", MaxAge: time.Hour, MaxCount: 10, UpdatedAt: updatedAt}, nil)
+			},
+			req: &calculatorv1.SetRetentionPolicyRequest{UserId: "user-id", MaxAge: durationpb.New(time.Hour), MaxCount: 10},
+			want: &calculatorv1.RetentionPolicy{
+				UserId:    "user-id",
+				MaxAge:    durationpb.New(time.Hour),
+				MaxCount:  10,
+				Custom:    true,
+				UpdatedAt: timestamppb.New(updatedAt),
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "negative max age",
+			setupMocks: func(*mocks.MockAdminRepository) {},
+			req:        &calculatorv1.SetRetentionPolicyRequest{UserId: "user-id", MaxAge: durationpb.New(-time.Hour)},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "negative max count",
+			setupMocks: func(*mocks.MockAdminRepository) {},
+			req:        &calculatorv1.SetRetentionPolicyRequest{UserId: "user-id", MaxCount: -1},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "user not found",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetRetentionPolicy(mock.Anything, mock.Anything).Return(nil, models.ErrUserNotFound)
+			},
+			req:      &calculatorv1.SetRetentionPolicyRequest{UserId: "nonexistent", MaxCount: 10},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockAdminRepository) {
+				repo.EXPECT().SetRetentionPolicy(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			req:      &calculatorv1.SetRetentionPolicyRequest{UserId: "user-id", MaxCount: 10},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockAdminRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+			got, err := svc.SetRetentionPolicy(ctx, tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdminService_CreateBackup(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "admin-id", Login: "admin", Role: models.UserRoleAdmin})
+	data := bytes.Repeat([]byte("backup"), backupChunkSize/3)
+
+	t.Run("streams the backup", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().Backup(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, path string) error {
+			return os.WriteFile(path, data, 0o600)
+		})
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+		stream := &testBackupStream{ctx: ctx}
+		require.NoError(t, svc.CreateBackup(&emptypb.Empty{}, stream))
+		require.Len(t, stream.chunks, 2)
+		assert.Equal(t, backupContentType, stream.chunks[0].ContentType)
+		var got []byte
+		for _, chunk := range stream.chunks {
+			got = append(got, chunk.Data...)
+		}
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("unsupported database", func(t *testing.T) {
+		repo := mocks.NewMockAdminRepository(t)
+		repo.EXPECT().Backup(mock.Anything, mock.Anything).Return(database.ErrBackupUnsupported)
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), repo, nil)
+
+		err := svc.CreateBackup(&emptypb.Empty{}, &testBackupStream{ctx: ctx})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+type testBackupStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks []*httpbody.HttpBody
+}
+
+func (s *testBackupStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testBackupStream) Send(chunk *httpbody.HttpBody) error {
+	s.chunks = append(s.chunks, &httpbody.HttpBody{ContentType: chunk.ContentType, Data: bytes.Clone(chunk.Data)})
+	return nil
+}
+
+func TestAdminService_WatchExpressionEvents(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "admin-id", Login: "admin", Role: models.UserRoleAdmin})
+
+	t.Run("streams the events", func(t *testing.T) {
+		events := NewStreamSink()
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAdminRepository(t), events)
+
+		stream := &testEventStream{ctx: ctx, received: make(chan *calculatorv1.ExpressionEvent)}
+		done := make(chan error)
+		go func() {
+			done <- svc.WatchExpressionEvents(&emptypb.Empty{}, stream)
+		}()
+		event := &calculatorv1.ExpressionEvent{Id: "e1"}
+		require.Eventually(t, func() bool {
+			return events.Deliver(ctx, event) == nil
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, event, <-stream.received)
+		require.ErrorIs(t, <-done, assert.AnError, "until the stream breaks")
+	})
+
+	t.Run("stream disabled", func(t *testing.T) {
+		svc := NewAdminService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAdminRepository(t), nil)
+
+		err := svc.WatchExpressionEvents(&emptypb.Empty{}, &testEventStream{ctx: ctx})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+type testEventStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received chan *calculatorv1.ExpressionEvent
+}
+
+func (s *testEventStream) Context() context.Context {
+	return s.ctx
+}
+
+// Send passes the first event on and breaks the stream.
+func (s *testEventStream) Send(event *calculatorv1.ExpressionEvent) error {
+	s.received <- event
+	return assert.AnError
+}
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
@@ -20,22 +24,36 @@ import (
 )
 
 type AgentRepository interface {
-	GetPendingTask(context.Context) (*models.Task, error)
-	FinishTask(context.Context, models.FinishTaskCmd) error
+	UpsertAgent(context.Context, models.UpsertAgentCmd) error
+	GetPendingTask(context.Context, models.GetPendingTaskCmd) (*models.Task, error)
+	FinishTask(context.Context, models.FinishTaskCmd) (*models.Expression, error)
 }
 
 type AgentService struct {
 	calculatorv1.UnimplementedAgentServiceServer
-	conf *config.Config
-	log  *slog.Logger
-	repo AgentRepository
+	conf    *config.Config
+	log     *slog.Logger
+	repo    AgentRepository
+	metrics *Metrics
+
+	heartbeatsMu sync.Mutex
+	heartbeats   map[string]agentHeartbeat
+}
+
+// agentHeartbeat is the last registration of an agent stored by the service.
+type agentHeartbeat struct {
+	operations models.TaskOperations
+	labels     models.AgentLabels
+	at         time.Time
 }
 
-func NewAgentService(conf *config.Config, log *slog.Logger, repo AgentRepository) *AgentService {
+func NewAgentService(conf *config.Config, log *slog.Logger, repo AgentRepository, metrics *Metrics) *AgentService {
 	return &AgentService{
-		conf: conf,
-		log:  logging.WithName(log, "agent-service"),
-		repo: repo,
+		conf:       conf,
+		log:        logging.WithName(log, "agent-service"),
+		repo:       repo,
+		metrics:    metrics,
+		heartbeats: make(map[string]agentHeartbeat),
 	}
 }
 
@@ -47,14 +65,32 @@ func (s *AgentService) RegisterGRPCGateway(ctx context.Context, mux *runtime.Ser
 	return calculatorv1.RegisterAgentServiceHandlerFromEndpoint(ctx, mux, "localhost"+s.conf.GRPCAddr, clientOpts)
 }
 
-func (s *AgentService) GetTask(ctx context.Context, _ *emptypb.Empty) (*calculatorv1.GetTaskResponse, error) {
-	task, err := s.repo.GetPendingTask(ctx)
+func (s *AgentService) GetTask(ctx context.Context, req *calculatorv1.GetTaskRequest) (*calculatorv1.GetTaskResponse, error) {
+	ops := make(models.TaskOperations, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		if op := mapTaskOperationToModel(op); op != "" {
+			ops = append(ops, op)
+		}
+	}
+	// No operations means all of them, an agent advertising only unknown ones supports none
+	if len(req.Operations) > 0 && len(ops) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no supported operations")
+	}
+
+	if req.AgentId != "" {
+		if err := s.heartbeat(ctx, models.UpsertAgentCmd{ID: req.AgentId, Operations: ops, Labels: req.Labels}); err != nil {
+			return nil, InternalError(fmt.Errorf("upsert agent: %w", err))
+		}
+	}
+
+	task, err := s.repo.GetPendingTask(ctx, models.GetPendingTaskCmd{Operations: ops, AgentID: req.AgentId, Lease: s.conf.TaskLeaseTimeout})
 	if err != nil {
 		if errors.Is(err, models.ErrNoPendingTasks) {
 			return nil, status.Error(codes.NotFound, "no pending tasks")
 		}
 		return nil, InternalError(fmt.Errorf("get pending task: %w", err))
 	}
+	s.metrics.taskClaimed(task)
 
 	return &calculatorv1.GetTaskResponse{
 		Task: mapTaskToAgentTaskResponse(task),
@@ -65,23 +101,61 @@ func (s *AgentService) SubmitTaskResult(ctx context.Context, req *calculatorv1.S
 	var finishTaskCmd models.FinishTaskCmd
 	if math.IsNaN(req.Result) {
 		finishTaskCmd = models.FinishTaskCmd{
-			ID:     req.Id,
-			Status: models.TaskStatusFailed,
-			Result: 0,
+			ID:          req.Id,
+			Status:      models.TaskStatusFailed,
+			Result:      0,
+			ComputeTime: req.ComputeTime.AsDuration(),
+			AgentID:     req.AgentId,
 		}
 	} else {
 		finishTaskCmd = models.FinishTaskCmd{
-			ID:     req.Id,
-			Status: models.TaskStatusCompleted,
-			Result: req.Result,
+			ID:          req.Id,
+			Status:      models.TaskStatusCompleted,
+			Result:      req.Result,
+			ComputeTime: req.ComputeTime.AsDuration(),
+			AgentID:     req.AgentId,
 		}
 	}
 
-	if err := s.repo.FinishTask(ctx, finishTaskCmd); err != nil {
+	expr, err := s.repo.FinishTask(ctx, finishTaskCmd)
+	if err != nil {
 		if errors.Is(err, models.ErrTaskNotFound) {
 			return nil, status.Error(codes.NotFound, "task not found")
 		}
+		if errors.Is(err, models.ErrTaskNotClaimed) {
+			return nil, status.Error(codes.FailedPrecondition, "task claimed by another agent")
+		}
 		return nil, InternalError(fmt.Errorf("finish task: %w", err))
 	}
+	if expr != nil {
+		s.metrics.expressionFinished(expr)
+	}
 	return &emptypb.Empty{}, nil
 }
+
+// heartbeat registers the agent unless it was registered with the same operations and labels
+// less than [config.Config.AgentHeartbeatInterval] ago, so that polling agents don't write on every request.
+func (s *AgentService) heartbeat(ctx context.Context, cmd models.UpsertAgentCmd) error {
+	now := time.Now()
+
+	s.heartbeatsMu.Lock()
+	last, ok := s.heartbeats[cmd.ID]
+	s.heartbeatsMu.Unlock()
+	if ok && now.Sub(last.at) < s.conf.AgentHeartbeatInterval &&
+		slices.Equal(last.operations, cmd.Operations) && maps.Equal(last.labels, cmd.Labels) {
+		return nil
+	}
+
+	if err := s.repo.UpsertAgent(ctx, cmd); err != nil {
+		return err
+	}
+
+	s.heartbeatsMu.Lock()
+	defer s.heartbeatsMu.Unlock()
+	// Agents gone for longer than their TTL are forgotten, they register again when they come back
+	maps.DeleteFunc(s.heartbeats, func(_ string, h agentHeartbeat) bool {
+		return now.Sub(h.at) >= s.conf.AgentTTL
+	})
+	s.heartbeats[cmd.ID] = agentHeartbeat{operations: cmd.Operations, labels: cmd.Labels, at: now}
+	return nil
+}
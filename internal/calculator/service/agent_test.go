@@ -7,9 +7,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database/sqlz"
 
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/calc"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/memory"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
 	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
@@ -18,21 +22,24 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestAgentService_GetTask(t *testing.T) {
 	tests := []struct {
 		name       string
 		setupMocks func(repo *mocks.MockAgentRepository)
+		req        *calculatorv1.GetTaskRequest
 		want       *calculatorv1.GetTaskResponse
 		wantErr    assert.ErrorAssertionFunc
 	}{
 		{
 			name: "successfully retrieve pending task",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
-				repo.EXPECT().GetPendingTask(mock.Anything).Return(&models.Task{
+				repo.EXPECT().GetPendingTask(mock.Anything, models.GetPendingTaskCmd{Operations: models.TaskOperations{}, Lease: time.Minute}).Return(&models.Task{
 					ID:            "task1",
 					ExpressionID:  "expr1",
 					ParentTask1ID: sqlz.Some("parent1"),
@@ -44,6 +51,7 @@ func TestAgentService_GetTask(t *testing.T) {
 					Status:        models.TaskStatusPending,
 				}, nil)
 			},
+			req: &calculatorv1.GetTaskRequest{},
 			want: &calculatorv1.GetTaskResponse{
 				Task: &calculatorv1.Task{
 					Id:            "task1",
@@ -55,19 +63,78 @@ func TestAgentService_GetTask(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "register agent and retrieve task for supported operations",
+			setupMocks: func(repo *mocks.MockAgentRepository) {
+				ops := models.TaskOperations{models.TaskOperationAddition, models.TaskOperationDivision}
+				repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{
+					ID:         "agent1",
+					Operations: ops,
+					Labels:     models.AgentLabels{"gpu": "false"},
+				}).Return(nil)
+				repo.EXPECT().GetPendingTask(mock.Anything, models.GetPendingTaskCmd{Operations: ops, AgentID: "agent1", Lease: time.Minute}).Return(&models.Task{
+					ID:            "task1",
+					Arg1:          sqlz.Some[float64](6),
+					Arg2:          sqlz.Some[float64](3),
+					Operation:     models.TaskOperationDivision,
+					OperationTime: time.Second,
+				}, nil)
+			},
+			req: &calculatorv1.GetTaskRequest{
+				AgentId: "agent1",
+				Operations: []calculatorv1.TaskOperation{
+					calculatorv1.TaskOperation_TASK_OPERATION_ADDITION,
+					calculatorv1.TaskOperation_TASK_OPERATION_DIVISION,
+				},
+				Labels: map[string]string{"gpu": "false"},
+			},
+			want: &calculatorv1.GetTaskResponse{
+				Task: &calculatorv1.Task{
+					Id:            "task1",
+					Arg1:          6,
+					Arg2:          3,
+					Operation:     calculatorv1.TaskOperation_TASK_OPERATION_DIVISION,
+					OperationTime: durationpb.New(time.Second),
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:       "only unknown operations",
+			setupMocks: func(repo *mocks.MockAgentRepository) {},
+			req: &calculatorv1.GetTaskRequest{
+				AgentId:    "agent1",
+				Operations: []calculatorv1.TaskOperation{calculatorv1.TaskOperation_TASK_OPERATION_UNSPECIFIED, 42},
+			},
+			want: nil,
+			wantErr: func(t assert.TestingT, err error, _ ...any) bool {
+				return assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "agent registration error",
+			setupMocks: func(repo *mocks.MockAgentRepository) {
+				repo.EXPECT().UpsertAgent(mock.Anything, mock.Anything).Return(assert.AnError)
+			},
+			req:     &calculatorv1.GetTaskRequest{AgentId: "agent1"},
+			want:    nil,
+			wantErr: assert.Error,
+		},
 		{
 			name: "no pending tasks",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
-				repo.EXPECT().GetPendingTask(mock.Anything).Return(nil, models.ErrNoPendingTasks)
+				repo.EXPECT().GetPendingTask(mock.Anything, mock.Anything).Return(nil, models.ErrNoPendingTasks)
 			},
+			req:     &calculatorv1.GetTaskRequest{},
 			want:    nil,
 			wantErr: assert.Error,
 		},
 		{
 			name: "repository error",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
-				repo.EXPECT().GetPendingTask(mock.Anything).Return(nil, assert.AnError)
+				repo.EXPECT().GetPendingTask(mock.Anything, mock.Anything).Return(nil, assert.AnError)
 			},
+			req:     &calculatorv1.GetTaskRequest{},
 			want:    nil,
 			wantErr: assert.Error,
 		},
@@ -79,17 +146,63 @@ func TestAgentService_GetTask(t *testing.T) {
 			repo := mocks.NewMockAgentRepository(t)
 
 			tt.setupMocks(repo)
-			svc := NewAgentService(&config.Config{}, testutil.DiscardLogger(), repo)
+			conf := &config.Config{TaskLeaseTimeout: time.Minute}
+			svc := NewAgentService(conf, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
 
-			got, err := svc.GetTask(ctx, &emptypb.Empty{})
-			if !tt.wantErr(t, err, fmt.Sprintf("GetTask(%v, %v)", ctx, &emptypb.Empty{})) {
+			got, err := svc.GetTask(ctx, tt.req)
+			if !tt.wantErr(t, err, fmt.Sprintf("GetTask(%v, %v)", ctx, tt.req)) {
 				return
 			}
-			assert.Equalf(t, tt.want, got, "GetTask(%v, %v)", ctx, &emptypb.Empty{})
+			assert.Equalf(t, tt.want, got, "GetTask(%v, %v)", ctx, tt.req)
 		})
 	}
 }
 
+func TestAgentService_GetTask_busyDatabase(t *testing.T) {
+	repo := mocks.NewMockAgentRepository(t)
+	repo.EXPECT().GetPendingTask(mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: %w", database.ErrBusy, assert.AnError))
+	svc := NewAgentService(&config.Config{}, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	_, err := svc.GetTask(context.Background(), &calculatorv1.GetTaskRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "agents retry the request")
+}
+
+func TestAgentService_GetTask_heartbeat(t *testing.T) {
+	add := []calculatorv1.TaskOperation{calculatorv1.TaskOperation_TASK_OPERATION_ADDITION}
+	gpu := map[string]string{"gpu": "true"}
+
+	repo := mocks.NewMockAgentRepository(t)
+	repo.EXPECT().GetPendingTask(mock.Anything, mock.Anything).Return(nil, models.ErrNoPendingTasks)
+	repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{ID: "agent1", Operations: models.TaskOperations{}}).Return(nil).Once()
+	repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{
+		ID:         "agent1",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+	}).Return(nil).Once()
+	repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{
+		ID:         "agent1",
+		Operations: models.TaskOperations{models.TaskOperationAddition},
+		Labels:     models.AgentLabels{"gpu": "true"},
+	}).Return(nil).Once()
+	repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{ID: "agent2", Operations: models.TaskOperations{}}).Return(assert.AnError).Once()
+	repo.EXPECT().UpsertAgent(mock.Anything, models.UpsertAgentCmd{ID: "agent2", Operations: models.TaskOperations{}}).Return(nil).Once()
+
+	conf := &config.Config{AgentTTL: time.Minute, AgentHeartbeatInterval: time.Minute}
+	svc := NewAgentService(conf, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	for _, req := range []*calculatorv1.GetTaskRequest{
+		{AgentId: "agent1"},
+		{AgentId: "agent1"},                               // throttled
+		{AgentId: "agent1", Operations: add},              // the operations changed
+		{AgentId: "agent1", Operations: add},              // throttled
+		{AgentId: "agent1", Operations: add, Labels: gpu}, // the labels changed
+		{AgentId: "agent1", Operations: add, Labels: gpu}, // throttled
+		{AgentId: "agent2"},                               // failed
+		{AgentId: "agent2"},                               // retried
+	} {
+		_, _ = svc.GetTask(context.Background(), req)
+	}
+}
+
 func TestAgentService_SubmitTaskResult(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -104,7 +217,13 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 					ID:     "task1",
 					Status: models.TaskStatusCompleted,
 					Result: 42.0,
-				}).Return(nil)
+				}).Return(&models.Expression{
+					ID:        "expr1",
+					Status:    models.ExpressionStatusCompleted,
+					Result:    sqlz.Some(42.0),
+					CreatedAt: time.Now().Add(-time.Second),
+					UpdatedAt: time.Now(),
+				}, nil)
 			},
 			req: &calculatorv1.SubmitTaskResultRequest{
 				Id:     "task1",
@@ -112,6 +231,25 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "successfully submit task result with compute time and agent",
+			setupMocks: func(repo *mocks.MockAgentRepository) {
+				repo.EXPECT().FinishTask(mock.Anything, models.FinishTaskCmd{
+					ID:          "task1",
+					Status:      models.TaskStatusCompleted,
+					Result:      42.0,
+					ComputeTime: 15 * time.Millisecond,
+					AgentID:     "agent1",
+				}).Return(nil, nil)
+			},
+			req: &calculatorv1.SubmitTaskResultRequest{
+				Id:          "task1",
+				Result:      42.0,
+				ComputeTime: durationpb.New(15 * time.Millisecond),
+				AgentId:     "agent1",
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "successfully submit failed task result",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
@@ -119,7 +257,7 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 					ID:     "task1",
 					Status: models.TaskStatusFailed,
 					Result: 0,
-				}).Return(nil)
+				}).Return(nil, nil)
 			},
 			req: &calculatorv1.SubmitTaskResultRequest{
 				Id:     "task1",
@@ -130,7 +268,7 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 		{
 			name: "task not found",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
-				repo.EXPECT().FinishTask(mock.Anything, mock.Anything).Return(models.ErrTaskNotFound)
+				repo.EXPECT().FinishTask(mock.Anything, mock.Anything).Return(nil, models.ErrTaskNotFound)
 			},
 			req: &calculatorv1.SubmitTaskResultRequest{
 				Id:     "nonexistent",
@@ -138,10 +276,29 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "task claimed by another agent",
+			setupMocks: func(repo *mocks.MockAgentRepository) {
+				repo.EXPECT().FinishTask(mock.Anything, models.FinishTaskCmd{
+					ID:      "task1",
+					Status:  models.TaskStatusCompleted,
+					Result:  10.0,
+					AgentID: "agent-1",
+				}).Return(nil, models.ErrTaskNotClaimed)
+			},
+			req: &calculatorv1.SubmitTaskResultRequest{
+				Id:      "task1",
+				Result:  10.0,
+				AgentId: "agent-1",
+			},
+			wantErr: func(t assert.TestingT, err error, msgAndArgs ...any) bool {
+				return assert.Equal(t, codes.FailedPrecondition, status.Code(err), msgAndArgs...)
+			},
+		},
 		{
 			name: "repository error",
 			setupMocks: func(repo *mocks.MockAgentRepository) {
-				repo.EXPECT().FinishTask(mock.Anything, mock.Anything).Return(assert.AnError)
+				repo.EXPECT().FinishTask(mock.Anything, mock.Anything).Return(nil, assert.AnError)
 			},
 			req: &calculatorv1.SubmitTaskResultRequest{
 				Id:     "task1",
@@ -157,10 +314,50 @@ func TestAgentService_SubmitTaskResult(t *testing.T) {
 			repo := mocks.NewMockAgentRepository(t)
 
 			tt.setupMocks(repo)
-			svc := NewAgentService(&config.Config{}, testutil.DiscardLogger(), repo)
+			svc := NewAgentService(&config.Config{}, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
 
 			_, err := svc.SubmitTaskResult(ctx, tt.req)
 			tt.wantErr(t, err, fmt.Sprintf("SubmitTaskResult(%v, %v)", ctx, tt.req))
 		})
 	}
 }
+
+func TestAgentService_memoryRepository(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "user-login"})
+
+	repo := memory.New()
+	metrics := NewMetrics(repo)
+	conf := &config.Config{OperationTimeMode: config.OperationTimeModeZero, AgentTTL: time.Minute}
+	calcSvc := NewCalculatorService(conf, testutil.DiscardLogger(), calc.NewCalculator(), repo, metrics)
+	agentSvc := NewAgentService(conf, testutil.DiscardLogger(), repo, metrics)
+
+	created, err := calcSvc.Calculate(ctx, &calculatorv1.CalculateRequest{Expression: "(2+3)*4-6/2"})
+	require.NoError(t, err)
+
+	apply := map[calculatorv1.TaskOperation]func(a, b float64) float64{
+		calculatorv1.TaskOperation_TASK_OPERATION_ADDITION:       func(a, b float64) float64 { return a + b },
+		calculatorv1.TaskOperation_TASK_OPERATION_SUBTRACTION:    func(a, b float64) float64 { return a - b },
+		calculatorv1.TaskOperation_TASK_OPERATION_MULTIPLICATION: func(a, b float64) float64 { return a * b },
+		calculatorv1.TaskOperation_TASK_OPERATION_DIVISION:       func(a, b float64) float64 { return a / b },
+	}
+	for {
+		resp, err := agentSvc.GetTask(ctx, &calculatorv1.GetTaskRequest{AgentId: "agent"})
+		if status.Code(err) == codes.NotFound {
+			break
+		}
+		require.NoError(t, err)
+		task := resp.Task
+		_, err = agentSvc.SubmitTaskResult(ctx, &calculatorv1.SubmitTaskResultRequest{
+			Id:          task.Id,
+			Result:      apply[task.Operation](task.Arg1, task.Arg2),
+			ComputeTime: durationpb.New(time.Millisecond),
+			AgentId:     "agent",
+		})
+		require.NoError(t, err)
+	}
+
+	got, err := calcSvc.GetExpression(ctx, &calculatorv1.GetExpressionRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, calculatorv1.ExpressionStatus_EXPRESSION_STATUS_COMPLETED, got.Expression.Status)
+	assert.Equal(t, 17.0, got.Expression.Result)
+}
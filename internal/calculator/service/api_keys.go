@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/server"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *UserService) CreateAPIKey(ctx context.Context, req *calculatorv1.CreateAPIKeyRequest) (*calculatorv1.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	var scopes models.APIKeyScopes
+	for _, scope := range req.Scopes {
+		v := mapAPIKeyScopeToModel(scope)
+		if v == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported scope %q", scope)
+		}
+		if !scopes.Has(v) {
+			scopes = append(scopes, v)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one scope is required")
+	}
+	slices.Sort(scopes)
+
+	var expiresAt sql.Null[time.Time]
+	if req.ExpiresAt != nil {
+		if err := req.ExpiresAt.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid expires_at: %v", err)
+		}
+		expiresAt = sql.Null[time.Time]{V: req.ExpiresAt.AsTime(), Valid: true}
+		if !expiresAt.V.After(time.Now()) {
+			return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+		}
+	}
+
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, InternalError(err)
+	}
+
+	apiKey, err := s.repo.CreateAPIKey(ctx, models.CreateAPIKeyCmd{
+		UserID:    auth.MustUserIDFromContext(ctx),
+		Name:      name,
+		KeyHash:   auth.HashAPIKey(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("create api key: %w", err))
+	}
+
+	server.WithHTTPResponseCode(ctx, http.StatusCreated)
+	return &calculatorv1.CreateAPIKeyResponse{ApiKey: mapAPIKeyToResponse(apiKey), Key: key}, nil
+}
+
+func (s *UserService) ListAPIKeys(ctx context.Context, _ *emptypb.Empty) (*calculatorv1.ListAPIKeysResponse, error) {
+	keys, err := s.repo.ListAPIKeys(ctx, auth.MustUserIDFromContext(ctx))
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("list api keys: %w", err))
+	}
+
+	resp := &calculatorv1.ListAPIKeysResponse{ApiKeys: make([]*calculatorv1.APIKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, mapAPIKeyToResponse(&key))
+	}
+	return resp, nil
+}
+
+func (s *UserService) RevokeAPIKey(ctx context.Context, req *calculatorv1.RevokeAPIKeyRequest) (*emptypb.Empty, error) {
+	if err := s.repo.RevokeAPIKey(ctx, models.RevokeAPIKeyCmd{
+		UserID: auth.MustUserIDFromContext(ctx),
+		ID:     req.Id,
+	}); err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return nil, status.Error(codes.NotFound, "api key not found")
+		}
+		return nil, InternalError(fmt.Errorf("revoke api key: %w", err))
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestUserService_CreateAPIKey(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockUserRepository)
+		req        *calculatorv1.CreateAPIKeyRequest
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful creation",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().CreateAPIKey(mock.Anything, mock.MatchedBy(func(cmd models.CreateAPIKeyCmd) bool {
+					return cmd.UserID == "user-id" && cmd.Name == "ci" && cmd.KeyHash != "" &&
+						assert.ObjectsAreEqual(models.APIKeyScopes{models.APIKeyScopeReadOnly, models.APIKeyScopeSubmit}, cmd.Scopes) &&
+						cmd.ExpiresAt.Valid && cmd.ExpiresAt.V.Equal(expiresAt)
+				})).RunAndReturn(func(_ context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error) {
+					return &models.APIKey{ID: "key-id", Name: cmd.Name, Scopes: cmd.Scopes, ExpiresAt: cmd.ExpiresAt}, nil
+				})
+			},
+			req: &calculatorv1.CreateAPIKeyRequest{
+				Name: " ci ",
+				Scopes: []calculatorv1.APIKeyScope{
+					calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT,
+					calculatorv1.APIKeyScope_API_KEY_SCOPE_READ_ONLY,
+					calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT,
+				},
+				ExpiresAt: timestamppb.New(expiresAt),
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "empty name",
+			setupMocks: func(*mocks.MockUserRepository) {},
+			req: &calculatorv1.CreateAPIKeyRequest{
+				Scopes: []calculatorv1.APIKeyScope{calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:       "no scopes",
+			setupMocks: func(*mocks.MockUserRepository) {},
+			req:        &calculatorv1.CreateAPIKeyRequest{Name: "ci"},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "unspecified scope",
+			setupMocks: func(*mocks.MockUserRepository) {},
+			req: &calculatorv1.CreateAPIKeyRequest{
+				Name:   "ci",
+				Scopes: []calculatorv1.APIKeyScope{calculatorv1.APIKeyScope_API_KEY_SCOPE_UNSPECIFIED},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:       "expiration in the past",
+			setupMocks: func(*mocks.MockUserRepository) {},
+			req: &calculatorv1.CreateAPIKeyRequest{
+				Name:      "ci",
+				Scopes:    []calculatorv1.APIKeyScope{calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT},
+				ExpiresAt: timestamppb.New(time.Now().Add(-time.Minute)),
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().CreateAPIKey(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			req: &calculatorv1.CreateAPIKeyRequest{
+				Name:   "ci",
+				Scopes: []calculatorv1.APIKeyScope{calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT},
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+			got, err := svc.CreateAPIKey(ctx, tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK {
+				return
+			}
+			assert.True(t, strings.HasPrefix(got.Key, auth.APIKeyPrefix))
+			assert.Equal(t, "key-id", got.ApiKey.Id)
+			assert.Equal(t, []calculatorv1.APIKeyScope{
+				calculatorv1.APIKeyScope_API_KEY_SCOPE_READ_ONLY,
+				calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT,
+			}, got.ApiKey.Scopes)
+			assert.NotNil(t, got.ApiKey.ExpiresAt)
+			assert.Nil(t, got.ApiKey.RevokedAt)
+		})
+	}
+}
+
+func TestUserService_ListAPIKeys(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	createdAt := time.Now().UTC()
+
+	repo := mocks.NewMockUserRepository(t)
+	repo.EXPECT().ListAPIKeys(mock.Anything, "user-id").Return([]models.APIKey{
+		{ID: "key-1", Name: "ci", Scopes: models.APIKeyScopes{models.APIKeyScopeSubmit}, CreatedAt: createdAt},
+	}, nil)
+	svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+	got, err := svc.ListAPIKeys(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, &calculatorv1.ListAPIKeysResponse{ApiKeys: []*calculatorv1.APIKey{{
+		Id:        "key-1",
+		Name:      "ci",
+		Scopes:    []calculatorv1.APIKeyScope{calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT},
+		CreatedAt: timestamppb.New(createdAt),
+	}}}, got)
+}
+
+func TestUserService_RevokeAPIKey(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+
+	tests := []struct {
+		name     string
+		repoErr  error
+		wantCode codes.Code
+	}{
+		{name: "successful revocation", wantCode: codes.OK},
+		{name: "key not found", repoErr: models.ErrAPIKeyNotFound, wantCode: codes.NotFound},
+		{name: "repository error", repoErr: assert.AnError, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+			repo.EXPECT().RevokeAPIKey(mock.Anything, models.RevokeAPIKeyCmd{UserID: "user-id", ID: "key-id"}).Return(tt.repoErr)
+			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+			_, err := svc.RevokeAPIKey(ctx, &calculatorv1.RevokeAPIKeyRequest{Id: "key-id"})
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+)
+
+const (
+	backupFilePrefix     = "calculator-"
+	backupFileExt        = ".sqlite"
+	backupFileTimeLayout = "20060102T150405Z"
+)
+
+type BackupRepository interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// BackupScheduler backs up the database into [config.Config.BackupDir] every [config.Config.BackupInterval]
+// and keeps [config.Config.BackupRetention] latest backups.
+type BackupScheduler struct {
+	conf *config.Config
+	log  *slog.Logger
+	repo BackupRepository
+}
+
+func NewBackupScheduler(conf *config.Config, log *slog.Logger, repo BackupRepository) *BackupScheduler {
+	return &BackupScheduler{
+		conf: conf,
+		log:  logging.WithName(log, "backup-scheduler"),
+		repo: repo,
+	}
+}
+
+// Start runs the scheduled backups. It blocks until the context is canceled.
+func (s *BackupScheduler) Start(ctx context.Context) error {
+	if err := os.MkdirAll(s.conf.BackupDir, 0o750); err != nil {
+		return fmt.Errorf("create backup dir: %w", err)
+	}
+
+	ticker := time.NewTicker(s.conf.BackupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			path, err := s.backup(ctx, now)
+			if err != nil {
+				s.log.ErrorContext(ctx, "scheduled backup failed", "error", err)
+				continue
+			}
+			s.log.InfoContext(ctx, "database backed up", "path", path)
+		}
+	}
+}
+
+// backup backs up the database into a file named after the time and removes the outdated backups.
+func (s *BackupScheduler) backup(ctx context.Context, now time.Time) (string, error) {
+	path := filepath.Join(s.conf.BackupDir, backupFilePrefix+now.UTC().Format(backupFileTimeLayout)+backupFileExt)
+	if err := s.repo.Backup(ctx, path); err != nil {
+		return "", fmt.Errorf("backup: %w", err)
+	}
+
+	// The names sort in the order of the backup times
+	backups, err := filepath.Glob(filepath.Join(s.conf.BackupDir, backupFilePrefix+"*"+backupFileExt))
+	if err != nil {
+		return "", fmt.Errorf("list backups: %w", err)
+	}
+	slices.Sort(backups)
+	for _, old := range backups[:max(0, len(backups)-s.conf.BackupRetention)] {
+		if err := os.Remove(old); err != nil {
+			return "", fmt.Errorf("remove outdated backup: %w", err)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupScheduler_backup(t *testing.T) {
+	dir := t.TempDir()
+	conf := &config.Config{BackupDir: dir, BackupRetention: 2}
+
+	repo := mocks.NewMockBackupRepository(t)
+	repo.EXPECT().Backup(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, path string) error {
+		return os.WriteFile(path, []byte("backup"), 0o600)
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.sqlite"), nil, 0o600))
+	s := NewBackupScheduler(conf, testutil.DiscardLogger(), repo)
+
+	start := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		path, err := s.backup(context.Background(), start.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{
+		"calculator-20250501T140000Z.sqlite",
+		"calculator-20250501T150000Z.sqlite",
+		"other.sqlite",
+	}, names, "the latest backups are kept, other files are left alone")
+}
+
+func TestBackupScheduler_backupError(t *testing.T) {
+	repo := mocks.NewMockBackupRepository(t)
+	repo.EXPECT().Backup(mock.Anything, mock.Anything).Return(assert.AnError)
+	s := NewBackupScheduler(&config.Config{BackupDir: t.TempDir(), BackupRetention: 1}, testutil.DiscardLogger(), repo)
+
+	_, err := s.backup(context.Background(), time.Now())
+	require.ErrorIs(t, err, assert.AnError)
+}
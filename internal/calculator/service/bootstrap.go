@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+)
+
+type BootstrapRepository interface {
+	Register(ctx context.Context, cmd models.RegisterUserCmd) error
+	ListUsers(ctx context.Context) ([]models.User, error)
+}
+
+// BootstrapAdmin creates an admin with a one-time password, which must be changed on first login.
+// A random password is generated if none is provided. Returns the password of the created admin.
+func BootstrapAdmin(ctx context.Context, conf *config.Config, repo BootstrapRepository, login, password string) (string, error) {
+	if problems := auth.ValidateLogin(login); len(problems) > 0 {
+		return "", fmt.Errorf("invalid login: %s", strings.Join(problems, "; "))
+	}
+
+	policy := passwordPolicy(conf)
+	if password == "" {
+		var err error
+		if password, err = policy.Generate(); err != nil {
+			return "", err
+		}
+	} else if problems := policy.Validate(password, login); len(problems) > 0 {
+		return "", fmt.Errorf("invalid password: %s", strings.Join(problems, "; "))
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+
+	if err := repo.Register(ctx, models.RegisterUserCmd{
+		Login:                  login,
+		PasswordHash:           hash,
+		Role:                   models.UserRoleAdmin,
+		PasswordChangeRequired: true,
+	}); err != nil {
+		if errors.Is(err, models.ErrUserExists) {
+			return "", fmt.Errorf("user %q already exists", login)
+		}
+		return "", fmt.Errorf("register admin: %w", err)
+	}
+	return password, nil
+}
+
+// HasAdmin reports whether at least one admin exists.
+func HasAdmin(ctx context.Context, repo BootstrapRepository) (bool, error) {
+	users, err := repo.ListUsers(ctx)
+	if err != nil {
+		return false, fmt.Errorf("list users: %w", err)
+	}
+	return slices.ContainsFunc(users, func(u models.User) bool { return u.Role == models.UserRoleAdmin }), nil
+}
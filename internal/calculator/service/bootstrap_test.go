@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	registered := func(login, password string) func(models.RegisterUserCmd) bool {
+		return func(cmd models.RegisterUserCmd) bool {
+			ok, _, err := auth.VerifyPassword(cmd.PasswordHash, password)
+			return cmd.Login == login && ok && err == nil &&
+				cmd.Role == models.UserRoleAdmin && cmd.PasswordChangeRequired
+		}
+	}
+
+	tests := []struct {
+		name       string
+		login      string
+		password   string
+		setupMocks func(repo *mocks.MockBootstrapRepository)
+		wantErr    bool
+	}{
+		{
+			name:     "provided password",
+			login:    "admin",
+			password: "admin-pass1",
+			setupMocks: func(repo *mocks.MockBootstrapRepository) {
+				repo.EXPECT().Register(mock.Anything, mock.MatchedBy(registered("admin", "admin-pass1"))).Return(nil)
+			},
+		},
+		{
+			name:  "generated password",
+			login: "admin",
+			setupMocks: func(repo *mocks.MockBootstrapRepository) {
+				repo.EXPECT().Register(mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:       "weak password",
+			login:      "admin",
+			password:   "admin",
+			setupMocks: func(*mocks.MockBootstrapRepository) {},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid login",
+			login:      "-",
+			setupMocks: func(*mocks.MockBootstrapRepository) {},
+			wantErr:    true,
+		},
+		{
+			name:  "user exists",
+			login: "admin",
+			setupMocks: func(repo *mocks.MockBootstrapRepository) {
+				repo.EXPECT().Register(mock.Anything, mock.Anything).Return(models.ErrUserExists)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockBootstrapRepository(t)
+			tt.setupMocks(repo)
+
+			password, err := BootstrapAdmin(context.Background(), testConfig(), repo, tt.login, tt.password)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, passwordPolicy(testConfig()).Validate(password, tt.login))
+			if tt.password != "" {
+				assert.Equal(t, tt.password, password)
+			}
+		})
+	}
+}
+
+func TestHasAdmin(t *testing.T) {
+	repo := mocks.NewMockBootstrapRepository(t)
+	repo.EXPECT().ListUsers(mock.Anything).Return([]models.User{{Role: models.UserRoleUser}}, nil).Once()
+	repo.EXPECT().ListUsers(mock.Anything).Return([]models.User{{Role: models.UserRoleUser}, {Role: models.UserRoleAdmin}}, nil).Once()
+
+	ok, err := HasAdmin(context.Background(), repo)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = HasAdmin(context.Background(), repo)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
@@ -15,6 +19,7 @@ import (
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/server"
 	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+	"github.com/belo4ya/edu-final-calculate-api/internal/tracing"
 
 	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
 
@@ -22,9 +27,16 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+const (
+	maxCallbackURLLength    = 2048
+	maxCallbackSecretLength = 256
+	maxIdempotencyKeyLength = 255
+)
+
 type (
 	Calculator interface {
 		Parse(string) ([]calctypes.Token, error)
@@ -36,23 +48,44 @@ type (
 		ListExpressions(context.Context, string) ([]models.Expression, error)
 		GetExpression(context.Context, string, string) (*models.Expression, error)
 		ListExpressionTasks(context.Context, string, string) ([]models.Task, error)
+		ListExpressionTaskEvents(context.Context, string, string) ([]models.TaskEvent, error)
+		GetExpressionCallback(context.Context, string, string) (*models.Callback, error)
+		ListExpressionCallbackAttempts(context.Context, string, string) ([]models.CallbackAttempt, error)
+		GetIdempotencyKey(context.Context, models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error)
+		GetOperationCosts(context.Context, time.Time) (map[models.TaskOperation]time.Duration, error)
 	}
 )
 
 type CalculatorService struct {
 	calculatorv1.UnimplementedCalculatorServiceServer
-	conf *config.Config
-	log  *slog.Logger
-	calc Calculator
-	repo CalculatorRepository
+	conf    *config.Config
+	log     *slog.Logger
+	calc    Calculator
+	repo    CalculatorRepository
+	metrics *Metrics
+
+	costsMu sync.Mutex
+	costs   map[models.TaskOperation]time.Duration
+	costsAt time.Time
+
+	callbackAddrs *callbackAddrPolicy
 }
 
-func NewCalculatorService(conf *config.Config, log *slog.Logger, calc Calculator, repo CalculatorRepository) *CalculatorService {
+func NewCalculatorService(
+	conf *config.Config,
+	log *slog.Logger,
+	calc Calculator,
+	repo CalculatorRepository,
+	metrics *Metrics,
+) *CalculatorService {
 	return &CalculatorService{
-		conf: conf,
-		log:  logging.WithName(log, "calculator-service"),
-		calc: calc,
-		repo: repo,
+		conf:    conf,
+		log:     logging.WithName(log, "calculator-service"),
+		calc:    calc,
+		repo:    repo,
+		metrics: metrics,
+
+		callbackAddrs: newCallbackAddrPolicy(conf),
 	}
 }
 
@@ -68,6 +101,27 @@ func (s *CalculatorService) Calculate(
 	ctx context.Context,
 	req *calculatorv1.CalculateRequest,
 ) (*calculatorv1.CalculateResponse, error) {
+	if err := s.validateCallback(ctx, req.CallbackUrl, req.CallbackSecret); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	key, err := idempotencyKey(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	userID := auth.MustUserIDFromContext(ctx)
+	var idempotency *models.CreateExpressionCmdIdempotencyKey
+	if key != "" {
+		idempotency = &models.CreateExpressionCmdIdempotencyKey{
+			Key:         key,
+			RequestHash: hashCalculateRequest(req),
+			ExpiresAt:   time.Now().Add(s.conf.IdempotencyKeyTTL),
+		}
+		if resp, err := s.replayCalculate(ctx, userID, idempotency); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
 	parsed, err := s.calc.Parse(req.Expression)
 	if err != nil {
 		if errors.Is(err, calctypes.ErrInvalidExpr) {
@@ -79,26 +133,43 @@ func (s *CalculatorService) Calculate(
 
 	tasks := s.calc.Schedule(parsed)
 
+	opTimes, err := s.getOperationTimes(ctx)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("get operation times: %w", err))
+	}
+
 	createExpr := models.CreateExpressionCmd{
-		Expression: req.Expression,
-		Tasks:      make([]models.CreateExpressionCmdTask, 0, len(tasks)),
+		Expression:     req.Expression,
+		Tasks:          make([]models.CreateExpressionCmdTask, 0, len(tasks)),
+		TraceParent:    tracing.TraceParent(ctx),
+		CallbackURL:    req.CallbackUrl,
+		CallbackSecret: req.CallbackSecret,
+		IdempotencyKey: idempotency,
 	}
 	for _, t := range tasks {
+		op := s.mapTaskOperation(t.Operation)
 		createExpr.Tasks = append(createExpr.Tasks, models.CreateExpressionCmdTask{
 			ID:            t.ID,
 			ParentTask1ID: t.ParentTask1ID,
 			ParentTask2ID: t.ParentTask2ID,
 			Arg1:          t.Arg1,
 			Arg2:          t.Arg2,
-			Operation:     s.mapTaskOperation(t.Operation),
-			OperationTime: s.getTaskOperationTime(t.Operation),
+			Operation:     op,
+			OperationTime: opTimes[op],
 		})
 	}
 
-	id, err := s.repo.CreateExpression(ctx, auth.MustUserIDFromContext(ctx), createExpr)
+	id, err := s.repo.CreateExpression(ctx, userID, createExpr)
 	if err != nil {
+		if errors.Is(err, models.ErrIdempotencyKeyExists) {
+			// A concurrent request with the same key has created the expression first
+			if resp, err := s.replayCalculate(ctx, userID, idempotency); resp != nil || err != nil {
+				return resp, err
+			}
+		}
 		return nil, InternalError(fmt.Errorf("create expression: %w", err))
 	}
+	s.metrics.expressionSubmitted()
 
 	server.WithHTTPResponseCode(ctx, http.StatusCreated)
 	return &calculatorv1.CalculateResponse{Id: id}, nil
@@ -155,6 +226,53 @@ func (s *CalculatorService) ListExpressionTasks(
 	return resp, nil
 }
 
+func (s *CalculatorService) ListExpressionTaskEvents(
+	ctx context.Context,
+	req *calculatorv1.ListExpressionTaskEventsRequest,
+) (*calculatorv1.ListExpressionTaskEventsResponse, error) {
+	events, err := s.repo.ListExpressionTaskEvents(ctx, auth.MustUserIDFromContext(ctx), req.Id)
+	if err != nil {
+		if errors.Is(err, models.ErrExpressionNotFound) {
+			return nil, status.Error(codes.NotFound, "expression not found")
+		}
+		return nil, InternalError(fmt.Errorf("list expression task events: %w", err))
+	}
+
+	resp := &calculatorv1.ListExpressionTaskEventsResponse{
+		Events: make([]*calculatorv1.ListExpressionTaskEventsResponse_TaskEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, mapTaskEventToResponse(&event))
+	}
+	return resp, nil
+}
+
+func (s *CalculatorService) GetExpressionCallback(
+	ctx context.Context,
+	req *calculatorv1.GetExpressionCallbackRequest,
+) (*calculatorv1.ExpressionCallback, error) {
+	userID := auth.MustUserIDFromContext(ctx)
+	callback, err := s.repo.GetExpressionCallback(ctx, userID, req.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrExpressionNotFound):
+			return nil, status.Error(codes.NotFound, "expression not found")
+		case errors.Is(err, models.ErrCallbackNotFound):
+			return nil, status.Error(codes.NotFound, "expression has no callback")
+		}
+		return nil, InternalError(fmt.Errorf("get expression callback: %w", err))
+	}
+
+	attempts, err := s.repo.ListExpressionCallbackAttempts(ctx, userID, req.Id)
+	if err != nil {
+		if errors.Is(err, models.ErrExpressionNotFound) {
+			return nil, status.Error(codes.NotFound, "expression not found")
+		}
+		return nil, InternalError(fmt.Errorf("list expression callback attempts: %w", err))
+	}
+	return mapCallbackToResponse(callback, attempts), nil
+}
+
 func (s *CalculatorService) mapTaskOperation(op string) models.TaskOperation {
 	switch op {
 	case "+":
@@ -170,17 +288,134 @@ func (s *CalculatorService) mapTaskOperation(op string) models.TaskOperation {
 	}
 }
 
-func (s *CalculatorService) getTaskOperationTime(op string) time.Duration {
-	ms := 0
-	switch op {
-	case "+":
-		ms = s.conf.TimeAdditionMs
-	case "-":
-		ms = s.conf.TimeSubtractionMs
-	case "*":
-		ms = s.conf.TimeMultiplicationMs
-	case "/":
-		ms = s.conf.TimeDivisionMs
+// replayCalculate returns the response to the earlier request with the idempotency key,
+// or nil if there was no such request within the TTL.
+func (s *CalculatorService) replayCalculate(
+	ctx context.Context,
+	userID string,
+	idempotency *models.CreateExpressionCmdIdempotencyKey,
+) (*calculatorv1.CalculateResponse, error) {
+	key, err := s.repo.GetIdempotencyKey(ctx, models.GetIdempotencyKeyCmd{UserID: userID, Key: idempotency.Key, Now: time.Now()})
+	if err != nil {
+		if errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+			return nil, nil
+		}
+		return nil, InternalError(fmt.Errorf("get idempotency key: %w", err))
+	}
+	if key.RequestHash != idempotency.RequestHash {
+		server.WithHTTPResponseCode(ctx, http.StatusUnprocessableEntity)
+		return nil, status.Error(codes.InvalidArgument, "idempotency key has been used with a different request")
+	}
+
+	server.WithIdempotentReplay(ctx)
+	server.WithHTTPResponseCode(ctx, http.StatusCreated)
+	return &calculatorv1.CalculateResponse{Id: key.ExpressionID}, nil
+}
+
+// idempotencyKey returns the idempotency key of the request passed either in the field or in the metadata.
+func idempotencyKey(ctx context.Context, req *calculatorv1.CalculateRequest) (string, error) {
+	key := req.IdempotencyKey
+	if mdKey := server.IdempotencyKey(ctx); mdKey != "" {
+		if key != "" && key != mdKey {
+			return "", errors.New("idempotency key of the header and of the request differ")
+		}
+		key = mdKey
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return "", fmt.Errorf("idempotency key must be at most %d characters long", maxIdempotencyKeyLength)
+	}
+	for _, c := range key {
+		if c < ' ' || c > '~' {
+			return "", errors.New("idempotency key must consist of printable ASCII characters")
+		}
+	}
+	return key, nil
+}
+
+// hashCalculateRequest returns the SHA-256 of the request, which the replays with its idempotency key must match.
+func hashCalculateRequest(req *calculatorv1.CalculateRequest) string {
+	req = proto.CloneOf(req)
+	req.IdempotencyKey = ""
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req) // a valid message always marshals
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// validateCallback checks that the callback URL, if any, is an absolute http(s) URL
+// whose host resolves to the addresses allowed to the callbacks.
+func (s *CalculatorService) validateCallback(ctx context.Context, callbackURL, secret string) error {
+	if callbackURL == "" {
+		if secret != "" {
+			return errors.New("callback secret requires a callback url")
+		}
+		return nil
+	}
+	if len(callbackURL) > maxCallbackURLLength {
+		return fmt.Errorf("callback url must be at most %d characters long", maxCallbackURLLength)
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("callback url must be an absolute http(s) url")
+	}
+	if len(secret) > maxCallbackSecretLength {
+		return fmt.Errorf("callback secret must be at most %d characters long", maxCallbackSecretLength)
+	}
+	if err := s.callbackAddrs.checkHost(ctx, u.Hostname()); err != nil {
+		if errors.Is(err, errCallbackAddrDenied) {
+			return err
+		}
+		return errors.New("callback url host can't be resolved")
+	}
+	return nil
+}
+
+// getOperationTimes returns the expected processing time of each operation
+// according to the configured operation time mode.
+func (s *CalculatorService) getOperationTimes(ctx context.Context) (map[models.TaskOperation]time.Duration, error) {
+	if s.conf.OperationTimeMode == config.OperationTimeModeZero {
+		return map[models.TaskOperation]time.Duration{}, nil
+	}
+
+	times := map[models.TaskOperation]time.Duration{
+		models.TaskOperationAddition:       time.Duration(s.conf.TimeAdditionMs) * time.Millisecond,
+		models.TaskOperationSubtraction:    time.Duration(s.conf.TimeSubtractionMs) * time.Millisecond,
+		models.TaskOperationMultiplication: time.Duration(s.conf.TimeMultiplicationMs) * time.Millisecond,
+		models.TaskOperationDivision:       time.Duration(s.conf.TimeDivisionMs) * time.Millisecond,
+	}
+	if s.conf.OperationTimeMode != config.OperationTimeModeCost {
+		return times, nil
+	}
+
+	costs, err := s.getOperationCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for op, cost := range costs {
+		times[op] = cost
+	}
+	return times, nil
+}
+
+// getOperationCosts returns the measured operation costs, calculated at most once
+// per [config.Config.OperationCostCacheTTL]. The returned map must not be modified.
+func (s *CalculatorService) getOperationCosts(ctx context.Context) (map[models.TaskOperation]time.Duration, error) {
+	// Held during the calculation, so that concurrent requests wait for it instead of repeating it
+	s.costsMu.Lock()
+	defer s.costsMu.Unlock()
+
+	now := time.Now()
+	if s.costs != nil && now.Sub(s.costsAt) < s.conf.OperationCostCacheTTL {
+		return s.costs, nil
+	}
+
+	costs, err := s.repo.GetOperationCosts(ctx, now.Add(-s.conf.OperationCostWindow))
+	if err != nil {
+		return nil, fmt.Errorf("get operation costs: %w", err)
+	}
+	if costs == nil {
+		costs = map[models.TaskOperation]time.Duration{}
 	}
-	return time.Duration(ms) * time.Millisecond
+	s.costs, s.costsAt = costs, now
+	return costs, nil
 }
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database/sqlz"
@@ -18,7 +20,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestCalculatorService_Calculate(t *testing.T) {
@@ -30,6 +38,24 @@ func TestCalculatorService_Calculate(t *testing.T) {
 		TimeSubtractionMs:    1000,
 		TimeMultiplicationMs: 1000,
 		TimeDivisionMs:       1000,
+		IdempotencyKeyTTL:    time.Hour,
+	}
+	lookupHost := func(_ context.Context, host string) ([]netip.Addr, error) {
+		if host == "internal.example.com" {
+			return []netip.Addr{netip.MustParseAddr("10.0.0.1")}, nil
+		}
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+	}
+	withKeyHeader := func(ctx context.Context, key string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("idempotency-key", key))
+	}
+	setupParse := func(calc *mocks.MockCalculator) {
+		calc.EXPECT().Parse("1+2").Return([]calctypes.Token{
+			calctypes.NewToken(1),
+			calctypes.NewToken(2),
+			calctypes.NewToken("+"),
+		}, nil)
+		calc.EXPECT().Schedule(mock.Anything).Return([]calctypes.Task{{ID: "task1", Arg1: 1, Arg2: 2, Operation: "+"}})
 	}
 
 	type args struct {
@@ -74,6 +100,163 @@ func TestCalculatorService_Calculate(t *testing.T) {
 			want:    &calculatorv1.CalculateResponse{Id: "expr123"},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "calculation with callback",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
+				calc.EXPECT().Parse("1+2").Return([]calctypes.Token{
+					calctypes.NewToken(1),
+					calctypes.NewToken(2),
+					calctypes.NewToken("+"),
+				}, nil)
+				calc.EXPECT().Schedule(mock.Anything).Return([]calctypes.Task{{ID: "task1", Arg1: 1, Arg2: 2, Operation: "+"}})
+				repo.EXPECT().CreateExpression(mock.Anything,
+					userID,
+					mock.MatchedBy(func(cmd models.CreateExpressionCmd) bool {
+						return cmd.CallbackURL == "https://example.com/hook" && cmd.CallbackSecret == "s3cret"
+					})).Return("expr123", nil)
+			},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{
+					Expression:     "1+2",
+					CallbackUrl:    "https://example.com/hook",
+					CallbackSecret: "s3cret",
+				},
+			},
+			want:    &calculatorv1.CalculateResponse{Id: "expr123"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:       "invalid callback url",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{
+					Expression:  "1+2",
+					CallbackUrl: "ftp://example.com/hook",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:       "callback url resolving to a private address",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{
+					Expression:  "1+2",
+					CallbackUrl: "https://internal.example.com/hook",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:       "callback secret without url",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{
+					Expression:     "1+2",
+					CallbackSecret: "s3cret",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "calculation with idempotency key",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetIdempotencyKey(mock.Anything, mock.MatchedBy(func(cmd models.GetIdempotencyKeyCmd) bool {
+					return cmd.UserID == userID && cmd.Key == "key1"
+				})).Return(nil, models.ErrIdempotencyKeyNotFound)
+				setupParse(calc)
+				repo.EXPECT().CreateExpression(mock.Anything,
+					userID,
+					mock.MatchedBy(func(cmd models.CreateExpressionCmd) bool {
+						return cmd.IdempotencyKey != nil && cmd.IdempotencyKey.Key == "key1" &&
+							cmd.IdempotencyKey.RequestHash == hashCalculateRequest(&calculatorv1.CalculateRequest{Expression: "1+2"}) &&
+							time.Until(cmd.IdempotencyKey.ExpiresAt) > 59*time.Minute
+					})).Return("expr123", nil)
+			},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{Expression: "1+2", IdempotencyKey: "key1"},
+			},
+			want:    &calculatorv1.CalculateResponse{Id: "expr123"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "replay by idempotency key",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetIdempotencyKey(mock.Anything, mock.Anything).Return(&models.IdempotencyKey{
+					UserID:       userID,
+					Key:          "key1",
+					ExpressionID: "expr-first",
+					RequestHash:  hashCalculateRequest(&calculatorv1.CalculateRequest{Expression: "1+2"}),
+				}, nil)
+			},
+			args: args{
+				ctx: withKeyHeader(authCtx, "key1"),
+				req: &calculatorv1.CalculateRequest{Expression: "1+2"},
+			},
+			want:    &calculatorv1.CalculateResponse{Id: "expr-first"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "idempotency key reused with different request",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetIdempotencyKey(mock.Anything, mock.Anything).Return(&models.IdempotencyKey{
+					ExpressionID: "expr-first",
+					RequestHash:  hashCalculateRequest(&calculatorv1.CalculateRequest{Expression: "2+2"}),
+				}, nil)
+			},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{Expression: "1+2", IdempotencyKey: "key1"},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "concurrent request with the same idempotency key",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetIdempotencyKey(mock.Anything, mock.Anything).Return(nil, models.ErrIdempotencyKeyNotFound).Once()
+				setupParse(calc)
+				repo.EXPECT().CreateExpression(mock.Anything, mock.Anything, mock.Anything).Return("", models.ErrIdempotencyKeyExists)
+				repo.EXPECT().GetIdempotencyKey(mock.Anything, mock.Anything).Return(&models.IdempotencyKey{
+					ExpressionID: "expr-concurrent",
+					RequestHash:  hashCalculateRequest(&calculatorv1.CalculateRequest{Expression: "1+2"}),
+				}, nil).Once()
+			},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{Expression: "1+2", IdempotencyKey: "key1"},
+			},
+			want:    &calculatorv1.CalculateResponse{Id: "expr-concurrent"},
+			wantErr: assert.NoError,
+		},
+		{
+			name:       "idempotency keys of header and field differ",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {},
+			args: args{
+				ctx: withKeyHeader(authCtx, "key1"),
+				req: &calculatorv1.CalculateRequest{Expression: "1+2", IdempotencyKey: "key2"},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name:       "invalid idempotency key",
+			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {},
+			args: args{
+				ctx: authCtx,
+				req: &calculatorv1.CalculateRequest{Expression: "1+2", IdempotencyKey: "key\n1"},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
 		{
 			name: "invalid expression",
 			setupMocks: func(calc *mocks.MockCalculator, repo *mocks.MockCalculatorRepository) {
@@ -134,7 +317,8 @@ func TestCalculatorService_Calculate(t *testing.T) {
 			repo := mocks.NewMockCalculatorRepository(t)
 
 			tt.setupMocks(calc, repo)
-			svc := NewCalculatorService(conf, testutil.DiscardLogger(), calc, repo)
+			svc := NewCalculatorService(conf, testutil.DiscardLogger(), calc, repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+			svc.callbackAddrs.lookup = lookupHost
 
 			got, err := svc.Calculate(tt.args.ctx, tt.args.req)
 			if !tt.wantErr(t, err, fmt.Sprintf("Calculate(%v, %v)", tt.args.ctx, tt.args.req)) {
@@ -216,7 +400,7 @@ func TestCalculatorService_ListExpressions(t *testing.T) {
 			repo := mocks.NewMockCalculatorRepository(t)
 
 			tt.setupMocks(calc, repo)
-			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), calc, repo)
+			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), calc, repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
 
 			got, err := svc.ListExpressions(ctx, &emptypb.Empty{})
 			if !tt.wantErr(t, err, fmt.Sprintf("ListExpressions(%v, %v)", ctx, &emptypb.Empty{})) {
@@ -324,7 +508,7 @@ func TestCalculatorService_GetExpression(t *testing.T) {
 			repo := mocks.NewMockCalculatorRepository(t)
 
 			tt.setupMocks(calc, repo)
-			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), calc, repo)
+			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), calc, repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
 
 			got, err := svc.GetExpression(ctx, tt.args.req)
 			if !tt.wantErr(t, err, fmt.Sprintf("GetExpression(%v, %v)", ctx, tt.args.req)) {
@@ -334,3 +518,290 @@ func TestCalculatorService_GetExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculatorService_ListExpressionTaskEvents(t *testing.T) {
+	userID := "user-id"
+	authCtx := auth.WithContext(context.Background(), auth.UserInfo{ID: userID, Login: "user-login"})
+	createdAt := time.Now().UTC()
+
+	type args struct {
+		req *calculatorv1.ListExpressionTaskEventsRequest
+	}
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockCalculatorRepository)
+		args       args
+		want       *calculatorv1.ListExpressionTaskEventsResponse
+		wantCode   codes.Code
+	}{
+		{
+			name: "events found",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().ListExpressionTaskEvents(mock.Anything, userID, "expr1").Return([]models.TaskEvent{
+					{ID: "event1", TaskID: "task1", ExpressionID: "expr1", Type: models.TaskEventCreated, CreatedAt: createdAt},
+					{ID: "event2", TaskID: "task1", ExpressionID: "expr1", Type: models.TaskEventClaimed, AgentID: sqlz.Some("agent1"), CreatedAt: createdAt},
+					{
+						ID:           "event3",
+						TaskID:       "task1",
+						ExpressionID: "expr1",
+						Type:         models.TaskEventCompleted,
+						AgentID:      sqlz.Some("agent1"),
+						Result:       sqlz.Some[float64](3),
+						CreatedAt:    createdAt,
+					},
+				}, nil)
+			},
+			args: args{req: &calculatorv1.ListExpressionTaskEventsRequest{Id: "expr1"}},
+			want: &calculatorv1.ListExpressionTaskEventsResponse{
+				Events: []*calculatorv1.ListExpressionTaskEventsResponse_TaskEvent{
+					{
+						Id:        "event1",
+						TaskId:    "task1",
+						Type:      calculatorv1.TaskEventType_TASK_EVENT_TYPE_CREATED,
+						CreatedAt: timestamppb.New(createdAt),
+					},
+					{
+						Id:        "event2",
+						TaskId:    "task1",
+						Type:      calculatorv1.TaskEventType_TASK_EVENT_TYPE_CLAIMED,
+						AgentId:   "agent1",
+						CreatedAt: timestamppb.New(createdAt),
+					},
+					{
+						Id:        "event3",
+						TaskId:    "task1",
+						Type:      calculatorv1.TaskEventType_TASK_EVENT_TYPE_COMPLETED,
+						AgentId:   "agent1",
+						Result:    3,
+						CreatedAt: timestamppb.New(createdAt),
+					},
+				},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "expression not found",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().ListExpressionTaskEvents(mock.Anything, userID, "non-existent").Return(nil, models.ErrExpressionNotFound)
+			},
+			args:     args{req: &calculatorv1.ListExpressionTaskEventsRequest{Id: "non-existent"}},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().ListExpressionTaskEvents(mock.Anything, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			args:     args{req: &calculatorv1.ListExpressionTaskEventsRequest{Id: "expr1"}},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockCalculatorRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockCalculator(t), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+			got, err := svc.ListExpressionTaskEvents(authCtx, tt.args.req)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCalculatorService_GetExpressionCallback(t *testing.T) {
+	userID := "user-id"
+	authCtx := auth.WithContext(context.Background(), auth.UserInfo{ID: userID, Login: "user-login"})
+	createdAt := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockCalculatorRepository)
+		want       *calculatorv1.ExpressionCallback
+		wantCode   codes.Code
+	}{
+		{
+			name: "callback found",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetExpressionCallback(mock.Anything, userID, "expr1").Return(&models.Callback{
+					ExpressionID:  "expr1",
+					URL:           "https://example.com/hook",
+					Secret:        "s3cret",
+					Status:        models.CallbackStatusPending,
+					Attempts:      1,
+					NextAttemptAt: sqlz.Some(createdAt.Add(time.Second)),
+				}, nil)
+				repo.EXPECT().ListExpressionCallbackAttempts(mock.Anything, userID, "expr1").Return([]models.CallbackAttempt{
+					{
+						ExpressionID: "expr1",
+						Attempt:      1,
+						StatusCode:   sqlz.Some(503),
+						Error:        sqlz.Some("unexpected response status"),
+						Duration:     time.Millisecond,
+						CreatedAt:    createdAt,
+					},
+				}, nil)
+			},
+			want: &calculatorv1.ExpressionCallback{
+				Url:           "https://example.com/hook",
+				Signed:        true,
+				Status:        calculatorv1.CallbackStatus_CALLBACK_STATUS_PENDING,
+				Attempts:      1,
+				NextAttemptAt: timestamppb.New(createdAt.Add(time.Second)),
+				Deliveries: []*calculatorv1.ExpressionCallback_Delivery{
+					{
+						Attempt:    1,
+						StatusCode: 503,
+						Error:      "unexpected response status",
+						Duration:   durationpb.New(time.Millisecond),
+						CreatedAt:  timestamppb.New(createdAt),
+					},
+				},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "expression not found",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetExpressionCallback(mock.Anything, userID, "expr1").Return(nil, models.ErrExpressionNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "no callback",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetExpressionCallback(mock.Anything, userID, "expr1").Return(nil, models.ErrCallbackNotFound)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetExpressionCallback(mock.Anything, userID, "expr1").Return(&models.Callback{}, nil)
+				repo.EXPECT().ListExpressionCallbackAttempts(mock.Anything, userID, "expr1").Return(nil, assert.AnError)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockCalculatorRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewCalculatorService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockCalculator(t), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+			got, err := svc.GetExpressionCallback(authCtx, &calculatorv1.GetExpressionCallbackRequest{Id: "expr1"})
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCalculatorService_getOperationTimes(t *testing.T) {
+	conf := config.Config{
+		OperationCostWindow:  time.Hour,
+		TimeAdditionMs:       1000,
+		TimeSubtractionMs:    2000,
+		TimeMultiplicationMs: 3000,
+		TimeDivisionMs:       4000,
+	}
+	fixed := map[models.TaskOperation]time.Duration{
+		models.TaskOperationAddition:       time.Second,
+		models.TaskOperationSubtraction:    2 * time.Second,
+		models.TaskOperationMultiplication: 3 * time.Second,
+		models.TaskOperationDivision:       4 * time.Second,
+	}
+
+	tests := []struct {
+		name       string
+		mode       string
+		setupMocks func(repo *mocks.MockCalculatorRepository)
+		want       map[models.TaskOperation]time.Duration
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			name:       "fixed mode",
+			mode:       config.OperationTimeModeFixed,
+			setupMocks: func(_ *mocks.MockCalculatorRepository) {},
+			want:       fixed,
+			wantErr:    assert.NoError,
+		},
+		{
+			name:       "zero mode",
+			mode:       config.OperationTimeModeZero,
+			setupMocks: func(_ *mocks.MockCalculatorRepository) {},
+			want:       map[models.TaskOperation]time.Duration{},
+			wantErr:    assert.NoError,
+		},
+		{
+			name: "cost mode falls back to fixed times",
+			mode: config.OperationTimeModeCost,
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetOperationCosts(mock.Anything, mock.Anything).Return(map[models.TaskOperation]time.Duration{
+					models.TaskOperationAddition: 5 * time.Microsecond,
+				}, nil)
+			},
+			want: map[models.TaskOperation]time.Duration{
+				models.TaskOperationAddition:       5 * time.Microsecond,
+				models.TaskOperationSubtraction:    2 * time.Second,
+				models.TaskOperationMultiplication: 3 * time.Second,
+				models.TaskOperationDivision:       4 * time.Second,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "cost mode repository error",
+			mode: config.OperationTimeModeCost,
+			setupMocks: func(repo *mocks.MockCalculatorRepository) {
+				repo.EXPECT().GetOperationCosts(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := mocks.NewMockCalculatorRepository(t)
+
+			tt.setupMocks(repo)
+			conf := conf
+			conf.OperationTimeMode = tt.mode
+			svc := NewCalculatorService(&conf, testutil.DiscardLogger(), mocks.NewMockCalculator(t), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+			got, err := svc.getOperationTimes(ctx)
+			if !tt.wantErr(t, err, fmt.Sprintf("getOperationTimes(%v)", ctx)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "getOperationTimes(%v)", ctx)
+		})
+	}
+}
+
+func TestCalculatorService_getOperationTimes_cache(t *testing.T) {
+	repo := mocks.NewMockCalculatorRepository(t)
+	repo.EXPECT().GetOperationCosts(mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+	repo.EXPECT().GetOperationCosts(mock.Anything, mock.Anything).Return(map[models.TaskOperation]time.Duration{
+		models.TaskOperationAddition: time.Millisecond,
+	}, nil).Once()
+
+	conf := &config.Config{OperationTimeMode: config.OperationTimeModeCost, OperationCostCacheTTL: time.Minute}
+	svc := NewCalculatorService(conf, testutil.DiscardLogger(), mocks.NewMockCalculator(t), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	_, err := svc.getOperationTimes(context.Background())
+	require.Error(t, err, "failures are not cached")
+	for range 3 {
+		got, err := svc.getOperationTimes(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, time.Millisecond, got[models.TaskOperationAddition])
+	}
+
+	svc.costsAt = time.Now().Add(-time.Minute)
+	repo.EXPECT().GetOperationCosts(mock.Anything, mock.Anything).Return(nil, nil).Once()
+	got, err := svc.getOperationTimes(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, got[models.TaskOperationAddition], "the costs are calculated again after the TTL")
+}
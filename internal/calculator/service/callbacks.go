@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// callbackSignatureHeader carries the HMAC-SHA256 signature of the callback body as "sha256=<hex>".
+const callbackSignatureHeader = "X-Calculator-Signature"
+
+var errCallbackAddrDenied = errors.New("callback url must not point to a loopback, private, link-local or denied address")
+
+type CallbackRepository interface {
+	ClaimDueCallbacks(context.Context, models.ClaimDueCallbacksCmd) ([]models.DueCallback, error)
+	RecordCallbackAttempt(context.Context, models.RecordCallbackAttemptCmd) error
+}
+
+// CallbackDispatcher POSTs the finished expressions to their callback URLs every [config.Config.CallbackInterval].
+// Failed deliveries are retried with an exponential backoff up to [config.Config.CallbackMaxAttempts] times,
+// and each attempt is recorded in the delivery log of the callback.
+type CallbackDispatcher struct {
+	conf    *config.Config
+	log     *slog.Logger
+	repo    CallbackRepository
+	metrics *Metrics
+	client  *http.Client
+}
+
+func NewCallbackDispatcher(conf *config.Config, log *slog.Logger, repo CallbackRepository, metrics *Metrics) *CallbackDispatcher {
+	return &CallbackDispatcher{
+		conf:    conf,
+		log:     logging.WithName(log, "callback-dispatcher"),
+		repo:    repo,
+		metrics: metrics,
+		client:  newCallbackClient(conf, newCallbackAddrPolicy(conf)),
+	}
+}
+
+// newCallbackClient returns the client connecting only to the addresses allowed by the policy.
+// The addresses are checked once resolved by the dialer, so a host resolving to another address
+// than on submission is checked as well. Redirects are not followed, the redirect response fails the attempt.
+func newCallbackClient(conf *config.Config, addrs *callbackAddrPolicy) *http.Client {
+	dialer := &net.Dialer{Timeout: conf.CallbackTimeout, KeepAlive: 30 * time.Second, Control: addrs.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would connect to the callback URL unchecked
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   conf.CallbackTimeout,
+		Transport: otelhttp.NewTransport(transport),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Start runs the deliveries. It blocks until the context is canceled.
+func (d *CallbackDispatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.conf.CallbackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Full batches are followed immediately, the next one is likely due already
+			for {
+				n, err := d.dispatch(ctx, time.Now())
+				if err != nil && ctx.Err() == nil {
+					d.log.ErrorContext(ctx, "failed to dispatch callbacks", "error", err)
+				}
+				if err != nil || n < d.conf.CallbackBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// dispatch claims a batch of the due callbacks and attempts them concurrently. Returns the number of attempted callbacks.
+func (d *CallbackDispatcher) dispatch(ctx context.Context, now time.Time) (int, error) {
+	// The lease outlasts the attempts of the batch, which run concurrently, and the recording of their results
+	callbacks, err := d.repo.ClaimDueCallbacks(ctx, models.ClaimDueCallbacksCmd{
+		Now:   now,
+		Limit: d.conf.CallbackBatchSize,
+		Lease: 2 * d.conf.CallbackTimeout,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list due callbacks: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(callbacks))
+	for i, callback := range callbacks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.attempt(ctx, callback)
+		}()
+	}
+	wg.Wait()
+	return len(callbacks), errors.Join(errs...)
+}
+
+// attempt delivers the callback once and records the attempt.
+func (d *CallbackDispatcher) attempt(ctx context.Context, callback models.DueCallback) error {
+	start := time.Now()
+	statusCode, deliverErr := d.deliver(ctx, callback)
+	if deliverErr != nil && ctx.Err() != nil {
+		return nil // interrupted by a shutdown rather than failed, attempted again after a restart
+	}
+
+	cmd := models.RecordCallbackAttemptCmd{
+		ExpressionID: callback.ExpressionID,
+		StatusCode:   statusCode,
+		Duration:     time.Since(start),
+		Delivered:    deliverErr == nil,
+	}
+	result := "delivered"
+	if deliverErr != nil {
+		cmd.Error = deliverErr.Error()
+		result = "failed"
+		if attempt := callback.Attempts + 1; attempt < d.conf.CallbackMaxAttempts {
+			cmd.NextAttemptAt = time.Now().Add(d.backoff(attempt))
+			result = "retried"
+		}
+	}
+	d.metrics.callbackAttempted(result)
+
+	if err := d.repo.RecordCallbackAttempt(context.WithoutCancel(ctx), cmd); err != nil {
+		if errors.Is(err, models.ErrCallbackNotFound) {
+			return nil // attempted concurrently by another replica or deleted along with the expression
+		}
+		return fmt.Errorf("record attempt of callback %s: %w", callback.ExpressionID, err)
+	}
+	if deliverErr != nil {
+		d.log.WarnContext(ctx, "callback delivery failed",
+			"expression_id", callback.ExpressionID, "attempt", callback.Attempts+1, "next_attempt_at", cmd.NextAttemptAt, "error", deliverErr)
+	}
+	return nil
+}
+
+// deliver POSTs the expression to the callback URL. Returns the status code of the response, if any.
+func (d *CallbackDispatcher) deliver(ctx context.Context, callback models.DueCallback) (int, error) {
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(mapExpressionToExpressionResponse(&callback.Expression))
+	if err != nil {
+		return 0, fmt.Errorf("protojson marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if callback.Secret != "" {
+		req.Header.Set(callbackSignatureHeader, signCallback(callback.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post expression: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the retry following the failed attempt, starting from 1.
+func (d *CallbackDispatcher) backoff(attempt int) time.Duration {
+	delay := d.conf.CallbackBackoff
+	for range attempt - 1 {
+		if delay >= d.conf.CallbackMaxBackoff {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, d.conf.CallbackMaxBackoff)
+}
+
+// signCallback returns the signature header value of the body.
+func signCallback(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// callbackAddrPolicy keeps the callbacks away from the internal network of the service.
+// Loopback, private, link-local and unspecified addresses are denied along with [config.Config.CallbackDeniedCIDRs],
+// unless they are in [config.Config.CallbackAllowedCIDRs].
+type callbackAddrPolicy struct {
+	allowed []netip.Prefix
+	denied  []netip.Prefix
+	lookup  func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+func newCallbackAddrPolicy(conf *config.Config) *callbackAddrPolicy {
+	return &callbackAddrPolicy{
+		allowed: conf.CallbackAllowedCIDRs,
+		denied:  conf.CallbackDeniedCIDRs,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+}
+
+// check returns [errCallbackAddrDenied] if the address is denied.
+func (p *callbackAddrPolicy) check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	contains := func(prefix netip.Prefix) bool { return prefix.Contains(addr) }
+	if slices.ContainsFunc(p.allowed, contains) {
+		return nil
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() || slices.ContainsFunc(p.denied, contains) {
+		return errCallbackAddrDenied
+	}
+	return nil
+}
+
+// checkHost resolves the host and checks all of its addresses.
+func (p *callbackAddrPolicy) checkHost(ctx context.Context, host string) error {
+	addrs, err := p.lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve host: %w", err)
+	}
+	for _, addr := range addrs {
+		if err := p.check(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// control checks the resolved address right before the dialer connects to it.
+func (p *callbackAddrPolicy) control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("parse address: %w", err)
+	}
+	return p.check(addrPort.Addr())
+}
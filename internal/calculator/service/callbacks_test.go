@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database/sqlz"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackCIDRs allow the callbacks to the httptest servers.
+var loopbackCIDRs = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func TestCallbackDispatcher_dispatch(t *testing.T) {
+	conf := &config.Config{
+		CallbackBatchSize:    10,
+		CallbackTimeout:      time.Second,
+		CallbackMaxAttempts:  3,
+		CallbackBackoff:      time.Minute,
+		CallbackMaxBackoff:   time.Hour,
+		CallbackAllowedCIDRs: loopbackCIDRs,
+	}
+
+	// The receiver verifies the signature like a client would
+	var (
+		mu       sync.Mutex
+		received = map[string]map[string]any{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if r.URL.Path == "/signed" {
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write(body)
+			if !hmac.Equal([]byte(r.Header.Get("X-Calculator-Signature")), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		} else {
+			assert.Empty(t, r.Header.Get("X-Calculator-Signature"), "unsigned without a secret")
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var expr map[string]any
+		assert.NoError(t, json.Unmarshal(body, &expr))
+		mu.Lock()
+		received[r.URL.Path] = expr
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	due := func(path, secret string, attempts int) models.DueCallback {
+		return models.DueCallback{
+			Callback: models.Callback{ExpressionID: "expr" + path, URL: srv.URL + path, Secret: secret, Attempts: attempts},
+			Expression: models.Expression{
+				ID: "expr" + path, Expression: "2+2", Status: models.ExpressionStatusCompleted, Result: sqlz.Some[float64](4),
+			},
+		}
+	}
+
+	repo := mocks.NewMockCallbackRepository(t)
+	now := time.Now()
+	repo.EXPECT().ClaimDueCallbacks(mock.Anything, models.ClaimDueCallbacksCmd{Now: now, Limit: 10, Lease: 2 * time.Second}).Return([]models.DueCallback{
+		due("/signed", "s3cret", 0),
+		due("/unsigned", "", 0),
+		due("/fail", "", 1),
+		due("/fail", "", 2),
+	}, nil)
+
+	var (
+		cmdsMu sync.Mutex
+		cmds   []models.RecordCallbackAttemptCmd
+	)
+	repo.EXPECT().RecordCallbackAttempt(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, cmd models.RecordCallbackAttemptCmd) error {
+		cmdsMu.Lock()
+		defer cmdsMu.Unlock()
+		cmds = append(cmds, cmd)
+		return nil
+	}).Times(4)
+
+	metrics := NewMetrics(mocks.NewMockMetricsRepository(t))
+	d := NewCallbackDispatcher(conf, testutil.DiscardLogger(), repo, metrics)
+
+	n, err := d.dispatch(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	assert.Equal(t, map[string]any{
+		"id": "expr/signed", "expression": "2+2", "status": "EXPRESSION_STATUS_COMPLETED", "result": 4.0, "error": "",
+	}, received["/signed"])
+	assert.Contains(t, received, "/unsigned")
+
+	byAttempts := map[bool][]models.RecordCallbackAttemptCmd{}
+	for _, cmd := range cmds {
+		byAttempts[cmd.Delivered] = append(byAttempts[cmd.Delivered], cmd)
+		if cmd.Delivered {
+			assert.Equal(t, http.StatusNoContent, cmd.StatusCode)
+			assert.Empty(t, cmd.Error)
+			assert.Zero(t, cmd.NextAttemptAt)
+		}
+	}
+	require.Len(t, byAttempts[true], 2)
+	require.Len(t, byAttempts[false], 2)
+	var retried, failed int
+	for _, cmd := range byAttempts[false] {
+		assert.Equal(t, http.StatusServiceUnavailable, cmd.StatusCode)
+		assert.Contains(t, cmd.Error, "503")
+		if cmd.NextAttemptAt.IsZero() {
+			failed++ // the third attempt was the last one
+			continue
+		}
+		retried++
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), cmd.NextAttemptAt, 5*time.Second, "the second retry waits twice as long")
+	}
+	assert.Equal(t, 1, retried)
+	assert.Equal(t, 1, failed)
+
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(metrics.callbackAttempts.WithLabelValues("delivered")))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(metrics.callbackAttempts.WithLabelValues("retried")))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(metrics.callbackAttempts.WithLabelValues("failed")))
+}
+
+func TestCallbackDispatcher_dispatch_recordError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	repo := mocks.NewMockCallbackRepository(t)
+	repo.EXPECT().ClaimDueCallbacks(mock.Anything, mock.Anything).Return([]models.DueCallback{
+		{Callback: models.Callback{ExpressionID: "expr1", URL: srv.URL}},
+		{Callback: models.Callback{ExpressionID: "expr2", URL: srv.URL}},
+	}, nil)
+	repo.EXPECT().RecordCallbackAttempt(mock.Anything, mock.MatchedBy(func(cmd models.RecordCallbackAttemptCmd) bool {
+		return cmd.ExpressionID == "expr1"
+	})).Return(models.ErrCallbackNotFound)
+	repo.EXPECT().RecordCallbackAttempt(mock.Anything, mock.MatchedBy(func(cmd models.RecordCallbackAttemptCmd) bool {
+		return cmd.ExpressionID == "expr2"
+	})).Return(assert.AnError)
+
+	conf := &config.Config{
+		CallbackBatchSize:    10,
+		CallbackTimeout:      time.Second,
+		CallbackMaxAttempts:  1,
+		CallbackAllowedCIDRs: loopbackCIDRs,
+	}
+	d := NewCallbackDispatcher(conf, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	n, err := d.dispatch(context.Background(), time.Now())
+	require.ErrorIs(t, err, assert.AnError, "callbacks finished concurrently are skipped")
+	assert.Equal(t, 2, n)
+}
+
+func TestCallbackDispatcher_deliver(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/hook", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	callback := func(path string) models.DueCallback {
+		return models.DueCallback{Callback: models.Callback{ExpressionID: "expr1", URL: srv.URL + path}}
+	}
+
+	t.Run("loopback denied", func(t *testing.T) {
+		hits.Store(0)
+		d := NewCallbackDispatcher(&config.Config{CallbackTimeout: time.Second}, testutil.DiscardLogger(), nil, nil)
+		_, err := d.deliver(context.Background(), callback("/hook"))
+		require.ErrorIs(t, err, errCallbackAddrDenied)
+		assert.Zero(t, hits.Load())
+	})
+
+	t.Run("redirect not followed", func(t *testing.T) {
+		hits.Store(0)
+		d := NewCallbackDispatcher(&config.Config{CallbackTimeout: time.Second, CallbackAllowedCIDRs: loopbackCIDRs},
+			testutil.DiscardLogger(), nil, nil)
+		code, err := d.deliver(context.Background(), callback("/redirect"))
+		require.Error(t, err)
+		assert.Equal(t, http.StatusFound, code)
+		assert.Equal(t, int32(1), hits.Load())
+	})
+}
+
+func TestCallbackAddrPolicy_check(t *testing.T) {
+	p := newCallbackAddrPolicy(&config.Config{
+		CallbackAllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")},
+		CallbackDeniedCIDRs:  []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+	})
+
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{addr: "93.184.215.14", wantErr: false},
+		{addr: "2606:2800:21f:cb07:6820:80da:af6b:8b2c", wantErr: false},
+		{addr: "127.0.0.1", wantErr: true},
+		{addr: "::1", wantErr: true},
+		{addr: "::ffff:127.0.0.1", wantErr: true},
+		{addr: "10.0.0.1", wantErr: true},
+		{addr: "172.16.0.1", wantErr: true},
+		{addr: "192.168.1.1", wantErr: true},
+		{addr: "fd00::1", wantErr: true},
+		{addr: "169.254.169.254", wantErr: true},
+		{addr: "fe80::1", wantErr: true},
+		{addr: "0.0.0.0", wantErr: true},
+		{addr: "::", wantErr: true},
+		{addr: "203.0.113.7", wantErr: true},
+		{addr: "10.1.2.3", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := p.check(netip.MustParseAddr(tt.addr))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errCallbackAddrDenied)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCallbackDispatcher_backoff(t *testing.T) {
+	d := NewCallbackDispatcher(&config.Config{CallbackBackoff: time.Second, CallbackMaxBackoff: 10 * time.Second},
+		testutil.DiscardLogger(), nil, nil)
+
+	var got []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		got = append(got, d.backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, got)
+	assert.Equal(t, 10*time.Second, d.backoff(1000), "no overflow")
+}
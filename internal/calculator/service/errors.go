@@ -1,10 +1,41 @@
 package service
 
 import (
+	"errors"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// InternalError reports an unexpected error. Errors caused by a busy database are reported as Unavailable,
+// so that clients retry the request.
 func InternalError(err error) error {
+	if errors.Is(err, database.ErrBusy) {
+		return status.Errorf(codes.Unavailable, "database is busy, try again later: %v", err)
+	}
 	return status.Errorf(codes.Internal, "oops, something went wrong: %v", err)
 }
+
+// fieldViolations collects invalid request fields to be reported as [errdetails.BadRequest] details.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+func (v *fieldViolations) Add(field string, descriptions ...string) {
+	for _, d := range descriptions {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: d})
+	}
+}
+
+// Err returns an InvalidArgument error with the violations, or nil if there are none.
+func (v fieldViolations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return InternalError(err)
+	}
+	return st.Err()
+}
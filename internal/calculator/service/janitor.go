@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+)
+
+type JanitorRepository interface {
+	ListRetentionPolicies(context.Context) ([]models.RetentionPolicy, error)
+	PurgeExpressions(context.Context, models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error)
+	DeleteExpiredIdempotencyKeys(context.Context, time.Time) (int, error)
+}
+
+// Janitor purges the finished expressions breaking the retention policies every [config.Config.RetentionInterval].
+// The global policy of the configuration applies to the users without a policy of their own.
+// Expired idempotency keys are deleted along the way.
+type Janitor struct {
+	conf    *config.Config
+	log     *slog.Logger
+	repo    JanitorRepository
+	metrics *Metrics
+}
+
+func NewJanitor(conf *config.Config, log *slog.Logger, repo JanitorRepository, metrics *Metrics) *Janitor {
+	return &Janitor{
+		conf:    conf,
+		log:     logging.WithName(log, "janitor"),
+		repo:    repo,
+		metrics: metrics,
+	}
+}
+
+// Start runs the purges. It blocks until the context is canceled.
+func (j *Janitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(j.conf.RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			res, err := j.purge(ctx, now)
+			if err != nil {
+				j.log.ErrorContext(ctx, "purge failed", "error", err)
+			}
+			if res.Expressions > 0 {
+				j.log.InfoContext(ctx, "expressions purged", "expressions", res.Expressions, "tasks", res.Tasks)
+			}
+
+			n, err := j.repo.DeleteExpiredIdempotencyKeys(ctx, now)
+			if err != nil {
+				j.log.ErrorContext(ctx, "failed to delete expired idempotency keys", "error", err)
+			}
+			if n > 0 {
+				j.log.InfoContext(ctx, "expired idempotency keys deleted", "keys", n)
+			}
+		}
+	}
+}
+
+// purge applies the global policy and the policies of users. Returns the total number of purged rows.
+func (j *Janitor) purge(ctx context.Context, now time.Time) (models.PurgeExpressionsResult, error) {
+	policies, err := j.repo.ListRetentionPolicies(ctx)
+	if err != nil {
+		return models.PurgeExpressionsResult{}, fmt.Errorf("list retention policies: %w", err)
+	}
+	policies = append(policies, models.RetentionPolicy{MaxAge: j.conf.RetentionMaxAge, MaxCount: j.conf.RetentionMaxCount})
+
+	var total models.PurgeExpressionsResult
+	for _, policy := range policies {
+		if !policy.Enabled() {
+			continue
+		}
+		cmd := models.PurgeExpressionsCmd{UserID: policy.UserID, KeepLatest: policy.MaxCount, Limit: j.conf.RetentionBatchSize}
+		if policy.MaxAge > 0 {
+			cmd.UpdatedBefore = now.Add(-policy.MaxAge)
+		}
+
+		// Small batches keep the write transactions short
+		for {
+			res, err := j.repo.PurgeExpressions(ctx, cmd)
+			if err != nil {
+				return total, fmt.Errorf("purge expressions of user %q: %w", policy.UserID, err)
+			}
+			j.metrics.purged(res)
+			total.Expressions += res.Expressions
+			total.Tasks += res.Tasks
+			if res.Expressions < cmd.Limit {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJanitor_purge(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	conf := &config.Config{RetentionMaxAge: 30 * 24 * time.Hour, RetentionBatchSize: 2}
+
+	repo := mocks.NewMockJanitorRepository(t)
+	repo.EXPECT().ListRetentionPolicies(mock.Anything).Return([]models.RetentionPolicy{
+		{UserID: "keep-all"},
+		{UserID: "keep-latest", MaxCount: 100},
+	}, nil)
+	// Batches are purged until a partial one
+	repo.EXPECT().PurgeExpressions(mock.Anything, models.PurgeExpressionsCmd{UserID: "keep-latest", KeepLatest: 100, Limit: 2}).
+		Return(models.PurgeExpressionsResult{Expressions: 2, Tasks: 6}, nil).Once()
+	repo.EXPECT().PurgeExpressions(mock.Anything, models.PurgeExpressionsCmd{UserID: "keep-latest", KeepLatest: 100, Limit: 2}).
+		Return(models.PurgeExpressionsResult{Expressions: 1, Tasks: 1}, nil).Once()
+	repo.EXPECT().PurgeExpressions(mock.Anything, models.PurgeExpressionsCmd{UpdatedBefore: now.Add(-30 * 24 * time.Hour), Limit: 2}).
+		Return(models.PurgeExpressionsResult{}, nil).Once()
+
+	metrics := NewMetrics(mocks.NewMockMetricsRepository(t))
+	j := NewJanitor(conf, testutil.DiscardLogger(), repo, metrics)
+
+	res, err := j.purge(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeExpressionsResult{Expressions: 3, Tasks: 7}, res)
+	assert.Equal(t, 3.0, promtestutil.ToFloat64(metrics.expressionsPurged))
+	assert.Equal(t, 7.0, promtestutil.ToFloat64(metrics.tasksPurged))
+}
+
+func TestJanitor_purgeDisabled(t *testing.T) {
+	repo := mocks.NewMockJanitorRepository(t)
+	repo.EXPECT().ListRetentionPolicies(mock.Anything).Return(nil, nil)
+	j := NewJanitor(&config.Config{RetentionBatchSize: 10}, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	res, err := j.purge(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, res, "nothing is purged without the global and user policies")
+}
+
+func TestJanitor_purgeError(t *testing.T) {
+	repo := mocks.NewMockJanitorRepository(t)
+	repo.EXPECT().ListRetentionPolicies(mock.Anything).Return(nil, nil)
+	repo.EXPECT().PurgeExpressions(mock.Anything, mock.Anything).Return(models.PurgeExpressionsResult{}, assert.AnError)
+	j := NewJanitor(&config.Config{RetentionMaxCount: 10, RetentionBatchSize: 10}, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)))
+
+	_, err := j.purge(context.Background(), time.Now())
+	require.ErrorIs(t, err, assert.AnError)
+}
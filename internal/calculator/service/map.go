@@ -1,6 +1,7 @@
 package service
 
 import (
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 
 	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
@@ -15,6 +16,7 @@ func mapExpressionToExpressionResponse(expr *models.Expression) *calculatorv1.Ex
 		Expression: expr.Expression,
 		Status:     mapExpressionStatus(expr.Status),
 		Result:     expr.Result.V,
+		Error:      expr.Error.V,
 	}
 }
 
@@ -25,6 +27,7 @@ func mapTaskToAgentTaskResponse(task *models.Task) *calculatorv1.Task {
 		Arg2:          task.Arg2.V,
 		Operation:     mapTaskOperation(task.Operation),
 		OperationTime: durationpb.New(task.OperationTime),
+		TraceParent:   task.TraceParent.V,
 	}
 }
 
@@ -43,9 +46,128 @@ func mapTaskToInternalTaskResponse(task models.Task) *calculatorv1.ListExpressio
 		ExpireAt:       timestamppb.New(task.ExpireAt.V),
 		CreatedAt:      timestamppb.New(task.CreatedAt),
 		UpdatedAt:      timestamppb.New(task.UpdatedAt),
+		ComputeTime:    durationpb.New(task.ComputeTime.V),
 	}
 }
 
+func mapTaskEventToResponse(event *models.TaskEvent) *calculatorv1.ListExpressionTaskEventsResponse_TaskEvent {
+	return &calculatorv1.ListExpressionTaskEventsResponse_TaskEvent{
+		Id:        event.ID,
+		TaskId:    event.TaskID,
+		Type:      mapTaskEventType(event.Type),
+		AgentId:   event.AgentID.V,
+		Result:    event.Result.V,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+}
+
+func mapAPIKeyToResponse(key *models.APIKey) *calculatorv1.APIKey {
+	resp := &calculatorv1.APIKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Scopes:    make([]calculatorv1.APIKeyScope, 0, len(key.Scopes)),
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	for _, scope := range key.Scopes {
+		resp.Scopes = append(resp.Scopes, mapAPIKeyScope(scope))
+	}
+	if key.ExpiresAt.Valid {
+		resp.ExpiresAt = timestamppb.New(key.ExpiresAt.V)
+	}
+	if key.RevokedAt.Valid {
+		resp.RevokedAt = timestamppb.New(key.RevokedAt.V)
+	}
+	return resp
+}
+
+func mapUserToResponse(user *models.User) *calculatorv1.User {
+	return &calculatorv1.User{
+		Id:                     user.ID,
+		Login:                  user.Login,
+		Role:                   mapUserRole(user.Role),
+		CreatedAt:              timestamppb.New(user.CreatedAt),
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}
+}
+
+func mapUserToUserInfo(user *models.User) auth.UserInfo {
+	return auth.UserInfo{
+		ID:                     user.ID,
+		Login:                  user.Login,
+		Role:                   user.Role,
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}
+}
+
+func mapAgentToResponse(agent *models.Agent) *calculatorv1.Agent {
+	resp := &calculatorv1.Agent{
+		Id:         agent.ID,
+		Operations: make([]calculatorv1.TaskOperation, 0, len(agent.Operations)),
+		Labels:     agent.Labels,
+		LastSeenAt: timestamppb.New(agent.LastSeenAt),
+	}
+	for _, op := range agent.Operations {
+		resp.Operations = append(resp.Operations, mapTaskOperation(op))
+	}
+	return resp
+}
+
+// mapRetentionPolicyToResponse maps the policy of a user, custom tells whether it overrides the global one.
+func mapRetentionPolicyToResponse(policy *models.RetentionPolicy, custom bool) *calculatorv1.RetentionPolicy {
+	resp := &calculatorv1.RetentionPolicy{
+		UserId:   policy.UserID,
+		MaxCount: int32(policy.MaxCount),
+		Custom:   custom,
+	}
+	if policy.MaxAge > 0 {
+		resp.MaxAge = durationpb.New(policy.MaxAge)
+	}
+	if custom {
+		resp.UpdatedAt = timestamppb.New(policy.UpdatedAt)
+	}
+	return resp
+}
+
+func mapOutboxEventToResponse(event *models.OutboxEvent) *calculatorv1.ExpressionEvent {
+	return &calculatorv1.ExpressionEvent{
+		Id:     event.ID,
+		Type:   mapOutboxEventType(event.Type),
+		UserId: event.UserID,
+		Expression: &calculatorv1.Expression{
+			Id:         event.ExpressionID,
+			Expression: event.Expression,
+			Status:     mapExpressionStatus(event.Status),
+			Result:     event.Result.V,
+			Error:      event.Error.V,
+		},
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+}
+
+// mapCallbackToResponse maps the callback along with its delivery log, leaving out the secret.
+func mapCallbackToResponse(callback *models.Callback, attempts []models.CallbackAttempt) *calculatorv1.ExpressionCallback {
+	resp := &calculatorv1.ExpressionCallback{
+		Url:        callback.URL,
+		Signed:     callback.Secret != "",
+		Status:     mapCallbackStatus(callback.Status),
+		Attempts:   int32(callback.Attempts),
+		Deliveries: make([]*calculatorv1.ExpressionCallback_Delivery, 0, len(attempts)),
+	}
+	if callback.NextAttemptAt.Valid {
+		resp.NextAttemptAt = timestamppb.New(callback.NextAttemptAt.V)
+	}
+	for _, attempt := range attempts {
+		resp.Deliveries = append(resp.Deliveries, &calculatorv1.ExpressionCallback_Delivery{
+			Attempt:    int32(attempt.Attempt),
+			StatusCode: int32(attempt.StatusCode.V),
+			Error:      attempt.Error.V,
+			Duration:   durationpb.New(attempt.Duration),
+			CreatedAt:  timestamppb.New(attempt.CreatedAt),
+		})
+	}
+	return resp
+}
+
 func mapExpressionStatus(s models.ExpressionStatus) calculatorv1.ExpressionStatus {
 	switch s {
 	case models.ExpressionStatusPending:
@@ -76,6 +198,21 @@ func mapTaskOperation(s models.TaskOperation) calculatorv1.TaskOperation {
 	}
 }
 
+func mapTaskOperationToModel(op calculatorv1.TaskOperation) models.TaskOperation {
+	switch op {
+	case calculatorv1.TaskOperation_TASK_OPERATION_ADDITION:
+		return models.TaskOperationAddition
+	case calculatorv1.TaskOperation_TASK_OPERATION_SUBTRACTION:
+		return models.TaskOperationSubtraction
+	case calculatorv1.TaskOperation_TASK_OPERATION_MULTIPLICATION:
+		return models.TaskOperationMultiplication
+	case calculatorv1.TaskOperation_TASK_OPERATION_DIVISION:
+		return models.TaskOperationDivision
+	default:
+		return ""
+	}
+}
+
 func mapTaskStatus(s models.TaskStatus) calculatorv1.TaskStatus {
 	switch s {
 	case models.TaskStatusCreated:
@@ -92,3 +229,94 @@ func mapTaskStatus(s models.TaskStatus) calculatorv1.TaskStatus {
 		return calculatorv1.TaskStatus_TASK_STATUS_UNSPECIFIED
 	}
 }
+
+func mapTaskEventType(t models.TaskEventType) calculatorv1.TaskEventType {
+	switch t {
+	case models.TaskEventCreated:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_CREATED
+	case models.TaskEventClaimed:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_CLAIMED
+	case models.TaskEventReleased:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_RELEASED
+	case models.TaskEventReclaimed:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_RECLAIMED
+	case models.TaskEventCompleted:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_COMPLETED
+	case models.TaskEventFailed:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_FAILED
+	default:
+		return calculatorv1.TaskEventType_TASK_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+func mapOutboxEventType(t models.OutboxEventType) calculatorv1.ExpressionEventType {
+	switch t {
+	case models.OutboxEventExpressionCompleted:
+		return calculatorv1.ExpressionEventType_EXPRESSION_EVENT_TYPE_COMPLETED
+	case models.OutboxEventExpressionFailed:
+		return calculatorv1.ExpressionEventType_EXPRESSION_EVENT_TYPE_FAILED
+	default:
+		return calculatorv1.ExpressionEventType_EXPRESSION_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+func mapCallbackStatus(s models.CallbackStatus) calculatorv1.CallbackStatus {
+	switch s {
+	case models.CallbackStatusPending:
+		return calculatorv1.CallbackStatus_CALLBACK_STATUS_PENDING
+	case models.CallbackStatusDelivered:
+		return calculatorv1.CallbackStatus_CALLBACK_STATUS_DELIVERED
+	case models.CallbackStatusFailed:
+		return calculatorv1.CallbackStatus_CALLBACK_STATUS_FAILED
+	default:
+		return calculatorv1.CallbackStatus_CALLBACK_STATUS_UNSPECIFIED
+	}
+}
+
+func mapAPIKeyScope(s models.APIKeyScope) calculatorv1.APIKeyScope {
+	switch s {
+	case models.APIKeyScopeReadOnly:
+		return calculatorv1.APIKeyScope_API_KEY_SCOPE_READ_ONLY
+	case models.APIKeyScopeSubmit:
+		return calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT
+	default:
+		return calculatorv1.APIKeyScope_API_KEY_SCOPE_UNSPECIFIED
+	}
+}
+
+func mapAPIKeyScopeToModel(s calculatorv1.APIKeyScope) models.APIKeyScope {
+	switch s {
+	case calculatorv1.APIKeyScope_API_KEY_SCOPE_READ_ONLY:
+		return models.APIKeyScopeReadOnly
+	case calculatorv1.APIKeyScope_API_KEY_SCOPE_SUBMIT:
+		return models.APIKeyScopeSubmit
+	default:
+		return ""
+	}
+}
+
+func mapUserRole(r models.UserRole) calculatorv1.UserRole {
+	switch r {
+	case models.UserRoleAdmin:
+		return calculatorv1.UserRole_USER_ROLE_ADMIN
+	case models.UserRoleUser:
+		return calculatorv1.UserRole_USER_ROLE_USER
+	case models.UserRoleReadOnly:
+		return calculatorv1.UserRole_USER_ROLE_READ_ONLY
+	default:
+		return calculatorv1.UserRole_USER_ROLE_UNSPECIFIED
+	}
+}
+
+func mapUserRoleToModel(r calculatorv1.UserRole) models.UserRole {
+	switch r {
+	case calculatorv1.UserRole_USER_ROLE_ADMIN:
+		return models.UserRoleAdmin
+	case calculatorv1.UserRole_USER_ROLE_USER:
+		return models.UserRoleUser
+	case calculatorv1.UserRole_USER_ROLE_READ_ONLY:
+		return models.UserRoleReadOnly
+	default:
+		return ""
+	}
+}
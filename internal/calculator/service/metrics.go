@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsCollectTimeout bounds the repository queries made on each scrape.
+const metricsCollectTimeout = 5 * time.Second
+
+type MetricsRepository interface {
+	CountExpressionsByStatus(context.Context) (map[models.ExpressionStatus]int, error)
+	CountTasksByStatus(context.Context) (map[models.TaskStatus]int, error)
+}
+
+// Metrics represents a collection of calculator business metrics to be registered on a
+// Prometheus metrics registry. Queue depth and expression counts are read from the
+// repository at scrape time, the rest is observed by the services at state transitions.
+type Metrics struct {
+	repo MetricsRepository
+
+	expressionsDesc      *prometheus.Desc
+	tasksDesc            *prometheus.Desc
+	expressionsSubmitted prometheus.Counter
+	expressionDuration   *prometheus.HistogramVec
+	taskWaitDuration     *prometheus.HistogramVec
+	expressionsPurged    prometheus.Counter
+	tasksPurged          prometheus.Counter
+	outboxDelivered      *prometheus.CounterVec
+	outboxFailures       *prometheus.CounterVec
+	callbackAttempts     *prometheus.CounterVec
+}
+
+// NewMetrics returns a new Metrics object.
+func NewMetrics(repo MetricsRepository) *Metrics {
+	return &Metrics{
+		repo: repo,
+		expressionsDesc: prometheus.NewDesc(
+			"calculator_expressions",
+			"Number of stored expressions by status.",
+			[]string{"status"}, nil,
+		),
+		tasksDesc: prometheus.NewDesc(
+			"calculator_tasks",
+			"Number of stored tasks by status, i.e. the task queue depth.",
+			[]string{"status"}, nil,
+		),
+		expressionsSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "calculator_expressions_submitted_total",
+			Help: "Total number of expressions submitted by users.",
+		}),
+		expressionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "calculator_expression_duration_seconds",
+			Help:    "End-to-end latency from expression submission until it is completed or failed.",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+		}, []string{"status"}),
+		taskWaitDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "calculator_task_wait_duration_seconds",
+			Help:    "Time a task stays pending until it is claimed by an agent.",
+			Buckets: []float64{0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		}, []string{"operation"}),
+		expressionsPurged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "calculator_expressions_purged_total",
+			Help: "Total number of finished expressions deleted by the retention policies.",
+		}),
+		tasksPurged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "calculator_tasks_purged_total",
+			Help: "Total number of tasks deleted along with the purged expressions.",
+		}),
+		outboxDelivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "calculator_outbox_events_delivered_total",
+			Help: "Total number of expression lifecycle events delivered to the outbox sinks, including redeliveries.",
+		}, []string{"sink"}),
+		outboxFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "calculator_outbox_delivery_failures_total",
+			Help: "Total number of failed deliveries of expression lifecycle events to the outbox sinks.",
+		}, []string{"sink"}),
+		callbackAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "calculator_callback_attempts_total",
+			Help: "Total number of expression callback delivery attempts by result: delivered, retried or failed.",
+		}, []string{"result"}),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector to the provided channel and returns once
+// the last descriptor has been sent.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.expressionsDesc
+	ch <- m.tasksDesc
+	m.expressionsSubmitted.Describe(ch)
+	m.expressionDuration.Describe(ch)
+	m.taskWaitDuration.Describe(ch)
+	m.expressionsPurged.Describe(ch)
+	m.tasksPurged.Describe(ch)
+	m.outboxDelivered.Describe(ch)
+	m.outboxFailures.Describe(ch)
+	m.callbackAttempts.Describe(ch)
+}
+
+// Collect is called by the Prometheus registry when collecting
+// metrics. The implementation sends each collected metric via the
+// provided channel and returns once the last metric has been sent.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+
+	m.collectExpressions(ctx, ch)
+	m.collectTasks(ctx, ch)
+	m.expressionsSubmitted.Collect(ch)
+	m.expressionDuration.Collect(ch)
+	m.taskWaitDuration.Collect(ch)
+	m.expressionsPurged.Collect(ch)
+	m.tasksPurged.Collect(ch)
+	m.outboxDelivered.Collect(ch)
+	m.outboxFailures.Collect(ch)
+	m.callbackAttempts.Collect(ch)
+}
+
+func (m *Metrics) collectExpressions(ctx context.Context, ch chan<- prometheus.Metric) {
+	counts, err := m.repo.CountExpressionsByStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(m.expressionsDesc, fmt.Errorf("count expressions: %w", err))
+		return
+	}
+	for _, st := range []models.ExpressionStatus{
+		models.ExpressionStatusPending,
+		models.ExpressionStatusInProgress,
+		models.ExpressionStatusCompleted,
+		models.ExpressionStatusFailed,
+	} {
+		ch <- prometheus.MustNewConstMetric(m.expressionsDesc, prometheus.GaugeValue, float64(counts[st]), string(st))
+	}
+}
+
+func (m *Metrics) collectTasks(ctx context.Context, ch chan<- prometheus.Metric) {
+	counts, err := m.repo.CountTasksByStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(m.tasksDesc, fmt.Errorf("count tasks: %w", err))
+		return
+	}
+	for _, st := range []models.TaskStatus{
+		models.TaskStatusCreated,
+		models.TaskStatusPending,
+		models.TaskStatusInProgress,
+		models.TaskStatusCompleted,
+		models.TaskStatusFailed,
+	} {
+		ch <- prometheus.MustNewConstMetric(m.tasksDesc, prometheus.GaugeValue, float64(counts[st]), string(st))
+	}
+}
+
+func (m *Metrics) expressionSubmitted() {
+	m.expressionsSubmitted.Inc()
+}
+
+func (m *Metrics) expressionFinished(expr *models.Expression) {
+	m.expressionDuration.WithLabelValues(string(expr.Status)).Observe(expr.UpdatedAt.Sub(expr.CreatedAt).Seconds())
+}
+
+func (m *Metrics) taskClaimed(task *models.Task) {
+	if !task.PendingAt.Valid {
+		return
+	}
+	m.taskWaitDuration.WithLabelValues(operationLabel(task.Operation)).Observe(task.UpdatedAt.Sub(task.PendingAt.V).Seconds())
+}
+
+func (m *Metrics) purged(res models.PurgeExpressionsResult) {
+	m.expressionsPurged.Add(float64(res.Expressions))
+	m.tasksPurged.Add(float64(res.Tasks))
+}
+
+func (m *Metrics) outboxDispatched(sink string, delivered int, failed bool) {
+	m.outboxDelivered.WithLabelValues(sink).Add(float64(delivered))
+	if failed {
+		m.outboxFailures.WithLabelValues(sink).Inc()
+	}
+}
+
+func (m *Metrics) callbackAttempted(result string) {
+	m.callbackAttempts.WithLabelValues(result).Inc()
+}
+
+// operationLabel converts + into addition.
+func operationLabel(op models.TaskOperation) string {
+	return strings.ToLower(strings.TrimPrefix(mapTaskOperation(op).String(), "TASK_OPERATION_"))
+}
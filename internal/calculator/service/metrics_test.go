@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/database/sqlz"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Collect(t *testing.T) {
+	repo := mocks.NewMockMetricsRepository(t)
+	repo.EXPECT().CountExpressionsByStatus(mock.Anything).Return(map[models.ExpressionStatus]int{
+		models.ExpressionStatusPending:   2,
+		models.ExpressionStatusCompleted: 5,
+	}, nil)
+	repo.EXPECT().CountTasksByStatus(mock.Anything).Return(map[models.TaskStatus]int{
+		models.TaskStatusPending:    3,
+		models.TaskStatusInProgress: 1,
+	}, nil)
+
+	metrics := NewMetrics(repo)
+
+	now := time.Now()
+	metrics.expressionSubmitted()
+	metrics.expressionSubmitted()
+	metrics.expressionFinished(&models.Expression{
+		Status:    models.ExpressionStatusCompleted,
+		CreatedAt: now.Add(-2 * time.Second),
+		UpdatedAt: now,
+	})
+	metrics.taskClaimed(&models.Task{
+		Operation: models.TaskOperationAddition,
+		PendingAt: sqlz.Some(now.Add(-time.Second)),
+		UpdatedAt: now,
+	})
+	metrics.taskClaimed(&models.Task{Operation: models.TaskOperationAddition}) // unknown pending time is skipped
+
+	err := promtestutil.CollectAndCompare(metrics, strings.NewReader(`
+# HELP calculator_expressions Number of stored expressions by status.
+# TYPE calculator_expressions gauge
+calculator_expressions{status="Completed"} 5
+calculator_expressions{status="Failed"} 0
+calculator_expressions{status="InProgress"} 0
+calculator_expressions{status="Pending"} 2
+# HELP calculator_tasks Number of stored tasks by status, i.e. the task queue depth.
+# TYPE calculator_tasks gauge
+calculator_tasks{status="Completed"} 0
+calculator_tasks{status="Created"} 0
+calculator_tasks{status="Failed"} 0
+calculator_tasks{status="InProgress"} 1
+calculator_tasks{status="Pending"} 3
+# HELP calculator_expressions_submitted_total Total number of expressions submitted by users.
+# TYPE calculator_expressions_submitted_total counter
+calculator_expressions_submitted_total 2
+`), "calculator_expressions", "calculator_tasks", "calculator_expressions_submitted_total")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.expressionDuration))
+	assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.taskWaitDuration))
+	assert.Equal(t, uint64(1), histogramCount(t, metrics, "calculator_task_wait_duration_seconds"))
+}
+
+func TestMetrics_Collect_repositoryError(t *testing.T) {
+	repo := mocks.NewMockMetricsRepository(t)
+	repo.EXPECT().CountExpressionsByStatus(mock.Anything).Return(nil, assert.AnError)
+	repo.EXPECT().CountTasksByStatus(mock.Anything).Return(nil, assert.AnError)
+
+	_, err := promtestutil.CollectAndLint(NewMetrics(repo))
+	assert.Error(t, err)
+}
+
+func histogramCount(t *testing.T, metrics *Metrics, name string) uint64 {
+	t.Helper()
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(metrics))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	var count uint64
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			count += m.GetHistogram().GetSampleCount()
+		}
+	}
+	return count
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	oidcLoginPath    = "/api/v1/oidc/login"
+	oidcCallbackPath = "/api/v1/oidc/callback"
+
+	oidcCookieName   = "oidc_login"
+	oidcLoginTimeout = 10 * time.Minute
+)
+
+type OIDCRepository interface {
+	GetUserByIdentity(ctx context.Context, cmd models.GetUserByIdentityCmd) (*models.User, error)
+	CreateUserWithIdentity(ctx context.Context, cmd models.CreateUserWithIdentityCmd) (*models.User, error)
+}
+
+// OIDCService implements login with an external OpenID Connect identity provider
+// using the authorization code flow with PKCE. Users are identified by the issuer and the sub claim,
+// and a local user without a password is created on first login.
+type OIDCService struct {
+	conf     *config.Config
+	log      *slog.Logger
+	users    *UserService
+	repo     OIDCRepository
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	mux      *runtime.ServeMux
+}
+
+// NewOIDCService discovers the identity provider configured with AUTH_OIDC_ISSUER_URL.
+// The tokens are issued the same way as by [UserService.Login].
+func NewOIDCService(ctx context.Context, conf *config.Config, log *slog.Logger, users *UserService, repo OIDCRepository) (*OIDCService, error) {
+	provider, err := oidc.NewProvider(ctx, conf.AuthOIDCIssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discover oidc provider: %w", err)
+	}
+
+	return &OIDCService{
+		conf:  conf,
+		log:   logging.WithName(log, "oidc-service"),
+		users: users,
+		repo:  repo,
+		oauth2: oauth2.Config{
+			ClientID:     conf.AuthOIDCClientID,
+			ClientSecret: conf.AuthOIDCClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  conf.AuthOIDCRedirectURL,
+			Scopes:       conf.AuthOIDCScopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: conf.AuthOIDCClientID}),
+	}, nil
+}
+
+func (s *OIDCService) RegisterHTTPHandlers(mux *runtime.ServeMux) error {
+	s.mux = mux
+	if err := mux.HandlePath(http.MethodGet, oidcLoginPath, s.handleLogin); err != nil {
+		return fmt.Errorf("handle %s: %w", oidcLoginPath, err)
+	}
+	if err := mux.HandlePath(http.MethodGet, oidcCallbackPath, s.handleCallback); err != nil {
+		return fmt.Errorf("handle %s: %w", oidcCallbackPath, err)
+	}
+	return nil
+}
+
+// handleLogin redirects to the identity provider. The state, nonce and PKCE verifier
+// are kept in a cookie until the callback.
+func (s *OIDCService) handleLogin(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	state, nonce, verifier := randomToken(), randomToken(), oauth2.GenerateVerifier()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    strings.Join([]string{state, nonce, verifier}, "."),
+		Path:     oidcCallbackPath,
+		MaxAge:   int(oidcLoginTimeout.Seconds()),
+		Secure:   strings.HasPrefix(s.conf.AuthOIDCRedirectURL, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, s.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), http.StatusFound)
+}
+
+// handleCallback exchanges the authorization code for an ID token and responds as [UserService.Login].
+func (s *OIDCService) handleCallback(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	ctx := r.Context()
+	http.SetCookie(w, &http.Cookie{Name: oidcCookieName, Path: oidcCallbackPath, MaxAge: -1})
+
+	resp, err := s.callback(ctx, r)
+	if err != nil {
+		runtime.HTTPError(ctx, s.mux, &runtime.JSONPb{}, w, r, err)
+		return
+	}
+	runtime.ForwardResponseMessage(ctx, s.mux, &runtime.JSONPb{}, w, r, resp)
+}
+
+func (s *OIDCService) callback(ctx context.Context, r *http.Request) (*calculatorv1.LoginResponse, error) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		s.log.WarnContext(ctx, "identity provider returned an error", "error", errCode, "description", query.Get("error_description"))
+		return nil, status.Errorf(codes.Unauthenticated, "identity provider error: %s", errCode)
+	}
+
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "login session not found or expired")
+	}
+	state, nonce, verifier, ok := parseOIDCCookie(cookie.Value)
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid state")
+	}
+
+	token, err := s.oauth2.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to exchange authorization code", "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no id token in the token response")
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to verify id token", "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid id token")
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid id token nonce")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, InternalError(fmt.Errorf("parse id token claims: %w", err))
+	}
+
+	user, err := s.userByIdentity(ctx, idToken.Issuer, idToken.Subject, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.users.issueTokens(ctx, user)
+}
+
+type oidcClaims struct {
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+}
+
+// maxLoginCollisions is the number of attempts to create a user with a unique login.
+const maxLoginCollisions = 5
+
+// userByIdentity returns the user linked to the external identity, creating one on first login.
+func (s *OIDCService) userByIdentity(ctx context.Context, issuer, subject string, claims oidcClaims) (*models.User, error) {
+	identity := models.GetUserByIdentityCmd{Issuer: issuer, Subject: subject}
+	user, err := s.repo.GetUserByIdentity(ctx, identity)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return nil, InternalError(fmt.Errorf("get user by identity: %w", err))
+	}
+
+	login := loginFromClaims(claims)
+	for range maxLoginCollisions {
+		user, err := s.repo.CreateUserWithIdentity(ctx, models.CreateUserWithIdentityCmd{
+			Login:   login,
+			Issuer:  issuer,
+			Subject: subject,
+		})
+		switch {
+		case err == nil:
+			s.log.InfoContext(ctx, "user created on first oidc login", "user_id", user.ID, "login", user.Login, "issuer", issuer)
+			return user, nil
+		case errors.Is(err, models.ErrUserExists):
+			login = loginFromClaims(claims) + "-" + randomSuffix()
+		case errors.Is(err, models.ErrIdentityExists): // created by a concurrent login
+			user, err := s.repo.GetUserByIdentity(ctx, identity)
+			if err != nil {
+				return nil, InternalError(fmt.Errorf("get user by identity: %w", err))
+			}
+			return user, nil
+		default:
+			return nil, InternalError(fmt.Errorf("create user with identity: %w", err))
+		}
+	}
+	return nil, InternalError(errors.New("no free login for the identity"))
+}
+
+var invalidLoginChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// loginFromClaims derives a login from the preferred username or the email,
+// leaving room for the suffix that resolves collisions.
+func loginFromClaims(claims oidcClaims) string {
+	login := claims.PreferredUsername
+	if login == "" {
+		login, _, _ = strings.Cut(claims.Email, "@")
+	}
+	login = strings.TrimLeft(invalidLoginChars.ReplaceAllString(login, ""), "._-")
+	if len(login) > auth.LoginMaxLength-7 {
+		login = login[:auth.LoginMaxLength-7]
+	}
+	if len(auth.ValidateLogin(login)) > 0 {
+		return "user"
+	}
+	return login
+}
+
+func parseOIDCCookie(value string) (state, nonce, verifier string, ok bool) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func randomSuffix() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
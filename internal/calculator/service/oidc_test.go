@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCService_login(t *testing.T) {
+	issuer := testutil.NewOIDCIssuer(t, "calculator")
+	user := &models.User{ID: "user-id", Login: "jdoe", Role: models.UserRoleUser}
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		setupMocks func(repo *mocks.MockOIDCRepository)
+		// tamper modifies the callback request
+		tamper   func(query url.Values, cookie *http.Cookie)
+		wantCode int
+	}{
+		{
+			name:   "existing user",
+			claims: jwt.MapClaims{"sub": "ext-1", "preferred_username": "jdoe"},
+			setupMocks: func(repo *mocks.MockOIDCRepository) {
+				repo.EXPECT().GetUserByIdentity(mock.Anything, models.GetUserByIdentityCmd{Issuer: issuer.URL, Subject: "ext-1"}).
+					Return(user, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "user created on first login",
+			claims: jwt.MapClaims{"sub": "ext-1", "email": "j.doe@example.com"},
+			setupMocks: func(repo *mocks.MockOIDCRepository) {
+				repo.EXPECT().GetUserByIdentity(mock.Anything, mock.Anything).Return(nil, models.ErrUserNotFound)
+				repo.EXPECT().CreateUserWithIdentity(mock.Anything, models.CreateUserWithIdentityCmd{
+					Login:   "j.doe",
+					Issuer:  issuer.URL,
+					Subject: "ext-1",
+				}).Return(user, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "login collision",
+			claims: jwt.MapClaims{"sub": "ext-1", "preferred_username": "jdoe"},
+			setupMocks: func(repo *mocks.MockOIDCRepository) {
+				repo.EXPECT().GetUserByIdentity(mock.Anything, mock.Anything).Return(nil, models.ErrUserNotFound)
+				repo.EXPECT().CreateUserWithIdentity(mock.Anything, mock.MatchedBy(func(cmd models.CreateUserWithIdentityCmd) bool {
+					return cmd.Login == "jdoe"
+				})).Return(nil, models.ErrUserExists)
+				repo.EXPECT().CreateUserWithIdentity(mock.Anything, mock.MatchedBy(func(cmd models.CreateUserWithIdentityCmd) bool {
+					return len(cmd.Login) == len("jdoe-123456") && len(auth.ValidateLogin(cmd.Login)) == 0
+				})).Return(user, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "state mismatch",
+			claims:     jwt.MapClaims{"sub": "ext-1"},
+			setupMocks: func(*mocks.MockOIDCRepository) {},
+			tamper:     func(query url.Values, _ *http.Cookie) { query.Set("state", "forged") },
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid code",
+			claims:     jwt.MapClaims{"sub": "ext-1"},
+			setupMocks: func(*mocks.MockOIDCRepository) {},
+			tamper:     func(query url.Values, _ *http.Cookie) { query.Set("code", "forged") },
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:       "identity provider error",
+			claims:     jwt.MapClaims{"sub": "ext-1"},
+			setupMocks: func(*mocks.MockOIDCRepository) {},
+			tamper:     func(query url.Values, _ *http.Cookie) { query.Set("error", "access_denied") },
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name:   "repository error",
+			claims: jwt.MapClaims{"sub": "ext-1"},
+			setupMocks: func(repo *mocks.MockOIDCRepository) {
+				repo.EXPECT().GetUserByIdentity(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer.SetClaims(tt.claims)
+			conf := testConfig()
+			conf.AuthOIDCIssuerURL = issuer.URL
+			conf.AuthOIDCClientID = "calculator"
+			conf.AuthOIDCRedirectURL = "http://calculator.test/api/v1/oidc/callback"
+			conf.AuthOIDCScopes = []string{"openid"}
+
+			authMock := mocks.NewMockAuth(t)
+			authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{ID: user.ID, Login: user.Login, Role: user.Role})).Return("jwt-token", nil).Maybe()
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil).Maybe()
+			repo := mocks.NewMockOIDCRepository(t)
+			tt.setupMocks(repo)
+
+			users := NewUserService(conf, testutil.DiscardLogger(), authMock, userRepo)
+			svc, err := NewOIDCService(context.Background(), conf, testutil.DiscardLogger(), users, repo)
+			require.NoError(t, err)
+			mux := runtime.NewServeMux()
+			require.NoError(t, svc.RegisterHTTPHandlers(mux))
+
+			// Login redirects to the identity provider
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil))
+			require.Equal(t, http.StatusFound, rec.Code)
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+
+			code, state := issuer.Authorize(t, rec.Header().Get("Location"))
+			query := url.Values{"code": {code}, "state": {state}}
+			if tt.tamper != nil {
+				tt.tamper(query, cookies[0])
+			}
+
+			// The identity provider redirects back to the callback
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?"+query.Encode(), nil)
+			req.AddCookie(cookies[0])
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				AccessToken  string `json:"accessToken"`
+				RefreshToken string `json:"refreshToken"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, "jwt-token", resp.AccessToken)
+			assert.NotEmpty(t, resp.RefreshToken)
+		})
+	}
+}
+
+func TestOIDCService_callbackWithoutLogin(t *testing.T) {
+	issuer := testutil.NewOIDCIssuer(t, "calculator")
+	conf := testConfig()
+	conf.AuthOIDCIssuerURL = issuer.URL
+	conf.AuthOIDCClientID = "calculator"
+
+	users := NewUserService(conf, testutil.DiscardLogger(), mocks.NewMockAuth(t), mocks.NewMockUserRepository(t))
+	svc, err := NewOIDCService(context.Background(), conf, testutil.DiscardLogger(), users, mocks.NewMockOIDCRepository(t))
+	require.NoError(t, err)
+	mux := runtime.NewServeMux()
+	require.NoError(t, svc.RegisterHTTPHandlers(mux))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?code=code&state=state", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestLoginFromClaims(t *testing.T) {
+	tests := []struct {
+		claims oidcClaims
+		want   string
+	}{
+		{claims: oidcClaims{PreferredUsername: "jdoe", Email: "john@example.com"}, want: "jdoe"},
+		{claims: oidcClaims{Email: "john.doe+calc@example.com"}, want: "john.doecalc"},
+		{claims: oidcClaims{PreferredUsername: "Иван"}, want: "user"},
+		{claims: oidcClaims{PreferredUsername: "_admin_"}, want: "admin_"},
+		{claims: oidcClaims{PreferredUsername: "a-very-long-preferred-username-from-sso"}, want: "a-very-long-preferred-use"},
+		{claims: oidcClaims{}, want: "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, loginFromClaims(tt.claims))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+)
+
+type OutboxRepository interface {
+	ListOutboxEvents(context.Context, models.ListOutboxEventsCmd) ([]models.OutboxEvent, error)
+	MarkOutboxEventsDelivered(context.Context, models.MarkOutboxEventsDeliveredCmd) error
+	DeleteDeliveredOutboxEvents(context.Context, []string) (int, error)
+}
+
+// OutboxSink delivers the lifecycle events of expressions to a downstream system.
+// Failed deliveries are retried, so the downstream system must tolerate duplicate events.
+type OutboxSink interface {
+	// Name identifies the sink in the outbox. Renaming a sink redelivers the events pending at the time.
+	Name() string
+	// Deliver returns once the event is accepted by the downstream system.
+	Deliver(ctx context.Context, event *calculatorv1.ExpressionEvent) error
+}
+
+// Dispatcher delivers the events recorded in the outbox to the sinks at least once, in the order of their creation.
+// Each sink is polled every [config.Config.OutboxInterval] independently, so a failing sink only delays its own events,
+// which are retried with an exponential backoff. Events delivered to all sinks are deleted from the outbox.
+type Dispatcher struct {
+	conf    *config.Config
+	log     *slog.Logger
+	repo    OutboxRepository
+	metrics *Metrics
+	sinks   []OutboxSink
+}
+
+func NewDispatcher(conf *config.Config, log *slog.Logger, repo OutboxRepository, metrics *Metrics, sinks ...OutboxSink) *Dispatcher {
+	return &Dispatcher{
+		conf:    conf,
+		log:     logging.WithName(log, "outbox-dispatcher"),
+		repo:    repo,
+		metrics: metrics,
+		sinks:   sinks,
+	}
+}
+
+// Start runs the deliveries. It blocks until the context is canceled.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, sink := range d.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.run(ctx, sink)
+		}()
+	}
+
+	names := make([]string, 0, len(d.sinks))
+	for _, sink := range d.sinks {
+		names = append(names, sink.Name())
+	}
+	ticker := time.NewTicker(d.conf.OutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := d.repo.DeleteDeliveredOutboxEvents(ctx, names); err != nil && ctx.Err() == nil {
+				d.log.ErrorContext(ctx, "failed to delete delivered events", "error", err)
+			}
+		}
+	}
+}
+
+// run delivers the events to the sink until the context is canceled.
+func (d *Dispatcher) run(ctx context.Context, sink OutboxSink) {
+	var backoff time.Duration
+	for {
+		delay := d.conf.OutboxInterval
+		n, err := d.dispatch(ctx, sink)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			backoff = min(max(2*backoff, d.conf.OutboxInterval), d.conf.OutboxMaxBackoff)
+			delay = backoff
+			d.log.WarnContext(ctx, "failed to dispatch events", "sink", sink.Name(), "retry_in", delay, "error", err)
+		case n == d.conf.OutboxBatchSize:
+			backoff, delay = 0, 0 // the next batch is likely pending already
+		default:
+			backoff = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// dispatch delivers a batch of the pending events to the sink in order, stopping at the first failure.
+// Returns the number of delivered events.
+func (d *Dispatcher) dispatch(ctx context.Context, sink OutboxSink) (int, error) {
+	events, err := d.repo.ListOutboxEvents(ctx, models.ListOutboxEventsCmd{Sink: sink.Name(), Limit: d.conf.OutboxBatchSize})
+	if err != nil {
+		return 0, fmt.Errorf("list outbox events: %w", err)
+	}
+
+	delivered := make([]string, 0, len(events))
+	var deliverErr error
+	for _, event := range events {
+		if err := sink.Deliver(ctx, mapOutboxEventToResponse(&event)); err != nil {
+			deliverErr = fmt.Errorf("deliver event %s: %w", event.ID, err)
+			break
+		}
+		delivered = append(delivered, event.ID)
+	}
+	d.metrics.outboxDispatched(sink.Name(), len(delivered), deliverErr != nil)
+
+	// The events delivered before a shutdown are still marked, otherwise they would be delivered again
+	if err := d.repo.MarkOutboxEventsDelivered(context.WithoutCancel(ctx), models.MarkOutboxEventsDeliveredCmd{
+		Sink:     sink.Name(),
+		EventIDs: delivered,
+	}); err != nil {
+		return 0, fmt.Errorf("mark outbox events delivered: %w", err)
+	}
+	return len(delivered), deliverErr
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// streamWatcherBuffer is the number of events buffered for a watcher of the stream sink.
+const streamWatcherBuffer = 256
+
+var errStreamWatcherTooSlow = errors.New("watcher is too slow")
+
+// marshalExpressionEvent encodes the event as JSON the same way the HTTP API does.
+func marshalExpressionEvent(event *calculatorv1.ExpressionEvent) ([]byte, error) {
+	b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("protojson marshal: %w", err)
+	}
+	return b, nil
+}
+
+// WebhookSink POSTs each event as JSON to a URL. Any 2xx response acknowledges the event.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+var _ OutboxSink = (*WebhookSink)(nil)
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, event *calculatorv1.ExpressionEvent) error {
+	body, err := marshalExpressionEvent(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.GetId())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post event: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+// FileSink appends each event as a line of JSON to a file, i.e. writes NDJSON.
+// The file is reopened for each event, so it can be rotated by renaming.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ OutboxSink = (*FileSink)(nil)
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Deliver(_ context.Context, event *calculatorv1.ExpressionEvent) error {
+	line, err := marshalExpressionEvent(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write event: %w", err)
+	}
+	// The event is acknowledged only once it is durable
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	return nil
+}
+
+// StreamSink hands the events to the watchers of the [AdminService.WatchExpressionEvents] stream.
+// Events occurring while nobody watches are not kept, so that they don't pile up in the outbox.
+// A watcher falling behind is disconnected rather than slowing down the others,
+// and the events sent to a disconnected watcher are lost for it.
+type StreamSink struct {
+	mu       sync.Mutex
+	watchers map[*streamWatcher]struct{}
+}
+
+type streamWatcher struct {
+	events  chan *calculatorv1.ExpressionEvent
+	dropped chan struct{} // closed once the watcher is disconnected for falling behind
+}
+
+var _ OutboxSink = (*StreamSink)(nil)
+
+func NewStreamSink() *StreamSink {
+	return &StreamSink{watchers: make(map[*streamWatcher]struct{})}
+}
+
+func (s *StreamSink) Name() string {
+	return "stream"
+}
+
+// Deliver hands the event to every watcher. Without watchers, the event is dropped.
+func (s *StreamSink) Deliver(_ context.Context, event *calculatorv1.ExpressionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w := range s.watchers {
+		select {
+		case w.events <- event:
+		default:
+			delete(s.watchers, w)
+			close(w.dropped)
+		}
+	}
+	return nil
+}
+
+// Watch calls send for each delivered event until the context is canceled or send fails.
+// Returns [errStreamWatcherTooSlow] if the watcher falls behind.
+func (s *StreamSink) Watch(ctx context.Context, send func(*calculatorv1.ExpressionEvent) error) error {
+	w := &streamWatcher{
+		events:  make(chan *calculatorv1.ExpressionEvent, streamWatcherBuffer),
+		dropped: make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.dropped:
+			return errStreamWatcherTooSlow
+		case event := <-w.events:
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_dispatch(t *testing.T) {
+	events := []models.OutboxEvent{
+		{ID: "e1", Type: models.OutboxEventExpressionCompleted, ExpressionID: "expr1", Status: models.ExpressionStatusCompleted},
+		{ID: "e2", Type: models.OutboxEventExpressionFailed, ExpressionID: "expr2", Status: models.ExpressionStatusFailed},
+		{ID: "e3", Type: models.OutboxEventExpressionCompleted, ExpressionID: "expr3", Status: models.ExpressionStatusCompleted},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		repo := mocks.NewMockOutboxRepository(t)
+		repo.EXPECT().ListOutboxEvents(mock.Anything, models.ListOutboxEventsCmd{Sink: "webhook", Limit: 10}).Return(events, nil)
+		repo.EXPECT().MarkOutboxEventsDelivered(mock.Anything, models.MarkOutboxEventsDeliveredCmd{
+			Sink: "webhook", EventIDs: []string{"e1", "e2", "e3"},
+		}).Return(nil)
+
+		var delivered []string
+		sink := mocks.NewMockOutboxSink(t)
+		sink.EXPECT().Name().Return("webhook")
+		sink.EXPECT().Deliver(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, e *calculatorv1.ExpressionEvent) error {
+			delivered = append(delivered, e.GetId())
+			return nil
+		})
+
+		metrics := NewMetrics(mocks.NewMockMetricsRepository(t))
+		d := NewDispatcher(&config.Config{OutboxBatchSize: 10}, testutil.DiscardLogger(), repo, metrics, sink)
+
+		n, err := d.dispatch(context.Background(), sink)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []string{"e1", "e2", "e3"}, delivered, "in order")
+		assert.Equal(t, 3.0, promtestutil.ToFloat64(metrics.outboxDelivered.WithLabelValues("webhook")))
+	})
+
+	t.Run("sink error", func(t *testing.T) {
+		repo := mocks.NewMockOutboxRepository(t)
+		repo.EXPECT().ListOutboxEvents(mock.Anything, mock.Anything).Return(events, nil)
+		// The events delivered before the failure are not delivered again
+		repo.EXPECT().MarkOutboxEventsDelivered(mock.Anything, models.MarkOutboxEventsDeliveredCmd{
+			Sink: "webhook", EventIDs: []string{"e1"},
+		}).Return(nil)
+
+		sink := mocks.NewMockOutboxSink(t)
+		sink.EXPECT().Name().Return("webhook")
+		sink.EXPECT().Deliver(mock.Anything, mock.Anything).Return(nil).Once()
+		sink.EXPECT().Deliver(mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		metrics := NewMetrics(mocks.NewMockMetricsRepository(t))
+		d := NewDispatcher(&config.Config{OutboxBatchSize: 10}, testutil.DiscardLogger(), repo, metrics, sink)
+
+		n, err := d.dispatch(context.Background(), sink)
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 1.0, promtestutil.ToFloat64(metrics.outboxFailures.WithLabelValues("webhook")))
+	})
+
+	t.Run("mark error", func(t *testing.T) {
+		repo := mocks.NewMockOutboxRepository(t)
+		repo.EXPECT().ListOutboxEvents(mock.Anything, mock.Anything).Return(events[:1], nil)
+		repo.EXPECT().MarkOutboxEventsDelivered(mock.Anything, mock.Anything).Return(assert.AnError)
+
+		sink := mocks.NewMockOutboxSink(t)
+		sink.EXPECT().Name().Return("webhook")
+		sink.EXPECT().Deliver(mock.Anything, mock.Anything).Return(nil)
+		d := NewDispatcher(&config.Config{OutboxBatchSize: 10}, testutil.DiscardLogger(), repo, NewMetrics(mocks.NewMockMetricsRepository(t)), sink)
+
+		_, err := d.dispatch(context.Background(), sink)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestWebhookSink_Deliver(t *testing.T) {
+	event := &calculatorv1.ExpressionEvent{
+		Id:         "e1",
+		Type:       calculatorv1.ExpressionEventType_EXPRESSION_EVENT_TYPE_COMPLETED,
+		UserId:     "user1",
+		Expression: &calculatorv1.Expression{Id: "expr1", Expression: "2+2", Status: calculatorv1.ExpressionStatus_EXPRESSION_STATUS_COMPLETED, Result: 4},
+	}
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "e1", r.Header.Get("X-Event-Id"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &got))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewWebhookSink(srv.URL, time.Second).Deliver(context.Background(), event))
+	assert.Equal(t, "e1", got["id"])
+	assert.Equal(t, "EXPRESSION_EVENT_TYPE_COMPLETED", got["type"])
+	assert.Equal(t, "user1", got["userId"])
+	assert.Equal(t, map[string]any{
+		"id": "expr1", "expression": "2+2", "status": "EXPRESSION_STATUS_COMPLETED", "result": 4.0, "error": "",
+	}, got["expression"])
+
+	err := NewWebhookSink(srv.URL+"/fail", time.Second).Deliver(context.Background(), event)
+	require.ErrorContains(t, err, "503")
+}
+
+func TestFileSink_Deliver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink := NewFileSink(path)
+
+	for _, id := range []string{"e1", "e2"} {
+		require.NoError(t, sink.Deliver(context.Background(), &calculatorv1.ExpressionEvent{Id: id}))
+	}
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	require.Len(t, lines, 2, "a line per event")
+	for i, id := range []string{"e1", "e2"} {
+		var got map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got))
+		assert.Equal(t, id, got["id"])
+	}
+
+	err = NewFileSink(filepath.Join(t.TempDir(), "missing", "events.ndjson")).Deliver(context.Background(), &calculatorv1.ExpressionEvent{})
+	require.Error(t, err)
+}
+
+func TestStreamSink(t *testing.T) {
+	sink := NewStreamSink()
+	require.NoError(t, sink.Deliver(context.Background(), &calculatorv1.ExpressionEvent{Id: "e0"}),
+		"events are dropped without watchers")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 1)
+	done := make(chan error)
+	go func() {
+		done <- sink.Watch(ctx, func(e *calculatorv1.ExpressionEvent) error {
+			received <- e.GetId()
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool { return streamWatchers(sink) == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, sink.Deliver(context.Background(), &calculatorv1.ExpressionEvent{Id: "e1"}))
+	assert.Equal(t, "e1", <-received, "the dropped event isn't sent to the later watcher")
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 0, streamWatchers(sink))
+	require.NoError(t, sink.Deliver(context.Background(), &calculatorv1.ExpressionEvent{Id: "e2"}))
+}
+
+func TestStreamSink_slowWatcher(t *testing.T) {
+	sink := NewStreamSink()
+
+	block := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- sink.Watch(context.Background(), func(*calculatorv1.ExpressionEvent) error {
+			<-block
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool { return streamWatchers(sink) == 1 }, time.Second, time.Millisecond)
+
+	// The watcher is stuck on the first event, its buffer fills up
+	for range streamWatcherBuffer + 2 {
+		require.NoError(t, sink.Deliver(context.Background(), &calculatorv1.ExpressionEvent{}))
+	}
+	assert.Equal(t, 0, streamWatchers(sink), "the slow watcher is disconnected")
+	close(block)
+	require.ErrorIs(t, <-done, errStreamWatcherTooSlow)
+}
+
+// streamWatchers returns the number of the watchers connected to the sink.
+func streamWatchers(sink *StreamSink) int {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return len(sink.watchers)
+}
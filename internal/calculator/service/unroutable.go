@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/logging"
+)
+
+type UnroutableRepository interface {
+	ReportUnroutableTasks(context.Context, time.Time) (int, error)
+}
+
+// UnroutableReporter marks the expressions whose pending tasks no live agent can execute
+// every [config.Config.AgentUnroutableInterval], so that they do not starve silently.
+type UnroutableReporter struct {
+	conf *config.Config
+	log  *slog.Logger
+	repo UnroutableRepository
+}
+
+func NewUnroutableReporter(conf *config.Config, log *slog.Logger, repo UnroutableRepository) *UnroutableReporter {
+	return &UnroutableReporter{
+		conf: conf,
+		log:  logging.WithName(log, "unroutable-reporter"),
+		repo: repo,
+	}
+}
+
+// Start runs the reports. It blocks until the context is canceled.
+func (r *UnroutableReporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.conf.AgentUnroutableInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			n, err := r.repo.ReportUnroutableTasks(ctx, now.Add(-r.conf.AgentTTL))
+			if err != nil {
+				if ctx.Err() == nil {
+					r.log.ErrorContext(ctx, "failed to report unroutable tasks", "error", err)
+				}
+				continue
+			}
+			if n > 0 {
+				r.log.WarnContext(ctx, "expressions have tasks no available agent can execute", "count", n)
+			}
+		}
+	}
+}
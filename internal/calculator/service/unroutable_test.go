@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
+	"github.com/belo4ya/edu-final-calculate-api/internal/testutil"
+	mocks "github.com/belo4ya/edu-final-calculate-api/internal/testutil/mocks/calculator/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnroutableReporter_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := mocks.NewMockUnroutableRepository(t)
+	repo.EXPECT().ReportUnroutableTasks(mock.Anything, mock.Anything).Return(0, assert.AnError).Once()
+	repo.EXPECT().ReportUnroutableTasks(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, since time.Time) (int, error) {
+		assert.WithinDuration(t, time.Now().Add(-time.Minute), since, time.Second, "agents seen within the TTL are alive")
+		cancel()
+		return 1, nil
+	}).Once()
+
+	conf := &config.Config{AgentTTL: time.Minute, AgentUnroutableInterval: time.Millisecond}
+	r := NewUnroutableReporter(conf, testutil.DiscardLogger(), repo)
+
+	done := make(chan error)
+	go func() { done <- r.Start(ctx) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err, "reports continue after a failure")
+	case <-time.After(5 * time.Second):
+		t.Fatal("reporter did not stop")
+	}
+}
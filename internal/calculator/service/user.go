@@ -2,12 +2,12 @@ package service
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
@@ -17,9 +17,12 @@ import (
 	calculatorv1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/samber/lo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -31,9 +34,30 @@ type (
 	UserRepository interface {
 		Register(ctx context.Context, cmd models.RegisterUserCmd) error
 		GetUser(ctx context.Context, cmd models.GetUserCmd) (*models.User, error)
+		UpdatePasswordHash(ctx context.Context, cmd models.UpdatePasswordHashCmd) error
+		GetUserByID(ctx context.Context, userID string) (*models.User, error)
+		CreateRefreshToken(ctx context.Context, cmd models.CreateRefreshTokenCmd) (*models.RefreshToken, error)
+		RotateRefreshToken(ctx context.Context, cmd models.RotateRefreshTokenCmd) (*models.RefreshToken, error)
+		RevokeRefreshToken(ctx context.Context, cmd models.RevokeRefreshTokenCmd) error
+		RevokeToken(ctx context.Context, cmd models.RevokeTokenCmd) error
+		ChangePassword(ctx context.Context, cmd models.ChangePasswordCmd) error
+		DeleteUser(ctx context.Context, userID string) error
+		GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+		RecordLoginFailure(ctx context.Context, cmd models.RecordLoginFailureCmd) (*models.LoginThrottle, error)
+		LockLogin(ctx context.Context, cmd models.LockLoginCmd) error
+		ResetLoginFailures(ctx context.Context, key string) error
+		CreateAuditEvent(ctx context.Context, cmd models.CreateAuditEventCmd) error
+		CreateAPIKey(ctx context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error)
+		ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+		RevokeAPIKey(ctx context.Context, cmd models.RevokeAPIKeyCmd) error
 	}
 )
 
+// dummyPasswordHash is verified against when the user doesn't exist.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	return lo.Must(auth.HashPassword("dummy-password"))
+})
+
 type UserService struct {
 	calculatorv1.UnimplementedUserServiceServer
 	conf *config.Config
@@ -60,9 +84,21 @@ func (s *UserService) RegisterGRPCGateway(ctx context.Context, mux *runtime.Serv
 }
 
 func (s *UserService) Register(ctx context.Context, req *calculatorv1.RegisterRequest) (*emptypb.Empty, error) {
+	var violations fieldViolations
+	violations.Add("login", auth.ValidateLogin(req.Login)...)
+	violations.Add("password", passwordPolicy(s.conf).Validate(req.Password, req.Login)...)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("hash password: %w", err))
+	}
+
 	if err := s.repo.Register(ctx, models.RegisterUserCmd{
 		Login:        req.Login,
-		PasswordHash: s.hashPassword(req.Password),
+		PasswordHash: hash,
 	}); err != nil {
 		if errors.Is(err, models.ErrUserExists) {
 			server.WithHTTPResponseCode(ctx, http.StatusBadRequest)
@@ -75,27 +111,354 @@ func (s *UserService) Register(ctx context.Context, req *calculatorv1.RegisterRe
 }
 
 func (s *UserService) Login(ctx context.Context, req *calculatorv1.LoginRequest) (*calculatorv1.LoginResponse, error) {
-	user, err := s.repo.GetUser(ctx, models.GetUserCmd{
-		Login:        req.Login,
-		PasswordHash: s.hashPassword(req.Password),
+	var violations fieldViolations
+	if req.Login == "" {
+		violations.Add("login", "is required")
+	}
+	if req.Password == "" {
+		violations.Add("password", "is required")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	throttles := s.loginThrottles(ctx, req.Login)
+	if err := s.checkLoginLockout(ctx, throttles); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUser(ctx, models.GetUserCmd{Login: req.Login})
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			// Spend the same time as for an existing user, so that logins can't be enumerated by timing.
+			_, _, _ = auth.VerifyPassword(dummyPasswordHash(), req.Password)
+			return nil, s.loginFailed(ctx, throttles)
+		}
+		return nil, InternalError(fmt.Errorf("get user: %w", err))
+	}
+
+	ok, rehash, err := auth.VerifyPassword(user.PasswordHash, req.Password)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("verify password: %w", err))
+	}
+	if !ok {
+		return nil, s.loginFailed(ctx, throttles)
+	}
+	if rehash {
+		s.rehashPassword(ctx, user.ID, req.Password)
+	}
+
+	// Only the login is reset, otherwise a valid account would allow guessing passwords of others from the same IP.
+	if err := s.repo.ResetLoginFailures(ctx, throttles[0].key); err != nil {
+		return nil, InternalError(fmt.Errorf("reset login failures: %w", err))
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+func (s *UserService) RefreshToken(ctx context.Context, req *calculatorv1.RefreshTokenRequest) (*calculatorv1.LoginResponse, error) {
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, InternalError(err)
+	}
+
+	rotated, err := s.repo.RotateRefreshToken(ctx, models.RotateRefreshTokenCmd{
+		TokenHash:    auth.HashRefreshToken(req.RefreshToken),
+		NewTokenHash: auth.HashRefreshToken(refreshToken),
+		ExpiresAt:    time.Now().Add(s.conf.AuthRefreshTokenExpirationTime),
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRefreshTokenReused):
+			s.log.WarnContext(ctx, "refresh token reuse detected, token family revoked")
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		case errors.Is(err, models.ErrRefreshTokenNotFound), errors.Is(err, models.ErrRefreshTokenExpired):
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, InternalError(fmt.Errorf("rotate refresh token: %w", err))
+	}
+
+	user, err := s.repo.GetUserByID(ctx, rotated.UserID)
 	if err != nil {
 		if errors.Is(err, models.ErrUserNotFound) {
-			server.WithHTTPResponseCode(ctx, http.StatusBadRequest)
-			return nil, status.Error(codes.FailedPrecondition, "bad login or password")
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
 		}
 		return nil, InternalError(fmt.Errorf("get user: %w", err))
 	}
 
-	token, err := s.auth.GenerateJWT(auth.UserInfo{ID: user.ID, Login: user.Login})
+	token, err := s.auth.GenerateJWT(mapUserToUserInfo(user))
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("generate jwt: %w", err))
+	}
+	return &calculatorv1.LoginResponse{
+		AccessToken:            token,
+		RefreshToken:           refreshToken,
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}, nil
+}
+
+func (s *UserService) Logout(ctx context.Context, req *calculatorv1.LogoutRequest) (*emptypb.Empty, error) {
+	userID := auth.MustUserIDFromContext(ctx)
+	token := lo.Must(auth.TokenFromContext(ctx))
+
+	if err := s.repo.RevokeToken(ctx, models.RevokeTokenCmd{
+		JTI:       token.ID,
+		UserID:    userID,
+		ExpiresAt: token.ExpiresAt,
+	}); err != nil {
+		return nil, InternalError(fmt.Errorf("revoke token: %w", err))
+	}
+
+	if req.RefreshToken != "" {
+		if err := s.repo.RevokeRefreshToken(ctx, models.RevokeRefreshTokenCmd{
+			UserID:    userID,
+			TokenHash: auth.HashRefreshToken(req.RefreshToken),
+		}); err != nil && !errors.Is(err, models.ErrRefreshTokenNotFound) {
+			return nil, InternalError(fmt.Errorf("revoke refresh token: %w", err))
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserService) GetMe(ctx context.Context, _ *emptypb.Empty) (*calculatorv1.User, error) {
+	user, err := s.repo.GetUserByID(ctx, auth.MustUserIDFromContext(ctx))
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("get user: %w", err))
+	}
+	return mapUserToResponse(user), nil
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, req *calculatorv1.ChangePasswordRequest) (*calculatorv1.LoginResponse, error) {
+	var violations fieldViolations
+	violations.Add("new_password", passwordPolicy(s.conf).Validate(req.NewPassword, lo.Must(auth.UserFromContext(ctx)).Login)...)
+	if req.NewPassword != "" && req.NewPassword == req.CurrentPassword {
+		violations.Add("new_password", "must differ from the current password")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Users created on the first OIDC login have no password and set an initial one
+	if user.PasswordHash != "" && req.CurrentPassword == "" {
+		violations.Add("current_password", "is required")
+		return nil, violations.Err()
+	}
+	if err := s.confirmUser(ctx, user, req.CurrentPassword); err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, InternalError(fmt.Errorf("hash password: %w", err))
+	}
+	if err := s.repo.ChangePassword(ctx, models.ChangePasswordCmd{UserID: user.ID, PasswordHash: hash}); err != nil {
+		return nil, InternalError(fmt.Errorf("change password: %w", err))
+	}
+	s.log.InfoContext(ctx, "password changed, tokens revoked", "user_id", user.ID)
+
+	user.PasswordChangeRequired = false
+	return s.issueTokens(ctx, user)
+}
+
+func (s *UserService) DeleteAccount(ctx context.Context, req *calculatorv1.DeleteAccountRequest) (*emptypb.Empty, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.confirmUser(ctx, user, req.Password); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteUser(ctx, user.ID); err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return nil, InternalError(fmt.Errorf("delete user: %w", err))
+	}
+	s.log.InfoContext(ctx, "account deleted", "user_id", user.ID)
+
+	return &emptypb.Empty{}, nil
+}
+
+// issueTokens issues a new pair of access and refresh tokens for the user who has just authenticated.
+func (s *UserService) issueTokens(ctx context.Context, user *models.User) (*calculatorv1.LoginResponse, error) {
+	info := mapUserToUserInfo(user)
+	info.AuthTime = time.Now().UTC()
+	token, err := s.auth.GenerateJWT(info)
 	if err != nil {
 		return nil, InternalError(fmt.Errorf("generate jwt: %w", err))
 	}
-	return &calculatorv1.LoginResponse{AccessToken: token}, nil
+
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, InternalError(err)
+	}
+	if _, err := s.repo.CreateRefreshToken(ctx, models.CreateRefreshTokenCmd{
+		UserID:    user.ID,
+		TokenHash: auth.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.conf.AuthRefreshTokenExpirationTime),
+	}); err != nil {
+		return nil, InternalError(fmt.Errorf("create refresh token: %w", err))
+	}
+
+	return &calculatorv1.LoginResponse{
+		AccessToken:            token,
+		RefreshToken:           refreshToken,
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}, nil
+}
+
+// currentUser returns the authenticated user.
+func (s *UserService) currentUser(ctx context.Context) (*models.User, error) {
+	user, err := s.repo.GetUserByID(ctx, auth.MustUserIDFromContext(ctx))
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, InternalError(fmt.Errorf("get user: %w", err))
+	}
+	return user, nil
+}
+
+// confirmUser confirms a sensitive operation with the password of the authenticated user.
+// Users without a password confirm it by having logged in less than
+// [config.Config.AuthReauthenticationMaxAge] ago, refreshed access tokens don't count as a login.
+func (s *UserService) confirmUser(ctx context.Context, user *models.User, password string) error {
+	if user.PasswordHash == "" {
+		authTime := lo.Must(auth.UserFromContext(ctx)).AuthTime
+		if authTime.IsZero() || time.Since(authTime) > s.conf.AuthReauthenticationMaxAge {
+			server.WithHTTPResponseCode(ctx, http.StatusBadRequest)
+			return status.Error(codes.FailedPrecondition, "recent login required, log in again")
+		}
+		return nil
+	}
+
+	ok, _, err := auth.VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return InternalError(fmt.Errorf("verify password: %w", err))
+	}
+	if !ok {
+		server.WithHTTPResponseCode(ctx, http.StatusBadRequest)
+		return status.Error(codes.FailedPrecondition, "wrong password")
+	}
+	return nil
+}
+
+func passwordPolicy(conf *config.Config) auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:      conf.AuthPasswordMinLength,
+		MaxLength:      conf.AuthPasswordMaxLength,
+		MinCharClasses: conf.AuthPasswordMinCharClasses,
+	}
+}
+
+// loginThrottle is a key login attempts are throttled by, such as the login or the client IP.
+type loginThrottle struct {
+	key    string
+	policy auth.LockoutPolicy
 }
 
-func (s *UserService) hashPassword(password string) string {
-	h := md5.New()
-	h.Write([]byte(password))
-	return hex.EncodeToString(h.Sum(nil))
+// loginThrottles returns the throttles of a login attempt. The login throttle always goes first.
+func (s *UserService) loginThrottles(ctx context.Context, login string) []loginThrottle {
+	policy := auth.LockoutPolicy{
+		MaxAttempts: s.conf.AuthLoginMaxAttempts,
+		Duration:    s.conf.AuthLoginLockoutDuration,
+		MaxDuration: s.conf.AuthLoginMaxLockoutDuration,
+	}
+	throttles := []loginThrottle{{key: models.LoginThrottleKey(login), policy: policy}}
+
+	if ip := server.ClientIP(ctx); ip != "" {
+		policy.MaxAttempts = s.conf.AuthLoginMaxAttemptsPerIP
+		throttles = append(throttles, loginThrottle{key: "ip:" + ip, policy: policy})
+	}
+	return throttles
+}
+
+// checkLoginLockout rejects the login attempt if any of its throttles is locked out.
+func (s *UserService) checkLoginLockout(ctx context.Context, throttles []loginThrottle) error {
+	keys := lo.Map(throttles, func(t loginThrottle, _ int) string { return t.key })
+	locked, err := s.repo.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return InternalError(fmt.Errorf("get login throttles: %w", err))
+	}
+
+	now := time.Now()
+	var lockedUntil time.Time
+	for _, t := range locked {
+		if t.Locked(now) && t.LockedUntil.V.After(lockedUntil) {
+			lockedUntil = t.LockedUntil.V
+		}
+	}
+	if lockedUntil.IsZero() {
+		return nil
+	}
+
+	st, err := status.New(codes.ResourceExhausted, "too many failed login attempts, try again later").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(lockedUntil.Sub(now).Round(time.Second))})
+	if err != nil {
+		return InternalError(err)
+	}
+	return st.Err()
+}
+
+// loginFailed records a failed login attempt, locks out the throttles that exceeded their limits
+// and returns the error to respond with.
+func (s *UserService) loginFailed(ctx context.Context, throttles []loginThrottle) error {
+	for _, t := range throttles {
+		if t.policy.MaxAttempts <= 0 {
+			continue
+		}
+
+		throttle, err := s.repo.RecordLoginFailure(ctx, models.RecordLoginFailureCmd{
+			Key:    t.key,
+			Window: s.conf.AuthLoginFailureWindow,
+		})
+		if err != nil {
+			return InternalError(fmt.Errorf("record login failure: %w", err))
+		}
+
+		lockout := t.policy.Lockout(throttle.Failures)
+		if lockout == 0 {
+			continue
+		}
+		lockedUntil := time.Now().Add(lockout)
+		if err := s.repo.LockLogin(ctx, models.LockLoginCmd{Key: t.key, LockedUntil: lockedUntil}); err != nil {
+			return InternalError(fmt.Errorf("lock login: %w", err))
+		}
+
+		s.log.WarnContext(ctx, "login attempts locked out", "key", t.key, "failures", throttle.Failures, "lockout", lockout)
+		if err := s.repo.CreateAuditEvent(ctx, models.CreateAuditEventCmd{
+			Event:   models.AuditEventLoginLockout,
+			Subject: t.key,
+			Details: fmt.Sprintf("%d consecutive failed attempts, locked out for %s", throttle.Failures, lockout),
+		}); err != nil {
+			s.log.ErrorContext(ctx, "failed to audit login lockout", "key", t.key, "error", err)
+		}
+	}
+	return s.badCredentials(ctx)
+}
+
+func (s *UserService) badCredentials(ctx context.Context) error {
+	server.WithHTTPResponseCode(ctx, http.StatusBadRequest)
+	return status.Error(codes.FailedPrecondition, "bad login or password")
+}
+
+// rehashPassword upgrades a legacy or outdated password hash after a successful login.
+// Failures are only logged, as the user has been authenticated anyway.
+func (s *UserService) rehashPassword(ctx context.Context, userID, password string) {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to rehash password", "user_id", userID, "error", err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, models.UpdatePasswordHashCmd{UserID: userID, PasswordHash: hash}); err != nil {
+		s.log.ErrorContext(ctx, "failed to update password hash", "user_id", userID, "error", err)
+		return
+	}
+	s.log.InfoContext(ctx, "password hash upgraded", "user_id", userID)
 }
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/auth"
 	"github.com/belo4ya/edu-final-calculate-api/internal/calculator/config"
@@ -16,6 +19,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestUserService_Register(t *testing.T) {
@@ -33,8 +43,9 @@ func TestUserService_Register(t *testing.T) {
 			name: "successful registration",
 			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
 				repo.EXPECT().Register(mock.Anything, mock.MatchedBy(func(cmd models.RegisterUserCmd) bool {
-					// Password "password123" should be hashed
-					return cmd.Login == "testuser" && cmd.PasswordHash != "password123"
+					// Password "password123" should be hashed with argon2id
+					ok, rehash, err := auth.VerifyPassword(cmd.PasswordHash, "password123")
+					return cmd.Login == "testuser" && ok && !rehash && err == nil
 				})).Return(nil)
 			},
 			args: args{
@@ -82,7 +93,7 @@ func TestUserService_Register(t *testing.T) {
 			repo := mocks.NewMockUserRepository(t)
 
 			tt.setupMocks(authMock, repo)
-			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), authMock, repo)
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), authMock, repo)
 
 			_, err := svc.Register(tt.args.ctx, tt.args.req)
 			tt.wantErr(t, err, fmt.Sprintf("Register(%v, %v)", tt.args.ctx, tt.args.req))
@@ -90,9 +101,64 @@ func TestUserService_Register(t *testing.T) {
 	}
 }
 
+func TestUserService_Register_validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *calculatorv1.RegisterRequest
+		wantFields []string
+	}{
+		{
+			name:       "empty request",
+			req:        &calculatorv1.RegisterRequest{},
+			wantFields: []string{"login", "password", "password"},
+		},
+		{
+			name:       "login with invalid characters",
+			req:        &calculatorv1.RegisterRequest{Login: "test user", Password: "password123"},
+			wantFields: []string{"login"},
+		},
+		{
+			name:       "short password",
+			req:        &calculatorv1.RegisterRequest{Login: "testuser", Password: "pass1"},
+			wantFields: []string{"password"},
+		},
+		{
+			name:       "password of a single char class",
+			req:        &calculatorv1.RegisterRequest{Login: "testuser", Password: "password"},
+			wantFields: []string{"password"},
+		},
+		{
+			name:       "password matches login",
+			req:        &calculatorv1.RegisterRequest{Login: "testuser1", Password: "TestUser1"},
+			wantFields: []string{"password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), mocks.NewMockAuth(t), mocks.NewMockUserRepository(t))
+
+			_, err := svc.Register(context.Background(), tt.req)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), err)
+
+			var fields []string
+			for _, d := range status.Convert(err).Details() {
+				badRequest, ok := d.(*errdetails.BadRequest)
+				require.True(t, ok)
+				for _, v := range badRequest.FieldViolations {
+					fields = append(fields, v.Field)
+				}
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
 func TestUserService_Login(t *testing.T) {
 	userID := "user-id"
 	userLogin := "testuser"
+	passwordHash, err := auth.HashPassword("password123")
+	require.NoError(t, err)
 
 	type args struct {
 		ctx context.Context
@@ -108,18 +174,21 @@ func TestUserService_Login(t *testing.T) {
 		{
 			name: "successful login",
 			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
-				repo.EXPECT().GetUser(mock.Anything, mock.MatchedBy(func(cmd models.GetUserCmd) bool {
-					// Password "password123" should be hashed
-					return cmd.Login == "testuser" && cmd.PasswordHash != "password123"
-				})).Return(&models.User{
-					ID:    userID,
-					Login: userLogin,
+				repo.EXPECT().GetUser(mock.Anything, models.GetUserCmd{Login: "testuser"}).Return(&models.User{
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: passwordHash,
+					Role:         models.UserRoleReadOnly,
 				}, nil)
 
-				authMock.EXPECT().GenerateJWT(auth.UserInfo{
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{
 					ID:    userID,
 					Login: userLogin,
-				}).Return("jwt-token", nil)
+					Role:  models.UserRoleReadOnly,
+				})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.MatchedBy(func(cmd models.CreateRefreshTokenCmd) bool {
+					return cmd.UserID == userID && cmd.TokenHash != "" && cmd.ExpiresAt.After(time.Now())
+				})).Return(&models.RefreshToken{}, nil)
 			},
 			args: args{
 				ctx: context.Background(),
@@ -133,6 +202,132 @@ func TestUserService_Login(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "successful login upgrades legacy md5 hash",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, models.GetUserCmd{Login: "admin"}).Return(&models.User{
+					ID:           "00000000000000000000",
+					Login:        "admin",
+					PasswordHash: "21232f297a57a5a743894a0e4a801fc3", // MD5 hash of "admin"
+				}, nil)
+				repo.EXPECT().UpdatePasswordHash(mock.Anything, mock.MatchedBy(func(cmd models.UpdatePasswordHashCmd) bool {
+					ok, rehash, err := auth.VerifyPassword(cmd.PasswordHash, "admin")
+					return cmd.UserID == "00000000000000000000" && ok && !rehash && err == nil
+				})).Return(nil)
+
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{
+					ID:    "00000000000000000000",
+					Login: "admin",
+				})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "admin",
+					Password: "admin",
+				},
+			},
+			want: &calculatorv1.LoginResponse{
+				AccessToken: "jwt-token",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "bootstrapped admin is required to change password",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, models.GetUserCmd{Login: "admin"}).Return(&models.User{
+					ID:                     "admin-id",
+					Login:                  "admin",
+					PasswordHash:           passwordHash,
+					Role:                   models.UserRoleAdmin,
+					PasswordChangeRequired: true,
+				}, nil)
+
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{
+					ID:                     "admin-id",
+					Login:                  "admin",
+					Role:                   models.UserRoleAdmin,
+					PasswordChangeRequired: true,
+				})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "admin",
+					Password: "password123",
+				},
+			},
+			want: &calculatorv1.LoginResponse{
+				AccessToken:            "jwt-token",
+				PasswordChangeRequired: true,
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "login succeeds when hash upgrade fails",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(&models.User{
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: "482c811da5d5b4bc6d497ffa98491e38", // MD5 hash of "password123"
+				}, nil)
+				repo.EXPECT().UpdatePasswordHash(mock.Anything, mock.Anything).Return(assert.AnError)
+
+				authMock.EXPECT().GenerateJWT(mock.Anything).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "testuser",
+					Password: "password123",
+				},
+			},
+			want: &calculatorv1.LoginResponse{
+				AccessToken: "jwt-token",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "wrong password",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(&models.User{
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: passwordHash,
+				}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "testuser",
+					Password: "wrong",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
+		{
+			name: "unknown password hash format",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(&models.User{
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: "password123",
+				}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "testuser",
+					Password: "password123",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
 		{
 			name: "user not found",
 			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
@@ -167,8 +362,9 @@ func TestUserService_Login(t *testing.T) {
 			name: "JWT generation error",
 			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
 				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(&models.User{
-					ID:    userID,
-					Login: userLogin,
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: passwordHash,
 				}, nil)
 
 				authMock.EXPECT().GenerateJWT(mock.Anything).Return("", errors.New("JWT signing error"))
@@ -183,6 +379,28 @@ func TestUserService_Login(t *testing.T) {
 			want:    nil,
 			wantErr: assert.Error,
 		},
+		{
+			name: "refresh token creation error",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(&models.User{
+					ID:           userID,
+					Login:        userLogin,
+					PasswordHash: passwordHash,
+				}, nil)
+
+				authMock.EXPECT().GenerateJWT(mock.Anything).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &calculatorv1.LoginRequest{
+					Login:    "testuser",
+					Password: "password123",
+				},
+			},
+			want:    nil,
+			wantErr: assert.Error,
+		},
 	}
 
 	for _, tt := range tests {
@@ -191,13 +409,519 @@ func TestUserService_Login(t *testing.T) {
 			repo := mocks.NewMockUserRepository(t)
 
 			tt.setupMocks(authMock, repo)
-			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), authMock, repo)
+			// Throttling is covered by TestUserService_Login_lockout
+			repo.EXPECT().GetLoginThrottles(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+			repo.EXPECT().RecordLoginFailure(mock.Anything, mock.Anything).Return(&models.LoginThrottle{Failures: 1}, nil).Maybe()
+			repo.EXPECT().ResetLoginFailures(mock.Anything, mock.Anything).Return(nil).Maybe()
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), authMock, repo)
 
 			got, err := svc.Login(tt.args.ctx, tt.args.req)
 			if !tt.wantErr(t, err, fmt.Sprintf("Login(%v, %v)", tt.args.ctx, tt.args.req)) {
 				return
 			}
+			if got != nil {
+				// The refresh token is random, so only its presence is checked
+				assert.NotEmpty(t, got.RefreshToken)
+				got.RefreshToken = ""
+			}
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestUserService_Login_lockout(t *testing.T) {
+	passwordHash, err := auth.HashPassword("password123")
+	require.NoError(t, err)
+	user := &models.User{ID: "user-id", Login: "testuser", PasswordHash: passwordHash}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}})
+
+	tests := []struct {
+		name       string
+		password   string
+		setupMocks func(repo *mocks.MockUserRepository)
+		wantCode   codes.Code
+	}{
+		{
+			name:     "locked out",
+			password: "password123",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetLoginThrottles(mock.Anything, []string{"login:testuser", "ip:192.0.2.1"}).Return([]models.LoginThrottle{
+					{Key: "ip:192.0.2.1", LockedUntil: sql.Null[time.Time]{V: time.Now().Add(time.Minute), Valid: true}},
+				}, nil)
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "expired lockout",
+			password: "password123",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetLoginThrottles(mock.Anything, mock.Anything).Return([]models.LoginThrottle{
+					{Key: "login:testuser", LockedUntil: sql.Null[time.Time]{V: time.Now().Add(-time.Minute), Valid: true}},
+				}, nil)
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(user, nil)
+				repo.EXPECT().ResetLoginFailures(mock.Anything, "login:testuser").Return(nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "failure below the limit",
+			password: "wrong",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetLoginThrottles(mock.Anything, mock.Anything).Return(nil, nil)
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(user, nil)
+				repo.EXPECT().RecordLoginFailure(mock.Anything, models.RecordLoginFailureCmd{Key: "login:testuser", Window: 15 * time.Minute}).
+					Return(&models.LoginThrottle{Key: "login:testuser", Failures: 4}, nil)
+				repo.EXPECT().RecordLoginFailure(mock.Anything, models.RecordLoginFailureCmd{Key: "ip:192.0.2.1", Window: 15 * time.Minute}).
+					Return(&models.LoginThrottle{Key: "ip:192.0.2.1", Failures: 4}, nil)
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "failure reaching the limit locks out and audits",
+			password: "wrong",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetLoginThrottles(mock.Anything, mock.Anything).Return(nil, nil)
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(nil, models.ErrUserNotFound)
+				repo.EXPECT().RecordLoginFailure(mock.Anything, mock.MatchedBy(func(cmd models.RecordLoginFailureCmd) bool {
+					return cmd.Key == "login:testuser"
+				})).Return(&models.LoginThrottle{Key: "login:testuser", Failures: 7}, nil)
+				repo.EXPECT().RecordLoginFailure(mock.Anything, mock.MatchedBy(func(cmd models.RecordLoginFailureCmd) bool {
+					return cmd.Key == "ip:192.0.2.1"
+				})).Return(&models.LoginThrottle{Key: "ip:192.0.2.1", Failures: 7}, nil)
+				// 4 minutes after the 2 failures over the limit of 5
+				repo.EXPECT().LockLogin(mock.Anything, mock.MatchedBy(func(cmd models.LockLoginCmd) bool {
+					return cmd.Key == "login:testuser" && time.Until(cmd.LockedUntil).Round(time.Minute) == 4*time.Minute
+				})).Return(nil)
+				repo.EXPECT().CreateAuditEvent(mock.Anything, mock.MatchedBy(func(cmd models.CreateAuditEventCmd) bool {
+					return cmd.Event == models.AuditEventLoginLockout && cmd.Subject == "login:testuser"
+				})).Return(assert.AnError) // audit failures don't fail the request
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "record failure error",
+			password: "wrong",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetLoginThrottles(mock.Anything, mock.Anything).Return(nil, nil)
+				repo.EXPECT().GetUser(mock.Anything, mock.Anything).Return(user, nil)
+				repo.EXPECT().RecordLoginFailure(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMock := mocks.NewMockAuth(t)
+			authMock.EXPECT().GenerateJWT(mock.Anything).Return("jwt-token", nil).Maybe()
+			repo := mocks.NewMockUserRepository(t)
+			repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil).Maybe()
+			tt.setupMocks(repo)
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), authMock, repo)
+
+			_, err := svc.Login(ctx, &calculatorv1.LoginRequest{Login: "TestUser", Password: tt.password})
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode == codes.ResourceExhausted {
+				require.Len(t, status.Convert(err).Details(), 1)
+				retryInfo, ok := status.Convert(err).Details()[0].(*errdetails.RetryInfo)
+				require.True(t, ok)
+				assert.Equal(t, time.Minute, retryInfo.RetryDelay.AsDuration())
+			}
+		})
+	}
+}
+
+func TestUserService_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository)
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful refresh",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.MatchedBy(func(cmd models.RotateRefreshTokenCmd) bool {
+					return cmd.TokenHash == auth.HashRefreshToken("refresh-token") && cmd.NewTokenHash != cmd.TokenHash
+				})).Return(&models.RefreshToken{UserID: "user-id"}, nil)
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{ID: "user-id", Login: "testuser"}, nil)
+				authMock.EXPECT().GenerateJWT(auth.UserInfo{ID: "user-id", Login: "testuser"}).Return("jwt-token", nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "unknown token",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.Anything).Return(nil, models.ErrRefreshTokenNotFound)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "expired token",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.Anything).Return(nil, models.ErrRefreshTokenExpired)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "reused token",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.Anything).Return(nil, models.ErrRefreshTokenReused)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "deleted user",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{UserID: "user-id"}, nil)
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(nil, models.ErrUserNotFound)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().RotateRefreshToken(mock.Anything, mock.Anything).Return(nil, assert.AnError)
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMock := mocks.NewMockAuth(t)
+			repo := mocks.NewMockUserRepository(t)
+
+			tt.setupMocks(authMock, repo)
+			svc := NewUserService(&config.Config{AuthRefreshTokenExpirationTime: time.Hour}, testutil.DiscardLogger(), authMock, repo)
+
+			got, err := svc.RefreshToken(context.Background(), &calculatorv1.RefreshTokenRequest{RefreshToken: "refresh-token"})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				return
+			}
+			assert.Equal(t, "jwt-token", got.AccessToken)
+			assert.NotEmpty(t, got.RefreshToken)
+			assert.NotEqual(t, "refresh-token", got.RefreshToken)
+		})
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	ctx = auth.WithToken(ctx, auth.TokenInfo{ID: "jti", ExpiresAt: expiresAt})
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockUserRepository)
+		req        *calculatorv1.LogoutRequest
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			name: "access token only",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().RevokeToken(mock.Anything, models.RevokeTokenCmd{JTI: "jti", UserID: "user-id", ExpiresAt: expiresAt}).Return(nil)
+			},
+			req:     &calculatorv1.LogoutRequest{},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "with refresh token",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().RevokeToken(mock.Anything, mock.Anything).Return(nil)
+				repo.EXPECT().RevokeRefreshToken(mock.Anything, models.RevokeRefreshTokenCmd{
+					UserID:    "user-id",
+					TokenHash: auth.HashRefreshToken("refresh-token"),
+				}).Return(nil)
+			},
+			req:     &calculatorv1.LogoutRequest{RefreshToken: "refresh-token"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "unknown refresh token is ignored",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().RevokeToken(mock.Anything, mock.Anything).Return(nil)
+				repo.EXPECT().RevokeRefreshToken(mock.Anything, mock.Anything).Return(models.ErrRefreshTokenNotFound)
+			},
+			req:     &calculatorv1.LogoutRequest{RefreshToken: "refresh-token"},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "revocation error",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().RevokeToken(mock.Anything, mock.Anything).Return(assert.AnError)
+			},
+			req:     &calculatorv1.LogoutRequest{RefreshToken: "refresh-token"},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+			_, err := svc.Logout(ctx, tt.req)
+			tt.wantErr(t, err)
+		})
+	}
+}
+
+func TestUserService_GetMe(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	createdAt := time.Now().UTC()
+
+	repo := mocks.NewMockUserRepository(t)
+	repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{
+		ID:        "user-id",
+		Login:     "testuser",
+		Role:      models.UserRoleUser,
+		CreatedAt: createdAt,
+	}, nil)
+	svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+	got, err := svc.GetMe(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, &calculatorv1.User{
+		Id:        "user-id",
+		Login:     "testuser",
+		Role:      calculatorv1.UserRole_USER_ROLE_USER,
+		CreatedAt: timestamppb.New(createdAt),
+	}, got)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	passwordHash, err := auth.HashPassword("password123")
+	require.NoError(t, err)
+	user := &models.User{ID: "user-id", Login: "testuser", PasswordHash: passwordHash}
+
+	tests := []struct {
+		name       string
+		setupMocks func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository)
+		req        *calculatorv1.ChangePasswordRequest
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful change",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+				repo.EXPECT().ChangePassword(mock.Anything, mock.MatchedBy(func(cmd models.ChangePasswordCmd) bool {
+					ok, _, err := auth.VerifyPassword(cmd.PasswordHash, "newpassword1")
+					return cmd.UserID == "user-id" && ok && err == nil
+				})).Return(nil)
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{ID: "user-id", Login: "testuser"})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			},
+			req:      &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword1"},
+			wantCode: codes.OK,
+		},
+		{
+			name: "required password change",
+			setupMocks: func(authMock *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(&models.User{
+					ID:                     "user-id",
+					Login:                  "testuser",
+					PasswordHash:           passwordHash,
+					PasswordChangeRequired: true,
+				}, nil)
+				repo.EXPECT().ChangePassword(mock.Anything, mock.Anything).Return(nil)
+				// The new access token is no longer restricted
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{ID: "user-id", Login: "testuser"})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			},
+			req:      &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword1"},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "new password matches current",
+			setupMocks: func(*mocks.MockAuth, *mocks.MockUserRepository) {},
+			req:        &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "password123"},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "wrong current password",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+			},
+			req:      &calculatorv1.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpassword1"},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:       "empty new password",
+			setupMocks: func(*mocks.MockAuth, *mocks.MockUserRepository) {},
+			req:        &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123"},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "new password violates policy",
+			setupMocks: func(*mocks.MockAuth, *mocks.MockUserRepository) {},
+			req:        &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "TestUser"},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(_ *mocks.MockAuth, repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+				repo.EXPECT().ChangePassword(mock.Anything, mock.Anything).Return(assert.AnError)
+			},
+			req:      &calculatorv1.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword1"},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMock := mocks.NewMockAuth(t)
+			repo := mocks.NewMockUserRepository(t)
+
+			tt.setupMocks(authMock, repo)
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), authMock, repo)
+
+			got, err := svc.ChangePassword(ctx, tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK {
+				return
+			}
+			assert.Equal(t, "jwt-token", got.AccessToken)
+			assert.NotEmpty(t, got.RefreshToken)
+			assert.False(t, got.PasswordChangeRequired)
+		})
+	}
+}
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	ctx := auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser"})
+	passwordHash, err := auth.HashPassword("password123")
+	require.NoError(t, err)
+	user := &models.User{ID: "user-id", Login: "testuser", PasswordHash: passwordHash}
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockUserRepository)
+		req        *calculatorv1.DeleteAccountRequest
+		wantCode   codes.Code
+	}{
+		{
+			name: "successful deletion",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+				repo.EXPECT().DeleteUser(mock.Anything, "user-id").Return(nil)
+			},
+			req:      &calculatorv1.DeleteAccountRequest{Password: "password123"},
+			wantCode: codes.OK,
+		},
+		{
+			name: "wrong password",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+			},
+			req:      &calculatorv1.DeleteAccountRequest{Password: "wrong"},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name: "already deleted",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(nil, models.ErrUserNotFound)
+			},
+			req:      &calculatorv1.DeleteAccountRequest{Password: "password123"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+				repo.EXPECT().DeleteUser(mock.Anything, "user-id").Return(assert.AnError)
+			},
+			req:      &calculatorv1.DeleteAccountRequest{Password: "password123"},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+
+			tt.setupMocks(repo)
+			svc := NewUserService(&config.Config{}, testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+			_, err := svc.DeleteAccount(ctx, tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+	}
+}
+
+func TestUserService_withoutPassword(t *testing.T) {
+	user := &models.User{ID: "user-id", Login: "testuser"} // created on the first OIDC login
+	userCtx := func(authTime time.Time) context.Context {
+		return auth.WithContext(context.Background(), auth.UserInfo{ID: "user-id", Login: "testuser", AuthTime: authTime})
+	}
+
+	tests := []struct {
+		name     string
+		authTime time.Time
+		wantCode codes.Code
+	}{
+		{name: "recent login", authTime: time.Now().Add(-time.Minute), wantCode: codes.OK},
+		{name: "stale login", authTime: time.Now().Add(-time.Hour), wantCode: codes.FailedPrecondition},
+		{name: "refreshed token", wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run("change password/"+tt.name, func(t *testing.T) {
+			authMock := mocks.NewMockAuth(t)
+			repo := mocks.NewMockUserRepository(t)
+			repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+			if tt.wantCode == codes.OK {
+				repo.EXPECT().ChangePassword(mock.Anything, mock.MatchedBy(func(cmd models.ChangePasswordCmd) bool {
+					ok, _, err := auth.VerifyPassword(cmd.PasswordHash, "newpassword1")
+					return cmd.UserID == "user-id" && ok && err == nil
+				})).Return(nil)
+				authMock.EXPECT().GenerateJWT(loginUserInfo(auth.UserInfo{ID: "user-id", Login: "testuser"})).Return("jwt-token", nil)
+				repo.EXPECT().CreateRefreshToken(mock.Anything, mock.Anything).Return(&models.RefreshToken{}, nil)
+			}
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), authMock, repo)
+
+			_, err := svc.ChangePassword(userCtx(tt.authTime), &calculatorv1.ChangePasswordRequest{NewPassword: "newpassword1"})
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+
+		t.Run("delete account/"+tt.name, func(t *testing.T) {
+			repo := mocks.NewMockUserRepository(t)
+			repo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(user, nil)
+			if tt.wantCode == codes.OK {
+				repo.EXPECT().DeleteUser(mock.Anything, "user-id").Return(nil)
+			}
+			svc := NewUserService(testConfig(), testutil.DiscardLogger(), mocks.NewMockAuth(t), repo)
+
+			_, err := svc.DeleteAccount(userCtx(tt.authTime), &calculatorv1.DeleteAccountRequest{})
+			assert.Equal(t, tt.wantCode, status.Code(err), err)
+		})
+	}
+}
+
+// loginUserInfo matches the user info of the access token issued on a login, which has the login time.
+func loginUserInfo(want auth.UserInfo) any {
+	return mock.MatchedBy(func(got auth.UserInfo) bool {
+		authTime := got.AuthTime
+		got.AuthTime = time.Time{}
+		return got == want && time.Since(authTime) < time.Minute
+	})
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		AuthRefreshTokenExpirationTime: time.Hour,
+		AuthReauthenticationMaxAge:     5 * time.Minute,
+		AuthPasswordMinLength:          8,
+		AuthPasswordMaxLength:          128,
+		AuthPasswordMinCharClasses:     2,
+		AuthLoginMaxAttempts:           5,
+		AuthLoginMaxAttemptsPerIP:      20,
+		AuthLoginLockoutDuration:       time.Minute,
+		AuthLoginMaxLockoutDuration:    time.Hour,
+		AuthLoginFailureWindow:         15 * time.Minute,
+	}
+}
@@ -22,9 +22,9 @@ func (_m *MockCalculatorAgentAPIClient) EXPECT() *MockCalculatorAgentAPIClient_E
 	return &MockCalculatorAgentAPIClient_Expecter{mock: &_m.Mock}
 }
 
-// GetTask provides a mock function with given fields: ctx
-func (_m *MockCalculatorAgentAPIClient) GetTask(ctx context.Context) (*v1.Task, error) {
-	ret := _m.Called(ctx)
+// GetTask provides a mock function with given fields: ctx, req
+func (_m *MockCalculatorAgentAPIClient) GetTask(ctx context.Context, req *v1.GetTaskRequest) (*v1.Task, error) {
+	ret := _m.Called(ctx, req)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTask")
@@ -32,19 +32,19 @@ func (_m *MockCalculatorAgentAPIClient) GetTask(ctx context.Context) (*v1.Task,
 
 	var r0 *v1.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*v1.Task, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.GetTaskRequest) (*v1.Task, error)); ok {
+		return rf(ctx, req)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *v1.Task); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.GetTaskRequest) *v1.Task); ok {
+		r0 = rf(ctx, req)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*v1.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.GetTaskRequest) error); ok {
+		r1 = rf(ctx, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -59,13 +59,14 @@ type MockCalculatorAgentAPIClient_GetTask_Call struct {
 
 // GetTask is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockCalculatorAgentAPIClient_Expecter) GetTask(ctx interface{}) *MockCalculatorAgentAPIClient_GetTask_Call {
-	return &MockCalculatorAgentAPIClient_GetTask_Call{Call: _e.mock.On("GetTask", ctx)}
+//   - req *v1.GetTaskRequest
+func (_e *MockCalculatorAgentAPIClient_Expecter) GetTask(ctx interface{}, req interface{}) *MockCalculatorAgentAPIClient_GetTask_Call {
+	return &MockCalculatorAgentAPIClient_GetTask_Call{Call: _e.mock.On("GetTask", ctx, req)}
 }
 
-func (_c *MockCalculatorAgentAPIClient_GetTask_Call) Run(run func(ctx context.Context)) *MockCalculatorAgentAPIClient_GetTask_Call {
+func (_c *MockCalculatorAgentAPIClient_GetTask_Call) Run(run func(ctx context.Context, req *v1.GetTaskRequest)) *MockCalculatorAgentAPIClient_GetTask_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(*v1.GetTaskRequest))
 	})
 	return _c
 }
@@ -75,7 +76,7 @@ func (_c *MockCalculatorAgentAPIClient_GetTask_Call) Return(_a0 *v1.Task, _a1 er
 	return _c
 }
 
-func (_c *MockCalculatorAgentAPIClient_GetTask_Call) RunAndReturn(run func(context.Context) (*v1.Task, error)) *MockCalculatorAgentAPIClient_GetTask_Call {
+func (_c *MockCalculatorAgentAPIClient_GetTask_Call) RunAndReturn(run func(context.Context, *v1.GetTaskRequest) (*v1.Task, error)) *MockCalculatorAgentAPIClient_GetTask_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,153 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockTokenStore is an autogenerated mock type for the TokenStore type
+type MockTokenStore struct {
+	mock.Mock
+}
+
+type MockTokenStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockTokenStore) EXPECT() *MockTokenStore_Expecter {
+	return &MockTokenStore_Expecter{mock: &_m.Mock}
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *MockTokenStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByHash")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, error)); ok {
+		return rf(ctx, keyHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTokenStore_GetAPIKeyByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAPIKeyByHash'
+type MockTokenStore_GetAPIKeyByHash_Call struct {
+	*mock.Call
+}
+
+// GetAPIKeyByHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keyHash string
+func (_e *MockTokenStore_Expecter) GetAPIKeyByHash(ctx interface{}, keyHash interface{}) *MockTokenStore_GetAPIKeyByHash_Call {
+	return &MockTokenStore_GetAPIKeyByHash_Call{Call: _e.mock.On("GetAPIKeyByHash", ctx, keyHash)}
+}
+
+func (_c *MockTokenStore_GetAPIKeyByHash_Call) Run(run func(ctx context.Context, keyHash string)) *MockTokenStore_GetAPIKeyByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockTokenStore_GetAPIKeyByHash_Call) Return(_a0 *models.APIKey, _a1 error) *MockTokenStore_GetAPIKeyByHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTokenStore_GetAPIKeyByHash_Call) RunAndReturn(run func(context.Context, string) (*models.APIKey, error)) *MockTokenStore_GetAPIKeyByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, cmd
+func (_m *MockTokenStore) IsTokenRevoked(ctx context.Context, cmd models.IsTokenRevokedCmd) (bool, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IsTokenRevokedCmd) (bool, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.IsTokenRevokedCmd) bool); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.IsTokenRevokedCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTokenStore_IsTokenRevoked_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsTokenRevoked'
+type MockTokenStore_IsTokenRevoked_Call struct {
+	*mock.Call
+}
+
+// IsTokenRevoked is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.IsTokenRevokedCmd
+func (_e *MockTokenStore_Expecter) IsTokenRevoked(ctx interface{}, cmd interface{}) *MockTokenStore_IsTokenRevoked_Call {
+	return &MockTokenStore_IsTokenRevoked_Call{Call: _e.mock.On("IsTokenRevoked", ctx, cmd)}
+}
+
+func (_c *MockTokenStore_IsTokenRevoked_Call) Run(run func(ctx context.Context, cmd models.IsTokenRevokedCmd)) *MockTokenStore_IsTokenRevoked_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.IsTokenRevokedCmd))
+	})
+	return _c
+}
+
+func (_c *MockTokenStore_IsTokenRevoked_Call) Return(_a0 bool, _a1 error) *MockTokenStore_IsTokenRevoked_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTokenStore_IsTokenRevoked_Call) RunAndReturn(run func(context.Context, models.IsTokenRevokedCmd) (bool, error)) *MockTokenStore_IsTokenRevoked_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockTokenStore creates a new instance of MockTokenStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockTokenStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockTokenStore {
+	mock := &MockTokenStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,544 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+	time "time"
+)
+
+// MockAdminRepository is an autogenerated mock type for the AdminRepository type
+type MockAdminRepository struct {
+	mock.Mock
+}
+
+type MockAdminRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAdminRepository) EXPECT() *MockAdminRepository_Expecter {
+	return &MockAdminRepository_Expecter{mock: &_m.Mock}
+}
+
+// Backup provides a mock function with given fields: ctx, path
+func (_m *MockAdminRepository) Backup(ctx context.Context, path string) error {
+	ret := _m.Called(ctx, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Backup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockAdminRepository_Backup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Backup'
+type MockAdminRepository_Backup_Call struct {
+	*mock.Call
+}
+
+// Backup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - path string
+func (_e *MockAdminRepository_Expecter) Backup(ctx interface{}, path interface{}) *MockAdminRepository_Backup_Call {
+	return &MockAdminRepository_Backup_Call{Call: _e.mock.On("Backup", ctx, path)}
+}
+
+func (_c *MockAdminRepository_Backup_Call) Run(run func(ctx context.Context, path string)) *MockAdminRepository_Backup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_Backup_Call) Return(_a0 error) *MockAdminRepository_Backup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockAdminRepository_Backup_Call) RunAndReturn(run func(context.Context, string) error) *MockAdminRepository_Backup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteRetentionPolicy provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) DeleteRetentionPolicy(_a0 context.Context, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRetentionPolicy")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockAdminRepository_DeleteRetentionPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRetentionPolicy'
+type MockAdminRepository_DeleteRetentionPolicy_Call struct {
+	*mock.Call
+}
+
+// DeleteRetentionPolicy is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *MockAdminRepository_Expecter) DeleteRetentionPolicy(_a0 interface{}, _a1 interface{}) *MockAdminRepository_DeleteRetentionPolicy_Call {
+	return &MockAdminRepository_DeleteRetentionPolicy_Call{Call: _e.mock.On("DeleteRetentionPolicy", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_DeleteRetentionPolicy_Call) Run(run func(_a0 context.Context, _a1 string)) *MockAdminRepository_DeleteRetentionPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_DeleteRetentionPolicy_Call) Return(_a0 error) *MockAdminRepository_DeleteRetentionPolicy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockAdminRepository_DeleteRetentionPolicy_Call) RunAndReturn(run func(context.Context, string) error) *MockAdminRepository_DeleteRetentionPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRetentionPolicy provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) GetRetentionPolicy(_a0 context.Context, _a1 string) (*models.RetentionPolicy, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRetentionPolicy")
+	}
+
+	var r0 *models.RetentionPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.RetentionPolicy, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.RetentionPolicy); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RetentionPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_GetRetentionPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRetentionPolicy'
+type MockAdminRepository_GetRetentionPolicy_Call struct {
+	*mock.Call
+}
+
+// GetRetentionPolicy is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *MockAdminRepository_Expecter) GetRetentionPolicy(_a0 interface{}, _a1 interface{}) *MockAdminRepository_GetRetentionPolicy_Call {
+	return &MockAdminRepository_GetRetentionPolicy_Call{Call: _e.mock.On("GetRetentionPolicy", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_GetRetentionPolicy_Call) Run(run func(_a0 context.Context, _a1 string)) *MockAdminRepository_GetRetentionPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_GetRetentionPolicy_Call) Return(_a0 *models.RetentionPolicy, _a1 error) *MockAdminRepository_GetRetentionPolicy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_GetRetentionPolicy_Call) RunAndReturn(run func(context.Context, string) (*models.RetentionPolicy, error)) *MockAdminRepository_GetRetentionPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByID provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) GetUserByID(_a0 context.Context, _a1 string) (*models.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_GetUserByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByID'
+type MockAdminRepository_GetUserByID_Call struct {
+	*mock.Call
+}
+
+// GetUserByID is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *MockAdminRepository_Expecter) GetUserByID(_a0 interface{}, _a1 interface{}) *MockAdminRepository_GetUserByID_Call {
+	return &MockAdminRepository_GetUserByID_Call{Call: _e.mock.On("GetUserByID", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_GetUserByID_Call) Run(run func(_a0 context.Context, _a1 string)) *MockAdminRepository_GetUserByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_GetUserByID_Call) Return(_a0 *models.User, _a1 error) *MockAdminRepository_GetUserByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_GetUserByID_Call) RunAndReturn(run func(context.Context, string) (*models.User, error)) *MockAdminRepository_GetUserByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAliveAgents provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) ListAliveAgents(_a0 context.Context, _a1 time.Time) ([]models.Agent, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAliveAgents")
+	}
+
+	var r0 []models.Agent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]models.Agent, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []models.Agent); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Agent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_ListAliveAgents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAliveAgents'
+type MockAdminRepository_ListAliveAgents_Call struct {
+	*mock.Call
+}
+
+// ListAliveAgents is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 time.Time
+func (_e *MockAdminRepository_Expecter) ListAliveAgents(_a0 interface{}, _a1 interface{}) *MockAdminRepository_ListAliveAgents_Call {
+	return &MockAdminRepository_ListAliveAgents_Call{Call: _e.mock.On("ListAliveAgents", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_ListAliveAgents_Call) Run(run func(_a0 context.Context, _a1 time.Time)) *MockAdminRepository_ListAliveAgents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_ListAliveAgents_Call) Return(_a0 []models.Agent, _a1 error) *MockAdminRepository_ListAliveAgents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_ListAliveAgents_Call) RunAndReturn(run func(context.Context, time.Time) ([]models.Agent, error)) *MockAdminRepository_ListAliveAgents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListExpressions provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) ListExpressions(_a0 context.Context, _a1 string) ([]models.Expression, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpressions")
+	}
+
+	var r0 []models.Expression
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.Expression, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Expression); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Expression)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_ListExpressions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExpressions'
+type MockAdminRepository_ListExpressions_Call struct {
+	*mock.Call
+}
+
+// ListExpressions is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *MockAdminRepository_Expecter) ListExpressions(_a0 interface{}, _a1 interface{}) *MockAdminRepository_ListExpressions_Call {
+	return &MockAdminRepository_ListExpressions_Call{Call: _e.mock.On("ListExpressions", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_ListExpressions_Call) Run(run func(_a0 context.Context, _a1 string)) *MockAdminRepository_ListExpressions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_ListExpressions_Call) Return(_a0 []models.Expression, _a1 error) *MockAdminRepository_ListExpressions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_ListExpressions_Call) RunAndReturn(run func(context.Context, string) ([]models.Expression, error)) *MockAdminRepository_ListExpressions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUsers provides a mock function with given fields: _a0
+func (_m *MockAdminRepository) ListUsers(_a0 context.Context) ([]models.User, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.User, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
+type MockAdminRepository_ListUsers_Call struct {
+	*mock.Call
+}
+
+// ListUsers is a helper method to define mock.On call
+//   - _a0 context.Context
+func (_e *MockAdminRepository_Expecter) ListUsers(_a0 interface{}) *MockAdminRepository_ListUsers_Call {
+	return &MockAdminRepository_ListUsers_Call{Call: _e.mock.On("ListUsers", _a0)}
+}
+
+func (_c *MockAdminRepository_ListUsers_Call) Run(run func(_a0 context.Context)) *MockAdminRepository_ListUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_ListUsers_Call) Return(_a0 []models.User, _a1 error) *MockAdminRepository_ListUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_ListUsers_Call) RunAndReturn(run func(context.Context) ([]models.User, error)) *MockAdminRepository_ListUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetRetentionPolicy provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) SetRetentionPolicy(_a0 context.Context, _a1 models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRetentionPolicy")
+	}
+
+	var r0 *models.RetentionPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SetRetentionPolicyCmd) *models.RetentionPolicy); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RetentionPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SetRetentionPolicyCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_SetRetentionPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetRetentionPolicy'
+type MockAdminRepository_SetRetentionPolicy_Call struct {
+	*mock.Call
+}
+
+// SetRetentionPolicy is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.SetRetentionPolicyCmd
+func (_e *MockAdminRepository_Expecter) SetRetentionPolicy(_a0 interface{}, _a1 interface{}) *MockAdminRepository_SetRetentionPolicy_Call {
+	return &MockAdminRepository_SetRetentionPolicy_Call{Call: _e.mock.On("SetRetentionPolicy", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_SetRetentionPolicy_Call) Run(run func(_a0 context.Context, _a1 models.SetRetentionPolicyCmd)) *MockAdminRepository_SetRetentionPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.SetRetentionPolicyCmd))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_SetRetentionPolicy_Call) Return(_a0 *models.RetentionPolicy, _a1 error) *MockAdminRepository_SetRetentionPolicy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_SetRetentionPolicy_Call) RunAndReturn(run func(context.Context, models.SetRetentionPolicyCmd) (*models.RetentionPolicy, error)) *MockAdminRepository_SetRetentionPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserRole provides a mock function with given fields: _a0, _a1
+func (_m *MockAdminRepository) SetUserRole(_a0 context.Context, _a1 models.SetUserRoleCmd) (*models.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SetUserRoleCmd) (*models.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SetUserRoleCmd) *models.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SetUserRoleCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAdminRepository_SetUserRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserRole'
+type MockAdminRepository_SetUserRole_Call struct {
+	*mock.Call
+}
+
+// SetUserRole is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.SetUserRoleCmd
+func (_e *MockAdminRepository_Expecter) SetUserRole(_a0 interface{}, _a1 interface{}) *MockAdminRepository_SetUserRole_Call {
+	return &MockAdminRepository_SetUserRole_Call{Call: _e.mock.On("SetUserRole", _a0, _a1)}
+}
+
+func (_c *MockAdminRepository_SetUserRole_Call) Run(run func(_a0 context.Context, _a1 models.SetUserRoleCmd)) *MockAdminRepository_SetUserRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.SetUserRoleCmd))
+	})
+	return _c
+}
+
+func (_c *MockAdminRepository_SetUserRole_Call) Return(_a0 *models.User, _a1 error) *MockAdminRepository_SetUserRole_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAdminRepository_SetUserRole_Call) RunAndReturn(run func(context.Context, models.SetUserRoleCmd) (*models.User, error)) *MockAdminRepository_SetUserRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockAdminRepository creates a new instance of MockAdminRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockAdminRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAdminRepository {
+	mock := &MockAdminRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
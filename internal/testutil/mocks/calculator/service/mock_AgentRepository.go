@@ -23,21 +23,33 @@ func (_m *MockAgentRepository) EXPECT() *MockAgentRepository_Expecter {
 }
 
 // FinishTask provides a mock function with given fields: _a0, _a1
-func (_m *MockAgentRepository) FinishTask(_a0 context.Context, _a1 models.FinishTaskCmd) error {
+func (_m *MockAgentRepository) FinishTask(_a0 context.Context, _a1 models.FinishTaskCmd) (*models.Expression, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FinishTask")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.FinishTaskCmd) error); ok {
+	var r0 *models.Expression
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.FinishTaskCmd) (*models.Expression, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.FinishTaskCmd) *models.Expression); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Expression)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, models.FinishTaskCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MockAgentRepository_FinishTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishTask'
@@ -59,19 +71,19 @@ func (_c *MockAgentRepository_FinishTask_Call) Run(run func(_a0 context.Context,
 	return _c
 }
 
-func (_c *MockAgentRepository_FinishTask_Call) Return(_a0 error) *MockAgentRepository_FinishTask_Call {
-	_c.Call.Return(_a0)
+func (_c *MockAgentRepository_FinishTask_Call) Return(_a0 *models.Expression, _a1 error) *MockAgentRepository_FinishTask_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockAgentRepository_FinishTask_Call) RunAndReturn(run func(context.Context, models.FinishTaskCmd) error) *MockAgentRepository_FinishTask_Call {
+func (_c *MockAgentRepository_FinishTask_Call) RunAndReturn(run func(context.Context, models.FinishTaskCmd) (*models.Expression, error)) *MockAgentRepository_FinishTask_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetPendingTask provides a mock function with given fields: _a0
-func (_m *MockAgentRepository) GetPendingTask(_a0 context.Context) (*models.Task, error) {
-	ret := _m.Called(_a0)
+// GetPendingTask provides a mock function with given fields: _a0, _a1
+func (_m *MockAgentRepository) GetPendingTask(_a0 context.Context, _a1 models.GetPendingTaskCmd) (*models.Task, error) {
+	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetPendingTask")
@@ -79,19 +91,19 @@ func (_m *MockAgentRepository) GetPendingTask(_a0 context.Context) (*models.Task
 
 	var r0 *models.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*models.Task, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetPendingTaskCmd) (*models.Task, error)); ok {
+		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *models.Task); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetPendingTaskCmd) *models.Task); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, models.GetPendingTaskCmd) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -106,13 +118,14 @@ type MockAgentRepository_GetPendingTask_Call struct {
 
 // GetPendingTask is a helper method to define mock.On call
 //   - _a0 context.Context
-func (_e *MockAgentRepository_Expecter) GetPendingTask(_a0 interface{}) *MockAgentRepository_GetPendingTask_Call {
-	return &MockAgentRepository_GetPendingTask_Call{Call: _e.mock.On("GetPendingTask", _a0)}
+//   - _a1 models.GetPendingTaskCmd
+func (_e *MockAgentRepository_Expecter) GetPendingTask(_a0 interface{}, _a1 interface{}) *MockAgentRepository_GetPendingTask_Call {
+	return &MockAgentRepository_GetPendingTask_Call{Call: _e.mock.On("GetPendingTask", _a0, _a1)}
 }
 
-func (_c *MockAgentRepository_GetPendingTask_Call) Run(run func(_a0 context.Context)) *MockAgentRepository_GetPendingTask_Call {
+func (_c *MockAgentRepository_GetPendingTask_Call) Run(run func(_a0 context.Context, _a1 models.GetPendingTaskCmd)) *MockAgentRepository_GetPendingTask_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(models.GetPendingTaskCmd))
 	})
 	return _c
 }
@@ -122,7 +135,54 @@ func (_c *MockAgentRepository_GetPendingTask_Call) Return(_a0 *models.Task, _a1
 	return _c
 }
 
-func (_c *MockAgentRepository_GetPendingTask_Call) RunAndReturn(run func(context.Context) (*models.Task, error)) *MockAgentRepository_GetPendingTask_Call {
+func (_c *MockAgentRepository_GetPendingTask_Call) RunAndReturn(run func(context.Context, models.GetPendingTaskCmd) (*models.Task, error)) *MockAgentRepository_GetPendingTask_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpsertAgent provides a mock function with given fields: _a0, _a1
+func (_m *MockAgentRepository) UpsertAgent(_a0 context.Context, _a1 models.UpsertAgentCmd) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertAgent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UpsertAgentCmd) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockAgentRepository_UpsertAgent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertAgent'
+type MockAgentRepository_UpsertAgent_Call struct {
+	*mock.Call
+}
+
+// UpsertAgent is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.UpsertAgentCmd
+func (_e *MockAgentRepository_Expecter) UpsertAgent(_a0 interface{}, _a1 interface{}) *MockAgentRepository_UpsertAgent_Call {
+	return &MockAgentRepository_UpsertAgent_Call{Call: _e.mock.On("UpsertAgent", _a0, _a1)}
+}
+
+func (_c *MockAgentRepository_UpsertAgent_Call) Run(run func(_a0 context.Context, _a1 models.UpsertAgentCmd)) *MockAgentRepository_UpsertAgent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.UpsertAgentCmd))
+	})
+	return _c
+}
+
+func (_c *MockAgentRepository_UpsertAgent_Call) Return(_a0 error) *MockAgentRepository_UpsertAgent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockAgentRepository_UpsertAgent_Call) RunAndReturn(run func(context.Context, models.UpsertAgentCmd) error) *MockAgentRepository_UpsertAgent_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockBackupRepository is an autogenerated mock type for the BackupRepository type
+type MockBackupRepository struct {
+	mock.Mock
+}
+
+type MockBackupRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockBackupRepository) EXPECT() *MockBackupRepository_Expecter {
+	return &MockBackupRepository_Expecter{mock: &_m.Mock}
+}
+
+// Backup provides a mock function with given fields: ctx, path
+func (_m *MockBackupRepository) Backup(ctx context.Context, path string) error {
+	ret := _m.Called(ctx, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Backup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockBackupRepository_Backup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Backup'
+type MockBackupRepository_Backup_Call struct {
+	*mock.Call
+}
+
+// Backup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - path string
+func (_e *MockBackupRepository_Expecter) Backup(ctx interface{}, path interface{}) *MockBackupRepository_Backup_Call {
+	return &MockBackupRepository_Backup_Call{Call: _e.mock.On("Backup", ctx, path)}
+}
+
+func (_c *MockBackupRepository_Backup_Call) Run(run func(ctx context.Context, path string)) *MockBackupRepository_Backup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockBackupRepository_Backup_Call) Return(_a0 error) *MockBackupRepository_Backup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockBackupRepository_Backup_Call) RunAndReturn(run func(context.Context, string) error) *MockBackupRepository_Backup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockBackupRepository creates a new instance of MockBackupRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockBackupRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockBackupRepository {
+	mock := &MockBackupRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
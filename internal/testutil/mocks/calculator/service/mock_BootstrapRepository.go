@@ -0,0 +1,142 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockBootstrapRepository is an autogenerated mock type for the BootstrapRepository type
+type MockBootstrapRepository struct {
+	mock.Mock
+}
+
+type MockBootstrapRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockBootstrapRepository) EXPECT() *MockBootstrapRepository_Expecter {
+	return &MockBootstrapRepository_Expecter{mock: &_m.Mock}
+}
+
+// ListUsers provides a mock function with given fields: ctx
+func (_m *MockBootstrapRepository) ListUsers(ctx context.Context) ([]models.User, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.User, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockBootstrapRepository_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
+type MockBootstrapRepository_ListUsers_Call struct {
+	*mock.Call
+}
+
+// ListUsers is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockBootstrapRepository_Expecter) ListUsers(ctx interface{}) *MockBootstrapRepository_ListUsers_Call {
+	return &MockBootstrapRepository_ListUsers_Call{Call: _e.mock.On("ListUsers", ctx)}
+}
+
+func (_c *MockBootstrapRepository_ListUsers_Call) Run(run func(ctx context.Context)) *MockBootstrapRepository_ListUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockBootstrapRepository_ListUsers_Call) Return(_a0 []models.User, _a1 error) *MockBootstrapRepository_ListUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockBootstrapRepository_ListUsers_Call) RunAndReturn(run func(context.Context) ([]models.User, error)) *MockBootstrapRepository_ListUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Register provides a mock function with given fields: ctx, cmd
+func (_m *MockBootstrapRepository) Register(ctx context.Context, cmd models.RegisterUserCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RegisterUserCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockBootstrapRepository_Register_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Register'
+type MockBootstrapRepository_Register_Call struct {
+	*mock.Call
+}
+
+// Register is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.RegisterUserCmd
+func (_e *MockBootstrapRepository_Expecter) Register(ctx interface{}, cmd interface{}) *MockBootstrapRepository_Register_Call {
+	return &MockBootstrapRepository_Register_Call{Call: _e.mock.On("Register", ctx, cmd)}
+}
+
+func (_c *MockBootstrapRepository_Register_Call) Run(run func(ctx context.Context, cmd models.RegisterUserCmd)) *MockBootstrapRepository_Register_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RegisterUserCmd))
+	})
+	return _c
+}
+
+func (_c *MockBootstrapRepository_Register_Call) Return(_a0 error) *MockBootstrapRepository_Register_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockBootstrapRepository_Register_Call) RunAndReturn(run func(context.Context, models.RegisterUserCmd) error) *MockBootstrapRepository_Register_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockBootstrapRepository creates a new instance of MockBootstrapRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockBootstrapRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockBootstrapRepository {
+	mock := &MockBootstrapRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -7,6 +7,7 @@ import (
 	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
 
 	mock "github.com/stretchr/testify/mock"
+	time "time"
 )
 
 // MockCalculatorRepository is an autogenerated mock type for the CalculatorRepository type
@@ -140,6 +141,304 @@ func (_c *MockCalculatorRepository_GetExpression_Call) RunAndReturn(run func(con
 	return _c
 }
 
+// GetExpressionCallback provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockCalculatorRepository) GetExpressionCallback(_a0 context.Context, _a1 string, _a2 string) (*models.Callback, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetExpressionCallback")
+	}
+
+	var r0 *models.Callback
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.Callback, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Callback); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Callback)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCalculatorRepository_GetExpressionCallback_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetExpressionCallback'
+type MockCalculatorRepository_GetExpressionCallback_Call struct {
+	*mock.Call
+}
+
+// GetExpressionCallback is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+//   - _a2 string
+func (_e *MockCalculatorRepository_Expecter) GetExpressionCallback(_a0 interface{}, _a1 interface{}, _a2 interface{}) *MockCalculatorRepository_GetExpressionCallback_Call {
+	return &MockCalculatorRepository_GetExpressionCallback_Call{Call: _e.mock.On("GetExpressionCallback", _a0, _a1, _a2)}
+}
+
+func (_c *MockCalculatorRepository_GetExpressionCallback_Call) Run(run func(_a0 context.Context, _a1 string, _a2 string)) *MockCalculatorRepository_GetExpressionCallback_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetExpressionCallback_Call) Return(_a0 *models.Callback, _a1 error) *MockCalculatorRepository_GetExpressionCallback_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetExpressionCallback_Call) RunAndReturn(run func(context.Context, string, string) (*models.Callback, error)) *MockCalculatorRepository_GetExpressionCallback_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetIdempotencyKey provides a mock function with given fields: _a0, _a1
+func (_m *MockCalculatorRepository) GetIdempotencyKey(_a0 context.Context, _a1 models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdempotencyKey")
+	}
+
+	var r0 *models.IdempotencyKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetIdempotencyKeyCmd) *models.IdempotencyKey); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.GetIdempotencyKeyCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCalculatorRepository_GetIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetIdempotencyKey'
+type MockCalculatorRepository_GetIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// GetIdempotencyKey is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.GetIdempotencyKeyCmd
+func (_e *MockCalculatorRepository_Expecter) GetIdempotencyKey(_a0 interface{}, _a1 interface{}) *MockCalculatorRepository_GetIdempotencyKey_Call {
+	return &MockCalculatorRepository_GetIdempotencyKey_Call{Call: _e.mock.On("GetIdempotencyKey", _a0, _a1)}
+}
+
+func (_c *MockCalculatorRepository_GetIdempotencyKey_Call) Run(run func(_a0 context.Context, _a1 models.GetIdempotencyKeyCmd)) *MockCalculatorRepository_GetIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.GetIdempotencyKeyCmd))
+	})
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetIdempotencyKey_Call) Return(_a0 *models.IdempotencyKey, _a1 error) *MockCalculatorRepository_GetIdempotencyKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetIdempotencyKey_Call) RunAndReturn(run func(context.Context, models.GetIdempotencyKeyCmd) (*models.IdempotencyKey, error)) *MockCalculatorRepository_GetIdempotencyKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOperationCosts provides a mock function with given fields: _a0, _a1
+func (_m *MockCalculatorRepository) GetOperationCosts(_a0 context.Context, _a1 time.Time) (map[models.TaskOperation]time.Duration, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOperationCosts")
+	}
+
+	var r0 map[models.TaskOperation]time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (map[models.TaskOperation]time.Duration, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) map[models.TaskOperation]time.Duration); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[models.TaskOperation]time.Duration)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCalculatorRepository_GetOperationCosts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOperationCosts'
+type MockCalculatorRepository_GetOperationCosts_Call struct {
+	*mock.Call
+}
+
+// GetOperationCosts is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 time.Time
+func (_e *MockCalculatorRepository_Expecter) GetOperationCosts(_a0 interface{}, _a1 interface{}) *MockCalculatorRepository_GetOperationCosts_Call {
+	return &MockCalculatorRepository_GetOperationCosts_Call{Call: _e.mock.On("GetOperationCosts", _a0, _a1)}
+}
+
+func (_c *MockCalculatorRepository_GetOperationCosts_Call) Run(run func(_a0 context.Context, _a1 time.Time)) *MockCalculatorRepository_GetOperationCosts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetOperationCosts_Call) Return(_a0 map[models.TaskOperation]time.Duration, _a1 error) *MockCalculatorRepository_GetOperationCosts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCalculatorRepository_GetOperationCosts_Call) RunAndReturn(run func(context.Context, time.Time) (map[models.TaskOperation]time.Duration, error)) *MockCalculatorRepository_GetOperationCosts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListExpressionCallbackAttempts provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockCalculatorRepository) ListExpressionCallbackAttempts(_a0 context.Context, _a1 string, _a2 string) ([]models.CallbackAttempt, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpressionCallbackAttempts")
+	}
+
+	var r0 []models.CallbackAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]models.CallbackAttempt, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []models.CallbackAttempt); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CallbackAttempt)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCalculatorRepository_ListExpressionCallbackAttempts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExpressionCallbackAttempts'
+type MockCalculatorRepository_ListExpressionCallbackAttempts_Call struct {
+	*mock.Call
+}
+
+// ListExpressionCallbackAttempts is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+//   - _a2 string
+func (_e *MockCalculatorRepository_Expecter) ListExpressionCallbackAttempts(_a0 interface{}, _a1 interface{}, _a2 interface{}) *MockCalculatorRepository_ListExpressionCallbackAttempts_Call {
+	return &MockCalculatorRepository_ListExpressionCallbackAttempts_Call{Call: _e.mock.On("ListExpressionCallbackAttempts", _a0, _a1, _a2)}
+}
+
+func (_c *MockCalculatorRepository_ListExpressionCallbackAttempts_Call) Run(run func(_a0 context.Context, _a1 string, _a2 string)) *MockCalculatorRepository_ListExpressionCallbackAttempts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockCalculatorRepository_ListExpressionCallbackAttempts_Call) Return(_a0 []models.CallbackAttempt, _a1 error) *MockCalculatorRepository_ListExpressionCallbackAttempts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCalculatorRepository_ListExpressionCallbackAttempts_Call) RunAndReturn(run func(context.Context, string, string) ([]models.CallbackAttempt, error)) *MockCalculatorRepository_ListExpressionCallbackAttempts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListExpressionTaskEvents provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockCalculatorRepository) ListExpressionTaskEvents(_a0 context.Context, _a1 string, _a2 string) ([]models.TaskEvent, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpressionTaskEvents")
+	}
+
+	var r0 []models.TaskEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]models.TaskEvent, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []models.TaskEvent); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TaskEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCalculatorRepository_ListExpressionTaskEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExpressionTaskEvents'
+type MockCalculatorRepository_ListExpressionTaskEvents_Call struct {
+	*mock.Call
+}
+
+// ListExpressionTaskEvents is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+//   - _a2 string
+func (_e *MockCalculatorRepository_Expecter) ListExpressionTaskEvents(_a0 interface{}, _a1 interface{}, _a2 interface{}) *MockCalculatorRepository_ListExpressionTaskEvents_Call {
+	return &MockCalculatorRepository_ListExpressionTaskEvents_Call{Call: _e.mock.On("ListExpressionTaskEvents", _a0, _a1, _a2)}
+}
+
+func (_c *MockCalculatorRepository_ListExpressionTaskEvents_Call) Run(run func(_a0 context.Context, _a1 string, _a2 string)) *MockCalculatorRepository_ListExpressionTaskEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockCalculatorRepository_ListExpressionTaskEvents_Call) Return(_a0 []models.TaskEvent, _a1 error) *MockCalculatorRepository_ListExpressionTaskEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCalculatorRepository_ListExpressionTaskEvents_Call) RunAndReturn(run func(context.Context, string, string) ([]models.TaskEvent, error)) *MockCalculatorRepository_ListExpressionTaskEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListExpressionTasks provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MockCalculatorRepository) ListExpressionTasks(_a0 context.Context, _a1 string, _a2 string) ([]models.Task, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -0,0 +1,143 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockCallbackRepository is an autogenerated mock type for the CallbackRepository type
+type MockCallbackRepository struct {
+	mock.Mock
+}
+
+type MockCallbackRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockCallbackRepository) EXPECT() *MockCallbackRepository_Expecter {
+	return &MockCallbackRepository_Expecter{mock: &_m.Mock}
+}
+
+// ClaimDueCallbacks provides a mock function with given fields: _a0, _a1
+func (_m *MockCallbackRepository) ClaimDueCallbacks(_a0 context.Context, _a1 models.ClaimDueCallbacksCmd) ([]models.DueCallback, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDueCallbacks")
+	}
+
+	var r0 []models.DueCallback
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ClaimDueCallbacksCmd) ([]models.DueCallback, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.ClaimDueCallbacksCmd) []models.DueCallback); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DueCallback)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.ClaimDueCallbacksCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCallbackRepository_ClaimDueCallbacks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDueCallbacks'
+type MockCallbackRepository_ClaimDueCallbacks_Call struct {
+	*mock.Call
+}
+
+// ClaimDueCallbacks is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.ClaimDueCallbacksCmd
+func (_e *MockCallbackRepository_Expecter) ClaimDueCallbacks(_a0 interface{}, _a1 interface{}) *MockCallbackRepository_ClaimDueCallbacks_Call {
+	return &MockCallbackRepository_ClaimDueCallbacks_Call{Call: _e.mock.On("ClaimDueCallbacks", _a0, _a1)}
+}
+
+func (_c *MockCallbackRepository_ClaimDueCallbacks_Call) Run(run func(_a0 context.Context, _a1 models.ClaimDueCallbacksCmd)) *MockCallbackRepository_ClaimDueCallbacks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.ClaimDueCallbacksCmd))
+	})
+	return _c
+}
+
+func (_c *MockCallbackRepository_ClaimDueCallbacks_Call) Return(_a0 []models.DueCallback, _a1 error) *MockCallbackRepository_ClaimDueCallbacks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCallbackRepository_ClaimDueCallbacks_Call) RunAndReturn(run func(context.Context, models.ClaimDueCallbacksCmd) ([]models.DueCallback, error)) *MockCallbackRepository_ClaimDueCallbacks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordCallbackAttempt provides a mock function with given fields: _a0, _a1
+func (_m *MockCallbackRepository) RecordCallbackAttempt(_a0 context.Context, _a1 models.RecordCallbackAttemptCmd) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordCallbackAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RecordCallbackAttemptCmd) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockCallbackRepository_RecordCallbackAttempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordCallbackAttempt'
+type MockCallbackRepository_RecordCallbackAttempt_Call struct {
+	*mock.Call
+}
+
+// RecordCallbackAttempt is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.RecordCallbackAttemptCmd
+func (_e *MockCallbackRepository_Expecter) RecordCallbackAttempt(_a0 interface{}, _a1 interface{}) *MockCallbackRepository_RecordCallbackAttempt_Call {
+	return &MockCallbackRepository_RecordCallbackAttempt_Call{Call: _e.mock.On("RecordCallbackAttempt", _a0, _a1)}
+}
+
+func (_c *MockCallbackRepository_RecordCallbackAttempt_Call) Run(run func(_a0 context.Context, _a1 models.RecordCallbackAttemptCmd)) *MockCallbackRepository_RecordCallbackAttempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RecordCallbackAttemptCmd))
+	})
+	return _c
+}
+
+func (_c *MockCallbackRepository_RecordCallbackAttempt_Call) Return(_a0 error) *MockCallbackRepository_RecordCallbackAttempt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCallbackRepository_RecordCallbackAttempt_Call) RunAndReturn(run func(context.Context, models.RecordCallbackAttemptCmd) error) *MockCallbackRepository_RecordCallbackAttempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockCallbackRepository creates a new instance of MockCallbackRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockCallbackRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockCallbackRepository {
+	mock := &MockCallbackRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,210 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+	time "time"
+)
+
+// MockJanitorRepository is an autogenerated mock type for the JanitorRepository type
+type MockJanitorRepository struct {
+	mock.Mock
+}
+
+type MockJanitorRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockJanitorRepository) EXPECT() *MockJanitorRepository_Expecter {
+	return &MockJanitorRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteExpiredIdempotencyKeys provides a mock function with given fields: _a0, _a1
+func (_m *MockJanitorRepository) DeleteExpiredIdempotencyKeys(_a0 context.Context, _a1 time.Time) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredIdempotencyKeys")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpiredIdempotencyKeys'
+type MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call struct {
+	*mock.Call
+}
+
+// DeleteExpiredIdempotencyKeys is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 time.Time
+func (_e *MockJanitorRepository_Expecter) DeleteExpiredIdempotencyKeys(_a0 interface{}, _a1 interface{}) *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call {
+	return &MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call{Call: _e.mock.On("DeleteExpiredIdempotencyKeys", _a0, _a1)}
+}
+
+func (_c *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call) Run(run func(_a0 context.Context, _a1 time.Time)) *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call) Return(_a0 int, _a1 error) *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call) RunAndReturn(run func(context.Context, time.Time) (int, error)) *MockJanitorRepository_DeleteExpiredIdempotencyKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRetentionPolicies provides a mock function with given fields: _a0
+func (_m *MockJanitorRepository) ListRetentionPolicies(_a0 context.Context) ([]models.RetentionPolicy, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRetentionPolicies")
+	}
+
+	var r0 []models.RetentionPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.RetentionPolicy, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.RetentionPolicy); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.RetentionPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockJanitorRepository_ListRetentionPolicies_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRetentionPolicies'
+type MockJanitorRepository_ListRetentionPolicies_Call struct {
+	*mock.Call
+}
+
+// ListRetentionPolicies is a helper method to define mock.On call
+//   - _a0 context.Context
+func (_e *MockJanitorRepository_Expecter) ListRetentionPolicies(_a0 interface{}) *MockJanitorRepository_ListRetentionPolicies_Call {
+	return &MockJanitorRepository_ListRetentionPolicies_Call{Call: _e.mock.On("ListRetentionPolicies", _a0)}
+}
+
+func (_c *MockJanitorRepository_ListRetentionPolicies_Call) Run(run func(_a0 context.Context)) *MockJanitorRepository_ListRetentionPolicies_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockJanitorRepository_ListRetentionPolicies_Call) Return(_a0 []models.RetentionPolicy, _a1 error) *MockJanitorRepository_ListRetentionPolicies_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockJanitorRepository_ListRetentionPolicies_Call) RunAndReturn(run func(context.Context) ([]models.RetentionPolicy, error)) *MockJanitorRepository_ListRetentionPolicies_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PurgeExpressions provides a mock function with given fields: _a0, _a1
+func (_m *MockJanitorRepository) PurgeExpressions(_a0 context.Context, _a1 models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeExpressions")
+	}
+
+	var r0 models.PurgeExpressionsResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PurgeExpressionsCmd) models.PurgeExpressionsResult); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(models.PurgeExpressionsResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PurgeExpressionsCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockJanitorRepository_PurgeExpressions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeExpressions'
+type MockJanitorRepository_PurgeExpressions_Call struct {
+	*mock.Call
+}
+
+// PurgeExpressions is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.PurgeExpressionsCmd
+func (_e *MockJanitorRepository_Expecter) PurgeExpressions(_a0 interface{}, _a1 interface{}) *MockJanitorRepository_PurgeExpressions_Call {
+	return &MockJanitorRepository_PurgeExpressions_Call{Call: _e.mock.On("PurgeExpressions", _a0, _a1)}
+}
+
+func (_c *MockJanitorRepository_PurgeExpressions_Call) Run(run func(_a0 context.Context, _a1 models.PurgeExpressionsCmd)) *MockJanitorRepository_PurgeExpressions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.PurgeExpressionsCmd))
+	})
+	return _c
+}
+
+func (_c *MockJanitorRepository_PurgeExpressions_Call) Return(_a0 models.PurgeExpressionsResult, _a1 error) *MockJanitorRepository_PurgeExpressions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockJanitorRepository_PurgeExpressions_Call) RunAndReturn(run func(context.Context, models.PurgeExpressionsCmd) (models.PurgeExpressionsResult, error)) *MockJanitorRepository_PurgeExpressions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockJanitorRepository creates a new instance of MockJanitorRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockJanitorRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockJanitorRepository {
+	mock := &MockJanitorRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,153 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockMetricsRepository is an autogenerated mock type for the MetricsRepository type
+type MockMetricsRepository struct {
+	mock.Mock
+}
+
+type MockMetricsRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockMetricsRepository) EXPECT() *MockMetricsRepository_Expecter {
+	return &MockMetricsRepository_Expecter{mock: &_m.Mock}
+}
+
+// CountExpressionsByStatus provides a mock function with given fields: _a0
+func (_m *MockMetricsRepository) CountExpressionsByStatus(_a0 context.Context) (map[models.ExpressionStatus]int, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountExpressionsByStatus")
+	}
+
+	var r0 map[models.ExpressionStatus]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[models.ExpressionStatus]int, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[models.ExpressionStatus]int); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[models.ExpressionStatus]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMetricsRepository_CountExpressionsByStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountExpressionsByStatus'
+type MockMetricsRepository_CountExpressionsByStatus_Call struct {
+	*mock.Call
+}
+
+// CountExpressionsByStatus is a helper method to define mock.On call
+//   - _a0 context.Context
+func (_e *MockMetricsRepository_Expecter) CountExpressionsByStatus(_a0 interface{}) *MockMetricsRepository_CountExpressionsByStatus_Call {
+	return &MockMetricsRepository_CountExpressionsByStatus_Call{Call: _e.mock.On("CountExpressionsByStatus", _a0)}
+}
+
+func (_c *MockMetricsRepository_CountExpressionsByStatus_Call) Run(run func(_a0 context.Context)) *MockMetricsRepository_CountExpressionsByStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_CountExpressionsByStatus_Call) Return(_a0 map[models.ExpressionStatus]int, _a1 error) *MockMetricsRepository_CountExpressionsByStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMetricsRepository_CountExpressionsByStatus_Call) RunAndReturn(run func(context.Context) (map[models.ExpressionStatus]int, error)) *MockMetricsRepository_CountExpressionsByStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountTasksByStatus provides a mock function with given fields: _a0
+func (_m *MockMetricsRepository) CountTasksByStatus(_a0 context.Context) (map[models.TaskStatus]int, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountTasksByStatus")
+	}
+
+	var r0 map[models.TaskStatus]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[models.TaskStatus]int, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[models.TaskStatus]int); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[models.TaskStatus]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMetricsRepository_CountTasksByStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountTasksByStatus'
+type MockMetricsRepository_CountTasksByStatus_Call struct {
+	*mock.Call
+}
+
+// CountTasksByStatus is a helper method to define mock.On call
+//   - _a0 context.Context
+func (_e *MockMetricsRepository_Expecter) CountTasksByStatus(_a0 interface{}) *MockMetricsRepository_CountTasksByStatus_Call {
+	return &MockMetricsRepository_CountTasksByStatus_Call{Call: _e.mock.On("CountTasksByStatus", _a0)}
+}
+
+func (_c *MockMetricsRepository_CountTasksByStatus_Call) Run(run func(_a0 context.Context)) *MockMetricsRepository_CountTasksByStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_CountTasksByStatus_Call) Return(_a0 map[models.TaskStatus]int, _a1 error) *MockMetricsRepository_CountTasksByStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMetricsRepository_CountTasksByStatus_Call) RunAndReturn(run func(context.Context) (map[models.TaskStatus]int, error)) *MockMetricsRepository_CountTasksByStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockMetricsRepository creates a new instance of MockMetricsRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockMetricsRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockMetricsRepository {
+	mock := &MockMetricsRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
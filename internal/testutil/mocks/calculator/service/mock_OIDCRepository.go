@@ -0,0 +1,155 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockOIDCRepository is an autogenerated mock type for the OIDCRepository type
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+type MockOIDCRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOIDCRepository) EXPECT() *MockOIDCRepository_Expecter {
+	return &MockOIDCRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateUserWithIdentity provides a mock function with given fields: ctx, cmd
+func (_m *MockOIDCRepository) CreateUserWithIdentity(ctx context.Context, cmd models.CreateUserWithIdentityCmd) (*models.User, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUserWithIdentity")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateUserWithIdentityCmd) (*models.User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateUserWithIdentityCmd) *models.User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateUserWithIdentityCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOIDCRepository_CreateUserWithIdentity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUserWithIdentity'
+type MockOIDCRepository_CreateUserWithIdentity_Call struct {
+	*mock.Call
+}
+
+// CreateUserWithIdentity is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.CreateUserWithIdentityCmd
+func (_e *MockOIDCRepository_Expecter) CreateUserWithIdentity(ctx interface{}, cmd interface{}) *MockOIDCRepository_CreateUserWithIdentity_Call {
+	return &MockOIDCRepository_CreateUserWithIdentity_Call{Call: _e.mock.On("CreateUserWithIdentity", ctx, cmd)}
+}
+
+func (_c *MockOIDCRepository_CreateUserWithIdentity_Call) Run(run func(ctx context.Context, cmd models.CreateUserWithIdentityCmd)) *MockOIDCRepository_CreateUserWithIdentity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.CreateUserWithIdentityCmd))
+	})
+	return _c
+}
+
+func (_c *MockOIDCRepository_CreateUserWithIdentity_Call) Return(_a0 *models.User, _a1 error) *MockOIDCRepository_CreateUserWithIdentity_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOIDCRepository_CreateUserWithIdentity_Call) RunAndReturn(run func(context.Context, models.CreateUserWithIdentityCmd) (*models.User, error)) *MockOIDCRepository_CreateUserWithIdentity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByIdentity provides a mock function with given fields: ctx, cmd
+func (_m *MockOIDCRepository) GetUserByIdentity(ctx context.Context, cmd models.GetUserByIdentityCmd) (*models.User, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByIdentity")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserByIdentityCmd) (*models.User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserByIdentityCmd) *models.User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.GetUserByIdentityCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOIDCRepository_GetUserByIdentity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByIdentity'
+type MockOIDCRepository_GetUserByIdentity_Call struct {
+	*mock.Call
+}
+
+// GetUserByIdentity is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.GetUserByIdentityCmd
+func (_e *MockOIDCRepository_Expecter) GetUserByIdentity(ctx interface{}, cmd interface{}) *MockOIDCRepository_GetUserByIdentity_Call {
+	return &MockOIDCRepository_GetUserByIdentity_Call{Call: _e.mock.On("GetUserByIdentity", ctx, cmd)}
+}
+
+func (_c *MockOIDCRepository_GetUserByIdentity_Call) Run(run func(ctx context.Context, cmd models.GetUserByIdentityCmd)) *MockOIDCRepository_GetUserByIdentity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.GetUserByIdentityCmd))
+	})
+	return _c
+}
+
+func (_c *MockOIDCRepository_GetUserByIdentity_Call) Return(_a0 *models.User, _a1 error) *MockOIDCRepository_GetUserByIdentity_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOIDCRepository_GetUserByIdentity_Call) RunAndReturn(run func(context.Context, models.GetUserByIdentityCmd) (*models.User, error)) *MockOIDCRepository_GetUserByIdentity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockOIDCRepository creates a new instance of MockOIDCRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOIDCRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOIDCRepository {
+	mock := &MockOIDCRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,200 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	models "github.com/belo4ya/edu-final-calculate-api/internal/calculator/repository/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockOutboxRepository is an autogenerated mock type for the OutboxRepository type
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+type MockOutboxRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOutboxRepository) EXPECT() *MockOutboxRepository_Expecter {
+	return &MockOutboxRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteDeliveredOutboxEvents provides a mock function with given fields: _a0, _a1
+func (_m *MockOutboxRepository) DeleteDeliveredOutboxEvents(_a0 context.Context, _a1 []string) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDeliveredOutboxEvents")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOutboxRepository_DeleteDeliveredOutboxEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDeliveredOutboxEvents'
+type MockOutboxRepository_DeleteDeliveredOutboxEvents_Call struct {
+	*mock.Call
+}
+
+// DeleteDeliveredOutboxEvents is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []string
+func (_e *MockOutboxRepository_Expecter) DeleteDeliveredOutboxEvents(_a0 interface{}, _a1 interface{}) *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call {
+	return &MockOutboxRepository_DeleteDeliveredOutboxEvents_Call{Call: _e.mock.On("DeleteDeliveredOutboxEvents", _a0, _a1)}
+}
+
+func (_c *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call) Run(run func(_a0 context.Context, _a1 []string)) *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call) Return(_a0 int, _a1 error) *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call) RunAndReturn(run func(context.Context, []string) (int, error)) *MockOutboxRepository_DeleteDeliveredOutboxEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListOutboxEvents provides a mock function with given fields: _a0, _a1
+func (_m *MockOutboxRepository) ListOutboxEvents(_a0 context.Context, _a1 models.ListOutboxEventsCmd) ([]models.OutboxEvent, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOutboxEvents")
+	}
+
+	var r0 []models.OutboxEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ListOutboxEventsCmd) ([]models.OutboxEvent, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.ListOutboxEventsCmd) []models.OutboxEvent); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OutboxEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.ListOutboxEventsCmd) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOutboxRepository_ListOutboxEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOutboxEvents'
+type MockOutboxRepository_ListOutboxEvents_Call struct {
+	*mock.Call
+}
+
+// ListOutboxEvents is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.ListOutboxEventsCmd
+func (_e *MockOutboxRepository_Expecter) ListOutboxEvents(_a0 interface{}, _a1 interface{}) *MockOutboxRepository_ListOutboxEvents_Call {
+	return &MockOutboxRepository_ListOutboxEvents_Call{Call: _e.mock.On("ListOutboxEvents", _a0, _a1)}
+}
+
+func (_c *MockOutboxRepository_ListOutboxEvents_Call) Run(run func(_a0 context.Context, _a1 models.ListOutboxEventsCmd)) *MockOutboxRepository_ListOutboxEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.ListOutboxEventsCmd))
+	})
+	return _c
+}
+
+func (_c *MockOutboxRepository_ListOutboxEvents_Call) Return(_a0 []models.OutboxEvent, _a1 error) *MockOutboxRepository_ListOutboxEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOutboxRepository_ListOutboxEvents_Call) RunAndReturn(run func(context.Context, models.ListOutboxEventsCmd) ([]models.OutboxEvent, error)) *MockOutboxRepository_ListOutboxEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkOutboxEventsDelivered provides a mock function with given fields: _a0, _a1
+func (_m *MockOutboxRepository) MarkOutboxEventsDelivered(_a0 context.Context, _a1 models.MarkOutboxEventsDeliveredCmd) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOutboxEventsDelivered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MarkOutboxEventsDeliveredCmd) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockOutboxRepository_MarkOutboxEventsDelivered_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkOutboxEventsDelivered'
+type MockOutboxRepository_MarkOutboxEventsDelivered_Call struct {
+	*mock.Call
+}
+
+// MarkOutboxEventsDelivered is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 models.MarkOutboxEventsDeliveredCmd
+func (_e *MockOutboxRepository_Expecter) MarkOutboxEventsDelivered(_a0 interface{}, _a1 interface{}) *MockOutboxRepository_MarkOutboxEventsDelivered_Call {
+	return &MockOutboxRepository_MarkOutboxEventsDelivered_Call{Call: _e.mock.On("MarkOutboxEventsDelivered", _a0, _a1)}
+}
+
+func (_c *MockOutboxRepository_MarkOutboxEventsDelivered_Call) Run(run func(_a0 context.Context, _a1 models.MarkOutboxEventsDeliveredCmd)) *MockOutboxRepository_MarkOutboxEventsDelivered_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.MarkOutboxEventsDeliveredCmd))
+	})
+	return _c
+}
+
+func (_c *MockOutboxRepository_MarkOutboxEventsDelivered_Call) Return(_a0 error) *MockOutboxRepository_MarkOutboxEventsDelivered_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockOutboxRepository_MarkOutboxEventsDelivered_Call) RunAndReturn(run func(context.Context, models.MarkOutboxEventsDeliveredCmd) error) *MockOutboxRepository_MarkOutboxEventsDelivered_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockOutboxRepository creates a new instance of MockOutboxRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOutboxRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOutboxRepository {
+	mock := &MockOutboxRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,129 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	v1 "github.com/belo4ya/edu-final-calculate-api/pkg/calculator/v1"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockOutboxSink is an autogenerated mock type for the OutboxSink type
+type MockOutboxSink struct {
+	mock.Mock
+}
+
+type MockOutboxSink_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockOutboxSink) EXPECT() *MockOutboxSink_Expecter {
+	return &MockOutboxSink_Expecter{mock: &_m.Mock}
+}
+
+// Deliver provides a mock function with given fields: ctx, event
+func (_m *MockOutboxSink) Deliver(ctx context.Context, event *v1.ExpressionEvent) error {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Deliver")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.ExpressionEvent) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockOutboxSink_Deliver_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Deliver'
+type MockOutboxSink_Deliver_Call struct {
+	*mock.Call
+}
+
+// Deliver is a helper method to define mock.On call
+//   - ctx context.Context
+//   - event *v1.ExpressionEvent
+func (_e *MockOutboxSink_Expecter) Deliver(ctx interface{}, event interface{}) *MockOutboxSink_Deliver_Call {
+	return &MockOutboxSink_Deliver_Call{Call: _e.mock.On("Deliver", ctx, event)}
+}
+
+func (_c *MockOutboxSink_Deliver_Call) Run(run func(ctx context.Context, event *v1.ExpressionEvent)) *MockOutboxSink_Deliver_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*v1.ExpressionEvent))
+	})
+	return _c
+}
+
+func (_c *MockOutboxSink_Deliver_Call) Return(_a0 error) *MockOutboxSink_Deliver_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockOutboxSink_Deliver_Call) RunAndReturn(run func(context.Context, *v1.ExpressionEvent) error) *MockOutboxSink_Deliver_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Name provides a mock function with no fields
+func (_m *MockOutboxSink) Name() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Name")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockOutboxSink_Name_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Name'
+type MockOutboxSink_Name_Call struct {
+	*mock.Call
+}
+
+// Name is a helper method to define mock.On call
+func (_e *MockOutboxSink_Expecter) Name() *MockOutboxSink_Name_Call {
+	return &MockOutboxSink_Name_Call{Call: _e.mock.On("Name")}
+}
+
+func (_c *MockOutboxSink_Name_Call) Run(run func()) *MockOutboxSink_Name_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockOutboxSink_Name_Call) Return(_a0 string) *MockOutboxSink_Name_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockOutboxSink_Name_Call) RunAndReturn(run func() string) *MockOutboxSink_Name_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockOutboxSink creates a new instance of MockOutboxSink. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOutboxSink(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOutboxSink {
+	mock := &MockOutboxSink{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,94 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	time "time"
+)
+
+// MockUnroutableRepository is an autogenerated mock type for the UnroutableRepository type
+type MockUnroutableRepository struct {
+	mock.Mock
+}
+
+type MockUnroutableRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockUnroutableRepository) EXPECT() *MockUnroutableRepository_Expecter {
+	return &MockUnroutableRepository_Expecter{mock: &_m.Mock}
+}
+
+// ReportUnroutableTasks provides a mock function with given fields: _a0, _a1
+func (_m *MockUnroutableRepository) ReportUnroutableTasks(_a0 context.Context, _a1 time.Time) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReportUnroutableTasks")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUnroutableRepository_ReportUnroutableTasks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReportUnroutableTasks'
+type MockUnroutableRepository_ReportUnroutableTasks_Call struct {
+	*mock.Call
+}
+
+// ReportUnroutableTasks is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 time.Time
+func (_e *MockUnroutableRepository_Expecter) ReportUnroutableTasks(_a0 interface{}, _a1 interface{}) *MockUnroutableRepository_ReportUnroutableTasks_Call {
+	return &MockUnroutableRepository_ReportUnroutableTasks_Call{Call: _e.mock.On("ReportUnroutableTasks", _a0, _a1)}
+}
+
+func (_c *MockUnroutableRepository_ReportUnroutableTasks_Call) Run(run func(_a0 context.Context, _a1 time.Time)) *MockUnroutableRepository_ReportUnroutableTasks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockUnroutableRepository_ReportUnroutableTasks_Call) Return(_a0 int, _a1 error) *MockUnroutableRepository_ReportUnroutableTasks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUnroutableRepository_ReportUnroutableTasks_Call) RunAndReturn(run func(context.Context, time.Time) (int, error)) *MockUnroutableRepository_ReportUnroutableTasks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockUnroutableRepository creates a new instance of MockUnroutableRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockUnroutableRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockUnroutableRepository {
+	mock := &MockUnroutableRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
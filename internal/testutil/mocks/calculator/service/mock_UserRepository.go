@@ -22,28 +22,570 @@ func (_m *MockUserRepository) EXPECT() *MockUserRepository_Expecter {
 	return &MockUserRepository_Expecter{mock: &_m.Mock}
 }
 
+// ChangePassword provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) ChangePassword(ctx context.Context, cmd models.ChangePasswordCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ChangePasswordCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_ChangePassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChangePassword'
+type MockUserRepository_ChangePassword_Call struct {
+	*mock.Call
+}
+
+// ChangePassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.ChangePasswordCmd
+func (_e *MockUserRepository_Expecter) ChangePassword(ctx interface{}, cmd interface{}) *MockUserRepository_ChangePassword_Call {
+	return &MockUserRepository_ChangePassword_Call{Call: _e.mock.On("ChangePassword", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_ChangePassword_Call) Run(run func(ctx context.Context, cmd models.ChangePasswordCmd)) *MockUserRepository_ChangePassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.ChangePasswordCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_ChangePassword_Call) Return(_a0 error) *MockUserRepository_ChangePassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_ChangePassword_Call) RunAndReturn(run func(context.Context, models.ChangePasswordCmd) error) *MockUserRepository_ChangePassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) CreateAPIKey(ctx context.Context, cmd models.CreateAPIKeyCmd) (*models.APIKey, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateAPIKeyCmd) (*models.APIKey, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateAPIKeyCmd) *models.APIKey); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateAPIKeyCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type MockUserRepository_CreateAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.CreateAPIKeyCmd
+func (_e *MockUserRepository_Expecter) CreateAPIKey(ctx interface{}, cmd interface{}) *MockUserRepository_CreateAPIKey_Call {
+	return &MockUserRepository_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_CreateAPIKey_Call) Run(run func(ctx context.Context, cmd models.CreateAPIKeyCmd)) *MockUserRepository_CreateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.CreateAPIKeyCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_CreateAPIKey_Call) Return(_a0 *models.APIKey, _a1 error) *MockUserRepository_CreateAPIKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_CreateAPIKey_Call) RunAndReturn(run func(context.Context, models.CreateAPIKeyCmd) (*models.APIKey, error)) *MockUserRepository_CreateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAuditEvent provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) CreateAuditEvent(ctx context.Context, cmd models.CreateAuditEventCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAuditEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateAuditEventCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_CreateAuditEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAuditEvent'
+type MockUserRepository_CreateAuditEvent_Call struct {
+	*mock.Call
+}
+
+// CreateAuditEvent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.CreateAuditEventCmd
+func (_e *MockUserRepository_Expecter) CreateAuditEvent(ctx interface{}, cmd interface{}) *MockUserRepository_CreateAuditEvent_Call {
+	return &MockUserRepository_CreateAuditEvent_Call{Call: _e.mock.On("CreateAuditEvent", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_CreateAuditEvent_Call) Run(run func(ctx context.Context, cmd models.CreateAuditEventCmd)) *MockUserRepository_CreateAuditEvent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.CreateAuditEventCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_CreateAuditEvent_Call) Return(_a0 error) *MockUserRepository_CreateAuditEvent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_CreateAuditEvent_Call) RunAndReturn(run func(context.Context, models.CreateAuditEventCmd) error) *MockUserRepository_CreateAuditEvent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) CreateRefreshToken(ctx context.Context, cmd models.CreateRefreshTokenCmd) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateRefreshTokenCmd) (*models.RefreshToken, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateRefreshTokenCmd) *models.RefreshToken); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateRefreshTokenCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_CreateRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRefreshToken'
+type MockUserRepository_CreateRefreshToken_Call struct {
+	*mock.Call
+}
+
+// CreateRefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.CreateRefreshTokenCmd
+func (_e *MockUserRepository_Expecter) CreateRefreshToken(ctx interface{}, cmd interface{}) *MockUserRepository_CreateRefreshToken_Call {
+	return &MockUserRepository_CreateRefreshToken_Call{Call: _e.mock.On("CreateRefreshToken", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_CreateRefreshToken_Call) Run(run func(ctx context.Context, cmd models.CreateRefreshTokenCmd)) *MockUserRepository_CreateRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.CreateRefreshTokenCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_CreateRefreshToken_Call) Return(_a0 *models.RefreshToken, _a1 error) *MockUserRepository_CreateRefreshToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_CreateRefreshToken_Call) RunAndReturn(run func(context.Context, models.CreateRefreshTokenCmd) (*models.RefreshToken, error)) *MockUserRepository_CreateRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteUser provides a mock function with given fields: ctx, userID
+func (_m *MockUserRepository) DeleteUser(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_DeleteUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUser'
+type MockUserRepository_DeleteUser_Call struct {
+	*mock.Call
+}
+
+// DeleteUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MockUserRepository_Expecter) DeleteUser(ctx interface{}, userID interface{}) *MockUserRepository_DeleteUser_Call {
+	return &MockUserRepository_DeleteUser_Call{Call: _e.mock.On("DeleteUser", ctx, userID)}
+}
+
+func (_c *MockUserRepository_DeleteUser_Call) Run(run func(ctx context.Context, userID string)) *MockUserRepository_DeleteUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_DeleteUser_Call) Return(_a0 error) *MockUserRepository_DeleteUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_DeleteUser_Call) RunAndReturn(run func(context.Context, string) error) *MockUserRepository_DeleteUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLoginThrottles provides a mock function with given fields: ctx, keys
+func (_m *MockUserRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	ret := _m.Called(ctx, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLoginThrottles")
+	}
+
+	var r0 []models.LoginThrottle
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]models.LoginThrottle, error)); ok {
+		return rf(ctx, keys)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []models.LoginThrottle); ok {
+		r0 = rf(ctx, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.LoginThrottle)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_GetLoginThrottles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLoginThrottles'
+type MockUserRepository_GetLoginThrottles_Call struct {
+	*mock.Call
+}
+
+// GetLoginThrottles is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []string
+func (_e *MockUserRepository_Expecter) GetLoginThrottles(ctx interface{}, keys interface{}) *MockUserRepository_GetLoginThrottles_Call {
+	return &MockUserRepository_GetLoginThrottles_Call{Call: _e.mock.On("GetLoginThrottles", ctx, keys)}
+}
+
+func (_c *MockUserRepository_GetLoginThrottles_Call) Run(run func(ctx context.Context, keys []string)) *MockUserRepository_GetLoginThrottles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetLoginThrottles_Call) Return(_a0 []models.LoginThrottle, _a1 error) *MockUserRepository_GetLoginThrottles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_GetLoginThrottles_Call) RunAndReturn(run func(context.Context, []string) ([]models.LoginThrottle, error)) *MockUserRepository_GetLoginThrottles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUser provides a mock function with given fields: ctx, cmd
 func (_m *MockUserRepository) GetUser(ctx context.Context, cmd models.GetUserCmd) (*models.User, error) {
 	ret := _m.Called(ctx, cmd)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserCmd) (*models.User, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserCmd) *models.User); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.GetUserCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type MockUserRepository_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.GetUserCmd
+func (_e *MockUserRepository_Expecter) GetUser(ctx interface{}, cmd interface{}) *MockUserRepository_GetUser_Call {
+	return &MockUserRepository_GetUser_Call{Call: _e.mock.On("GetUser", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_GetUser_Call) Run(run func(ctx context.Context, cmd models.GetUserCmd)) *MockUserRepository_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.GetUserCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetUser_Call) Return(_a0 *models.User, _a1 error) *MockUserRepository_GetUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_GetUser_Call) RunAndReturn(run func(context.Context, models.GetUserCmd) (*models.User, error)) *MockUserRepository_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *MockUserRepository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_GetUserByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByID'
+type MockUserRepository_GetUserByID_Call struct {
+	*mock.Call
+}
+
+// GetUserByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MockUserRepository_Expecter) GetUserByID(ctx interface{}, userID interface{}) *MockUserRepository_GetUserByID_Call {
+	return &MockUserRepository_GetUserByID_Call{Call: _e.mock.On("GetUserByID", ctx, userID)}
+}
+
+func (_c *MockUserRepository_GetUserByID_Call) Run(run func(ctx context.Context, userID string)) *MockUserRepository_GetUserByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetUserByID_Call) Return(_a0 *models.User, _a1 error) *MockUserRepository_GetUserByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_GetUserByID_Call) RunAndReturn(run func(context.Context, string) (*models.User, error)) *MockUserRepository_GetUserByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx, userID
+func (_m *MockUserRepository) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.APIKey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type MockUserRepository_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MockUserRepository_Expecter) ListAPIKeys(ctx interface{}, userID interface{}) *MockUserRepository_ListAPIKeys_Call {
+	return &MockUserRepository_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx, userID)}
+}
+
+func (_c *MockUserRepository_ListAPIKeys_Call) Run(run func(ctx context.Context, userID string)) *MockUserRepository_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_ListAPIKeys_Call) Return(_a0 []models.APIKey, _a1 error) *MockUserRepository_ListAPIKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_ListAPIKeys_Call) RunAndReturn(run func(context.Context, string) ([]models.APIKey, error)) *MockUserRepository_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockLogin provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) LockLogin(ctx context.Context, cmd models.LockLoginCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockLogin")
 	}
 
-	var r0 *models.User
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.LockLoginCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_LockLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockLogin'
+type MockUserRepository_LockLogin_Call struct {
+	*mock.Call
+}
+
+// LockLogin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.LockLoginCmd
+func (_e *MockUserRepository_Expecter) LockLogin(ctx interface{}, cmd interface{}) *MockUserRepository_LockLogin_Call {
+	return &MockUserRepository_LockLogin_Call{Call: _e.mock.On("LockLogin", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_LockLogin_Call) Run(run func(ctx context.Context, cmd models.LockLoginCmd)) *MockUserRepository_LockLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.LockLoginCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_LockLogin_Call) Return(_a0 error) *MockUserRepository_LockLogin_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_LockLogin_Call) RunAndReturn(run func(context.Context, models.LockLoginCmd) error) *MockUserRepository_LockLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordLoginFailure provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) RecordLoginFailure(ctx context.Context, cmd models.RecordLoginFailureCmd) (*models.LoginThrottle, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLoginFailure")
+	}
+
+	var r0 *models.LoginThrottle
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserCmd) (*models.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.RecordLoginFailureCmd) (*models.LoginThrottle, error)); ok {
 		return rf(ctx, cmd)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.GetUserCmd) *models.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.RecordLoginFailureCmd) *models.LoginThrottle); ok {
 		r0 = rf(ctx, cmd)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
+			r0 = ret.Get(0).(*models.LoginThrottle)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.GetUserCmd) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, models.RecordLoginFailureCmd) error); ok {
 		r1 = rf(ctx, cmd)
 	} else {
 		r1 = ret.Error(1)
@@ -52,31 +594,31 @@ func (_m *MockUserRepository) GetUser(ctx context.Context, cmd models.GetUserCmd
 	return r0, r1
 }
 
-// MockUserRepository_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
-type MockUserRepository_GetUser_Call struct {
+// MockUserRepository_RecordLoginFailure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordLoginFailure'
+type MockUserRepository_RecordLoginFailure_Call struct {
 	*mock.Call
 }
 
-// GetUser is a helper method to define mock.On call
+// RecordLoginFailure is a helper method to define mock.On call
 //   - ctx context.Context
-//   - cmd models.GetUserCmd
-func (_e *MockUserRepository_Expecter) GetUser(ctx interface{}, cmd interface{}) *MockUserRepository_GetUser_Call {
-	return &MockUserRepository_GetUser_Call{Call: _e.mock.On("GetUser", ctx, cmd)}
+//   - cmd models.RecordLoginFailureCmd
+func (_e *MockUserRepository_Expecter) RecordLoginFailure(ctx interface{}, cmd interface{}) *MockUserRepository_RecordLoginFailure_Call {
+	return &MockUserRepository_RecordLoginFailure_Call{Call: _e.mock.On("RecordLoginFailure", ctx, cmd)}
 }
 
-func (_c *MockUserRepository_GetUser_Call) Run(run func(ctx context.Context, cmd models.GetUserCmd)) *MockUserRepository_GetUser_Call {
+func (_c *MockUserRepository_RecordLoginFailure_Call) Run(run func(ctx context.Context, cmd models.RecordLoginFailureCmd)) *MockUserRepository_RecordLoginFailure_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(models.GetUserCmd))
+		run(args[0].(context.Context), args[1].(models.RecordLoginFailureCmd))
 	})
 	return _c
 }
 
-func (_c *MockUserRepository_GetUser_Call) Return(_a0 *models.User, _a1 error) *MockUserRepository_GetUser_Call {
+func (_c *MockUserRepository_RecordLoginFailure_Call) Return(_a0 *models.LoginThrottle, _a1 error) *MockUserRepository_RecordLoginFailure_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockUserRepository_GetUser_Call) RunAndReturn(run func(context.Context, models.GetUserCmd) (*models.User, error)) *MockUserRepository_GetUser_Call {
+func (_c *MockUserRepository_RecordLoginFailure_Call) RunAndReturn(run func(context.Context, models.RecordLoginFailureCmd) (*models.LoginThrottle, error)) *MockUserRepository_RecordLoginFailure_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -128,6 +670,300 @@ func (_c *MockUserRepository_Register_Call) RunAndReturn(run func(context.Contex
 	return _c
 }
 
+// ResetLoginFailures provides a mock function with given fields: ctx, key
+func (_m *MockUserRepository) ResetLoginFailures(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetLoginFailures")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_ResetLoginFailures_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetLoginFailures'
+type MockUserRepository_ResetLoginFailures_Call struct {
+	*mock.Call
+}
+
+// ResetLoginFailures is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *MockUserRepository_Expecter) ResetLoginFailures(ctx interface{}, key interface{}) *MockUserRepository_ResetLoginFailures_Call {
+	return &MockUserRepository_ResetLoginFailures_Call{Call: _e.mock.On("ResetLoginFailures", ctx, key)}
+}
+
+func (_c *MockUserRepository_ResetLoginFailures_Call) Run(run func(ctx context.Context, key string)) *MockUserRepository_ResetLoginFailures_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_ResetLoginFailures_Call) Return(_a0 error) *MockUserRepository_ResetLoginFailures_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_ResetLoginFailures_Call) RunAndReturn(run func(context.Context, string) error) *MockUserRepository_ResetLoginFailures_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) RevokeAPIKey(ctx context.Context, cmd models.RevokeAPIKeyCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RevokeAPIKeyCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type MockUserRepository_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.RevokeAPIKeyCmd
+func (_e *MockUserRepository_Expecter) RevokeAPIKey(ctx interface{}, cmd interface{}) *MockUserRepository_RevokeAPIKey_Call {
+	return &MockUserRepository_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_RevokeAPIKey_Call) Run(run func(ctx context.Context, cmd models.RevokeAPIKeyCmd)) *MockUserRepository_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RevokeAPIKeyCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeAPIKey_Call) Return(_a0 error) *MockUserRepository_RevokeAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeAPIKey_Call) RunAndReturn(run func(context.Context, models.RevokeAPIKeyCmd) error) *MockUserRepository_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeRefreshToken provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) RevokeRefreshToken(ctx context.Context, cmd models.RevokeRefreshTokenCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RevokeRefreshTokenCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_RevokeRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeRefreshToken'
+type MockUserRepository_RevokeRefreshToken_Call struct {
+	*mock.Call
+}
+
+// RevokeRefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.RevokeRefreshTokenCmd
+func (_e *MockUserRepository_Expecter) RevokeRefreshToken(ctx interface{}, cmd interface{}) *MockUserRepository_RevokeRefreshToken_Call {
+	return &MockUserRepository_RevokeRefreshToken_Call{Call: _e.mock.On("RevokeRefreshToken", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_RevokeRefreshToken_Call) Run(run func(ctx context.Context, cmd models.RevokeRefreshTokenCmd)) *MockUserRepository_RevokeRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RevokeRefreshTokenCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeRefreshToken_Call) Return(_a0 error) *MockUserRepository_RevokeRefreshToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeRefreshToken_Call) RunAndReturn(run func(context.Context, models.RevokeRefreshTokenCmd) error) *MockUserRepository_RevokeRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeToken provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) RevokeToken(ctx context.Context, cmd models.RevokeTokenCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RevokeTokenCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_RevokeToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeToken'
+type MockUserRepository_RevokeToken_Call struct {
+	*mock.Call
+}
+
+// RevokeToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.RevokeTokenCmd
+func (_e *MockUserRepository_Expecter) RevokeToken(ctx interface{}, cmd interface{}) *MockUserRepository_RevokeToken_Call {
+	return &MockUserRepository_RevokeToken_Call{Call: _e.mock.On("RevokeToken", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_RevokeToken_Call) Run(run func(ctx context.Context, cmd models.RevokeTokenCmd)) *MockUserRepository_RevokeToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RevokeTokenCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeToken_Call) Return(_a0 error) *MockUserRepository_RevokeToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeToken_Call) RunAndReturn(run func(context.Context, models.RevokeTokenCmd) error) *MockUserRepository_RevokeToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RotateRefreshToken provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) RotateRefreshToken(ctx context.Context, cmd models.RotateRefreshTokenCmd) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateRefreshToken")
+	}
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RotateRefreshTokenCmd) (*models.RefreshToken, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.RotateRefreshTokenCmd) *models.RefreshToken); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.RotateRefreshTokenCmd) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserRepository_RotateRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RotateRefreshToken'
+type MockUserRepository_RotateRefreshToken_Call struct {
+	*mock.Call
+}
+
+// RotateRefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.RotateRefreshTokenCmd
+func (_e *MockUserRepository_Expecter) RotateRefreshToken(ctx interface{}, cmd interface{}) *MockUserRepository_RotateRefreshToken_Call {
+	return &MockUserRepository_RotateRefreshToken_Call{Call: _e.mock.On("RotateRefreshToken", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_RotateRefreshToken_Call) Run(run func(ctx context.Context, cmd models.RotateRefreshTokenCmd)) *MockUserRepository_RotateRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.RotateRefreshTokenCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RotateRefreshToken_Call) Return(_a0 *models.RefreshToken, _a1 error) *MockUserRepository_RotateRefreshToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserRepository_RotateRefreshToken_Call) RunAndReturn(run func(context.Context, models.RotateRefreshTokenCmd) (*models.RefreshToken, error)) *MockUserRepository_RotateRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePasswordHash provides a mock function with given fields: ctx, cmd
+func (_m *MockUserRepository) UpdatePasswordHash(ctx context.Context, cmd models.UpdatePasswordHashCmd) error {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePasswordHash")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UpdatePasswordHashCmd) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserRepository_UpdatePasswordHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePasswordHash'
+type MockUserRepository_UpdatePasswordHash_Call struct {
+	*mock.Call
+}
+
+// UpdatePasswordHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cmd models.UpdatePasswordHashCmd
+func (_e *MockUserRepository_Expecter) UpdatePasswordHash(ctx interface{}, cmd interface{}) *MockUserRepository_UpdatePasswordHash_Call {
+	return &MockUserRepository_UpdatePasswordHash_Call{Call: _e.mock.On("UpdatePasswordHash", ctx, cmd)}
+}
+
+func (_c *MockUserRepository_UpdatePasswordHash_Call) Run(run func(ctx context.Context, cmd models.UpdatePasswordHashCmd)) *MockUserRepository_UpdatePasswordHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.UpdatePasswordHashCmd))
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_UpdatePasswordHash_Call) Return(_a0 error) *MockUserRepository_UpdatePasswordHash_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserRepository_UpdatePasswordHash_Call) RunAndReturn(run func(context.Context, models.UpdatePasswordHashCmd) error) *MockUserRepository_UpdatePasswordHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockUserRepository creates a new instance of MockUserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockUserRepository(t interface {
@@ -0,0 +1,163 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCIssuer is a minimal OpenID Connect provider for tests. It authorizes any request
+// and issues ID tokens for the configured subject.
+type OIDCIssuer struct {
+	URL      string
+	ClientID string
+
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims
+	nonces map[string]string // by authorization code
+}
+
+func NewOIDCIssuer(t *testing.T, clientID string) *OIDCIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	iss := &OIDCIssuer{ClientID: clientID, key: key, nonces: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.handleDiscovery)
+	mux.HandleFunc("/authorize", iss.handleAuthorize)
+	mux.HandleFunc("/token", iss.handleToken)
+	mux.HandleFunc("/jwks", iss.handleJWKS)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	iss.URL = srv.URL
+	return iss
+}
+
+// SetClaims sets the claims of the next ID tokens, such as sub and preferred_username.
+func (iss *OIDCIssuer) SetClaims(claims jwt.MapClaims) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.claims = claims
+}
+
+// Authorize emulates the user consenting at the authorization URL and returns the code for the redirect.
+func (iss *OIDCIssuer) Authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	defer resp.Body.Close()
+
+	loc, err := resp.Location()
+	if err != nil {
+		t.Fatalf("authorize redirect: %v", err)
+	}
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
+
+func (iss *OIDCIssuer) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                                iss.URL,
+		"authorization_endpoint":                iss.URL + "/authorize",
+		"token_endpoint":                        iss.URL + "/token",
+		"jwks_uri":                              iss.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (iss *OIDCIssuer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	code := rand.Text()
+
+	iss.mu.Lock()
+	iss.nonces[code] = query.Get("nonce")
+	iss.mu.Unlock()
+
+	redirect := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (iss *OIDCIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	iss.mu.Lock()
+	nonce, ok := iss.nonces[r.PostForm.Get("code")]
+	delete(iss.nonces, r.PostForm.Get("code"))
+	claims := jwt.MapClaims{}
+	for k, v := range iss.claims {
+		claims[k] = v
+	}
+	iss.mu.Unlock()
+
+	if !ok || r.PostForm.Get("code_verifier") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims["iss"] = iss.URL
+	claims["aud"] = iss.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	claims["nonce"] = nonce
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(iss.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (iss *OIDCIssuer) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": "test",
+			"n":   b64(iss.key.N.Bytes()),
+			"e":   b64(big.NewInt(int64(iss.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient is an [otlptrace.Client] appending each export request as a line of OTLP JSON,
+// the format read by the collector's otlpjsonfile receiver.
+type fileClient struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ otlptrace.Client = (*fileClient)(nil)
+
+func newFileClient(path string) *fileClient {
+	return &fileClient{path: path}
+}
+
+func (c *fileClient) Start(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	c.file = f
+	return nil
+}
+
+func (c *fileClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+func (c *fileClient) UploadTraces(_ context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := marshalOTLPJSON(&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return errors.New("file client is stopped")
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write spans: %w", err)
+	}
+	return nil
+}
+
+// marshalOTLPJSON encodes req following the OTLP/JSON rules, which differ from the canonical
+// protobuf JSON mapping: enums are integers and trace/span IDs are hex rather than base64.
+func marshalOTLPJSON(req *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("protojson marshal: %w", err)
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	if err := hexIDs(v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func hexIDs(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			switch k {
+			case "traceId", "spanId", "parentSpanId":
+				s, ok := val.(string)
+				if !ok {
+					continue
+				}
+				id, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return fmt.Errorf("decode %s: %w", k, err)
+				}
+				v[k] = hex.EncodeToString(id)
+			default:
+				if err := hexIDs(val); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, val := range v {
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
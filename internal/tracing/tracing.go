@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone disables span export.
+	ExporterNone = "none"
+	// ExporterStdout writes spans to stdout in a human-readable JSON form.
+	ExporterStdout = "stdout"
+	// ExporterOTLPFile appends spans to a file in the OTLP JSON Lines format.
+	ExporterOTLPFile = "otlp-file"
+	// ExporterOTLP sends spans to an OTLP/gRPC endpoint.
+	ExporterOTLP = "otlp"
+)
+
+// Exporters lists the supported span exporters.
+var Exporters = []string{ExporterNone, ExporterStdout, ExporterOTLPFile, ExporterOTLP}
+
+type Config struct {
+	ServiceName  string
+	Exporter     string
+	FilePath     string  // used by ExporterOTLPFile
+	OTLPEndpoint string  // used by ExporterOTLP, falls back to the OTEL_EXPORTER_OTLP_* variables
+	SampleRatio  float64 // fraction of new traces to sample, child spans follow their parent
+}
+
+// Configure installs the global tracer provider and the W3C trace context propagator.
+// The returned function flushes pending spans and must be called on shutdown.
+func Configure(ctx context.Context, conf *Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exp, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(conf.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, conf *Config) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		return exp, nil
+	case ExporterOTLPFile:
+		if conf.FilePath == "" {
+			return nil, errors.New("otlp-file exporter requires a file path")
+		}
+		exp, err := otlptrace.New(ctx, newFileClient(conf.FilePath))
+		if err != nil {
+			return nil, fmt.Errorf("create otlp-file exporter: %w", err)
+		}
+		return exp, nil
+	case ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if conf.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(conf.OTLPEndpoint))
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		return exp, nil
+	default:
+		return nil, fmt.Errorf("unsupported exporter %q", conf.Exporter)
+	}
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx,
+// or an empty string if ctx carries no sampled span.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// SpanContextFromTraceParent parses a W3C traceparent produced by [TraceParent].
+// Returns an invalid span context if traceParent is empty or malformed.
+func SpanContextFromTraceParent(traceParent string) trace.SpanContext {
+	if traceParent == "" {
+		return trace.SpanContext{}
+	}
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	return trace.SpanContextFromContext(ctx)
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParent(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	tp := TraceParent(ctx)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", tp)
+
+	got := SpanContextFromTraceParent(tp)
+	assert.True(t, got.IsValid())
+	assert.Equal(t, sc.TraceID(), got.TraceID())
+	assert.Equal(t, sc.SpanID(), got.SpanID())
+	assert.True(t, got.IsRemote())
+
+	assert.Empty(t, TraceParent(context.Background()))
+	assert.False(t, SpanContextFromTraceParent("").IsValid())
+	assert.False(t, SpanContextFromTraceParent("malformed").IsValid())
+}
+
+func TestConfigure_otlpFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	shutdown, err := Configure(ctx, &Config{
+		ServiceName: "test",
+		Exporter:    ExporterOTLPFile,
+		FilePath:    path,
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(ctx, "operation")
+	sc := span.SpanContext()
+	span.End()
+
+	require.NoError(t, shutdown(ctx))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan(), "expected an OTLP JSON line")
+
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID string `json:"traceId"`
+					SpanID  string `json:"spanId"`
+					Name    string `json:"name"`
+					Kind    int    `json:"kind"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &req))
+	require.Len(t, req.ResourceSpans, 1)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+
+	got := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "operation", got.Name)
+	assert.Equal(t, sc.TraceID().String(), got.TraceID)
+	assert.Equal(t, sc.SpanID().String(), got.SpanID)
+	assert.Equal(t, int(trace.SpanKindInternal), got.Kind)
+}
+
+func TestConfigure_unsupportedExporter(t *testing.T) {
+	_, err := Configure(context.Background(), &Config{Exporter: "jaeger"})
+	assert.Error(t, err)
+
+	_, err = Configure(context.Background(), &Config{Exporter: ExporterOTLPFile})
+	assert.Error(t, err)
+}
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of each supported database, see [FS].
+package migrations
+
+import "embed"
+
+// FS holds the migrations of each database in a directory named after it: sqlite and postgres.
+//
+//go:embed sqlite/*.sql postgres/*.sql
+var FS embed.FS
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN pending_at TIMESTAMP; -- when the task became ready to be claimed by an agent
+
+UPDATE tasks SET pending_at = updated_at WHERE status = 'Pending';
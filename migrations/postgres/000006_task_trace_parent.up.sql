@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN trace_parent TEXT; -- W3C traceparent of the request that submitted the expression
@@ -0,0 +1,30 @@
+-- Refresh tokens table
+CREATE TABLE refresh_tokens
+(
+    id          TEXT PRIMARY KEY,
+    user_id     TEXT      NOT NULL,
+    family_id   TEXT      NOT NULL, -- tokens obtained by rotation of the same login share the family
+    token_hash  TEXT      NOT NULL UNIQUE, -- SHA-256 of the opaque token
+    expires_at  TIMESTAMP NOT NULL,
+    revoked_at  TIMESTAMP,
+    replaced_by TEXT, -- ID of the token issued by rotation
+
+    created_at  TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Revoked access tokens, kept until they expire
+CREATE TABLE revoked_tokens
+(
+    jti        TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
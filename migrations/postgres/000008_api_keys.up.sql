@@ -0,0 +1,17 @@
+-- Personal API keys table
+CREATE TABLE api_keys
+(
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    name       TEXT      NOT NULL,
+    key_hash   TEXT      NOT NULL UNIQUE, -- SHA-256 of the key
+    scopes     TEXT      NOT NULL, -- comma-separated list of granted scopes
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,10 @@
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_user_id_fkey;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);
+
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_user_id_fkey;
+ALTER TABLE refresh_tokens ADD CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);
+
+ALTER TABLE expressions DROP CONSTRAINT expressions_user_id_fkey;
+ALTER TABLE expressions ADD CONSTRAINT expressions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);
+
+ALTER TABLE users DROP COLUMN tokens_revoked_at;
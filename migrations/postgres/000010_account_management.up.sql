@@ -0,0 +1,15 @@
+-- Access tokens issued before this time are rejected, set on password change
+ALTER TABLE users ADD COLUMN tokens_revoked_at TIMESTAMP;
+
+-- Deleting a user deletes their expressions (and tasks with them), refresh tokens and API keys
+ALTER TABLE expressions DROP CONSTRAINT expressions_user_id_fkey;
+ALTER TABLE expressions ADD CONSTRAINT expressions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_user_id_fkey;
+ALTER TABLE refresh_tokens ADD CONSTRAINT refresh_tokens_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_user_id_fkey;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
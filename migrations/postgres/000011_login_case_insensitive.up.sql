@@ -0,0 +1,2 @@
+-- Logins are unique regardless of case
+CREATE UNIQUE INDEX idx_users_login_lower ON users (lower(login));
@@ -0,0 +1,21 @@
+-- Failed login attempts per throttling key
+CREATE TABLE login_throttles
+(
+    key             TEXT PRIMARY KEY, -- login:<login> or ip:<address>
+    failures        INTEGER   NOT NULL,
+    locked_until    TIMESTAMP,
+    last_failure_at TIMESTAMP NOT NULL
+);
+
+-- Security-relevant events
+CREATE TABLE audit_events
+(
+    id         TEXT PRIMARY KEY,
+    event      TEXT      NOT NULL,
+    subject    TEXT      NOT NULL,
+    details    TEXT      NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
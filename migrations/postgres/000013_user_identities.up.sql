@@ -0,0 +1,14 @@
+-- Links of users to external identity provider accounts
+CREATE TABLE user_identities
+(
+    issuer     TEXT      NOT NULL,
+    subject    TEXT      NOT NULL, -- sub claim of the ID token
+    user_id    TEXT      NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,13 @@
+-- Retention policies of users overriding the global one, zero limits keep expressions
+CREATE TABLE retention_policies
+(
+    user_id    TEXT PRIMARY KEY,
+    max_age    BIGINT    NOT NULL DEFAULT 0, -- stored in nanoseconds, finished expressions older than that are purged
+    max_count  INTEGER   NOT NULL DEFAULT 0, -- number of the latest finished expressions kept
+
+    updated_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expressions_status_updated ON expressions (status, updated_at);
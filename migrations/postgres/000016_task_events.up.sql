@@ -0,0 +1,18 @@
+-- Append-only history of task state transitions
+CREATE TABLE task_events
+(
+    id            TEXT PRIMARY KEY,
+    task_id       TEXT      NOT NULL,
+    expression_id TEXT      NOT NULL,
+    type          TEXT      NOT NULL, -- created, claimed, completed or failed
+    agent_id      TEXT,               -- agent claiming or finishing the task
+    result        DOUBLE PRECISION,   -- result of a completed task
+
+    created_at    TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE,
+    FOREIGN KEY (expression_id) REFERENCES expressions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_events_expression_id ON task_events (expression_id);
+CREATE INDEX idx_task_events_task_id ON task_events (task_id);
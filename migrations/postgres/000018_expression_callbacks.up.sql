@@ -0,0 +1,34 @@
+-- Callbacks requested on expression submission, due once the expression is completed or failed
+CREATE TABLE expression_callbacks
+(
+    expression_id   TEXT PRIMARY KEY,
+    url             TEXT      NOT NULL,
+    secret          TEXT      NOT NULL DEFAULT '', -- HMAC key of the signature, empty for unsigned callbacks
+    status          TEXT      NOT NULL,            -- Pending, Delivered or Failed
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,                     -- earliest time of the retry after a failed attempt
+
+    created_at      TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    updated_at      TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (expression_id) REFERENCES expressions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expression_callbacks_status ON expression_callbacks (status, next_attempt_at);
+
+-- Delivery log of the callbacks
+CREATE TABLE callback_attempts
+(
+    id            TEXT PRIMARY KEY,
+    expression_id TEXT      NOT NULL,
+    attempt       INTEGER   NOT NULL,
+    status_code   INTEGER,          -- HTTP status of the response, NULL if there was none
+    error         TEXT,
+    duration      BIGINT    NOT NULL, -- stored in nanoseconds
+
+    created_at    TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+
+    FOREIGN KEY (expression_id) REFERENCES expressions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_callback_attempts_expression_id ON callback_attempts (expression_id);
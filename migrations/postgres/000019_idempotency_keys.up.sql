@@ -0,0 +1,17 @@
+-- Idempotency keys of Calculate requests, a replay within the TTL returns the expression created by the first request
+CREATE TABLE idempotency_keys
+(
+    user_id         TEXT      NOT NULL,
+    idempotency_key TEXT      NOT NULL,
+    expression_id   TEXT      NOT NULL,
+    request_hash    TEXT      NOT NULL, -- SHA-256 of the request, a replay must match it
+
+    created_at      TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    expires_at      TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (expression_id) REFERENCES expressions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
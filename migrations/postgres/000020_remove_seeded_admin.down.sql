@@ -0,0 +1,2 @@
+-- The removed admin is not restored, the first admin is created with `calculator bootstrap-admin`
+SELECT 1;
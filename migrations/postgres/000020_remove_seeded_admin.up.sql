@@ -0,0 +1,12 @@
+-- The admin seeded by 000002_admin_user with the well-known admin/admin credentials is removed
+-- only if it has never been used: its MD5 hash is rehashed with argon2id on the first login.
+-- A used admin and its data are kept, 000014 forces it to change the password on the next login.
+-- Without admins, the calculator warns at start to create one with `calculator bootstrap-admin`.
+DELETE FROM users
+WHERE id = '00000000000000000000'
+  AND password_hash = '21232f297a57a5a743894a0e4a801fc3'
+  AND NOT EXISTS (SELECT 1 FROM expressions WHERE user_id = users.id)
+  AND NOT EXISTS (SELECT 1 FROM refresh_tokens WHERE user_id = users.id)
+  AND NOT EXISTS (SELECT 1 FROM api_keys WHERE user_id = users.id)
+  AND NOT EXISTS (SELECT 1 FROM user_identities WHERE user_id = users.id)
+  AND NOT EXISTS (SELECT 1 FROM retention_policies WHERE user_id = users.id);
@@ -0,0 +1,3 @@
+-- password_hash is MD5 hash of "admin"
+INSERT INTO users (id, login, password_hash)
+VALUES ('00000000000000000000', 'admin', '21232f297a57a5a743894a0e4a801fc3');
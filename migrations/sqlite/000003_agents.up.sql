@@ -0,0 +1,13 @@
+-- Agents table
+CREATE TABLE agents
+(
+    id           TEXT PRIMARY KEY,
+    operations   TEXT      NOT NULL, -- comma-separated list of supported operations, empty means all
+    labels       TEXT      NOT NULL, -- JSON object of agent labels
+    last_seen_at TIMESTAMP NOT NULL,
+
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_agents_last_seen_at ON agents (last_seen_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_operation_updated;
+
+ALTER TABLE tasks DROP COLUMN compute_time;
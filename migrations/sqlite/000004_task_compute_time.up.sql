@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN compute_time INTEGER; -- measured by agent
+
+CREATE INDEX idx_tasks_operation_updated ON tasks (operation, updated_at);
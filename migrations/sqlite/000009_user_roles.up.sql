@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user'; -- admin, user or read-only
+
+UPDATE users SET role = 'admin' WHERE id = '00000000000000000000';
@@ -0,0 +1,63 @@
+-- Access tokens issued before this time are rejected, set on password change
+ALTER TABLE users ADD COLUMN tokens_revoked_at TIMESTAMP;
+
+-- Deleting a user deletes their expressions (and tasks with them), refresh tokens and API keys.
+-- SQLite can't alter foreign keys, so the tables are rebuilt.
+CREATE TABLE expressions_new
+(
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    expression TEXT      NOT NULL,
+    status     TEXT      NOT NULL,
+    result     REAL,
+    error      TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+INSERT INTO expressions_new SELECT id, user_id, expression, status, result, error, created_at, updated_at FROM expressions;
+DROP TABLE expressions;
+ALTER TABLE expressions_new RENAME TO expressions;
+CREATE INDEX idx_expressions_user_id ON expressions (user_id);
+CREATE INDEX idx_expressions_user_status ON expressions (user_id, status);
+
+CREATE TABLE refresh_tokens_new
+(
+    id          TEXT PRIMARY KEY,
+    user_id     TEXT      NOT NULL,
+    family_id   TEXT      NOT NULL, -- tokens obtained by rotation of the same login share the family
+    token_hash  TEXT      NOT NULL UNIQUE, -- SHA-256 of the opaque token
+    expires_at  TIMESTAMP NOT NULL,
+    revoked_at  TIMESTAMP,
+    replaced_by TEXT, -- ID of the token issued by rotation
+
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+INSERT INTO refresh_tokens_new SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens;
+DROP TABLE refresh_tokens;
+ALTER TABLE refresh_tokens_new RENAME TO refresh_tokens;
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+CREATE TABLE api_keys_new
+(
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    name       TEXT      NOT NULL,
+    key_hash   TEXT      NOT NULL UNIQUE, -- SHA-256 of the key
+    scopes     TEXT      NOT NULL, -- comma-separated list of granted scopes
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+INSERT INTO api_keys_new SELECT id, user_id, name, key_hash, scopes, expires_at, revoked_at, created_at FROM api_keys;
+DROP TABLE api_keys;
+ALTER TABLE api_keys_new RENAME TO api_keys;
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
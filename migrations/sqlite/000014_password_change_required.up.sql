@@ -0,0 +1,8 @@
+-- Users created with a one-time password must change it before using the API
+ALTER TABLE users ADD COLUMN password_change_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The admin seeded by 000002_admin_user is assumed to have the default password,
+-- its MD5 hash is rehashed with argon2id on login, so the hash doesn't tell whether it was changed
+UPDATE users
+SET password_change_required = TRUE
+WHERE id = '00000000000000000000';
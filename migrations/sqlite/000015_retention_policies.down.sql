@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expressions_status_updated;
+DROP TABLE IF EXISTS retention_policies;
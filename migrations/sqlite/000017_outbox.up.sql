@@ -0,0 +1,28 @@
+-- Lifecycle events of expressions awaiting delivery to the sinks, written along with the state transitions.
+-- Events carry a snapshot of the expression, so they don't reference it and outlive its deletion.
+CREATE TABLE outbox_events
+(
+    id            TEXT PRIMARY KEY,
+    type          TEXT      NOT NULL, -- expression.completed or expression.failed
+    expression_id TEXT      NOT NULL,
+    user_id       TEXT      NOT NULL,
+    expression    TEXT      NOT NULL,
+    status        TEXT      NOT NULL,
+    result        DOUBLE PRECISION,
+    error         TEXT,
+
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_events_created_at ON outbox_events (created_at, id);
+
+-- Events delivered to each sink, an event is deleted once it is delivered to all of them
+CREATE TABLE outbox_deliveries
+(
+    event_id     TEXT      NOT NULL,
+    sink         TEXT      NOT NULL,
+    delivered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (event_id, sink),
+    FOREIGN KEY (event_id) REFERENCES outbox_events (id) ON DELETE CASCADE
+);
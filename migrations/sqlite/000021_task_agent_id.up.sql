@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN agent_id TEXT; -- agent holding the lease of the claimed task
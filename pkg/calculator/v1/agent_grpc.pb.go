@@ -28,11 +28,11 @@ const (
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Internal service for agent communication.
+// Manages communication between system and calculation agents.
 type AgentServiceClient interface {
-	// Get task for execution (for agents).
-	GetTask(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetTaskResponse, error)
-	// Submit task processing result (from agents).
+	// Retrieves a task for execution.
+	GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*GetTaskResponse, error)
+	// Submits computation result for a task.
 	SubmitTaskResult(ctx context.Context, in *SubmitTaskResultRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
@@ -44,7 +44,7 @@ func NewAgentServiceClient(cc grpc.ClientConnInterface) AgentServiceClient {
 	return &agentServiceClient{cc}
 }
 
-func (c *agentServiceClient) GetTask(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetTaskResponse, error) {
+func (c *agentServiceClient) GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*GetTaskResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetTaskResponse)
 	err := c.cc.Invoke(ctx, AgentService_GetTask_FullMethodName, in, out, cOpts...)
@@ -68,11 +68,11 @@ func (c *agentServiceClient) SubmitTaskResult(ctx context.Context, in *SubmitTas
 // All implementations should embed UnimplementedAgentServiceServer
 // for forward compatibility.
 //
-// Internal service for agent communication.
+// Manages communication between system and calculation agents.
 type AgentServiceServer interface {
-	// Get task for execution (for agents).
-	GetTask(context.Context, *emptypb.Empty) (*GetTaskResponse, error)
-	// Submit task processing result (from agents).
+	// Retrieves a task for execution.
+	GetTask(context.Context, *GetTaskRequest) (*GetTaskResponse, error)
+	// Submits computation result for a task.
 	SubmitTaskResult(context.Context, *SubmitTaskResultRequest) (*emptypb.Empty, error)
 }
 
@@ -83,7 +83,7 @@ type AgentServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedAgentServiceServer struct{}
 
-func (UnimplementedAgentServiceServer) GetTask(context.Context, *emptypb.Empty) (*GetTaskResponse, error) {
+func (UnimplementedAgentServiceServer) GetTask(context.Context, *GetTaskRequest) (*GetTaskResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTask not implemented")
 }
 func (UnimplementedAgentServiceServer) SubmitTaskResult(context.Context, *SubmitTaskResultRequest) (*emptypb.Empty, error) {
@@ -110,7 +110,7 @@ func RegisterAgentServiceServer(s grpc.ServiceRegistrar, srv AgentServiceServer)
 }
 
 func _AgentService_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(GetTaskRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -122,7 +122,7 @@ func _AgentService_GetTask_Handler(srv interface{}, ctx context.Context, dec fun
 		FullMethod: AgentService_GetTask_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AgentServiceServer).GetTask(ctx, req.(*emptypb.Empty))
+		return srv.(AgentServiceServer).GetTask(ctx, req.(*GetTaskRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
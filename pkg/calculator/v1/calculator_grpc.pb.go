@@ -20,26 +20,32 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CalculatorService_Calculate_FullMethodName           = "/calculator.v1.CalculatorService/Calculate"
-	CalculatorService_ListExpressions_FullMethodName     = "/calculator.v1.CalculatorService/ListExpressions"
-	CalculatorService_GetExpression_FullMethodName       = "/calculator.v1.CalculatorService/GetExpression"
-	CalculatorService_ListExpressionTasks_FullMethodName = "/calculator.v1.CalculatorService/ListExpressionTasks"
+	CalculatorService_Calculate_FullMethodName                = "/calculator.v1.CalculatorService/Calculate"
+	CalculatorService_ListExpressions_FullMethodName          = "/calculator.v1.CalculatorService/ListExpressions"
+	CalculatorService_GetExpression_FullMethodName            = "/calculator.v1.CalculatorService/GetExpression"
+	CalculatorService_ListExpressionTasks_FullMethodName      = "/calculator.v1.CalculatorService/ListExpressionTasks"
+	CalculatorService_ListExpressionTaskEvents_FullMethodName = "/calculator.v1.CalculatorService/ListExpressionTaskEvents"
+	CalculatorService_GetExpressionCallback_FullMethodName    = "/calculator.v1.CalculatorService/GetExpressionCallback"
 )
 
 // CalculatorServiceClient is the client API for CalculatorService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Handles calculation of arithmetic expressions.
+// Processes arithmetic expressions.
 type CalculatorServiceClient interface {
-	// Submits a new arithmetic expression for calculation.
+	// Submits an arithmetic expression for calculation.
 	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
-	// Returns all expressions.
+	// Lists all expressions.
 	ListExpressions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListExpressionsResponse, error)
-	// Returns a specific expression by its identifier.
+	// Gets expression by identifier.
 	GetExpression(ctx context.Context, in *GetExpressionRequest, opts ...grpc.CallOption) (*GetExpressionResponse, error)
-	// Returns all tasks for a specific expression.
+	// Lists tasks for specified expression.
 	ListExpressionTasks(ctx context.Context, in *ListExpressionTasksRequest, opts ...grpc.CallOption) (*ListExpressionTasksResponse, error)
+	// Lists state transitions of tasks for specified expression.
+	ListExpressionTaskEvents(ctx context.Context, in *ListExpressionTaskEventsRequest, opts ...grpc.CallOption) (*ListExpressionTaskEventsResponse, error)
+	// Gets the callback of specified expression along with its delivery log.
+	GetExpressionCallback(ctx context.Context, in *GetExpressionCallbackRequest, opts ...grpc.CallOption) (*ExpressionCallback, error)
 }
 
 type calculatorServiceClient struct {
@@ -90,20 +96,44 @@ func (c *calculatorServiceClient) ListExpressionTasks(ctx context.Context, in *L
 	return out, nil
 }
 
+func (c *calculatorServiceClient) ListExpressionTaskEvents(ctx context.Context, in *ListExpressionTaskEventsRequest, opts ...grpc.CallOption) (*ListExpressionTaskEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListExpressionTaskEventsResponse)
+	err := c.cc.Invoke(ctx, CalculatorService_ListExpressionTaskEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) GetExpressionCallback(ctx context.Context, in *GetExpressionCallbackRequest, opts ...grpc.CallOption) (*ExpressionCallback, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExpressionCallback)
+	err := c.cc.Invoke(ctx, CalculatorService_GetExpressionCallback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalculatorServiceServer is the server API for CalculatorService service.
 // All implementations should embed UnimplementedCalculatorServiceServer
 // for forward compatibility.
 //
-// Handles calculation of arithmetic expressions.
+// Processes arithmetic expressions.
 type CalculatorServiceServer interface {
-	// Submits a new arithmetic expression for calculation.
+	// Submits an arithmetic expression for calculation.
 	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
-	// Returns all expressions.
+	// Lists all expressions.
 	ListExpressions(context.Context, *emptypb.Empty) (*ListExpressionsResponse, error)
-	// Returns a specific expression by its identifier.
+	// Gets expression by identifier.
 	GetExpression(context.Context, *GetExpressionRequest) (*GetExpressionResponse, error)
-	// Returns all tasks for a specific expression.
+	// Lists tasks for specified expression.
 	ListExpressionTasks(context.Context, *ListExpressionTasksRequest) (*ListExpressionTasksResponse, error)
+	// Lists state transitions of tasks for specified expression.
+	ListExpressionTaskEvents(context.Context, *ListExpressionTaskEventsRequest) (*ListExpressionTaskEventsResponse, error)
+	// Gets the callback of specified expression along with its delivery log.
+	GetExpressionCallback(context.Context, *GetExpressionCallbackRequest) (*ExpressionCallback, error)
 }
 
 // UnimplementedCalculatorServiceServer should be embedded to have
@@ -125,6 +155,12 @@ func (UnimplementedCalculatorServiceServer) GetExpression(context.Context, *GetE
 func (UnimplementedCalculatorServiceServer) ListExpressionTasks(context.Context, *ListExpressionTasksRequest) (*ListExpressionTasksResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListExpressionTasks not implemented")
 }
+func (UnimplementedCalculatorServiceServer) ListExpressionTaskEvents(context.Context, *ListExpressionTaskEventsRequest) (*ListExpressionTaskEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExpressionTaskEvents not implemented")
+}
+func (UnimplementedCalculatorServiceServer) GetExpressionCallback(context.Context, *GetExpressionCallbackRequest) (*ExpressionCallback, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExpressionCallback not implemented")
+}
 func (UnimplementedCalculatorServiceServer) testEmbeddedByValue() {}
 
 // UnsafeCalculatorServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -217,6 +253,42 @@ func _CalculatorService_ListExpressionTasks_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CalculatorService_ListExpressionTaskEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExpressionTaskEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).ListExpressionTaskEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalculatorService_ListExpressionTaskEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).ListExpressionTaskEvents(ctx, req.(*ListExpressionTaskEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_GetExpressionCallback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExpressionCallbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).GetExpressionCallback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalculatorService_GetExpressionCallback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).GetExpressionCallback(ctx, req.(*GetExpressionCallbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CalculatorService_ServiceDesc is the grpc.ServiceDesc for CalculatorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -240,6 +312,14 @@ var CalculatorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListExpressionTasks",
 			Handler:    _CalculatorService_ListExpressionTasks_Handler,
 		},
+		{
+			MethodName: "ListExpressionTaskEvents",
+			Handler:    _CalculatorService_ListExpressionTaskEvents_Handler,
+		},
+		{
+			MethodName: "GetExpressionCallback",
+			Handler:    _CalculatorService_GetExpressionCallback_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "calculator/v1/calculator.proto",